@@ -0,0 +1,2 @@
+// Package aave provides a Golang client wrapper for the Aave V3 lending pool
+package aave
@@ -0,0 +1,131 @@
+package aave
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// PoolAddressesProviderAddress points to the aave v3 PoolAddressesProvider on mainnet.
+var PoolAddressesProviderAddress = common.HexToAddress("0x2f39d218133AFaB8F2B819B1066c7E434Ad94E9e")
+
+// InterestRateMode is the interest rate mode used when borrowing and repaying
+type InterestRateMode int64
+
+const (
+	// StableRate borrows at a stable interest rate, which is disabled for most assets
+	StableRate InterestRateMode = 1
+	// VariableRate borrows at a variable interest rate
+	VariableRate InterestRateMode = 2
+)
+
+// referralCode is passed to methods accepting a referral code, 0 meaning no referral
+const referralCode = uint16(0)
+
+// UserAccountData is the aggregated position of a user across all reserves. Collateral and debt
+// values are denominated in the base currency of the market, which is USD with 8 decimals on
+// mainnet. The liquidation threshold and ltv are in basis points, and the health factor is
+// scaled by 1e18 with positions below 1e18 being liquidatable
+type UserAccountData struct {
+	TotalCollateralBase         *big.Int
+	TotalDebtBase               *big.Int
+	AvailableBorrowsBase        *big.Int
+	CurrentLiquidationThreshold *big.Int
+	Ltv                         *big.Int
+	HealthFactor                *big.Int
+}
+
+// V3Pool wraps the aave v3 Pool contract for supplying and borrowing assets
+type V3Pool struct {
+	address common.Address
+	pool    *bind.BoundContract
+}
+
+// NewV3Pool returns a wrapper for the aave v3 pool, resolving the current pool address from
+// the PoolAddressesProvider at provider, which is PoolAddressesProviderAddress on mainnet
+func NewV3Pool(ctx context.Context, provider common.Address, bc utils.Blockchain) (*V3Pool, error) {
+	providerABI, err := abi.JSON(strings.NewReader(poolAddressesProviderABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse provider abi")
+	}
+	var out []interface{}
+	if err := bind.NewBoundContract(provider, providerABI, bc, bc, bc).Call(&bind.CallOpts{Context: ctx}, &out, "getPool"); err != nil {
+		return nil, errors.Wrap(err, "get pool")
+	}
+	address := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	parsed, err := abi.JSON(strings.NewReader(poolABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse pool abi")
+	}
+	return &V3Pool{
+		address: address,
+		pool:    bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Address returns the address of the pool contract
+func (p *V3Pool) Address() common.Address {
+	return p.address
+}
+
+// Supply supplies amount of asset to the pool, crediting the aTokens to onBehalfOf. The pool must
+// have been approved to spend amount beforehand. This claims the authorizer lock and must not be
+// called while the lock is already held.
+func (p *V3Pool) Supply(ctx context.Context, auth *utils.Authorizer, asset common.Address, amount *big.Int, onBehalfOf common.Address) (*types.Transaction, error) {
+	return p.transact(ctx, auth, "supply", asset, amount, onBehalfOf, referralCode)
+}
+
+// Withdraw withdraws amount of asset from the pool sending it to the to address. Use
+// math.MaxBig256 to withdraw the entire balance. This claims the authorizer lock and must
+// not be called while the lock is already held.
+func (p *V3Pool) Withdraw(ctx context.Context, auth *utils.Authorizer, asset common.Address, amount *big.Int, to common.Address) (*types.Transaction, error) {
+	return p.transact(ctx, auth, "withdraw", asset, amount, to)
+}
+
+// Borrow borrows amount of asset against the collateral of onBehalfOf, which must have delegated
+// credit to the authorizer if it is a different account. This claims the authorizer lock and
+// must not be called while the lock is already held.
+func (p *V3Pool) Borrow(ctx context.Context, auth *utils.Authorizer, asset common.Address, amount *big.Int, rateMode InterestRateMode, onBehalfOf common.Address) (*types.Transaction, error) {
+	return p.transact(ctx, auth, "borrow", asset, amount, big.NewInt(int64(rateMode)), referralCode, onBehalfOf)
+}
+
+// Repay repays amount of the authorizer's debt in asset for the given rate mode. Use math.MaxBig256
+// to repay the entire debt. The pool must have been approved to spend amount beforehand. This claims
+// the authorizer lock and must not be called while the lock is already held.
+func (p *V3Pool) Repay(ctx context.Context, auth *utils.Authorizer, asset common.Address, amount *big.Int, rateMode InterestRateMode) (*types.Transaction, error) {
+	return p.transact(ctx, auth, "repay", asset, amount, big.NewInt(int64(rateMode)), auth.From)
+}
+
+// GetUserAccountData returns the aggregated position of user across all reserves
+func (p *V3Pool) GetUserAccountData(ctx context.Context, user common.Address) (*UserAccountData, error) {
+	var out []interface{}
+	if err := p.pool.Call(&bind.CallOpts{Context: ctx}, &out, "getUserAccountData", user); err != nil {
+		return nil, errors.Wrap(err, "get user account data")
+	}
+	return &UserAccountData{
+		TotalCollateralBase:         *abi.ConvertType(out[0], new(*big.Int)).(**big.Int),
+		TotalDebtBase:               *abi.ConvertType(out[1], new(*big.Int)).(**big.Int),
+		AvailableBorrowsBase:        *abi.ConvertType(out[2], new(*big.Int)).(**big.Int),
+		CurrentLiquidationThreshold: *abi.ConvertType(out[3], new(*big.Int)).(**big.Int),
+		Ltv:                         *abi.ConvertType(out[4], new(*big.Int)).(**big.Int),
+		HealthFactor:                *abi.ConvertType(out[5], new(*big.Int)).(**big.Int),
+	}, nil
+}
+
+// transact sends a transaction calling method on the pool
+func (p *V3Pool) transact(ctx context.Context, auth *utils.Authorizer, method string, params ...interface{}) (*types.Transaction, error) {
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return p.pool.Transact(opts, method, params...)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	return tx, nil
+}
@@ -0,0 +1,30 @@
+package aave
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABI(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(poolABI))
+	require.NoError(t, err)
+	// selectors of the aave v3 pool methods
+	selectors := map[string]string{
+		"supply":             "617ba037",
+		"withdraw":           "69328dec",
+		"borrow":             "a415bcad",
+		"repay":              "573ade81",
+		"getUserAccountData": "bf92857c",
+	}
+	for name, selector := range selectors {
+		method, ok := parsed.Methods[name]
+		require.True(t, ok, name)
+		require.Equal(t, selector, common.Bytes2Hex(method.ID), name)
+	}
+	_, err = abi.JSON(strings.NewReader(poolAddressesProviderABI))
+	require.NoError(t, err)
+}
@@ -0,0 +1,7 @@
+package balancer
+
+// vaultABI is the subset of the Balancer V2 vault ABI used by BalancerVault
+const vaultABI = `[
+	{"inputs":[{"name":"kind","type":"uint8"},{"components":[{"name":"poolId","type":"bytes32"},{"name":"assetInIndex","type":"uint256"},{"name":"assetOutIndex","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"userData","type":"bytes"}],"name":"swaps","type":"tuple[]"},{"name":"assets","type":"address[]"},{"components":[{"name":"sender","type":"address"},{"name":"fromInternalBalance","type":"bool"},{"name":"recipient","type":"address"},{"name":"toInternalBalance","type":"bool"}],"name":"funds","type":"tuple"},{"name":"limits","type":"int256[]"},{"name":"deadline","type":"uint256"}],"name":"batchSwap","outputs":[{"name":"assetDeltas","type":"int256[]"}],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"kind","type":"uint8"},{"components":[{"name":"poolId","type":"bytes32"},{"name":"assetInIndex","type":"uint256"},{"name":"assetOutIndex","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"userData","type":"bytes"}],"name":"swaps","type":"tuple[]"},{"name":"assets","type":"address[]"},{"components":[{"name":"sender","type":"address"},{"name":"fromInternalBalance","type":"bool"},{"name":"recipient","type":"address"},{"name":"toInternalBalance","type":"bool"}],"name":"funds","type":"tuple"}],"name":"queryBatchSwap","outputs":[{"name":"","type":"int256[]"}],"stateMutability":"nonpayable","type":"function"}
+]`
@@ -0,0 +1,2 @@
+// Package balancer provides a Golang client wrapper for swapping through the Balancer V2 vault
+package balancer
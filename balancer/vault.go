@@ -0,0 +1,141 @@
+package balancer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// VaultAddress points to the Balancer V2 vault, which has the same address on mainnet and most other chains.
+var VaultAddress = common.HexToAddress("0xBA12222222228d8Ba445958a75a0704d566BF2C8")
+
+// SwapKind determines whether the amounts of a batch swap are the amounts sent or received
+type SwapKind uint8
+
+const (
+	// GivenIn swaps exact amounts of the asset sent for as much as possible of the asset received
+	GivenIn SwapKind = 0
+	// GivenOut swaps as little as possible of the asset sent for exact amounts of the asset received
+	GivenOut SwapKind = 1
+)
+
+// BatchSwapStep is a single swap through a pool. Assets are referenced by index into the assets
+// of the batch swap, and a zero amount uses the output of the previous step, which allows multi-hop
+// routes. UserData is passed to the pool and is empty for most pools
+type BatchSwapStep struct {
+	PoolID        [32]byte `abi:"poolId"`
+	AssetInIndex  *big.Int `abi:"assetInIndex"`
+	AssetOutIndex *big.Int `abi:"assetOutIndex"`
+	Amount        *big.Int `abi:"amount"`
+	UserData      []byte   `abi:"userData"`
+}
+
+// FundManagement describes where the assets of a batch swap are sent from and to. The zero value of
+// the internal balance flags uses token balances rather than internal balances held by the vault
+type FundManagement struct {
+	Sender              common.Address `abi:"sender"`
+	FromInternalBalance bool           `abi:"fromInternalBalance"`
+	Recipient           common.Address `abi:"recipient"`
+	ToInternalBalance   bool           `abi:"toInternalBalance"`
+}
+
+// BalancerVault wraps the Balancer V2 vault, through which every swap of the protocol is made
+type BalancerVault struct {
+	address common.Address
+	vault   *bind.BoundContract
+}
+
+// NewBalancerVault returns a wrapper for the vault at address, which is usually VaultAddress
+func NewBalancerVault(address common.Address, bc utils.Blockchain) (*BalancerVault, error) {
+	parsed, err := abi.JSON(strings.NewReader(vaultABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &BalancerVault{
+		address: address,
+		vault:   bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Address returns the address of the vault
+func (v *BalancerVault) Address() common.Address {
+	return v.address
+}
+
+// QueryBatchSwap simulates a batch swap returning the asset deltas of the vault, which are positive
+// for assets sent to the vault and negative for assets received from it, in the same order as
+// assets. The deltas can be used to derive the limits passed to BatchSwap
+func (v *BalancerVault) QueryBatchSwap(ctx context.Context, kind SwapKind, swaps []BatchSwapStep, assets []common.Address, funds FundManagement) ([]*big.Int, error) {
+	var out []interface{}
+	if err := v.vault.Call(&bind.CallOpts{Context: ctx}, &out, "queryBatchSwap", uint8(kind), swaps, assets, funds); err != nil {
+		return nil, errors.Wrap(err, "query batch swap")
+	}
+	return *abi.ConvertType(out[0], new([]*big.Int)).(*[]*big.Int), nil
+}
+
+// PoolQuoter quotes swaps through a single pool of the vault, implementing utils.Quoter
+type PoolQuoter struct {
+	vault  *BalancerVault
+	poolID [32]byte
+}
+
+// Quoter returns a quoter for swaps through the pool with the given id
+func (v *BalancerVault) Quoter(poolID [32]byte) *PoolQuoter {
+	return &PoolQuoter{vault: v, poolID: poolID}
+}
+
+// Quote returns the amount of tokenOut received when swapping amountIn of tokenIn through the pool,
+// simulating the swap with QueryBatchSwap
+func (q *PoolQuoter) Quote(ctx context.Context, tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	swaps := []BatchSwapStep{{
+		PoolID:        q.poolID,
+		AssetInIndex:  big.NewInt(0),
+		AssetOutIndex: big.NewInt(1),
+		Amount:        amountIn,
+		UserData:      []byte{},
+	}}
+	deltas, err := q.vault.QueryBatchSwap(ctx, GivenIn, swaps, []common.Address{tokenIn, tokenOut}, FundManagement{})
+	if err != nil {
+		return nil, err
+	}
+	if len(deltas) != 2 {
+		return nil, errors.Errorf("got %d deltas for 2 assets", len(deltas))
+	}
+	// the amount received is paid out by the vault so its delta is negative
+	return new(big.Int).Neg(deltas[1]), nil
+}
+
+// BatchSwap performs a batch swap through the vault, reverting if the delta of any asset exceeds its
+// limit or once deadline passes. Limits are the maximum amounts sent to the vault, with negative
+// limits being the minimum amounts received. Assets using the zero address are ETH, whose positive
+// limit is sent as the value of the transaction. The vault must have been approved to spend the
+// tokens sent beforehand. This claims the authorizer lock and must not be called while the lock is
+// already held.
+func (v *BalancerVault) BatchSwap(ctx context.Context, auth *utils.Authorizer, kind SwapKind, swaps []BatchSwapStep, assets []common.Address, funds FundManagement, limits []*big.Int, deadline *big.Int) (*types.Transaction, error) {
+	if len(limits) != len(assets) {
+		return nil, errors.Errorf("%d limits given for %d assets", len(limits), len(assets))
+	}
+	value := new(big.Int)
+	for i, asset := range assets {
+		if asset == (common.Address{}) && limits[i].Sign() > 0 {
+			value.Add(value, limits[i])
+		}
+	}
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if value.Sign() > 0 {
+			defer auth.WithValue(value)()
+		}
+		return v.vault.Transact(opts, "batchSwap", uint8(kind), swaps, assets, funds, limits, deadline)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "batch swap")
+	}
+	return tx, nil
+}
@@ -0,0 +1,49 @@
+package balancer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABI(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(vaultABI))
+	require.NoError(t, err)
+	// selectors of the vault methods
+	selectors := map[string]string{
+		"batchSwap":      "945bcec9",
+		"queryBatchSwap": "f84d066e",
+	}
+	for name, selector := range selectors {
+		method, ok := parsed.Methods[name]
+		require.True(t, ok, name)
+		require.Equal(t, selector, common.Bytes2Hex(method.ID), name)
+	}
+
+	// the swap structs are packed into the tuples of the vault
+	swaps := []BatchSwapStep{{
+		AssetInIndex:  big.NewInt(0),
+		AssetOutIndex: big.NewInt(1),
+		Amount:        big.NewInt(1000),
+	}}
+	assets := []common.Address{{}, common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")}
+	funds := FundManagement{Sender: assets[1], Recipient: assets[1]}
+	_, err = parsed.Pack("batchSwap", uint8(GivenIn), swaps, assets, funds, []*big.Int{big.NewInt(1000), big.NewInt(-990)}, big.NewInt(1))
+	require.NoError(t, err)
+	_, err = parsed.Pack("queryBatchSwap", uint8(GivenOut), swaps, assets, funds)
+	require.NoError(t, err)
+}
+
+func TestBatchSwapLimits(t *testing.T) {
+	vault, err := NewBalancerVault(VaultAddress, nil)
+	require.NoError(t, err)
+	require.Equal(t, VaultAddress, vault.Address())
+	// a limit is required for every asset, which is checked before claiming the lock
+	_, err = vault.BatchSwap(context.Background(), nil, GivenIn, nil, []common.Address{{}}, FundManagement{}, nil, big.NewInt(1))
+	require.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package chainlink
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/pkg/errors"
+)
+
+// weiPerEther is the number of wei in one ether
+var weiPerEther = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// EstimateCostUSD returns the cost in USD of a transaction using gasLimit at the gas price
+// suggested by the node, priced with priceFeed which must be an ETH / USD feed such as the one at
+// ETHUSDFeedAddress. The gas limit is the most a transaction can cost, so the actual cost is lower
+// when less gas is used. Callers wanting to reject outdated prices should check the feed with IsStale
+func EstimateCostUSD(ctx context.Context, bc utils.Blockchain, gasLimit uint64, priceFeed *PriceFeed) (*big.Rat, error) {
+	gasPrice, err := bc.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "suggest gas price")
+	}
+	data, err := priceFeed.LatestPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return costUSD(gasLimit, gasPrice, data), nil
+}
+
+// costUSD returns the cost in USD of gasLimit at gasPrice wei, given the ETH / USD price data
+func costUSD(gasLimit uint64, gasPrice *big.Int, data *PriceData) *big.Rat {
+	eth := new(big.Rat).SetFrac(utils.CalcGasCost(gasLimit, gasPrice), weiPerEther)
+	return eth.Mul(eth, data.Price())
+}
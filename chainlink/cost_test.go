@@ -0,0 +1,17 @@
+package chainlink
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostUSD(t *testing.T) {
+	// 21000 gas at 20 gwei is 0.00042 ETH
+	data := &PriceData{Answer: big.NewInt(312345000000), Decimals: 8}
+	cost := costUSD(21000, big.NewInt(20000000000), data)
+	require.Equal(t, "1.3118490", cost.FloatString(7))
+	// the cost is exact rather than rounded
+	require.Equal(t, "1311849/1000000", cost.String())
+}
@@ -0,0 +1,2 @@
+// Package chainlink provides a Golang client wrapper for reading chainlink price feeds
+package chainlink
@@ -0,0 +1,124 @@
+package chainlink
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// ETHUSDFeedAddress points to the chainlink ETH / USD price feed on mainnet.
+var ETHUSDFeedAddress = common.HexToAddress("0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419")
+
+// PriceData is the latest round reported by a price feed. Answer is scaled by 10^Decimals
+type PriceData struct {
+	RoundID         *big.Int
+	Answer          *big.Int
+	Decimals        uint8
+	StartedAt       time.Time
+	UpdatedAt       time.Time
+	AnsweredInRound *big.Int
+}
+
+// Price returns the answer normalized by the decimals of the feed, allowing feeds
+// with different decimals to be compared
+func (p *PriceData) Price() *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p.Decimals)), nil)
+	return new(big.Rat).SetFrac(p.Answer, scale)
+}
+
+// PriceFeed wraps a chainlink aggregator implementing AggregatorV3Interface
+type PriceFeed struct {
+	address common.Address
+	feed    *bind.BoundContract
+
+	// decimals are immutable so are cached after the first fetch
+	mx          sync.Mutex
+	decimals    uint8
+	hasDecimals bool
+}
+
+// NewPriceFeed returns a wrapper for the chainlink price feed at address, such as ETHUSDFeedAddress
+func NewPriceFeed(address common.Address, bc utils.Blockchain) (*PriceFeed, error) {
+	parsed, err := abi.JSON(strings.NewReader(aggregatorV3ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &PriceFeed{
+		address: address,
+		feed:    bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Address returns the address of the price feed
+func (f *PriceFeed) Address() common.Address {
+	return f.address
+}
+
+// Decimals returns the number of decimals of the answers reported by the feed, caching it after the first fetch
+func (f *PriceFeed) Decimals(ctx context.Context) (uint8, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if f.hasDecimals {
+		return f.decimals, nil
+	}
+	var out []interface{}
+	if err := f.feed.Call(&bind.CallOpts{Context: ctx}, &out, "decimals"); err != nil {
+		return 0, errors.Wrap(err, "decimals")
+	}
+	f.decimals, f.hasDecimals = *abi.ConvertType(out[0], new(uint8)).(*uint8), true
+	return f.decimals, nil
+}
+
+// Description returns the description of the feed such as "ETH / USD"
+func (f *PriceFeed) Description(ctx context.Context) (string, error) {
+	var out []interface{}
+	if err := f.feed.Call(&bind.CallOpts{Context: ctx}, &out, "description"); err != nil {
+		return "", errors.Wrap(err, "description")
+	}
+	return *abi.ConvertType(out[0], new(string)).(*string), nil
+}
+
+// LatestPrice returns the latest round reported by the feed. Callers should check the round
+// isn't outdated using IsStale before relying on it
+func (f *PriceFeed) LatestPrice(ctx context.Context) (*PriceData, error) {
+	decimals, err := f.Decimals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	if err := f.feed.Call(&bind.CallOpts{Context: ctx}, &out, "latestRoundData"); err != nil {
+		return nil, errors.Wrap(err, "latest round data")
+	}
+	startedAt := *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	updatedAt := *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
+	return &PriceData{
+		RoundID:         *abi.ConvertType(out[0], new(*big.Int)).(**big.Int),
+		Answer:          *abi.ConvertType(out[1], new(*big.Int)).(**big.Int),
+		Decimals:        decimals,
+		StartedAt:       time.Unix(startedAt.Int64(), 0),
+		UpdatedAt:       time.Unix(updatedAt.Int64(), 0),
+		AnsweredInRound: *abi.ConvertType(out[4], new(*big.Int)).(**big.Int),
+	}, nil
+}
+
+// IsStale returns true if data was last updated more than maxAge ago. Rounds with a non-positive
+// answer or which were never updated are also considered stale, as these are never valid prices
+func (f *PriceFeed) IsStale(data *PriceData, maxAge time.Duration) bool {
+	return isStale(data, maxAge, time.Now())
+}
+
+// isStale is like IsStale but relative to now
+func isStale(data *PriceData, maxAge time.Duration, now time.Time) bool {
+	if data.Answer == nil || data.Answer.Sign() <= 0 || data.UpdatedAt.Unix() == 0 {
+		return true
+	}
+	return now.Sub(data.UpdatedAt) > maxAge
+}
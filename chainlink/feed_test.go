@@ -0,0 +1,56 @@
+package chainlink
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABI(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(aggregatorV3ABI))
+	require.NoError(t, err)
+	// selectors of the AggregatorV3Interface methods
+	selectors := map[string]string{
+		"decimals":        "313ce567",
+		"description":     "7284e416",
+		"latestRoundData": "feaf968c",
+	}
+	for name, selector := range selectors {
+		method, ok := parsed.Methods[name]
+		require.True(t, ok, name)
+		require.Equal(t, selector, common.Bytes2Hex(method.ID), name)
+	}
+}
+
+func TestPriceDataPrice(t *testing.T) {
+	data := &PriceData{Answer: big.NewInt(312345000000), Decimals: 8}
+	require.Equal(t, "3123.45", data.Price().FloatString(2))
+	// feeds with different decimals compare equal for the same price
+	other := &PriceData{Answer: new(big.Int).Mul(big.NewInt(312345), big.NewInt(1e16)), Decimals: 18}
+	require.Equal(t, 0, data.Price().Cmp(other.Price()))
+}
+
+func TestIsStale(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name string
+		data *PriceData
+		want bool
+	}{
+		{"fresh", &PriceData{Answer: big.NewInt(1), UpdatedAt: now.Add(-time.Minute)}, false},
+		{"outdated", &PriceData{Answer: big.NewInt(1), UpdatedAt: now.Add(-time.Hour * 2)}, true},
+		{"never updated", &PriceData{Answer: big.NewInt(1), UpdatedAt: time.Unix(0, 0)}, true},
+		{"zero answer", &PriceData{Answer: big.NewInt(0), UpdatedAt: now}, true},
+		{"negative answer", &PriceData{Answer: big.NewInt(-1), UpdatedAt: now}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isStale(tt.data, time.Hour, now))
+		})
+	}
+}
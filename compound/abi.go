@@ -0,0 +1,11 @@
+package compound
+
+// cometABI is the subset of the Comet ABI used by the Comet wrapper
+const cometABI = `[
+	{"inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"}],"name":"supply","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"}],"name":"withdraw","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"account","type":"address"}],"name":"borrowBalanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"account","type":"address"},{"name":"asset","type":"address"}],"name":"collateralBalanceOf","outputs":[{"name":"","type":"uint128"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"baseToken","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"}
+]`
@@ -0,0 +1,100 @@
+package compound
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// USDCMarketAddress points to the compound v3 USDC market on mainnet.
+var USDCMarketAddress = common.HexToAddress("0xc3d688B66703497DAA19211EEdff47f25384cdc3")
+
+// Comet wraps a compound v3 market. Each market has a single base asset which can be supplied
+// to earn interest or borrowed against supplied collateral assets
+type Comet struct {
+	address common.Address
+	comet   *bind.BoundContract
+}
+
+// NewComet returns a wrapper for the compound v3 market at address
+func NewComet(address common.Address, bc utils.Blockchain) (*Comet, error) {
+	parsed, err := abi.JSON(strings.NewReader(cometABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &Comet{
+		address: address,
+		comet:   bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Address returns the address of the market
+func (c *Comet) Address() common.Address {
+	return c.address
+}
+
+// BaseToken returns the address of the base asset of the market
+func (c *Comet) BaseToken(ctx context.Context) (common.Address, error) {
+	var out []interface{}
+	if err := c.comet.Call(&bind.CallOpts{Context: ctx}, &out, "baseToken"); err != nil {
+		return common.Address{}, errors.Wrap(err, "base token")
+	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
+
+// BalanceOf returns the supplied balance of the base asset of account including accrued interest
+func (c *Comet) BalanceOf(ctx context.Context, account common.Address) (*big.Int, error) {
+	return c.callBigInt(ctx, "balanceOf", account)
+}
+
+// BorrowBalanceOf returns the borrowed balance of the base asset of account including accrued interest
+func (c *Comet) BorrowBalanceOf(ctx context.Context, account common.Address) (*big.Int, error) {
+	return c.callBigInt(ctx, "borrowBalanceOf", account)
+}
+
+// CollateralBalanceOf returns the amount of the collateral asset supplied by account
+func (c *Comet) CollateralBalanceOf(ctx context.Context, account, asset common.Address) (*big.Int, error) {
+	return c.callBigInt(ctx, "collateralBalanceOf", account, asset)
+}
+
+// Supply supplies amount of asset to the market, which either earns interest when asset is the
+// base token or is used as collateral otherwise. Supplying the base token while borrowing repays
+// the debt. The market must have been approved to spend amount beforehand. This claims the
+// authorizer lock and must not be called while the lock is already held.
+func (c *Comet) Supply(ctx context.Context, auth *utils.Authorizer, asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return c.transact(ctx, auth, "supply", asset, amount)
+}
+
+// Withdraw withdraws amount of asset from the market. Withdrawing more of the base token than
+// is supplied borrows the difference. This claims the authorizer lock and must not be called
+// while the lock is already held.
+func (c *Comet) Withdraw(ctx context.Context, auth *utils.Authorizer, asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return c.transact(ctx, auth, "withdraw", asset, amount)
+}
+
+// callBigInt calls a view method of the market returning a single integer
+func (c *Comet) callBigInt(ctx context.Context, method string, params ...interface{}) (*big.Int, error) {
+	var out []interface{}
+	if err := c.comet.Call(&bind.CallOpts{Context: ctx}, &out, method, params...); err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// transact sends a transaction calling method on the market
+func (c *Comet) transact(ctx context.Context, auth *utils.Authorizer, method string, params ...interface{}) (*types.Transaction, error) {
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.comet.Transact(opts, method, params...)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	return tx, nil
+}
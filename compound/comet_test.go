@@ -0,0 +1,29 @@
+package compound
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABI(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(cometABI))
+	require.NoError(t, err)
+	// selectors of the comet methods
+	selectors := map[string]string{
+		"supply":              "f2b9fdb8",
+		"withdraw":            "f3fef3a3",
+		"balanceOf":           "70a08231",
+		"borrowBalanceOf":     "374c49b4",
+		"collateralBalanceOf": "5c2549ee",
+		"baseToken":           "c55dae63",
+	}
+	for name, selector := range selectors {
+		method, ok := parsed.Methods[name]
+		require.True(t, ok, name)
+		require.Equal(t, selector, common.Bytes2Hex(method.ID), name)
+	}
+}
@@ -0,0 +1,2 @@
+// Package compound provides a Golang client wrapper for Compound V3 (Comet) markets
+package compound
@@ -7,12 +7,13 @@ package config
 import (
 	"context"
 	"io/ioutil"
+	"math/big"
 	"os"
 
 	"github.com/bonedaddy/go-defi/testenv"
 	"github.com/bonedaddy/go-defi/utils"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/pkg/errors"
 	"github.com/vrischmann/envconfig"
 	"go.bobheadxi.dev/zapx/zapx"
@@ -43,6 +44,10 @@ type Blockchain struct {
 	RPC string `yaml:"rpc" envconfig:"optional"`
 	// Websockets when set to true will attempt to use websockets connection negotiation
 	Websockets bool `yaml:"websockets" envconfig:"optional"`
+	// ChainID is the chain id transactions are signed for. When unset the chain id of the
+	// simulated blockchain is used for the simulated provider, and the chain id reported
+	// by the rpc provider otherwise
+	ChainID int64 `yaml:"chain_id" envconfig:"optional"`
 	// Account provides configuration for transaction signing and gas price estimation
 	Account `yaml:"account"`
 }
@@ -229,19 +234,47 @@ func (c *Config) EthClient(ctx context.Context) (utils.Blockchain, error) {
 	return ec, err
 }
 
-// Authorizer parses the Account configuration struct to return a transaction signer
+// ChainID returns the chain id transactions are signed for, which is the configured chain id if set.
+// Otherwise the simulated provider uses the chain id of the simulated blockchain, while other
+// providers are connected to in order to ask for their chain id
+func (c *Config) ChainID(ctx context.Context) (*big.Int, error) {
+	if c.Blockchain.ChainID != 0 {
+		return big.NewInt(c.Blockchain.ChainID), nil
+	}
+	if c.Blockchain.ProviderType == "simulated" {
+		// the simulated backend runs with the chain config of AllEthashProtocolChanges
+		return new(big.Int).Set(params.AllEthashProtocolChanges.ChainID), nil
+	}
+	bc, err := c.EthClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "eth client")
+	}
+	ec, ok := bc.(*ethclient.Client)
+	if !ok || ec == nil {
+		return nil, errors.New("unsupported provider type, must be one of: infura, direct, simulated")
+	}
+	defer ec.Close()
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "chain id")
+	}
+	return chainID, nil
+}
+
+// Authorizer parses the Account configuration struct to return a transaction signer for the chain
+// id returned by ChainID
 // from https://github.com/indexed-finance/circuit-breaker/blob/master/cmd/services_run.go
 // copyright for this code can be found in the LICENSE file of indexed-finance/circuit-breaker
-func (c *Config) Authorizer() (*utils.Authorizer, error) {
+func (c *Config) Authorizer(ctx context.Context) (*utils.Authorizer, error) {
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
 	switch c.Blockchain.Account.Mode {
 	case "keyfile":
-		return utils.NewAuthorizer(c.Blockchain.Account.KeyFilePath, c.Blockchain.Account.KeyFilePassword)
+		return utils.NewAuthorizerWithChainID(c.Blockchain.Account.KeyFilePath, c.Blockchain.Account.KeyFilePassword, chainID)
 	case "privatekey":
-		pk, err := crypto.HexToECDSA(c.Blockchain.Account.PrivateKey)
-		if err != nil {
-			return nil, errors.Wrap(err, "hex to ecdsa")
-		}
-		return utils.NewAuthorizerFromPK(pk), nil
+		return utils.NewAuthorizerFromHexWithChainID(c.Blockchain.Account.PrivateKey, chainID)
 	default:
 		return nil, errors.New("unsupported account mode, must be one of: keyfile, privatekey")
 	}
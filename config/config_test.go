@@ -1,9 +1,16 @@
 package config
 
 import (
+	"context"
+	"encoding/hex"
+	"math/big"
 	"os"
 	"testing"
 
+	"github.com/bonedaddy/go-defi/testenv"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,3 +40,41 @@ func TestConfigEnv(t *testing.T) {
 	logger.Info("hello")
 	logger.Sync()
 }
+
+func TestConfigAuthorizerSimulated(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	cfg := &Config{Blockchain: Blockchain{
+		ProviderType: "simulated",
+		Account:      Account{Mode: "privatekey", PrivateKey: hex.EncodeToString(crypto.FromECDSA(pk))},
+	}}
+	auth, err := cfg.Authorizer(ctx)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), auth.From)
+
+	bc, err := cfg.EthClient(ctx)
+	require.NoError(t, err)
+	sim := bc.(*testenv.Testenv)
+	defer sim.Close()
+	require.NoError(t, sim.SendETH(auth.From, big.NewInt(1e18)))
+
+	// the simulated node only accepts transactions signed for its chain id
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, sim.Auth.From, big.NewInt(1), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, tx))
+	sim.Commit()
+	receipt, err := bind.WaitMined(ctx, sim, tx)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	cfg.Blockchain.ChainID = 5
+	chainID, err := cfg.ChainID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(5), chainID)
+	cfg.Blockchain.Account.Mode = "ledger"
+	_, err = cfg.Authorizer(ctx)
+	require.Error(t, err)
+}
@@ -0,0 +1,2 @@
+// Package curve provides a Golang client wrapper for exchanging coins through Curve pools
+package curve
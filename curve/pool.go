@@ -0,0 +1,148 @@
+package curve
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ThreePoolAddress points to the DAI/USDC/USDT StableSwap pool on mainnet, which uses Int128Indices.
+	ThreePoolAddress = common.HexToAddress("0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7")
+	// TriCrypto2Address points to the USDT/WBTC/WETH crypto pool on mainnet, which uses Uint256Indices.
+	TriCrypto2Address = common.HexToAddress("0xD51a44d3FaE010294C616388b506AcdA1bfAAE46")
+)
+
+// maxCoins is the largest number of coins held by a curve pool
+const maxCoins = 8
+
+// IndexType is the ABI type a pool uses for coin indices, which differs between pool versions
+type IndexType int
+
+const (
+	// Int128Indices is used by the original StableSwap pools such as 3pool
+	Int128Indices IndexType = iota
+	// Uint256Indices is used by newer pools such as the crypto and factory pools
+	Uint256Indices
+)
+
+// CurvePool wraps a curve pool, exchanging between its coins which are identified by their index
+// in the pool. Use CoinIndex to resolve the index of a token rather than hardcoding it
+type CurvePool struct {
+	address common.Address
+	pool    *bind.BoundContract
+}
+
+// NewCurvePool returns a wrapper for the curve pool at address, which takes coin indices of the
+// given type. Using the wrong index type results in calls to the pool reverting
+func NewCurvePool(address common.Address, indexType IndexType, bc utils.Blockchain) (*CurvePool, error) {
+	var poolABI string
+	switch indexType {
+	case Int128Indices:
+		poolABI = int128PoolABI
+	case Uint256Indices:
+		poolABI = uint256PoolABI
+	default:
+		return nil, errors.Errorf("unknown index type %d", indexType)
+	}
+	parsed, err := abi.JSON(strings.NewReader(poolABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &CurvePool{
+		address: address,
+		pool:    bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Address returns the address of the pool
+func (p *CurvePool) Address() common.Address {
+	return p.address
+}
+
+// Coin returns the address of the coin at index i
+func (p *CurvePool) Coin(ctx context.Context, i int) (common.Address, error) {
+	var out []interface{}
+	if err := p.pool.Call(&bind.CallOpts{Context: ctx}, &out, "coins", big.NewInt(int64(i))); err != nil {
+		return common.Address{}, errors.Wrapf(err, "coins %d", i)
+	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
+
+// CoinIndex returns the index of token in the pool by scanning its coins in order. Pools revert
+// when reading past their last coin, which is treated as the token not being held by the pool
+func (p *CurvePool) CoinIndex(ctx context.Context, token common.Address) (int, error) {
+	for i := 0; i < maxCoins; i++ {
+		coin, err := p.Coin(ctx, i)
+		if err != nil {
+			if i == 0 || ctx.Err() != nil {
+				return 0, err
+			}
+			break
+		}
+		if coin == token {
+			return i, nil
+		}
+	}
+	return 0, errors.Errorf("token %s is not a coin of pool %s", token.Hex(), p.address.Hex())
+}
+
+// GetDy returns the amount of coin j received when exchanging dx of coin i
+func (p *CurvePool) GetDy(ctx context.Context, i, j int, dx *big.Int) (*big.Int, error) {
+	if err := checkIndices(i, j); err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	if err := p.pool.Call(&bind.CallOpts{Context: ctx}, &out, "get_dy", big.NewInt(int64(i)), big.NewInt(int64(j)), dx); err != nil {
+		return nil, errors.Wrap(err, "get dy")
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// Quote returns the amount of tokenOut received when exchanging amountIn of tokenIn through the pool,
+// looking up the index of both coins with CoinIndex, implementing utils.Quoter
+func (p *CurvePool) Quote(ctx context.Context, tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	i, err := p.CoinIndex(ctx, tokenIn)
+	if err != nil {
+		return nil, err
+	}
+	j, err := p.CoinIndex(ctx, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetDy(ctx, i, j, amountIn)
+}
+
+// Exchange exchanges dx of coin i for at least minDy of coin j, reverting if less would be received.
+// The pool must have been approved to spend dx of coin i beforehand. This claims the authorizer
+// lock and must not be called while the lock is already held.
+func (p *CurvePool) Exchange(ctx context.Context, auth *utils.Authorizer, i, j int, dx, minDy *big.Int) (*types.Transaction, error) {
+	if err := checkIndices(i, j); err != nil {
+		return nil, err
+	}
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return p.pool.Transact(opts, "exchange", big.NewInt(int64(i)), big.NewInt(int64(j)), dx, minDy)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "exchange")
+	}
+	return tx, nil
+}
+
+// checkIndices returns an error if i and j can't identify two different coins of a pool
+func checkIndices(i, j int) error {
+	if i < 0 || j < 0 || i >= maxCoins || j >= maxCoins {
+		return errors.Errorf("coin indices %d and %d must be between 0 and %d", i, j, maxCoins-1)
+	}
+	if i == j {
+		return errors.Errorf("cannot exchange coin %d for itself", i)
+	}
+	return nil
+}
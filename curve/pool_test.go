@@ -0,0 +1,47 @@
+package curve
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABI(t *testing.T) {
+	// selectors of the pool methods for each index type
+	for _, tt := range []struct {
+		abi       string
+		selectors map[string]string
+	}{
+		{int128PoolABI, map[string]string{"coins": "c6610657", "get_dy": "5e0d443f", "exchange": "3df02124"}},
+		{uint256PoolABI, map[string]string{"coins": "c6610657", "get_dy": "556d6e9f", "exchange": "5b41b908"}},
+	} {
+		parsed, err := abi.JSON(strings.NewReader(tt.abi))
+		require.NoError(t, err)
+		for name, selector := range tt.selectors {
+			method, ok := parsed.Methods[name]
+			require.True(t, ok, name)
+			require.Equal(t, selector, common.Bytes2Hex(method.ID), name)
+		}
+	}
+}
+
+func TestNewCurvePool(t *testing.T) {
+	_, err := NewCurvePool(ThreePoolAddress, IndexType(2), nil)
+	require.Error(t, err)
+	pool, err := NewCurvePool(ThreePoolAddress, Int128Indices, nil)
+	require.NoError(t, err)
+	require.Equal(t, ThreePoolAddress, pool.Address())
+
+	// invalid indices are rejected without calling the pool
+	for _, indices := range [][2]int{{0, 0}, {-1, 1}, {0, maxCoins}} {
+		_, err = pool.GetDy(context.Background(), indices[0], indices[1], big.NewInt(1))
+		require.Error(t, err)
+		_, err = pool.Exchange(context.Background(), nil, indices[0], indices[1], big.NewInt(1), big.NewInt(1))
+		require.Error(t, err)
+	}
+}
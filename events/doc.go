@@ -0,0 +1,2 @@
+// Package events decodes common DeFi events such as ERC20 transfers and uniswap swaps into typed structs
+package events
@@ -0,0 +1,8 @@
+package events
+
+import "errors"
+
+var (
+	// ErrUnknownEvent is returned when decoding a log which isn't one of the supported events
+	ErrUnknownEvent = errors.New("unknown event")
+)
@@ -0,0 +1,240 @@
+package events
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// TransferEvent is an ERC20 Transfer of Value tokens of the Token contract
+type TransferEvent struct {
+	Token common.Address
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+	Raw   types.Log
+}
+
+// ApprovalEvent is an ERC20 Approval allowing Spender to transfer Value tokens of Owner
+type ApprovalEvent struct {
+	Token   common.Address
+	Owner   common.Address
+	Spender common.Address
+	Value   *big.Int
+	Raw     types.Log
+}
+
+// SwapEvent is a swap through a uniswap v2 or v3 pool, or one of their forks. Amount0 and
+// Amount1 are the changes in the balances of the pool, such that the token swapped in has a
+// positive amount and the token swapped out a negative one. For v2 swaps the raw in and out
+// amounts are kept as well, while SqrtPriceX96, Liquidity and Tick are only set for v3 swaps
+type SwapEvent struct {
+	// Version is the uniswap version of the pool, either 2 or 3
+	Version   int
+	Pool      common.Address
+	Sender    common.Address
+	Recipient common.Address
+	Amount0   *big.Int
+	Amount1   *big.Int
+
+	Amount0In  *big.Int
+	Amount1In  *big.Int
+	Amount0Out *big.Int
+	Amount1Out *big.Int
+
+	SqrtPriceX96 *big.Int
+	Liquidity    *big.Int
+	Tick         int32
+
+	Raw types.Log
+}
+
+// SyncEvent is emitted by uniswap v2 pairs with their reserves after every change
+type SyncEvent struct {
+	Pool     common.Address
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+	Raw      types.Log
+}
+
+// DeFiEvents decodes logs of common DeFi events into typed structs
+type DeFiEvents struct {
+	erc20    abi.ABI
+	v2Pair   abi.ABI
+	v3Pool   abi.ABI
+	decoders map[common.Hash]func(types.Log) (interface{}, error)
+}
+
+// NewDeFiEvents returns a decoder for ERC20 Transfer and Approval events, along with
+// uniswap v2 Swap and Sync events and uniswap v3 Swap events
+func NewDeFiEvents() (*DeFiEvents, error) {
+	d := &DeFiEvents{}
+	for _, parse := range []struct {
+		out *abi.ABI
+		abi string
+	}{{&d.erc20, erc20ABI}, {&d.v2Pair, uniswapV2PairABI}, {&d.v3Pool, uniswapV3PoolABI}} {
+		parsed, err := abi.JSON(strings.NewReader(parse.abi))
+		if err != nil {
+			return nil, errors.Wrap(err, "parse abi")
+		}
+		*parse.out = parsed
+	}
+	swap := func(log types.Log) (interface{}, error) { return d.DecodeSwap(log) }
+	d.decoders = map[common.Hash]func(types.Log) (interface{}, error){
+		d.erc20.Events["Transfer"].ID: func(log types.Log) (interface{}, error) { return d.DecodeTransfer(log) },
+		d.erc20.Events["Approval"].ID: func(log types.Log) (interface{}, error) { return d.DecodeApproval(log) },
+		d.v2Pair.Events["Swap"].ID:    swap,
+		d.v3Pool.Events["Swap"].ID:    swap,
+		d.v2Pair.Events["Sync"].ID:    func(log types.Log) (interface{}, error) { return d.DecodeSync(log) },
+	}
+	return d, nil
+}
+
+// Decode decodes log into one of *TransferEvent, *ApprovalEvent, *SwapEvent or *SyncEvent
+// depending on its first topic, allowing indexers to process logs of mixed events. An error
+// wrapping ErrUnknownEvent is returned for logs of any other event
+func (d *DeFiEvents) Decode(log types.Log) (interface{}, error) {
+	if len(log.Topics) == 0 {
+		return nil, errors.Wrap(ErrUnknownEvent, "anonymous event")
+	}
+	decode, ok := d.decoders[log.Topics[0]]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownEvent, "topic %s", log.Topics[0].Hex())
+	}
+	return decode(log)
+}
+
+// DecodeTransfer decodes an ERC20 Transfer log. ERC721 transfers, which share the same
+// signature but index the token id, return an error wrapping ErrUnknownEvent
+func (d *DeFiEvents) DecodeTransfer(log types.Log) (*TransferEvent, error) {
+	value, err := d.decodeERC20(log, "Transfer")
+	if err != nil {
+		return nil, err
+	}
+	return &TransferEvent{
+		Token: log.Address,
+		From:  common.BytesToAddress(log.Topics[1].Bytes()),
+		To:    common.BytesToAddress(log.Topics[2].Bytes()),
+		Value: value,
+		Raw:   log,
+	}, nil
+}
+
+// DecodeApproval decodes an ERC20 Approval log
+func (d *DeFiEvents) DecodeApproval(log types.Log) (*ApprovalEvent, error) {
+	value, err := d.decodeERC20(log, "Approval")
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalEvent{
+		Token:   log.Address,
+		Owner:   common.BytesToAddress(log.Topics[1].Bytes()),
+		Spender: common.BytesToAddress(log.Topics[2].Bytes()),
+		Value:   value,
+		Raw:     log,
+	}, nil
+}
+
+// decodeERC20 checks log is the named ERC20 event returning its value
+func (d *DeFiEvents) decodeERC20(log types.Log, name string) (*big.Int, error) {
+	if err := checkEvent(d.erc20, name, log, 3); err != nil {
+		return nil, err
+	}
+	var out struct{ Value *big.Int }
+	if err := d.erc20.UnpackIntoInterface(&out, name, log.Data); err != nil {
+		return nil, errors.Wrapf(err, "unpack %s", name)
+	}
+	return out.Value, nil
+}
+
+// DecodeSwap decodes the Swap log of a uniswap v2 or v3 pool
+func (d *DeFiEvents) DecodeSwap(log types.Log) (*SwapEvent, error) {
+	if len(log.Topics) > 0 && log.Topics[0] == d.v3Pool.Events["Swap"].ID {
+		return d.decodeSwapV3(log)
+	}
+	if err := checkEvent(d.v2Pair, "Swap", log, 3); err != nil {
+		return nil, err
+	}
+	var out struct {
+		Amount0In  *big.Int
+		Amount1In  *big.Int
+		Amount0Out *big.Int
+		Amount1Out *big.Int
+	}
+	if err := d.v2Pair.UnpackIntoInterface(&out, "Swap", log.Data); err != nil {
+		return nil, errors.Wrap(err, "unpack Swap")
+	}
+	return &SwapEvent{
+		Version:    2,
+		Pool:       log.Address,
+		Sender:     common.BytesToAddress(log.Topics[1].Bytes()),
+		Recipient:  common.BytesToAddress(log.Topics[2].Bytes()),
+		Amount0:    new(big.Int).Sub(out.Amount0In, out.Amount0Out),
+		Amount1:    new(big.Int).Sub(out.Amount1In, out.Amount1Out),
+		Amount0In:  out.Amount0In,
+		Amount1In:  out.Amount1In,
+		Amount0Out: out.Amount0Out,
+		Amount1Out: out.Amount1Out,
+		Raw:        log,
+	}, nil
+}
+
+// decodeSwapV3 decodes the Swap log of a uniswap v3 pool
+func (d *DeFiEvents) decodeSwapV3(log types.Log) (*SwapEvent, error) {
+	if err := checkEvent(d.v3Pool, "Swap", log, 3); err != nil {
+		return nil, err
+	}
+	var out struct {
+		Amount0      *big.Int
+		Amount1      *big.Int
+		SqrtPriceX96 *big.Int
+		Liquidity    *big.Int
+		Tick         *big.Int
+	}
+	if err := d.v3Pool.UnpackIntoInterface(&out, "Swap", log.Data); err != nil {
+		return nil, errors.Wrap(err, "unpack Swap")
+	}
+	return &SwapEvent{
+		Version:      3,
+		Pool:         log.Address,
+		Sender:       common.BytesToAddress(log.Topics[1].Bytes()),
+		Recipient:    common.BytesToAddress(log.Topics[2].Bytes()),
+		Amount0:      out.Amount0,
+		Amount1:      out.Amount1,
+		SqrtPriceX96: out.SqrtPriceX96,
+		Liquidity:    out.Liquidity,
+		Tick:         int32(out.Tick.Int64()),
+		Raw:          log,
+	}, nil
+}
+
+// DecodeSync decodes the Sync log of a uniswap v2 pair
+func (d *DeFiEvents) DecodeSync(log types.Log) (*SyncEvent, error) {
+	if err := checkEvent(d.v2Pair, "Sync", log, 1); err != nil {
+		return nil, err
+	}
+	var out struct {
+		Reserve0 *big.Int
+		Reserve1 *big.Int
+	}
+	if err := d.v2Pair.UnpackIntoInterface(&out, "Sync", log.Data); err != nil {
+		return nil, errors.Wrap(err, "unpack Sync")
+	}
+	return &SyncEvent{Pool: log.Address, Reserve0: out.Reserve0, Reserve1: out.Reserve1, Raw: log}, nil
+}
+
+// checkEvent returns an error wrapping ErrUnknownEvent unless log is the named event of
+// parsed with the given number of topics, including the event signature
+func checkEvent(parsed abi.ABI, name string, log types.Log, topics int) error {
+	if len(log.Topics) == 0 || log.Topics[0] != parsed.Events[name].ID {
+		return errors.Wrapf(ErrUnknownEvent, "not a %s event", name)
+	}
+	if len(log.Topics) != topics {
+		return errors.Wrapf(ErrUnknownEvent, "%s event with %d topics, expected %d", name, len(log.Topics), topics)
+	}
+	return nil
+}
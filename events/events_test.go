@@ -0,0 +1,130 @@
+package events
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	token  = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	pool   = common.HexToAddress("0xA478c2975Ab1Ea89e8196811F51A7B7Ade33eB11")
+	alice  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob    = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	router = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+)
+
+// newLog returns a log of the named event of abiJSON emitted by address
+func newLog(t *testing.T, abiJSON, name string, address common.Address, indexed []common.Address, values ...interface{}) types.Log {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	require.NoError(t, err)
+	event := parsed.Events[name]
+	data, err := event.Inputs.NonIndexed().Pack(values...)
+	require.NoError(t, err)
+	topics := []common.Hash{event.ID}
+	for _, addr := range indexed {
+		topics = append(topics, common.BytesToHash(addr.Bytes()))
+	}
+	return types.Log{Address: address, Topics: topics, Data: data}
+}
+
+func TestABI(t *testing.T) {
+	// topics of the supported events
+	topics := []struct {
+		abi   string
+		name  string
+		topic string
+	}{
+		{erc20ABI, "Transfer", "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+		{erc20ABI, "Approval", "8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"},
+		{uniswapV2PairABI, "Swap", "d78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822"},
+		{uniswapV2PairABI, "Sync", "1c411e9a96e071241c2f21f7726b17ae89e3cab4c78be50e062b03a9fffbbad1"},
+		{uniswapV3PoolABI, "Swap", "c42079f94a6350d7e6235f29174924f928cc2ac818eb64fed8004e115fbcca67"},
+	}
+	for _, tt := range topics {
+		parsed, err := abi.JSON(strings.NewReader(tt.abi))
+		require.NoError(t, err)
+		event, ok := parsed.Events[tt.name]
+		require.True(t, ok, tt.name)
+		require.Equal(t, tt.topic, common.Bytes2Hex(event.ID.Bytes()), tt.name)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	d, err := NewDeFiEvents()
+	require.NoError(t, err)
+
+	decoded, err := d.Decode(newLog(t, erc20ABI, "Transfer", token, []common.Address{alice, bob}, big.NewInt(1000)))
+	require.NoError(t, err)
+	transfer := decoded.(*TransferEvent)
+	require.Equal(t, token, transfer.Token)
+	require.Equal(t, alice, transfer.From)
+	require.Equal(t, bob, transfer.To)
+	require.Equal(t, "1000", transfer.Value.String())
+
+	decoded, err = d.Decode(newLog(t, erc20ABI, "Approval", token, []common.Address{alice, router}, big.NewInt(5)))
+	require.NoError(t, err)
+	approval := decoded.(*ApprovalEvent)
+	require.Equal(t, alice, approval.Owner)
+	require.Equal(t, router, approval.Spender)
+	require.Equal(t, "5", approval.Value.String())
+
+	// amounts are the changes in the balances of the pool
+	decoded, err = d.Decode(newLog(t, uniswapV2PairABI, "Swap", pool, []common.Address{router, bob},
+		big.NewInt(1000), big.NewInt(0), big.NewInt(0), big.NewInt(997)))
+	require.NoError(t, err)
+	swap := decoded.(*SwapEvent)
+	require.Equal(t, 2, swap.Version)
+	require.Equal(t, pool, swap.Pool)
+	require.Equal(t, router, swap.Sender)
+	require.Equal(t, bob, swap.Recipient)
+	require.Equal(t, "1000", swap.Amount0.String())
+	require.Equal(t, "-997", swap.Amount1.String())
+	require.Equal(t, "997", swap.Amount1Out.String())
+	require.Nil(t, swap.SqrtPriceX96)
+
+	decoded, err = d.Decode(newLog(t, uniswapV3PoolABI, "Swap", pool, []common.Address{router, bob},
+		big.NewInt(-500), big.NewInt(1000), big.NewInt(79228162514264337), big.NewInt(123456), big.NewInt(-887220)))
+	require.NoError(t, err)
+	swap = decoded.(*SwapEvent)
+	require.Equal(t, 3, swap.Version)
+	require.Equal(t, "-500", swap.Amount0.String())
+	require.Equal(t, "1000", swap.Amount1.String())
+	require.Equal(t, "79228162514264337", swap.SqrtPriceX96.String())
+	require.Equal(t, "123456", swap.Liquidity.String())
+	require.Equal(t, int32(-887220), swap.Tick)
+	require.Nil(t, swap.Amount0In)
+
+	decoded, err = d.Decode(newLog(t, uniswapV2PairABI, "Sync", pool, nil, big.NewInt(10), big.NewInt(20)))
+	require.NoError(t, err)
+	sync := decoded.(*SyncEvent)
+	require.Equal(t, pool, sync.Pool)
+	require.Equal(t, "10", sync.Reserve0.String())
+	require.Equal(t, "20", sync.Reserve1.String())
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	d, err := NewDeFiEvents()
+	require.NoError(t, err)
+	_, err = d.Decode(types.Log{})
+	require.True(t, errors.Is(err, ErrUnknownEvent))
+	_, err = d.Decode(types.Log{Topics: []common.Hash{common.HexToHash("0x1234")}})
+	require.True(t, errors.Is(err, ErrUnknownEvent))
+
+	// erc721 transfers index the token id
+	erc721 := newLog(t, erc20ABI, "Transfer", token, []common.Address{alice, bob}, big.NewInt(1))
+	erc721.Topics = append(erc721.Topics, common.BigToHash(big.NewInt(1)))
+	erc721.Data = nil
+	_, err = d.DecodeTransfer(erc721)
+	require.True(t, errors.Is(err, ErrUnknownEvent))
+
+	// decoding as the wrong event fails
+	_, err = d.DecodeSync(newLog(t, erc20ABI, "Transfer", token, []common.Address{alice, bob}, big.NewInt(1)))
+	require.True(t, errors.Is(err, ErrUnknownEvent))
+}
@@ -0,0 +1,2 @@
+// Package gnosis provides a Golang client wrapper for proposing and executing Gnosis Safe multisig transactions
+package gnosis
@@ -0,0 +1,265 @@
+package gnosis
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/pkg/errors"
+)
+
+// Operation is the type of call made by the safe when executing a transaction
+type Operation uint8
+
+const (
+	// Call performs a regular call from the safe
+	Call Operation = 0
+	// DelegateCall executes the code of the target in the context of the safe
+	DelegateCall Operation = 1
+)
+
+// safeTxTypes are the EIP-712 types of a safe transaction
+var safeTxTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"SafeTx": {
+		{Name: "to", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "data", Type: "bytes"},
+		{Name: "operation", Type: "uint8"},
+		{Name: "safeTxGas", Type: "uint256"},
+		{Name: "baseGas", Type: "uint256"},
+		{Name: "gasPrice", Type: "uint256"},
+		{Name: "gasToken", Type: "address"},
+		{Name: "refundReceiver", Type: "address"},
+		{Name: "nonce", Type: "uint256"},
+	},
+}
+
+// SafeTx is a transaction executed by a safe once signed by enough owners. Only To is required,
+// nil amounts default to zero, while a nil Nonce is replaced by the current nonce of the safe.
+// Leaving the gas fields and refund addresses unset means the executor pays for gas without a refund
+type SafeTx struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      Operation
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+// TypedData returns the EIP-712 typed data of tx for the safe at address on the given chain
+func (tx *SafeTx) TypedData(chainID *big.Int, safe common.Address) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       safeTxTypes,
+		PrimaryType: "SafeTx",
+		Domain: apitypes.TypedDataDomain{
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: safe.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"to":             tx.To.Hex(),
+			"value":          orZero(tx.Value).String(),
+			"data":           hexutil.Encode(tx.Data),
+			"operation":      big.NewInt(int64(tx.Operation)).String(),
+			"safeTxGas":      orZero(tx.SafeTxGas).String(),
+			"baseGas":        orZero(tx.BaseGas).String(),
+			"gasPrice":       orZero(tx.GasPrice).String(),
+			"gasToken":       tx.GasToken.Hex(),
+			"refundReceiver": tx.RefundReceiver.Hex(),
+			"nonce":          orZero(tx.Nonce).String(),
+		},
+	}
+}
+
+// Hash returns the safe transaction hash of tx for the safe at address on the given chain, which
+// is what owners sign and matches getTransactionHash of the safe
+func (tx *SafeTx) Hash(chainID *big.Int, safe common.Address) (common.Hash, error) {
+	digest, err := utils.TypedDataHash(tx.TypedData(chainID, safe))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(digest), nil
+}
+
+// Safe wraps a Gnosis Safe v1.3 multisig wallet
+type Safe struct {
+	address common.Address
+	safe    *bind.BoundContract
+}
+
+// NewSafe returns a wrapper for the safe at address
+func NewSafe(address common.Address, bc utils.Blockchain) (*Safe, error) {
+	parsed, err := abi.JSON(strings.NewReader(safeABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &Safe{
+		address: address,
+		safe:    bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Address returns the address of the safe
+func (s *Safe) Address() common.Address {
+	return s.address
+}
+
+// Nonce returns the nonce of the next transaction executed by the safe
+func (s *Safe) Nonce(ctx context.Context) (*big.Int, error) {
+	return s.callUint(ctx, "nonce")
+}
+
+// Threshold returns the number of owner signatures required to execute a transaction
+func (s *Safe) Threshold(ctx context.Context) (*big.Int, error) {
+	return s.callUint(ctx, "getThreshold")
+}
+
+// ChainID returns the chain id the safe uses when hashing transactions
+func (s *Safe) ChainID(ctx context.Context) (*big.Int, error) {
+	return s.callUint(ctx, "getChainId")
+}
+
+// Owners returns the owners of the safe
+func (s *Safe) Owners(ctx context.Context) ([]common.Address, error) {
+	var out []interface{}
+	if err := s.safe.Call(&bind.CallOpts{Context: ctx}, &out, "getOwners"); err != nil {
+		return nil, errors.Wrap(err, "get owners")
+	}
+	return *abi.ConvertType(out[0], new([]common.Address)).(*[]common.Address), nil
+}
+
+// ProposeTransaction signs tx as an owner of the safe, returning the safe transaction hash and the
+// owner's signature to be shared with the other owners. When tx.Nonce is nil the current nonce of
+// the safe is fetched and set on tx, so that the same transaction can later be executed with
+// ExecTransaction. Authorizers backed by hardware wallets or a KMS return utils.ErrSignerNotSupported
+func (s *Safe) ProposeTransaction(ctx context.Context, auth *utils.Authorizer, tx *SafeTx) (common.Hash, []byte, error) {
+	if tx.Nonce == nil {
+		nonce, err := s.Nonce(ctx)
+		if err != nil {
+			return common.Hash{}, nil, err
+		}
+		tx.Nonce = nonce
+	}
+	chainID, err := s.ChainID(ctx)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	hash, err := tx.Hash(chainID, s.address)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	sig, err := auth.SignTypedData(tx.TypedData(chainID, s.address))
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	return hash, sig, nil
+}
+
+// ExecTransaction executes tx using the owner signatures collected with ProposeTransaction, which
+// are sorted by owner address as required by the safe. The authorizer pays for gas and doesn't need
+// to be an owner. An error is returned without sending a transaction if fewer signatures than the
+// threshold are given. This claims the authorizer lock and must not be called while the lock is
+// already held.
+func (s *Safe) ExecTransaction(ctx context.Context, auth *utils.Authorizer, tx *SafeTx, signatures [][]byte) (*types.Transaction, error) {
+	threshold, err := s.Threshold(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if big.NewInt(int64(len(signatures))).Cmp(threshold) < 0 {
+		return nil, errors.Errorf("%d signatures given but the threshold is %s", len(signatures), threshold)
+	}
+	if tx.Nonce == nil {
+		nonce, err := s.Nonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tx.Nonce = nonce
+	}
+	chainID, err := s.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := tx.Hash(chainID, s.address)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := packSignatures(hash, signatures)
+	if err != nil {
+		return nil, err
+	}
+	sent, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return s.safe.Transact(opts, "execTransaction",
+			tx.To, orZero(tx.Value), tx.Data, uint8(tx.Operation), orZero(tx.SafeTxGas), orZero(tx.BaseGas),
+			orZero(tx.GasPrice), tx.GasToken, tx.RefundReceiver, packed)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "exec transaction")
+	}
+	return sent, nil
+}
+
+// callUint calls a method of the safe returning a single uint256
+func (s *Safe) callUint(ctx context.Context, method string) (*big.Int, error) {
+	var out []interface{}
+	if err := s.safe.Call(&bind.CallOpts{Context: ctx}, &out, method); err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// packSignatures concatenates 65 byte ECDSA signatures of hash ordered by ascending signer
+// address, which the safe requires to detect duplicate signers
+func packSignatures(hash common.Hash, signatures [][]byte) ([]byte, error) {
+	type signed struct {
+		signer common.Address
+		sig    []byte
+	}
+	sigs := make([]signed, 0, len(signatures))
+	for i, sig := range signatures {
+		if len(sig) != crypto.SignatureLength || (sig[64] != 27 && sig[64] != 28) {
+			return nil, errors.Errorf("signature %d is not a 65 byte signature with a recovery id of 27 or 28", i)
+		}
+		normalized := make([]byte, crypto.SignatureLength)
+		copy(normalized, sig)
+		normalized[64] -= 27
+		pub, err := crypto.SigToPub(hash.Bytes(), normalized)
+		if err != nil {
+			return nil, errors.Wrapf(err, "recover signer of signature %d", i)
+		}
+		sigs = append(sigs, signed{signer: crypto.PubkeyToAddress(*pub), sig: sig})
+	}
+	sort.Slice(sigs, func(i, j int) bool {
+		return bytes.Compare(sigs[i].signer.Bytes(), sigs[j].signer.Bytes()) < 0
+	})
+	packed := make([]byte, 0, len(sigs)*crypto.SignatureLength)
+	for _, s := range sigs {
+		packed = append(packed, s.sig...)
+	}
+	return packed, nil
+}
+
+// orZero returns v, or zero if it is nil
+func orZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package gnosis
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABI(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(safeABI))
+	require.NoError(t, err)
+	// selectors of the gnosis safe methods
+	selectors := map[string]string{
+		"nonce":           "affed0e0",
+		"getThreshold":    "e75235b8",
+		"getChainId":      "3408e470",
+		"getOwners":       "a0e67e2b",
+		"execTransaction": "6a761202",
+	}
+	for name, selector := range selectors {
+		method, ok := parsed.Methods[name]
+		require.True(t, ok, name)
+		require.Equal(t, selector, common.Bytes2Hex(method.ID), name)
+	}
+}
+
+func TestSafeTxHash(t *testing.T) {
+	tx := &SafeTx{
+		To:    common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		Value: big.NewInt(1e18),
+		Data:  common.FromHex("0xdeadbeef"),
+		Nonce: big.NewInt(5),
+	}
+	hash, err := tx.Hash(big.NewInt(1), common.HexToAddress("0x1111111111111111111111111111111111111111"))
+	require.NoError(t, err)
+	require.Equal(t, common.HexToHash("0x987bc38bc4dc57311ff60757cb7a7321e2c1109797d3dc4425a73d4c14dd9c60"), hash)
+}
+
+func TestPackSignatures(t *testing.T) {
+	safe := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx := &SafeTx{To: common.HexToAddress("0x2222222222222222222222222222222222222222"), Nonce: big.NewInt(0)}
+	hash, err := tx.Hash(big.NewInt(1), safe)
+	require.NoError(t, err)
+	var (
+		signers    []common.Address
+		signatures [][]byte
+	)
+	for i := 0; i < 3; i++ {
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		auth, err := utils.NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+		require.NoError(t, err)
+		sig, err := auth.SignTypedData(tx.TypedData(big.NewInt(1), safe))
+		require.NoError(t, err)
+		signers = append(signers, auth.From)
+		signatures = append(signatures, sig)
+	}
+	packed, err := packSignatures(hash, signatures)
+	require.NoError(t, err)
+	require.Len(t, packed, 3*crypto.SignatureLength)
+	// the packed signatures are ordered by ascending signer address
+	var prev common.Address
+	for i := 0; i < 3; i++ {
+		sig := append([]byte{}, packed[i*65:(i+1)*65]...)
+		sig[64] -= 27
+		pub, err := crypto.SigToPub(hash.Bytes(), sig)
+		require.NoError(t, err)
+		signer := crypto.PubkeyToAddress(*pub)
+		require.Contains(t, signers, signer)
+		require.Equal(t, 1, bytes.Compare(signer.Bytes(), prev.Bytes()))
+		prev = signer
+	}
+
+	_, err = packSignatures(hash, [][]byte{make([]byte, 64)})
+	require.Error(t, err)
+}
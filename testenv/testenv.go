@@ -12,6 +12,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/pkg/errors"
 )
 
@@ -26,7 +28,8 @@ type Testenv struct {
 // NewBlockchain is used to generate a simulated blockchain
 func NewBlockchain(ctx context.Context) (*Testenv, error) {
 	ctx, cancel := context.WithCancel(ctx)
-	auth, pk, err := utils.NewAccount()
+	// the simulated backend runs with the chain config of AllEthashProtocolChanges
+	auth, pk, err := utils.NewAccountWithChainID(params.AllEthashProtocolChanges.ChainID)
 	if err != nil {
 		cancel()
 		return nil, errors.Wrap(err, "new account")
@@ -40,6 +43,30 @@ func NewBlockchain(ctx context.Context) (*Testenv, error) {
 	return &Testenv{ctx: ctx, cancel: cancel, pk: pk, Auth: auth, SimulatedBackend: sim}, nil
 }
 
+// NewSimulatedAuthorizer generates a fresh key funded in the genesis alloc of a new simulated
+// backend, returning an authorizer for the key alongside the ready to use backend. Any accounts
+// in alloc are also included in the genesis, and the authorizer signs for the chain id of the
+// simulated backend (1337)
+func NewSimulatedAuthorizer(alloc core.GenesisAlloc) (*utils.Authorizer, *backends.SimulatedBackend, error) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generate key")
+	}
+	gAlloc := make(core.GenesisAlloc, len(alloc)+1)
+	for addr, acct := range alloc {
+		gAlloc[addr] = acct
+	}
+	balance := new(big.Int).Mul(big.NewInt(999999999999999), big.NewInt(999999999999999))
+	gAlloc[crypto.PubkeyToAddress(pk.PublicKey)] = core.GenesisAccount{Balance: balance}
+	sim := backends.NewSimulatedBackend(gAlloc, 8000000)
+	auth, err := utils.NewAuthorizerFromPKWithChainID(pk, sim.Blockchain().Config().ChainID)
+	if err != nil {
+		sim.Close()
+		return nil, nil, errors.Wrap(err, "new authorizer")
+	}
+	return auth, sim, nil
+}
+
 func (t *Testenv) DoWaitMined(tx *types.Transaction, printArgs ...string) error {
 	t.Commit()
 	rcpt, err := bind.WaitMined(t.ctx, t, tx)
@@ -2,9 +2,11 @@ package testenv
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
 	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,3 +15,20 @@ func TestTestenv(t *testing.T) {
 	require.NoError(t, err)
 	testenv.SendETH(testenv.Auth.From, utils.ToWei("100.0", 18))
 }
+
+func TestNewSimulatedAuthorizer(t *testing.T) {
+	auth, sim, err := NewSimulatedAuthorizer(nil)
+	require.NoError(t, err)
+	defer sim.Close()
+	gasPrice, err := sim.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1337), tx.ChainId())
+	// the simulated backend rejects transactions signed for any other chain
+	require.NoError(t, sim.SendTransaction(context.Background(), tx))
+	sim.Commit()
+	rcpt, err := sim.TransactionReceipt(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, rcpt.Status)
+}
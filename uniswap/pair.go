@@ -19,7 +19,7 @@
 package uniswap
 
 import (
-	"encoding/hex"
+	"bytes"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -32,30 +32,23 @@ var FactoryAddress = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5
 // Router02Address points to the uniswap v2 02 router.
 var Router02Address = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
 
-const pairAddressSuffix = "96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"
+// PairInitCodeHash is the keccak256 hash of the uniswap v2 pair init code, used to derive pair addresses.
+var PairInitCodeHash = common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f")
 
 // GeneratePairAddress generates a pair address for the given tokens
 func GeneratePairAddress(token0, token1 common.Address) common.Address {
-	// addresses need to be sorted in an ascending order for proper behaviour
-	token0, token1 = sortAddressess(token0, token1)
+	return PairFor(FactoryAddress, token0, token1, PairInitCodeHash)
+}
 
-	// 255 is required as a prefix for this to work
+// PairFor computes the CREATE2 address of the pair for tokenA and tokenB deployed by factory, without
+// making any calls. This works for any uniswap v2 fork given the factory and the init code hash of its
+// pair contract, and the tokens may be given in either order
+func PairFor(factory, tokenA, tokenB common.Address, initCodeHash [32]byte) common.Address {
+	// addresses need to be sorted in an ascending order for proper behaviour
 	// see: https://uniswap.org/docs/v2/javascript-SDK/getting-pair-addresses/
-	message := []byte{255}
-
-	message = append(message, FactoryAddress.Bytes()...)
-
-	addrSum := token0.Bytes()
-	addrSum = append(addrSum, token1.Bytes()...)
-
-	message = append(message, crypto.Keccak256(addrSum)...)
-
-	b, _ := hex.DecodeString(pairAddressSuffix)
-	message = append(message, b...)
-	hashed := crypto.Keccak256(message)
-	addressBytes := big.NewInt(0).SetBytes(hashed)
-	addressBytes = addressBytes.Abs(addressBytes)
-	return common.BytesToAddress(addressBytes.Bytes())
+	token0, token1 := SortTokens(tokenA, tokenB)
+	salt := crypto.Keccak256Hash(token0.Bytes(), token1.Bytes())
+	return crypto.CreateAddress2(factory, salt, initCodeHash[:])
 }
 
 // Quote gets the exchange quote for a given amount of tokens and the respective pairs reserves.
@@ -73,15 +66,17 @@ func Quote(amount, reserve0, reserve1 *big.Int) *big.Int {
 	return res
 }
 
-func sortAddressess(tkn0, tkn1 common.Address) (common.Address, common.Address) {
-	token0Rep := big.NewInt(0).SetBytes(tkn0.Bytes())
-	token1Rep := big.NewInt(0).SetBytes(tkn1.Bytes())
-
-	if token0Rep.Cmp(token1Rep) > 0 {
-		tkn0, tkn1 = tkn1, tkn0
+// SortTokens returns the given tokens ordered as token0 and token1 of a pair, comparing the
+// addresses as big-endian integers
+func SortTokens(a, b common.Address) (token0, token1 common.Address) {
+	if bytes.Compare(a.Bytes(), b.Bytes()) > 0 {
+		return b, a
 	}
+	return a, b
+}
 
-	return tkn0, tkn1
+func sortAddressess(tkn0, tkn1 common.Address) (common.Address, common.Address) {
+	return SortTokens(tkn0, tkn1)
 }
 
 // Pair represents a token pair.
@@ -61,6 +61,29 @@ func TestGeneratePairAddress(t *testing.T) {
 	}
 }
 
+func TestPairFor(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	want := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+	if got := PairFor(FactoryAddress, usdc, weth, PairInitCodeHash); got != want {
+		t.Errorf("PairFor() = %v, want %v", got, want)
+	}
+	if got := PairFor(FactoryAddress, weth, usdc, PairInitCodeHash); got != want {
+		t.Errorf("PairFor() with reversed tokens = %v, want %v", got, want)
+	}
+}
+
+func TestSortTokens(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	for _, tokens := range [][2]common.Address{{usdc, weth}, {weth, usdc}} {
+		token0, token1 := SortTokens(tokens[0], tokens[1])
+		if token0 != usdc || token1 != weth {
+			t.Errorf("SortTokens(%v, %v) = %v, %v, want %v, %v", tokens[0], tokens[1], token0, token1, usdc, weth)
+		}
+	}
+}
+
 func Test_sortAddressess(t *testing.T) {
 	type args struct {
 		tkn0 common.Address
@@ -0,0 +1,282 @@
+package uniswap
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// PositionManagerAddress points to the uniswap v3 NonfungiblePositionManager.
+var PositionManagerAddress = common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88")
+
+const (
+	// MinTick is the lowest tick of uniswap v3 pools
+	MinTick = -887272
+	// MaxTick is the highest tick of uniswap v3 pools
+	MaxTick = 887272
+)
+
+// maxUint128 is the largest uint128, used to collect every fee owed to a position
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// TickSpacing returns the spacing of the initializable ticks of pools with the given fee tier,
+// which the tick range of positions must be a multiple of
+func TickSpacing(fee uint32) (int32, error) {
+	switch fee {
+	case 100:
+		return 1, nil
+	case 500:
+		return 10, nil
+	case 3000:
+		return 60, nil
+	case 10000:
+		return 200, nil
+	}
+	return 0, ValidateFeeTier(fee)
+}
+
+// MintParams describes a new position in the uniswap v3 pool of Token0 and Token1 with the Fee
+// tier, which must be sorted as by SortTokens. Liquidity is provided between TickLower and
+// TickUpper, which must be multiples of the tick spacing of the fee tier. At most Amount0Desired
+// and Amount1Desired are deposited, and the transaction reverts if less than Amount0Min or
+// Amount1Min would be, protecting against price movements. The position is minted to Recipient,
+// and the transaction reverts once Deadline passes, with nil using utils.DefaultDeadline from now
+type MintParams struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            uint32
+	TickLower      int32
+	TickUpper      int32
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Recipient      common.Address
+	Deadline       *big.Int
+}
+
+// validate returns an error if the pool or tick range of the position are invalid
+func (p MintParams) validate() error {
+	spacing, err := TickSpacing(p.Fee)
+	if err != nil {
+		return err
+	}
+	if token0, _ := SortTokens(p.Token0, p.Token1); p.Token0 == p.Token1 || token0 != p.Token0 {
+		return errors.Errorf("token0 %s must sort before token1 %s", p.Token0, p.Token1)
+	}
+	if p.TickLower >= p.TickUpper || p.TickLower < MinTick || p.TickUpper > MaxTick {
+		return errors.Errorf("invalid tick range [%d, %d]", p.TickLower, p.TickUpper)
+	}
+	if p.TickLower%spacing != 0 || p.TickUpper%spacing != 0 {
+		return errors.Errorf("ticks %d and %d must be multiples of the tick spacing %d", p.TickLower, p.TickUpper, spacing)
+	}
+	return nil
+}
+
+// mintParams mirrors the MintParams struct of NonfungiblePositionManager
+type mintParams struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            *big.Int
+	TickLower      *big.Int
+	TickUpper      *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Recipient      common.Address
+	Deadline       *big.Int
+}
+
+// increaseLiquidityParams mirrors the IncreaseLiquidityParams struct of NonfungiblePositionManager
+type increaseLiquidityParams struct {
+	TokenId        *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Deadline       *big.Int
+}
+
+// decreaseLiquidityParams mirrors the DecreaseLiquidityParams struct of NonfungiblePositionManager
+type decreaseLiquidityParams struct {
+	TokenId    *big.Int
+	Liquidity  *big.Int
+	Amount0Min *big.Int
+	Amount1Min *big.Int
+	Deadline   *big.Int
+}
+
+// collectParams mirrors the CollectParams struct of NonfungiblePositionManager
+type collectParams struct {
+	TokenId    *big.Int
+	Recipient  common.Address
+	Amount0Max *big.Int
+	Amount1Max *big.Int
+}
+
+// NonfungiblePositionManager wraps the uniswap v3 NonfungiblePositionManager for minting and
+// managing liquidity positions, which are represented as NFTs
+type NonfungiblePositionManager struct {
+	address  common.Address
+	bc       utils.Blockchain
+	abi      abi.ABI
+	contract *bind.BoundContract
+}
+
+// NewNonfungiblePositionManager returns a wrapper of the NonfungiblePositionManager at address,
+// which is typically PositionManagerAddress
+func NewNonfungiblePositionManager(address common.Address, bc utils.Blockchain) (*NonfungiblePositionManager, error) {
+	parsed, err := abi.JSON(strings.NewReader(positionManagerABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse position manager abi")
+	}
+	return &NonfungiblePositionManager{
+		address:  address,
+		bc:       bc,
+		abi:      parsed,
+		contract: bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// NewNonfungiblePositionManagerForChain is like NewNonfungiblePositionManager using the
+// NonfungiblePositionManager deployed on chain, returning an error wrapping utils.ErrNotDeployed
+// if it is missing
+func NewNonfungiblePositionManagerForChain(chain utils.Chain, bc utils.Blockchain) (*NonfungiblePositionManager, error) {
+	if err := chain.Require("uniswap v3 position manager", chain.UniswapV3PositionManager); err != nil {
+		return nil, err
+	}
+	return NewNonfungiblePositionManager(chain.UniswapV3PositionManager, bc)
+}
+
+// Mint creates a new position described by params, waiting for the transaction to be mined using
+// opts on top of the PollInterval of auth and returning the id of the minted NFT from its
+// IncreaseLiquidity event. The position manager must have been approved to spend both tokens
+// beforehand. If the transaction reverted an error wrapping utils.ErrTxReverted is returned along
+// with the transaction. This claims the authorizer lock and must not be called while the lock is
+// already held.
+func (m *NonfungiblePositionManager) Mint(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	params MintParams,
+	opts ...utils.WaitOption,
+) (tokenID *big.Int, tx *types.Transaction, err error) {
+	if err := params.validate(); err != nil {
+		return nil, nil, err
+	}
+	args := mintParams{
+		Token0:         params.Token0,
+		Token1:         params.Token1,
+		Fee:            new(big.Int).SetUint64(uint64(params.Fee)),
+		TickLower:      big.NewInt(int64(params.TickLower)),
+		TickUpper:      big.NewInt(int64(params.TickUpper)),
+		Amount0Desired: params.Amount0Desired,
+		Amount1Desired: params.Amount1Desired,
+		Amount0Min:     params.Amount0Min,
+		Amount1Min:     params.Amount1Min,
+		Recipient:      params.Recipient,
+		Deadline:       deadlineOrDefault(params.Deadline),
+	}
+	tx, err = auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return m.contract.Transact(opts, "mint", args)
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "mint")
+	}
+	logs, err := utils.WaitForEvents(ctx, m.bc, tx.Hash(), m.abi.Events["IncreaseLiquidity"].ID, auth.WaitDefaults(opts...)...)
+	if err != nil {
+		return nil, tx, err
+	}
+	for _, log := range logs {
+		if log.Address == m.address && len(log.Topics) == 2 {
+			return new(big.Int).SetBytes(log.Topics[1].Bytes()), tx, nil
+		}
+	}
+	return nil, tx, errors.Wrapf(utils.ErrEventNotFound, "IncreaseLiquidity of %s in transaction %s", m.address, tx.Hash().Hex())
+}
+
+// IncreaseLiquidity adds liquidity to the position of tokenID in the same way as Mint, depositing at
+// most amount0Desired and amount1Desired and reverting if less than amount0Min or amount1Min would
+// be. When deadline is nil it defaults to utils.DefaultDeadline from now. This claims the authorizer
+// lock and must not be called while the lock is already held.
+func (m *NonfungiblePositionManager) IncreaseLiquidity(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenID *big.Int,
+	amount0Desired, amount1Desired, amount0Min, amount1Min *big.Int,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	params := increaseLiquidityParams{
+		TokenId:        tokenID,
+		Amount0Desired: amount0Desired,
+		Amount1Desired: amount1Desired,
+		Amount0Min:     amount0Min,
+		Amount1Min:     amount1Min,
+		Deadline:       deadlineOrDefault(deadline),
+	}
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return m.contract.Transact(opts, "increaseLiquidity", params)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "increase liquidity")
+	}
+	return tx, nil
+}
+
+// DecreaseLiquidity removes liquidity from the position of tokenID, reverting if less than
+// amount0Min or amount1Min would be withdrawn. The tokens withdrawn are credited to the position
+// rather than transferred, and must be claimed along with the fees using Collect. When deadline is
+// nil it defaults to utils.DefaultDeadline from now. This claims the authorizer lock and must not be
+// called while the lock is already held.
+func (m *NonfungiblePositionManager) DecreaseLiquidity(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenID, liquidity, amount0Min, amount1Min *big.Int,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	params := decreaseLiquidityParams{
+		TokenId:    tokenID,
+		Liquidity:  liquidity,
+		Amount0Min: amount0Min,
+		Amount1Min: amount1Min,
+		Deadline:   deadlineOrDefault(deadline),
+	}
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return m.contract.Transact(opts, "decreaseLiquidity", params)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "decrease liquidity")
+	}
+	return tx, nil
+}
+
+// Collect sends every token owed to the position of tokenID to recipient, which includes the fees
+// earned along with the liquidity withdrawn by DecreaseLiquidity. This claims the authorizer lock
+// and must not be called while the lock is already held.
+func (m *NonfungiblePositionManager) Collect(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenID *big.Int,
+	recipient common.Address,
+) (*types.Transaction, error) {
+	params := collectParams{
+		TokenId:    tokenID,
+		Recipient:  recipient,
+		Amount0Max: maxUint128,
+		Amount1Max: maxUint128,
+	}
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return m.contract.Transact(opts, "collect", params)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "collect")
+	}
+	return tx, nil
+}
@@ -0,0 +1,121 @@
+package uniswap
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// positionBackend records the transactions sent to it like swapBackend, mining each with the
+// IncreaseLiquidity event of the position manager
+type positionBackend struct {
+	swapBackend
+	manager common.Address
+	topic   common.Hash
+	tokenID *big.Int
+}
+
+func (b *positionBackend) TransactionReceipt(context.Context, common.Hash) (*types.Receipt, error) {
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		BlockNumber: big.NewInt(1),
+		Logs: []*types.Log{
+			// the IncreaseLiquidity event of another contract is ignored
+			{Address: common.HexToAddress("0x1"), Topics: []common.Hash{b.topic, common.BigToHash(big.NewInt(1))}},
+			{Address: b.manager, Topics: []common.Hash{b.topic, common.BigToHash(b.tokenID)}},
+		},
+	}, nil
+}
+
+func TestPositionManagerABI(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(positionManagerABI))
+	require.NoError(t, err)
+	for method, selector := range map[string]string{
+		"mint":              "88316456",
+		"increaseLiquidity": "219f5d17",
+		"decreaseLiquidity": "0c49ccbe",
+		"collect":           "fc6f7865",
+	} {
+		require.Equal(t, selector, common.Bytes2Hex(parsed.Methods[method].ID), method)
+	}
+	require.Equal(t, crypto.Keccak256Hash([]byte("IncreaseLiquidity(uint256,uint128,uint256,uint256)")), parsed.Events["IncreaseLiquidity"].ID)
+}
+
+func TestMintParamsValidate(t *testing.T) {
+	token0 := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	token1 := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	for _, tt := range []struct {
+		name   string
+		params MintParams
+		valid  bool
+	}{
+		{"valid", MintParams{Token0: token0, Token1: token1, Fee: 3000, TickLower: -600, TickUpper: 600}, true},
+		{"full range", MintParams{Token0: token0, Token1: token1, Fee: 10000, TickLower: -887200, TickUpper: 887200}, true},
+		{"unsorted", MintParams{Token0: token1, Token1: token0, Fee: 3000, TickLower: -600, TickUpper: 600}, false},
+		{"same token", MintParams{Token0: token0, Token1: token0, Fee: 3000, TickLower: -600, TickUpper: 600}, false},
+		{"fee tier", MintParams{Token0: token0, Token1: token1, Fee: 1000, TickLower: -600, TickUpper: 600}, false},
+		{"empty range", MintParams{Token0: token0, Token1: token1, Fee: 3000, TickLower: 600, TickUpper: 600}, false},
+		{"spacing", MintParams{Token0: token0, Token1: token1, Fee: 3000, TickLower: -610, TickUpper: 600}, false},
+		{"out of range", MintParams{Token0: token0, Token1: token1, Fee: 100, TickLower: MinTick - 1, TickUpper: 0}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.validate()
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestMint(t *testing.T) {
+	ctx := context.Background()
+	parsed, err := abi.JSON(strings.NewReader(positionManagerABI))
+	require.NoError(t, err)
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := utils.NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+	require.NoError(t, err)
+	backend := &positionBackend{manager: PositionManagerAddress, topic: parsed.Events["IncreaseLiquidity"].ID, tokenID: big.NewInt(4242)}
+	manager, err := NewNonfungiblePositionManager(PositionManagerAddress, backend)
+	require.NoError(t, err)
+
+	tokenID, tx, err := manager.Mint(ctx, auth, MintParams{
+		Token0:         common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"),
+		Token1:         common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+		Fee:            500,
+		TickLower:      -100,
+		TickUpper:      100,
+		Amount0Desired: big.NewInt(1000),
+		Amount1Desired: big.NewInt(2000),
+		Amount0Min:     big.NewInt(900),
+		Amount1Min:     big.NewInt(1800),
+		Recipient:      auth.From,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "4242", tokenID.String())
+	args, err := parsed.Methods["mint"].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	params := abi.ConvertType(args[0], new(mintParams)).(*mintParams)
+	require.Equal(t, "-100", params.TickLower.String())
+	require.Equal(t, "500", params.Fee.String())
+	require.NotZero(t, params.Deadline.Sign())
+
+	tx, err = manager.Collect(ctx, auth, tokenID, auth.From)
+	require.NoError(t, err)
+	args, err = parsed.Methods["collect"].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	collect := abi.ConvertType(args[0], new(collectParams)).(*collectParams)
+	require.Equal(t, "4242", collect.TokenId.String())
+	require.Equal(t, 128, collect.Amount0Max.BitLen())
+	require.Equal(t, maxUint128.String(), collect.Amount1Max.String())
+}
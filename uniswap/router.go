@@ -0,0 +1,300 @@
+package uniswap
+
+import (
+	"context"
+	"math/big"
+
+	uniswapv2router "github.com/bonedaddy/go-defi/bindings/uniswap/router"
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// V2Router wraps the uniswap v2 router for quoting and performing swaps
+type V2Router struct {
+	router *uniswapv2router.Uniswapv2router
+}
+
+// NewV2Router returns a router wrapper for the uniswap v2 router at address,
+// which is typically Router02Address
+func NewV2Router(address common.Address, bc utils.Blockchain) (*V2Router, error) {
+	router, err := uniswapv2router.NewUniswapv2router(address, bc)
+	if err != nil {
+		return nil, errors.Wrap(err, "new uniswap router")
+	}
+	return &V2Router{router: router}, nil
+}
+
+// NewV2RouterForChain is like NewV2Router using the uniswap v2 router deployed on chain,
+// returning an error wrapping utils.ErrNotDeployed if there is none
+func NewV2RouterForChain(chain utils.Chain, bc utils.Blockchain) (*V2Router, error) {
+	if err := chain.Require("uniswap v2 router", chain.UniswapV2Router); err != nil {
+		return nil, err
+	}
+	return NewV2Router(chain.UniswapV2Router, bc)
+}
+
+// GetAmountsOut returns the amounts received at each step of the path when swapping amountIn
+func (r *V2Router) GetAmountsOut(ctx context.Context, amountIn *big.Int, path []common.Address) ([]*big.Int, error) {
+	amounts, err := r.router.GetAmountsOut(&bind.CallOpts{Context: ctx}, amountIn, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "get amounts out")
+	}
+	return amounts, nil
+}
+
+// Quote returns the amount of tokenOut received when swapping amountIn of tokenIn through their
+// pair, implementing utils.Quoter. Use FindPath to also consider routes through other tokens
+func (r *V2Router) Quote(ctx context.Context, tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	amounts, err := r.GetAmountsOut(ctx, amountIn, []common.Address{tokenIn, tokenOut})
+	if err != nil {
+		return nil, err
+	}
+	if len(amounts) != 2 {
+		return nil, errors.Errorf("got %d amounts for a path of 2 tokens", len(amounts))
+	}
+	return amounts[1], nil
+}
+
+// ErrNoPath is returned by FindPath when no path between the tokens has liquidity
+var ErrNoPath = errors.New("no swap path found")
+
+// FindPath returns the path yielding the most output when swapping amountIn of tokenIn for tokenOut,
+// along with the output amount. The direct path is tried along with one-hop paths through each of
+// intermediaries, such as WETH and USDC, quoting each with GetAmountsOut. Paths through pairs that
+// don't exist or lack liquidity fail to quote and are skipped, returning an error wrapping ErrNoPath
+// if every path is skipped
+func FindPath(
+	ctx context.Context,
+	router *V2Router,
+	amountIn *big.Int,
+	tokenIn, tokenOut common.Address,
+	intermediaries []common.Address,
+) ([]common.Address, *big.Int, error) {
+	paths := [][]common.Address{{tokenIn, tokenOut}}
+	for _, hop := range intermediaries {
+		if hop != tokenIn && hop != tokenOut {
+			paths = append(paths, []common.Address{tokenIn, hop, tokenOut})
+		}
+	}
+	var best []common.Address
+	var bestOut *big.Int
+	for _, path := range paths {
+		amounts, err := router.GetAmountsOut(ctx, amountIn, path)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+			continue
+		}
+		if len(amounts) != len(path) {
+			continue
+		}
+		if out := amounts[len(amounts)-1]; out.Sign() > 0 && (bestOut == nil || out.Cmp(bestOut) > 0) {
+			best, bestOut = path, out
+		}
+	}
+	if best == nil {
+		return nil, nil, errors.Wrapf(ErrNoPath, "from %s to %s", tokenIn.Hex(), tokenOut.Hex())
+	}
+	return best, bestOut, nil
+}
+
+// SwapExactTokensForTokens swaps exactly amountIn of the first token in path for at least amountOutMin
+// of the last token in path, sending the output to the to address. When deadline is nil it defaults to
+// utils.DefaultDeadline from now. The router must have been approved to spend amountIn beforehand.
+// This claims the authorizer lock and must not be called while the lock is already held.
+func (r *V2Router) SwapExactTokensForTokens(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	amountIn, amountOutMin *big.Int,
+	path []common.Address,
+	to common.Address,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	deadline = deadlineOrDefault(deadline)
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.router.SwapExactTokensForTokens(opts, amountIn, amountOutMin, path, to, deadline)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "swap exact tokens for tokens")
+	}
+	return tx, nil
+}
+
+// WETH returns the address of the wrapped native token the router trades ETH through
+func (r *V2Router) WETH(ctx context.Context) (common.Address, error) {
+	weth, err := r.router.WETH(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "weth")
+	}
+	return weth, nil
+}
+
+// SwapETHForTokens swaps exactly amountIn of ETH for at least amountOutMin of tokenOut, sending the
+// output to the to address. The path through the WETH pair of tokenOut is built automatically, and
+// amountIn is sent as the value of the transaction which the router wraps, with the value of the
+// authorizer restored afterwards. When deadline is nil it defaults to utils.DefaultDeadline from
+// now. This claims the authorizer lock and must not be called while the lock is already held.
+func (r *V2Router) SwapETHForTokens(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	amountIn *big.Int,
+	tokenOut common.Address,
+	amountOutMin *big.Int,
+	to common.Address,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	weth, err := r.WETH(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline = deadlineOrDefault(deadline)
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		defer auth.WithValue(amountIn)()
+		return r.router.SwapExactETHForTokens(opts, amountOutMin, []common.Address{weth, tokenOut}, to, deadline)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "swap exact eth for tokens")
+	}
+	return tx, nil
+}
+
+// SwapTokensForETH swaps exactly amountIn of tokenIn for at least amountOutMin of ETH through the
+// WETH pair of tokenIn, with the router unwrapping the WETH and sending the ETH to the to address.
+// Any value set on the authorizer is cleared for the swap, as the router rejects it, and restored
+// afterwards. When deadline is nil it defaults to utils.DefaultDeadline from now. The router must
+// have been approved to spend amountIn beforehand. This claims the authorizer lock and must not be
+// called while the lock is already held.
+func (r *V2Router) SwapTokensForETH(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenIn common.Address,
+	amountIn, amountOutMin *big.Int,
+	to common.Address,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	weth, err := r.WETH(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline = deadlineOrDefault(deadline)
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		defer auth.WithValue(nil)()
+		return r.router.SwapExactTokensForETH(opts, amountIn, amountOutMin, []common.Address{tokenIn, weth}, to, deadline)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "swap exact tokens for eth")
+	}
+	return tx, nil
+}
+
+// AddLiquidity deposits up to amountADesired of tokenA and amountBDesired of tokenB into their pair,
+// in the ratio of its reserves, minting liquidity tokens to the to address. The pair is created if
+// it doesn't exist. The transaction reverts if less than amountAMin or amountBMin would be deposited.
+// When deadline is nil it defaults to utils.DefaultDeadline from now. The router must have been
+// approved to spend both tokens beforehand, and the liquidity minted can be read from the receipt
+// with MintedLiquidity. This claims the authorizer lock and must not be called while the lock is
+// already held.
+func (r *V2Router) AddLiquidity(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenA, tokenB common.Address,
+	amountADesired, amountBDesired, amountAMin, amountBMin *big.Int,
+	to common.Address,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	deadline = deadlineOrDefault(deadline)
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.router.AddLiquidity(opts, tokenA, tokenB, amountADesired, amountBDesired, amountAMin, amountBMin, to, deadline)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "add liquidity")
+	}
+	return tx, nil
+}
+
+// AddLiquidityETH is like AddLiquidity for the pair of token and WETH, sending amountETHDesired
+// as the value of the transaction which the router wraps. Any ETH which isn't deposited is refunded.
+// The value of the authorizer is restored afterwards. This claims the authorizer lock and must not
+// be called while the lock is already held.
+func (r *V2Router) AddLiquidityETH(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	token common.Address,
+	amountTokenDesired, amountETHDesired, amountTokenMin, amountETHMin *big.Int,
+	to common.Address,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	deadline = deadlineOrDefault(deadline)
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		defer auth.WithValue(amountETHDesired)()
+		return r.router.AddLiquidityETH(opts, token, amountTokenDesired, amountTokenMin, amountETHMin, to, deadline)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "add liquidity eth")
+	}
+	return tx, nil
+}
+
+// RemoveLiquidity burns liquidity tokens of the pair of tokenA and tokenB, sending the underlying
+// tokens to the to address. The transaction reverts if less than amountAMin or amountBMin would be
+// received. When deadline is nil it defaults to utils.DefaultDeadline from now. The router must have
+// been approved to spend the liquidity tokens of the pair beforehand. This claims the authorizer
+// lock and must not be called while the lock is already held.
+func (r *V2Router) RemoveLiquidity(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenA, tokenB common.Address,
+	liquidity, amountAMin, amountBMin *big.Int,
+	to common.Address,
+	deadline *big.Int,
+) (*types.Transaction, error) {
+	deadline = deadlineOrDefault(deadline)
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.router.RemoveLiquidity(opts, tokenA, tokenB, liquidity, amountAMin, amountBMin, to, deadline)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "remove liquidity")
+	}
+	return tx, nil
+}
+
+var (
+	// transferTopic is the topic of the ERC20 Transfer event emitted when liquidity tokens are minted
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// mintTopic is the topic of the Mint event emitted by pairs when liquidity is added
+	mintTopic = crypto.Keccak256Hash([]byte("Mint(address,uint256,uint256)"))
+)
+
+// MintedLiquidity returns the liquidity tokens minted to the to address by a transaction adding
+// liquidity, such as AddLiquidity, from its receipt. The pair is identified by its Mint event and
+// the liquidity by the transfer from the zero address which accompanies it
+func MintedLiquidity(receipt *types.Receipt, to common.Address) (*big.Int, error) {
+	pairs := make(map[common.Address]bool)
+	for _, log := range receipt.Logs {
+		if len(log.Topics) > 0 && log.Topics[0] == mintTopic {
+			pairs[log.Address] = true
+		}
+	}
+	for _, log := range receipt.Logs {
+		if !pairs[log.Address] || len(log.Topics) != 3 || log.Topics[0] != transferTopic {
+			continue
+		}
+		if log.Topics[1] == (common.Hash{}) && common.BytesToAddress(log.Topics[2].Bytes()) == to {
+			return new(big.Int).SetBytes(log.Data), nil
+		}
+	}
+	return nil, errors.New("no liquidity minted by transaction")
+}
+
+// deadlineOrDefault returns deadline, or a deadline utils.DefaultDeadline from now if it is nil
+func deadlineOrDefault(deadline *big.Int) *big.Int {
+	if deadline != nil {
+		return deadline
+	}
+	return utils.Deadline(utils.DefaultDeadline)
+}
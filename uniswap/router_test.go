@@ -0,0 +1,255 @@
+package uniswap
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	uniswapv2router "github.com/bonedaddy/go-defi/bindings/uniswap/router"
+	"github.com/bonedaddy/go-defi/utils"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadlineOrDefault(t *testing.T) {
+	deadline := big.NewInt(12345)
+	require.Equal(t, deadline, deadlineOrDefault(deadline))
+	before := time.Now().Add(utils.DefaultDeadline).Unix()
+	got := deadlineOrDefault(nil).Int64()
+	after := time.Now().Add(utils.DefaultDeadline).Unix()
+	require.GreaterOrEqual(t, got, before)
+	require.LessOrEqual(t, got, after)
+}
+
+func TestRouterForChain(t *testing.T) {
+	require.Equal(t, Router02Address, utils.Mainnet.UniswapV2Router)
+	require.Equal(t, SwapRouter02Address, utils.Mainnet.UniswapV3Router)
+	require.Equal(t, QuoterV2Address, utils.Mainnet.UniswapV3Quoter)
+	_, err := NewV2RouterForChain(utils.Base, nil)
+	require.NoError(t, err)
+	_, err = NewV3RouterForChain(utils.Base, nil)
+	require.NoError(t, err)
+
+	chain := utils.Chain{ID: big.NewInt(1337), Name: "local"}
+	_, err = NewV2RouterForChain(chain, nil)
+	require.True(t, errors.Is(err, utils.ErrNotDeployed))
+	_, err = NewV3RouterForChain(chain, nil)
+	require.True(t, errors.Is(err, utils.ErrNotDeployed))
+}
+
+func TestMintedLiquidity(t *testing.T) {
+	pair := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+	router := Router02Address
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	transfer := func(address common.Address, from, to common.Address, amount int64) *types.Log {
+		return &types.Log{
+			Address: address,
+			Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:    common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		}
+	}
+	receipt := &types.Receipt{Logs: []*types.Log{
+		// tokens deposited into the pair
+		transfer(common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), to, pair, 1000),
+		// the minimum liquidity locked by the first deposit
+		transfer(pair, common.Address{}, common.Address{}, 1000),
+		transfer(pair, common.Address{}, to, 12345),
+		{Address: pair, Topics: []common.Hash{mintTopic, common.BytesToHash(router.Bytes())}},
+	}}
+	liquidity, err := MintedLiquidity(receipt, to)
+	require.NoError(t, err)
+	require.Equal(t, "12345", liquidity.String())
+
+	_, err = MintedLiquidity(&types.Receipt{Logs: receipt.Logs[:3]}, to)
+	require.Error(t, err)
+}
+
+// quoteBackend answers getAmountsOut with the output amounts of known paths,
+// reverting for paths through pairs that don't exist
+type quoteBackend struct {
+	utils.Blockchain
+	routerABI abi.ABI
+	quotes    map[string]int64
+}
+
+func (b *quoteBackend) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *quoteBackend) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	args, err := b.routerABI.Methods["getAmountsOut"].Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	amountIn, path := args[0].(*big.Int), args[1].([]common.Address)
+	var key []string
+	for _, token := range path {
+		key = append(key, token.Hex())
+	}
+	out, ok := b.quotes[strings.Join(key, ",")]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	amounts := []*big.Int{amountIn}
+	for i := 1; i < len(path); i++ {
+		amounts = append(amounts, big.NewInt(out))
+	}
+	return b.routerABI.Methods["getAmountsOut"].Outputs.Pack(amounts)
+}
+
+func TestFindPath(t *testing.T) {
+	routerABI, err := abi.JSON(strings.NewReader(uniswapv2router.Uniswapv2routerABI))
+	require.NoError(t, err)
+	tokenIn := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	tokenOut := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	weth := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	usdc := common.HexToAddress("0x4000000000000000000000000000000000000004")
+	dai := common.HexToAddress("0x5000000000000000000000000000000000000005")
+	join := func(path ...common.Address) string {
+		var key []string
+		for _, token := range path {
+			key = append(key, token.Hex())
+		}
+		return strings.Join(key, ",")
+	}
+	backend := &quoteBackend{routerABI: routerABI, quotes: map[string]int64{
+		join(tokenIn, tokenOut):       900,
+		join(tokenIn, weth, tokenOut): 980,
+		join(tokenIn, usdc, tokenOut): 950,
+	}}
+	router, err := NewV2Router(Router02Address, backend)
+	require.NoError(t, err)
+	ctx := context.Background()
+	amountIn := big.NewInt(1000)
+
+	// dai has no pairs, and intermediaries equal to either token are skipped
+	path, out, err := FindPath(ctx, router, amountIn, tokenIn, tokenOut, []common.Address{usdc, dai, weth, tokenOut})
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{tokenIn, weth, tokenOut}, path)
+	require.Equal(t, "980", out.String())
+
+	// without a direct pair a one-hop path is still found
+	delete(backend.quotes, join(tokenIn, tokenOut))
+	path, out, err = FindPath(ctx, router, amountIn, tokenIn, tokenOut, []common.Address{usdc})
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{tokenIn, usdc, tokenOut}, path)
+	require.Equal(t, "950", out.String())
+
+	_, _, err = FindPath(ctx, router, amountIn, tokenIn, tokenOut, []common.Address{dai})
+	require.True(t, errors.Is(err, ErrNoPath))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = FindPath(cancelled, router, amountIn, tokenIn, tokenOut, nil)
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestV2RouterQuote(t *testing.T) {
+	routerABI, err := abi.JSON(strings.NewReader(uniswapv2router.Uniswapv2routerABI))
+	require.NoError(t, err)
+	tokenIn := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	tokenOut := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	other := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	backend := &quoteBackend{routerABI: routerABI, quotes: map[string]int64{tokenIn.Hex() + "," + tokenOut.Hex(): 900}}
+	router, err := NewV2Router(Router02Address, backend)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	out, err := router.Quote(ctx, tokenIn, tokenOut, big.NewInt(1000))
+	require.NoError(t, err)
+	require.Equal(t, "900", out.String())
+	_, err = router.Quote(ctx, tokenIn, other, big.NewInt(1000))
+	require.Error(t, err)
+
+	// the router is picked among venues when it offers the most
+	quote, err := utils.BestQuote(ctx, []utils.Quoter{router}, tokenIn, tokenOut, big.NewInt(1000))
+	require.NoError(t, err)
+	require.Equal(t, router, quote.Quoter)
+	_, err = utils.BestQuote(ctx, []utils.Quoter{router}, tokenIn, other, big.NewInt(1000))
+	require.True(t, errors.Is(err, utils.ErrNoQuote))
+}
+
+// swapBackend answers the WETH call of the router and records the transactions sent to it
+type swapBackend struct {
+	utils.Blockchain
+	routerABI abi.ABI
+	weth      common.Address
+	sent      []*types.Transaction
+}
+
+func (b *swapBackend) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	return b.routerABI.Methods["WETH"].Outputs.Pack(b.weth)
+}
+
+func (b *swapBackend) PendingCodeAt(context.Context, common.Address) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *swapBackend) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return uint64(len(b.sent)), nil
+}
+
+func (b *swapBackend) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(1)}, nil
+}
+
+func (b *swapBackend) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *swapBackend) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 200000, nil
+}
+
+func (b *swapBackend) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func TestSwapETH(t *testing.T) {
+	routerABI, err := abi.JSON(strings.NewReader(uniswapv2router.Uniswapv2routerABI))
+	require.NoError(t, err)
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := utils.NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+	require.NoError(t, err)
+	backend := &swapBackend{routerABI: routerABI, weth: utils.WETHAddress}
+	router, err := NewV2Router(Router02Address, backend)
+	require.NoError(t, err)
+	token := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	deadline := big.NewInt(12345)
+
+	tx, err := router.SwapETHForTokens(ctx, auth, big.NewInt(1000), token, big.NewInt(900), to, deadline)
+	require.NoError(t, err)
+	require.Equal(t, "1000", tx.Value().String())
+	require.Nil(t, auth.Value)
+	method, err := routerABI.MethodById(tx.Data()[:4])
+	require.NoError(t, err)
+	require.Equal(t, "swapExactETHForTokens", method.Name)
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{utils.WETHAddress, token}, args[1])
+
+	// a value left on the authorizer isn't sent with the swap but is restored afterwards
+	auth.Value = big.NewInt(7)
+	tx, err = router.SwapTokensForETH(ctx, auth, token, big.NewInt(1000), big.NewInt(900), to, deadline)
+	require.NoError(t, err)
+	require.Equal(t, "0", tx.Value().String())
+	require.Equal(t, "7", auth.Value.String())
+	method, err = routerABI.MethodById(tx.Data()[:4])
+	require.NoError(t, err)
+	require.Equal(t, "swapExactTokensForETH", method.Name)
+	args, err = method.Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{token, utils.WETHAddress}, args[2])
+	require.Len(t, backend.sent, 2)
+}
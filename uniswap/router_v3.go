@@ -0,0 +1,328 @@
+package uniswap
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// SwapRouter02Address points to the uniswap v3 SwapRouter02.
+var SwapRouter02Address = common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45")
+
+// QuoterV2Address points to the uniswap v3 QuoterV2.
+var QuoterV2Address = common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e")
+
+// FeeTiers are the fee tiers of uniswap v3 pools in hundredths of a basis point
+var FeeTiers = []uint32{100, 500, 3000, 10000}
+
+// ValidateFeeTier returns an error if fee is not one of FeeTiers
+func ValidateFeeTier(fee uint32) error {
+	for _, tier := range FeeTiers {
+		if fee == tier {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid fee tier %d, must be one of %v", fee, FeeTiers)
+}
+
+// exactInputSingleParams mirrors the ExactInputSingleParams struct of SwapRouter02
+type exactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// quoteExactInputSingleParams mirrors the QuoteExactInputSingleParams struct of QuoterV2
+type quoteExactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	AmountIn          *big.Int
+	Fee               *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// V3Quote is the result of quoting a swap with QuoterV2
+type V3Quote struct {
+	AmountOut               *big.Int
+	SqrtPriceX96After       *big.Int
+	InitializedTicksCrossed uint32
+	GasEstimate             *big.Int
+}
+
+// V3Router wraps the uniswap v3 SwapRouter02 and QuoterV2 contracts for quoting and performing swaps
+type V3Router struct {
+	address   common.Address
+	bc        utils.Blockchain
+	routerABI abi.ABI
+	permitABI abi.ABI
+	router    *bind.BoundContract
+	quoter    *bind.BoundContract
+}
+
+// NewV3Router returns a router wrapper using the SwapRouter02 at router and the QuoterV2 at
+// quoter, which are typically SwapRouter02Address and QuoterV2Address
+func NewV3Router(router, quoter common.Address, bc utils.Blockchain) (*V3Router, error) {
+	routerABI, err := abi.JSON(strings.NewReader(swapRouter02ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse router abi")
+	}
+	quoterABI, err := abi.JSON(strings.NewReader(quoterV2ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse quoter abi")
+	}
+	parsedPermitABI, err := abi.JSON(strings.NewReader(permitABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse permit abi")
+	}
+	return &V3Router{
+		address:   router,
+		bc:        bc,
+		routerABI: routerABI,
+		permitABI: parsedPermitABI,
+		router:    bind.NewBoundContract(router, routerABI, bc, bc, bc),
+		quoter:    bind.NewBoundContract(quoter, quoterABI, bc, bc, bc),
+	}, nil
+}
+
+// NewV3RouterForChain is like NewV3Router using the SwapRouter02 and QuoterV2 deployed on
+// chain, returning an error wrapping utils.ErrNotDeployed if either is missing
+func NewV3RouterForChain(chain utils.Chain, bc utils.Blockchain) (*V3Router, error) {
+	if err := chain.Require("uniswap v3 router", chain.UniswapV3Router); err != nil {
+		return nil, err
+	}
+	if err := chain.Require("uniswap v3 quoter", chain.UniswapV3Quoter); err != nil {
+		return nil, err
+	}
+	return NewV3Router(chain.UniswapV3Router, chain.UniswapV3Quoter, bc)
+}
+
+// ExactInputSingle swaps exactly amountIn of tokenIn for at least amountOutMin of tokenOut through
+// the pool with the given fee tier, sending the output to recipient. A nil sqrtPriceLimitX96 applies
+// no price limit. The router must have been approved to spend amountIn beforehand. This claims the
+// authorizer lock and must not be called while the lock is already held.
+func (r *V3Router) ExactInputSingle(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenIn, tokenOut common.Address,
+	fee uint32,
+	amountIn, amountOutMin *big.Int,
+	recipient common.Address,
+	sqrtPriceLimitX96 *big.Int,
+) (*types.Transaction, error) {
+	if err := ValidateFeeTier(fee); err != nil {
+		return nil, err
+	}
+	params := newExactInputSingleParams(tokenIn, tokenOut, fee, amountIn, amountOutMin, recipient, sqrtPriceLimitX96)
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.router.Transact(opts, "exactInputSingle", params)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "exact input single")
+	}
+	return tx, nil
+}
+
+// ApproveAndSwapV3 is like ExactInputSingle but takes care of approving the router. When tokenIn
+// supports EIP-2612 permits, as detected through its DOMAIN_SEPARATOR and nonces methods, a permit
+// for amountIn is signed and bundled with the swap in a single multicall of the router, avoiding
+// a separate approval transaction. The multicall reverts once deadline passes, with nil using
+// utils.DefaultDeadline from now. Other tokens, along with authorizers unable to sign permits, fall
+// back to approving the router with utils.EnsureAllowance before swapping. Nothing is approved if
+// the allowance already covers amountIn. Tokens such as DAI whose permit differs from EIP-2612 are
+// not supported by selfPermit and cause the multicall to revert. The returned transactions are in
+// the order they were sent, the last being the swap. This claims the authorizer lock and must not
+// be called while the lock is already held.
+func (r *V3Router) ApproveAndSwapV3(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenIn, tokenOut common.Address,
+	fee uint32,
+	amountIn, amountOutMin *big.Int,
+	recipient common.Address,
+	sqrtPriceLimitX96, deadline *big.Int,
+) ([]*types.Transaction, error) {
+	if err := ValidateFeeTier(fee); err != nil {
+		return nil, err
+	}
+	token, err := utils.NewERC20(tokenIn, r.bc)
+	if err != nil {
+		return nil, err
+	}
+	allowance, err := token.Allowance(ctx, auth.From, r.address)
+	if err != nil {
+		return nil, err
+	}
+	if allowance.Cmp(amountIn) < 0 {
+		deadline = deadlineOrDefault(deadline)
+		tx, err := r.permitAndSwap(ctx, auth, tokenIn, tokenOut, fee, amountIn, amountOutMin, recipient, sqrtPriceLimitX96, deadline)
+		if err != nil {
+			return nil, err
+		}
+		if tx != nil {
+			return []*types.Transaction{tx}, nil
+		}
+	}
+	var txs []*types.Transaction
+	approval, err := utils.EnsureAllowance(ctx, auth, token, r.address, amountIn)
+	if err != nil {
+		return nil, errors.Wrap(err, "approve router")
+	}
+	if approval != nil {
+		txs = append(txs, approval)
+	}
+	tx, err := r.ExactInputSingle(ctx, auth, tokenIn, tokenOut, fee, amountIn, amountOutMin, recipient, sqrtPriceLimitX96)
+	if err != nil {
+		return txs, err
+	}
+	return append(txs, tx), nil
+}
+
+// permitAndSwap sends a multicall of selfPermit and exactInputSingle, returning a nil transaction
+// if tokenIn doesn't support permits or the authorizer can't sign them
+func (r *V3Router) permitAndSwap(
+	ctx context.Context,
+	auth *utils.Authorizer,
+	tokenIn, tokenOut common.Address,
+	fee uint32,
+	amountIn, amountOutMin *big.Int,
+	recipient common.Address,
+	sqrtPriceLimitX96, deadline *big.Int,
+) (*types.Transaction, error) {
+	domainSeparator, nonce, ok, err := r.permitInfo(ctx, tokenIn, auth.From)
+	if err != nil || !ok {
+		return nil, err
+	}
+	v, rs, ss, err := utils.SignPermit(auth, tokenIn, r.address, amountIn, nonce, deadline, domainSeparator)
+	if errors.Is(err, utils.ErrSignerNotSupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	permit, err := r.routerABI.Pack("selfPermit", tokenIn, amountIn, deadline, v, rs, ss)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack self permit")
+	}
+	params := newExactInputSingleParams(tokenIn, tokenOut, fee, amountIn, amountOutMin, recipient, sqrtPriceLimitX96)
+	swap, err := r.routerABI.Pack("exactInputSingle", params)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack exact input single")
+	}
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.router.Transact(opts, "multicall", deadline, [][]byte{permit, swap})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "permit and swap")
+	}
+	return tx, nil
+}
+
+// permitInfo returns the domain separator of token and the permit nonce of owner, with ok false
+// if the token doesn't implement either method
+func (r *V3Router) permitInfo(ctx context.Context, token, owner common.Address) (domainSeparator [32]byte, nonce *big.Int, ok bool, err error) {
+	contract := bind.NewBoundContract(token, r.permitABI, r.bc, r.bc, r.bc)
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "DOMAIN_SEPARATOR"); err != nil {
+		// tokens without the method revert or return no data, which fails to unpack
+		return domainSeparator, nil, false, ctx.Err()
+	}
+	domainSeparator = *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+	out = nil
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "nonces", owner); err != nil {
+		return domainSeparator, nil, false, ctx.Err()
+	}
+	return domainSeparator, *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), true, nil
+}
+
+// newExactInputSingleParams returns the params of an exactInputSingle call
+func newExactInputSingleParams(
+	tokenIn, tokenOut common.Address,
+	fee uint32,
+	amountIn, amountOutMin *big.Int,
+	recipient common.Address,
+	sqrtPriceLimitX96 *big.Int,
+) exactInputSingleParams {
+	return exactInputSingleParams{
+		TokenIn:           tokenIn,
+		TokenOut:          tokenOut,
+		Fee:               big.NewInt(int64(fee)),
+		Recipient:         recipient,
+		AmountIn:          amountIn,
+		AmountOutMinimum:  amountOutMin,
+		SqrtPriceLimitX96: priceLimitOrDefault(sqrtPriceLimitX96),
+	}
+}
+
+// QuoteExactInputSingle returns the amount of tokenOut received when swapping amountIn of tokenIn
+// through the pool with the given fee tier. A nil sqrtPriceLimitX96 applies no price limit
+func (r *V3Router) QuoteExactInputSingle(
+	ctx context.Context,
+	tokenIn, tokenOut common.Address,
+	fee uint32,
+	amountIn *big.Int,
+	sqrtPriceLimitX96 *big.Int,
+) (*V3Quote, error) {
+	if err := ValidateFeeTier(fee); err != nil {
+		return nil, err
+	}
+	params := quoteExactInputSingleParams{
+		TokenIn:           tokenIn,
+		TokenOut:          tokenOut,
+		AmountIn:          amountIn,
+		Fee:               big.NewInt(int64(fee)),
+		SqrtPriceLimitX96: priceLimitOrDefault(sqrtPriceLimitX96),
+	}
+	var out []interface{}
+	if err := r.quoter.Call(&bind.CallOpts{Context: ctx}, &out, "quoteExactInputSingle", params); err != nil {
+		return nil, errors.Wrap(err, "quote exact input single")
+	}
+	return &V3Quote{
+		AmountOut:               *abi.ConvertType(out[0], new(*big.Int)).(**big.Int),
+		SqrtPriceX96After:       *abi.ConvertType(out[1], new(*big.Int)).(**big.Int),
+		InitializedTicksCrossed: *abi.ConvertType(out[2], new(uint32)).(*uint32),
+		GasEstimate:             *abi.ConvertType(out[3], new(*big.Int)).(**big.Int),
+	}, nil
+}
+
+// Quote returns the amount of tokenOut received when swapping amountIn of tokenIn through the pool of
+// whichever fee tier yields the most, implementing utils.Quoter. Fee tiers without a pool for the
+// pair fail to quote and are skipped, with the quote failing if every tier is skipped
+func (r *V3Router) Quote(ctx context.Context, tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	var best *big.Int
+	var err error
+	for _, fee := range FeeTiers {
+		var quote *V3Quote
+		if quote, err = r.QuoteExactInputSingle(ctx, tokenIn, tokenOut, fee, amountIn, nil); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if best == nil || quote.AmountOut.Cmp(best) > 0 {
+			best = quote.AmountOut
+		}
+	}
+	if best == nil {
+		return nil, errors.Wrapf(err, "no fee tier quotes %s to %s", tokenIn.Hex(), tokenOut.Hex())
+	}
+	return best, nil
+}
+
+// priceLimitOrDefault returns sqrtPriceLimitX96, or zero meaning no limit if it is nil
+func priceLimitOrDefault(sqrtPriceLimitX96 *big.Int) *big.Int {
+	if sqrtPriceLimitX96 == nil {
+		return big.NewInt(0)
+	}
+	return sqrtPriceLimitX96
+}
@@ -0,0 +1,73 @@
+package uniswap
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFeeTier(t *testing.T) {
+	for _, fee := range []uint32{100, 500, 3000, 10000} {
+		require.NoError(t, ValidateFeeTier(fee))
+	}
+	for _, fee := range []uint32{0, 30, 1000, 100000} {
+		require.Error(t, ValidateFeeTier(fee))
+	}
+}
+
+func TestV3RouterABI(t *testing.T) {
+	routerABI, err := abi.JSON(strings.NewReader(swapRouter02ABI))
+	require.NoError(t, err)
+	data, err := routerABI.Pack("exactInputSingle", exactInputSingleParams{
+		TokenIn:           common.HexToAddress("0x1"),
+		TokenOut:          common.HexToAddress("0x2"),
+		Fee:               big.NewInt(3000),
+		Recipient:         common.HexToAddress("0x3"),
+		AmountIn:          big.NewInt(4),
+		AmountOutMinimum:  big.NewInt(5),
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "04e45aaf", common.Bytes2Hex(data[:4]))
+	require.Len(t, data, 4+7*32)
+
+	quoterABI, err := abi.JSON(strings.NewReader(quoterV2ABI))
+	require.NoError(t, err)
+	data, err = quoterABI.Pack("quoteExactInputSingle", quoteExactInputSingleParams{
+		TokenIn:           common.HexToAddress("0x1"),
+		TokenOut:          common.HexToAddress("0x2"),
+		AmountIn:          big.NewInt(4),
+		Fee:               big.NewInt(500),
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "c6a5026a", common.Bytes2Hex(data[:4]))
+}
+
+func TestPermitAndSwapABI(t *testing.T) {
+	routerABI, err := abi.JSON(strings.NewReader(swapRouter02ABI))
+	require.NoError(t, err)
+	permit, err := routerABI.Pack("selfPermit", common.HexToAddress("0x1"), big.NewInt(4), big.NewInt(5), uint8(27), [32]byte{1}, [32]byte{2})
+	require.NoError(t, err)
+	require.Equal(t, "f3995c67", common.Bytes2Hex(permit[:4]))
+	require.Len(t, permit, 4+6*32)
+	swap, err := routerABI.Pack("exactInputSingle", newExactInputSingleParams(
+		common.HexToAddress("0x1"), common.HexToAddress("0x2"), 3000, big.NewInt(4), big.NewInt(5), common.HexToAddress("0x3"), nil,
+	))
+	require.NoError(t, err)
+	data, err := routerABI.Pack("multicall", big.NewInt(6), [][]byte{permit, swap})
+	require.NoError(t, err)
+	require.Equal(t, "5ae401dc", common.Bytes2Hex(data[:4]))
+	args, err := routerABI.Methods["multicall"].Inputs.Unpack(data[4:])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{permit, swap}, args[1])
+
+	parsed, err := abi.JSON(strings.NewReader(permitABI))
+	require.NoError(t, err)
+	require.Equal(t, "3644e515", common.Bytes2Hex(parsed.Methods["DOMAIN_SEPARATOR"].ID))
+	require.Equal(t, "7ecebe00", common.Bytes2Hex(parsed.Methods["nonces"].ID))
+}
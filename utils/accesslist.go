@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// accessListResult is the result of eth_createAccessList
+type accessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// GenerateAccessList returns the EIP-2930 access list of the storage slots touched when the
+// authorizer sends value and data to the to address, along with the gas used by the transaction
+// when using the access list. Transactions touching many slots are cheaper with an access list,
+// which can be attached to the next transaction built with BuildTx using SetAccessList. Access
+// lists are only sent with access list and dynamic fee transactions, so ErrAccessListLegacy is
+// returned if the authorizer sends legacy transactions. This claims the lock and must not be
+// called while the lock is already held.
+func GenerateAccessList(ctx context.Context, client *rpc.Client, auth *Authorizer, to common.Address, data []byte, value *big.Int) (types.AccessList, uint64, error) {
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, 0, err
+	}
+	legacy := auth.isLegacy()
+	auth.Unlock()
+	if legacy {
+		return nil, 0, ErrAccessListLegacy
+	}
+	return createAccessList(ctx, client, auth.From, to, data, value)
+}
+
+// rpcCaller is implemented by *rpc.Client, and by backends wrapping one
+type rpcCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// createAccessList calls eth_createAccessList for a transaction from the from address against
+// the pending block
+func createAccessList(ctx context.Context, client rpcCaller, from, to common.Address, data []byte, value *big.Int) (types.AccessList, uint64, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	args := map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"data":  hexutil.Bytes(data),
+		"value": (*hexutil.Big)(value),
+	}
+	var result accessListResult
+	if err := client.CallContext(ctx, &result, "eth_createAccessList", args, "pending"); err != nil {
+		return nil, 0, errors.Wrap(err, "create access list")
+	}
+	if result.Error != "" {
+		return nil, 0, errors.Errorf("create access list: %s", result.Error)
+	}
+	return result.AccessList, uint64(result.GasUsed), nil
+}
+
+// SetAccessList attaches al to the next transaction built with BuildTx, after which it is
+// cleared. ErrAccessListLegacy is returned if the authorizer sends legacy transactions. The
+// contract bindings don't support access lists so they aren't attached to transactions sent
+// through them. This claims the lock and must not be called while the lock is already held.
+func (a *Authorizer) SetAccessList(al types.AccessList) error {
+	a.Lock()
+	defer a.Unlock()
+	if a.isLegacy() {
+		return ErrAccessListLegacy
+	}
+	a.accessList = al
+	return nil
+}
+
+// isLegacy returns true if the authorizer is configured to send legacy transactions, and
+// expects the caller to hold the lock
+func (a *Authorizer) isLegacy() bool {
+	switch a.TxType {
+	case TxTypeLegacy:
+		return true
+	case TxTypeAccessList, TxTypeDynamicFee:
+		return false
+	}
+	return a.GasPrice != nil && !a.isDynamicFee()
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// accessListService serves eth_createAccessList returning a fixed access list
+type accessListService struct {
+	accessList types.AccessList
+	args       map[string]interface{}
+}
+
+func (s *accessListService) CreateAccessList(args map[string]interface{}, block string) accessListResult {
+	s.args = args
+	return accessListResult{AccessList: s.accessList, GasUsed: hexutil.Uint64(30000)}
+}
+
+func TestGenerateAccessList(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	al := types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+	service := &accessListService{accessList: al}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	t.Cleanup(server.Stop)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+
+	generated, gas, err := GenerateAccessList(ctx, client, auth, to, []byte{1}, nil)
+	require.NoError(t, err)
+	require.Equal(t, al, generated)
+	require.Equal(t, uint64(30000), gas)
+	require.Equal(t, "0x01", service.args["data"])
+
+	auth.UseLegacyGas(big.NewInt(1))
+	_, _, err = GenerateAccessList(ctx, client, auth, to, nil, nil)
+	require.Equal(t, ErrAccessListLegacy, err)
+	require.Equal(t, ErrAccessListLegacy, auth.SetAccessList(al))
+}
+
+func TestSetAccessList(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	al := types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+
+	require.NoError(t, auth.SetAccessList(al))
+	tx, err := auth.BuildTx(ctx, sim, to, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	require.Equal(t, al, tx.AccessList())
+	require.NoError(t, Send(ctx, sim, tx))
+	sim.Commit()
+
+	// the access list only applies to the next transaction
+	tx, err = auth.BuildTx(ctx, sim, to, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, tx.AccessList())
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// ParseAddress parses a hex encoded address, optionally prefixed with 0x. Addresses using
+// mixed case are expected to be EIP-55 checksummed and an error wrapping ErrInvalidChecksum
+// is returned if the checksum doesn't match, which typically indicates a typo. All lower
+// or all upper case addresses carry no checksum so are accepted as is
+func ParseAddress(s string) (common.Address, error) {
+	s = strings.TrimSpace(s)
+	if !common.IsHexAddress(s) {
+		return common.Address{}, errors.Wrap(ErrInvalidAddress, s)
+	}
+	addr := common.HexToAddress(s)
+	hexPart := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if hexPart != strings.ToLower(hexPart) && hexPart != strings.ToUpper(hexPart) &&
+		hexPart != strings.TrimPrefix(ChecksumAddress(addr), "0x") {
+		return common.Address{}, errors.Wrapf(ErrInvalidChecksum, "got %s expected %s", s, ChecksumAddress(addr))
+	}
+	return addr, nil
+}
+
+// ChecksumAddress returns the EIP-55 checksummed hex encoding of addr
+func ChecksumAddress(addr common.Address) string {
+	return addr.Hex()
+}
+
+// IsContract returns true if there is code deployed at addr. Note that this
+// returns false for contracts which are still being constructed
+func IsContract(ctx context.Context, bc Blockchain, addr common.Address) (bool, error) {
+	code, err := bc.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "code at")
+	}
+	return len(code) > 0, nil
+}
+
+// Create2Address returns the address a contract deployed by deployer with CREATE2 using salt and
+// initCode will have, keccak256(0xff ++ deployer ++ salt ++ keccak256(initCode))[12:], allowing
+// the address to be known before the contract is deployed such as for counterfactual wallets
+func Create2Address(deployer common.Address, salt [32]byte, initCode []byte) common.Address {
+	return Create2AddressFromHash(deployer, salt, crypto.Keccak256Hash(initCode))
+}
+
+// Create2AddressFromHash is like Create2Address using the keccak256 hash of the init code, which
+// factories such as the uniswap v2 factory expose rather than the init code itself
+func Create2AddressFromHash(deployer common.Address, salt [32]byte, initCodeHash common.Hash) common.Address {
+	return crypto.CreateAddress2(deployer, salt, initCodeHash.Bytes())
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	// test vectors from EIP-55
+	checksummed := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+	for _, s := range checksummed {
+		addr, err := ParseAddress(s)
+		require.NoError(t, err)
+		require.Equal(t, s, ChecksumAddress(addr))
+		// addresses without a checksum are accepted
+		_, err = ParseAddress(strings.ToLower(s))
+		require.NoError(t, err)
+		_, err = ParseAddress("0x" + strings.ToUpper(s[2:]))
+		require.NoError(t, err)
+		_, err = ParseAddress(s[2:])
+		require.NoError(t, err)
+	}
+	_, err := ParseAddress("0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	require.ErrorIs(t, err, ErrInvalidChecksum)
+	_, err = ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA")
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	_, err = ParseAddress("not an address")
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
+
+func TestIsContract(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	ctx := context.Background()
+	isContract, err := IsContract(ctx, sim, auth.From)
+	require.NoError(t, err)
+	require.False(t, isContract)
+
+	// deploys a contract whose runtime code returns 42
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewContractCreation(0, big.NewInt(0), 100000, gasPrice,
+		common.FromHex("0x600a600c600039600a6000f3602a60005260206000f3")))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, tx))
+	sim.Commit()
+	isContract, err = IsContract(ctx, sim, crypto.CreateAddress(auth.From, 0))
+	require.NoError(t, err)
+	require.True(t, isContract)
+}
+
+func TestCreate2Address(t *testing.T) {
+	implementation := "bebebebebebebebebebebebebebebebebebebebe"
+	tests := []struct {
+		name     string
+		deployer string
+		salt     string
+		initCode string
+		want     string
+	}{
+		// test vectors from EIP-1014
+		{"eip1014 zero", "0x0000000000000000000000000000000000000000", "0x00", "0x00", "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"},
+		{"eip1014 deadbeef", "0x00000000000000000000000000000000deadbeef", "0xcafebabe", "0xdeadbeef", "0x60f3f640a8508fC6a86d45DF051962668E1e8AC7"},
+		{"eip1014 empty init code", "0x0000000000000000000000000000000000000000", "0x00", "0x", "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0"},
+		// a minimal proxy deployed by the OpenZeppelin Clones library with cloneDeterministic
+		{
+			"openzeppelin clone",
+			"0x5FbDB2315678afecb367f032d93F642f64180aa3",
+			"0x01",
+			"0x3d602d80600a3d3981f3363d3d373d3d3d363d73" + implementation + "5af43d82803e903d91602b57fd5bf3",
+			"0xe7f08455c6e0f72819a122837fbe5962abac51a0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer := common.HexToAddress(tt.deployer)
+			salt := common.BytesToHash(common.FromHex(tt.salt))
+			initCode := common.FromHex(tt.initCode)
+			want := common.HexToAddress(tt.want)
+			require.Equal(t, want, Create2Address(deployer, salt, initCode))
+			require.Equal(t, want, Create2AddressFromHash(deployer, salt, crypto.Keccak256Hash(initCode)))
+		})
+	}
+}
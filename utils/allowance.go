@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// SafeApprove approves spender to transfer amount tokens on behalf of the authorizer, handling
+// tokens that revert when changing a nonzero allowance to another nonzero value. Known examples
+// are USDT (0xdAC17F958D2ee523a2206206994597C13D831ec7) and the legacy KNC token
+// (0xdd974D5C2e2928deA5F71b9825b8b646686BD200). When both the current allowance and amount are
+// nonzero the approval is first simulated, and only if it reverts is the allowance reset to zero
+// with a separate transaction. The returned transactions are in the order they were sent. This
+// claims the authorizer lock and must not be called while the lock is already held.
+func SafeApprove(ctx context.Context, auth *Authorizer, token *ERC20, spender common.Address, amount *big.Int) ([]*types.Transaction, error) {
+	var txs []*types.Transaction
+	if amount.Sign() > 0 {
+		current, err := token.Allowance(ctx, auth.From, spender)
+		if err != nil {
+			return nil, err
+		}
+		if current.Sign() > 0 {
+			reverts, err := token.approveReverts(ctx, auth.From, spender, amount)
+			if err != nil {
+				return nil, err
+			}
+			if reverts {
+				tx, err := token.Approve(ctx, auth, spender, big.NewInt(0))
+				if err != nil {
+					return nil, errors.Wrap(err, "reset allowance")
+				}
+				txs = append(txs, tx)
+			}
+		}
+	}
+	tx, err := token.Approve(ctx, auth, spender, amount)
+	if err != nil {
+		return txs, err
+	}
+	return append(txs, tx), nil
+}
+
+// EnsureAllowance approves spender to transfer needed tokens on behalf of the authorizer, unless
+// the current allowance already covers it in which case a nil transaction is returned. Tokens such
+// as USDT reject changing a nonzero allowance, so SafeApprove is used to reset it to zero first when
+// required, and only the final approval is returned. This claims the authorizer lock
+// and must not be called while the lock is already held.
+func EnsureAllowance(ctx context.Context, auth *Authorizer, token *ERC20, spender common.Address, needed *big.Int) (*types.Transaction, error) {
+	return ensureAllowance(ctx, auth, token, spender, needed, needed)
+}
+
+// EnsureMaxAllowance is like EnsureAllowance except it approves the maximum uint256 value when the
+// current allowance doesn't cover needed, avoiding approvals for subsequent transfers. This claims
+// the authorizer lock and must not be called while the lock is already held.
+func EnsureMaxAllowance(ctx context.Context, auth *Authorizer, token *ERC20, spender common.Address, needed *big.Int) (*types.Transaction, error) {
+	return ensureAllowance(ctx, auth, token, spender, needed, math.MaxBig256)
+}
+
+// ensureAllowance approves amount if the current allowance is less than needed
+func ensureAllowance(ctx context.Context, auth *Authorizer, token *ERC20, spender common.Address, needed, amount *big.Int) (*types.Transaction, error) {
+	current, err := token.Allowance(ctx, auth.From, spender)
+	if err != nil {
+		return nil, err
+	}
+	if current.Cmp(needed) >= 0 {
+		return nil, nil
+	}
+	txs, err := SafeApprove(ctx, auth, token, spender, amount)
+	if err != nil {
+		return nil, err
+	}
+	return txs[len(txs)-1], nil
+}
+
+// PreflightSwap checks that owner holds at least amountIn of tokenIn and has allowed router to
+// spend it, so that a swap can fail fast with an actionable error rather than reverting on chain.
+// An error wrapping ErrInsufficientBalance or ErrInsufficientAllowance is returned respectively,
+// with the balance being checked first as approving doesn't help without the tokens
+func PreflightSwap(ctx context.Context, bc Blockchain, owner, router common.Address, tokenIn common.Address, amountIn *big.Int) error {
+	token, err := NewERC20(tokenIn, bc)
+	if err != nil {
+		return err
+	}
+	balance, err := token.BalanceOf(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(amountIn) < 0 {
+		return errors.Wrapf(ErrInsufficientBalance, "balance of %s is %s but %s is needed", owner.Hex(), balance, amountIn)
+	}
+	allowance, err := token.Allowance(ctx, owner, router)
+	if err != nil {
+		return err
+	}
+	if allowance.Cmp(amountIn) < 0 {
+		return errors.Wrapf(ErrInsufficientAllowance, "allowance of %s is %s but %s is needed", router.Hex(), allowance, amountIn)
+	}
+	return nil
+}
+
+// Allowance is the amount of Token that Spender may transfer on behalf of Owner
+type Allowance struct {
+	Token   common.Address
+	Owner   common.Address
+	Spender common.Address
+	Amount  *big.Int
+}
+
+// ListAllowances returns the allowance owner granted every spender for every token using a single
+// aggregated call, ordered by token and then by spender, such as to audit the approvals of a wallet
+// before revoking them with RevokeAll. Zero allowances are included, while allowances which couldn't
+// be read, such as for addresses which aren't tokens, are omitted rather than failing the whole batch
+func ListAllowances(ctx context.Context, mc *Multicall, owner common.Address, tokens []common.Address, spenders []common.Address) ([]Allowance, error) {
+	tokenABI, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	calls := make([]Call, 0, len(tokens)*len(spenders))
+	for _, token := range tokens {
+		for _, spender := range spenders {
+			data, err := tokenABI.Pack("allowance", owner, spender)
+			if err != nil {
+				return nil, errors.Wrap(err, "pack allowance call")
+			}
+			calls = append(calls, Call{Target: token, CallData: data})
+		}
+	}
+	results, err := mc.TryAggregate(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAllowances(owner, tokens, spenders, results), nil
+}
+
+// decodeAllowances decodes the results of the allowance calls made by ListAllowances, which are
+// ordered by token and then by spender, skipping failed calls
+func decodeAllowances(owner common.Address, tokens []common.Address, spenders []common.Address, results []CallResult) []Allowance {
+	var allowances []Allowance
+	for i, token := range tokens {
+		for j, spender := range spenders {
+			n := i*len(spenders) + j
+			// calls to accounts without code succeed with no return data
+			if n >= len(results) || !results[n].Success || len(results[n].ReturnData) != common.HashLength {
+				continue
+			}
+			allowances = append(allowances, Allowance{
+				Token:   token,
+				Owner:   owner,
+				Spender: spender,
+				Amount:  new(big.Int).SetBytes(results[n].ReturnData),
+			})
+		}
+	}
+	return allowances
+}
+
+// RevokeAll approves zero for every nonzero allowance, skipping those already zero, returning the
+// transactions in the order they were sent. Every allowance must be owned by the authorizer. If an
+// approval fails the sweep stops, returning the transactions sent so far along with the error. This
+// claims the authorizer lock and must not be called while the lock is already held.
+func RevokeAll(ctx context.Context, bc Blockchain, auth *Authorizer, allowances []Allowance) ([]*types.Transaction, error) {
+	for _, allowance := range allowances {
+		if allowance.Owner != auth.From {
+			return nil, errors.Errorf("allowance of %s for %s is owned by %s, not the authorizer", allowance.Token.Hex(), allowance.Spender.Hex(), allowance.Owner.Hex())
+		}
+	}
+	var txs []*types.Transaction
+	for _, allowance := range allowances {
+		if allowance.Amount == nil || allowance.Amount.Sign() == 0 {
+			continue
+		}
+		token, err := NewERC20(allowance.Token, bc)
+		if err != nil {
+			return txs, err
+		}
+		tx, err := token.Approve(ctx, auth, allowance.Spender, big.NewInt(0))
+		if err != nil {
+			return txs, errors.Wrapf(err, "revoke allowance of %s for %s", allowance.Token.Hex(), allowance.Spender.Hex())
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
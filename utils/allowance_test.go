@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// allowanceBackend answers balanceOf and allowance calls of a token with fixed amounts, and
+// approve calls failing with approveErr when set
+type allowanceBackend struct {
+	Blockchain
+	abi        abi.ABI
+	balance    *big.Int
+	allowance  *big.Int
+	approveErr error
+}
+
+func (b *allowanceBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	method, err := b.abi.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "balanceOf":
+		return method.Outputs.Pack(b.balance)
+	case "allowance":
+		return method.Outputs.Pack(b.allowance)
+	case "approve":
+		if b.approveErr != nil {
+			return nil, b.approveErr
+		}
+		return method.Outputs.Pack(true)
+	}
+	return nil, errors.Errorf("unexpected call to %s", method.Name)
+}
+
+// CodeAt returns non-empty code so the bindings don't consider the token undeployed
+func (b *allowanceBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func TestPreflightSwap(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	owner := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	tests := []struct {
+		name      string
+		balance   int64
+		allowance int64
+		err       error
+	}{
+		{"sufficient", 100, 100, nil},
+		{"insufficient balance", 99, 100, ErrInsufficientBalance},
+		{"insufficient allowance", 100, 99, ErrInsufficientAllowance},
+		{"balance checked first", 0, 0, ErrInsufficientBalance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := &allowanceBackend{abi: parsed, balance: big.NewInt(tt.balance), allowance: big.NewInt(tt.allowance)}
+			err := PreflightSwap(context.Background(), bc, owner, common.Address{1}, token, big.NewInt(100))
+			if tt.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.True(t, errors.Is(err, tt.err), err)
+			}
+		})
+	}
+}
+
+func TestApproveReverts(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	owner := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	usdt := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	tests := []struct {
+		name    string
+		err     error
+		reverts bool
+		fails   bool
+	}{
+		{"approves", nil, false, false},
+		{"reverts", errors.New("execution reverted"), true, false},
+		// failing to reach the node isn't mistaken for a revert
+		{"unreachable", errors.New("dial tcp: connection refused"), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := &allowanceBackend{abi: parsed, approveErr: tt.err}
+			token, err := NewERC20(usdt, bc)
+			require.NoError(t, err)
+			reverts, err := token.approveReverts(context.Background(), owner, common.Address{1}, big.NewInt(100))
+			require.Equal(t, tt.fails, err != nil, err)
+			require.Equal(t, tt.reverts, reverts)
+		})
+	}
+}
+
+func TestDecodeAllowances(t *testing.T) {
+	owner := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	dai := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	spenders := []common.Address{common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"), common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45")}
+	results := []CallResult{
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(0).Bytes(), 32)},
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(2).Bytes(), 32)},
+		{Success: false},
+		// calls to accounts without code return no data
+		{Success: true},
+	}
+	allowances := decodeAllowances(owner, []common.Address{usdc, dai}, spenders, results)
+	require.Equal(t, []Allowance{
+		{Token: usdc, Owner: owner, Spender: spenders[0], Amount: big.NewInt(0)},
+		{Token: usdc, Owner: owner, Spender: spenders[1], Amount: big.NewInt(2)},
+	}, allowances)
+}
+
+func TestRevokeAll(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	// a contract stopping without doing anything accepts the approvals in place of a token
+	token := deployCode(t, sim, auth, "6001600c60003960016000f300")
+	spenders := []common.Address{{1}, {2}, {3}}
+	allowances := []Allowance{
+		{Token: token, Owner: auth.From, Spender: spenders[0], Amount: big.NewInt(100)},
+		{Token: token, Owner: auth.From, Spender: spenders[1], Amount: big.NewInt(0)},
+		{Token: token, Owner: auth.From, Spender: spenders[2], Amount: big.NewInt(1)},
+	}
+
+	_, err = RevokeAll(ctx, sim, auth, append(allowances, Allowance{Token: token, Owner: common.Address{4}, Spender: spenders[0], Amount: big.NewInt(1)}))
+	require.Error(t, err)
+
+	txs, err := RevokeAll(ctx, sim, auth, allowances)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	for i, spender := range []common.Address{spenders[0], spenders[2]} {
+		require.Equal(t, token, *txs[i].To())
+		data, err := parsed.Pack("approve", spender, big.NewInt(0))
+		require.NoError(t, err)
+		require.Equal(t, data, txs[i].Data())
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ToWeiStrict converts a human readable amount, given as a decimal string or float64, into its
+// integer representation for a token using the given number of decimals. Unlike ToWei, strings
+// are parsed digit by digit so no floating point rounding occurs, and an error wrapping
+// ErrTooManyDecimals is returned instead of silently truncating amounts with more fractional
+// digits than the token supports. Floats are converted using their shortest decimal
+// representation, so a value such as 1.0000001 is rejected for a token with 6 decimals rather
+// than rounded
+func ToWeiStrict(amount interface{}, decimals uint8) (*big.Int, error) {
+	var value string
+	switch v := amount.(type) {
+	case string:
+		value = v
+	case float64:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, errors.Errorf("unsupported amount type %T", amount)
+	}
+	return parseUnits(value, decimals)
+}
+
+// GasPriceGwei is a gas price or fee cap denominated in gwei, used by the gas setting methods of
+// Authorizer to make the unit explicit
+type GasPriceGwei float64
+
+// Wei returns the gas price in wei, see Gwei
+func (g GasPriceGwei) Wei() *big.Int {
+	return Gwei(float64(g))
+}
+
+// Gwei returns n gwei in wei, so that Gwei(1.5) is 1500000000. Fractions of a wei are rounded to
+// the nearest wei. This panics if n is NaN or infinite
+func Gwei(n float64) *big.Int {
+	return floatUnits(n, 9)
+}
+
+// Ether returns n ether in wei, so that Ether(0.1) is 100000000000000000. Fractions of a
+// wei are rounded to the nearest wei. This panics if n is NaN or infinite
+func Ether(n float64) *big.Int {
+	return floatUnits(n, 18)
+}
+
+// ParseGwei parses a decimal amount of gwei such as "1.5" into wei, returning an error wrapping
+// ErrInvalidAmount if it isn't a decimal number, or ErrTooManyDecimals if it has more than 9
+// fractional digits, which would be a fraction of a wei
+func ParseGwei(s string) (*big.Int, error) {
+	return parseUnits(s, 9)
+}
+
+// floatUnits converts n scaled by 10^decimals using its shortest decimal representation,
+// only rounding when that has more fractional digits than decimals
+func floatUnits(n float64, decimals uint8) *big.Int {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		panic("utils: amount must be a finite number")
+	}
+	wei, err := parseUnits(strconv.FormatFloat(n, 'f', -1, 64), decimals)
+	if err != nil {
+		wei, _ = parseUnits(strconv.FormatFloat(n, 'f', int(decimals), 64), decimals)
+	}
+	return wei
+}
+
+// FromWei converts an integer token amount into an exact rational
+// number of whole tokens using the given number of decimals
+func FromWei(amount *big.Int, decimals uint8) *big.Rat {
+	return new(big.Rat).SetFrac(amount, pow10(decimals))
+}
+
+// FormatWei is like FromWei but returns the amount as a decimal string,
+// with any trailing fractional zeros removed
+func FormatWei(amount *big.Int, decimals uint8) string {
+	formatted := FromWei(amount, decimals).FloatString(int(decimals))
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(formatted, "0")
+		formatted = strings.TrimSuffix(formatted, ".")
+	}
+	return formatted
+}
+
+// parseUnits parses a decimal string such as "1.5" scaled by 10^decimals
+func parseUnits(value string, decimals uint8) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	whole, frac := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		whole, frac = value[:i], value[i+1:]
+	}
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return nil, errors.Wrap(ErrInvalidAmount, value)
+	}
+	// trailing zeros don't change the amount so aren't counted against the decimals
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > int(decimals) {
+		return nil, errors.Wrapf(ErrTooManyDecimals, "%s has %d decimals, token supports %d", value, len(frac), decimals)
+	}
+	digits := whole + frac + strings.Repeat("0", int(decimals)-len(frac))
+	wei, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, errors.Wrap(ErrInvalidAmount, value)
+	}
+	if negative {
+		wei.Neg(wei)
+	}
+	return wei, nil
+}
+
+// isDigits returns true if s only contains the digits 0-9
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pow10 returns 10^n
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToWeiStrict(t *testing.T) {
+	type args struct {
+		amount   interface{}
+		decimals uint8
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr error
+	}{
+		{"usdc-whole", args{"100", 6}, "100000000", nil},
+		{"usdc-smallest", args{"0.000001", 6}, "1", nil},
+		{"usdc-fraction-only", args{".5", 6}, "500000", nil},
+		{"usdc-trailing-zeros", args{"1.5000000000", 6}, "1500000", nil},
+		{"usdc-too-many-decimals", args{"0.0000001", 6}, "", ErrTooManyDecimals},
+		{"usdc-float", args{1.25, 6}, "1250000", nil},
+		{"usdc-float-too-many-decimals", args{1.0000001, 6}, "", ErrTooManyDecimals},
+		{"weth-whole", args{"1", 18}, "1000000000000000000", nil},
+		{"weth-smallest", args{"0.000000000000000001", 18}, "1", nil},
+		{"weth-too-many-decimals", args{"0.0000000000000000001", 18}, "", ErrTooManyDecimals},
+		{"weth-large", args{"123456789.123456789123456789", 18}, "123456789123456789123456789", nil},
+		{"weth-negative", args{"-1.5", 18}, "-1500000000000000000", nil},
+		{"weth-float", args{0.5, 18}, "500000000000000000", nil},
+		{"empty", args{"", 18}, "", ErrInvalidAmount},
+		{"dot", args{".", 18}, "", ErrInvalidAmount},
+		{"letters", args{"1.5e3", 18}, "", ErrInvalidAmount},
+		{"two-dots", args{"1.2.3", 18}, "", ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToWeiStrict(tt.args.amount, tt.args.decimals)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.String())
+		})
+	}
+	_, err := ToWeiStrict(int64(1), 18)
+	require.Error(t, err)
+}
+
+func TestFromWei(t *testing.T) {
+	usdc, _ := new(big.Int).SetString("1500001", 10)
+	require.Equal(t, "1500001/1000000", FromWei(usdc, 6).String())
+	require.Equal(t, "1.500001", FormatWei(usdc, 6))
+	weth, _ := new(big.Int).SetString("1500000000000000000", 10)
+	require.Equal(t, "3/2", FromWei(weth, 18).String())
+	require.Equal(t, "1.5", FormatWei(weth, 18))
+	require.Equal(t, "0.000000000000000001", FormatWei(big.NewInt(1), 18))
+	require.Equal(t, "0", FormatWei(big.NewInt(0), 6))
+	require.Equal(t, "42", FormatWei(big.NewInt(42), 0))
+	// round trips without loss of precision
+	for _, amount := range []string{"0.000001", "1", "123456.789"} {
+		wei, err := ToWeiStrict(amount, 6)
+		require.NoError(t, err)
+		require.Equal(t, amount, FormatWei(wei, 6))
+	}
+}
+
+func TestGwei(t *testing.T) {
+	require.Equal(t, big.NewInt(1_500_000_000), Gwei(1.5))
+	require.Equal(t, "100000000", Gwei(0.1).String())
+	require.Equal(t, "2", Gwei(0.0000000016).String())
+	require.Equal(t, "0", Gwei(0).String())
+	require.Equal(t, "100000000000000000", Ether(0.1).String())
+	require.Equal(t, "1500000000000000000000", Ether(1500).String())
+	require.Panics(t, func() { Gwei(math.NaN()) })
+
+	wei, err := ParseGwei("2.5")
+	require.NoError(t, err)
+	require.Equal(t, "2500000000", wei.String())
+	_, err = ParseGwei("0.0000000001")
+	require.True(t, errors.Is(err, ErrTooManyDecimals))
+	_, err = ParseGwei("2.5 gwei")
+	require.True(t, errors.Is(err, ErrInvalidAmount))
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	auth.SetDynamicFeesGwei(30, 1.5)
+	require.Equal(t, "30000000000", auth.GasFeeCap.String())
+	require.Equal(t, "1500000000", auth.GasTipCap.String())
+	auth.UseLegacyGasGwei(GasPriceGwei(20))
+	require.Equal(t, "20000000000", auth.GasPrice.String())
+	require.Nil(t, auth.GasFeeCap)
+}
@@ -1,13 +1,21 @@
 package utils
 
 import (
+	"context"
 	"crypto/ecdsa"
+	"encoding/hex"
 	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
 )
@@ -18,12 +26,68 @@ import (
 // Whenever using the embed bind.TransactOpts you must call Authorizer::Lock and
 // Authorizer::Unlock to avoid any possible race conditions
 type Authorizer struct {
-	mx sync.Mutex
+	// sem is a buffered channel of size 1 used as the lock, which unlike
+	// sync.Mutex allows for acquisition to be bounded by a context
+	sem     chan struct{}
+	semOnce sync.Once
+	// hardware indicates signing is delegated to a hardware wallet
+	hardware bool
+	// pk is the private key used for signing arbitrary digests, and is nil
+	// for authorizers whose key is held externally such as by a KMS
+	pk *ecdsa.PrivateKey
+	// impersonated is the signer of authorizers created by NewImpersonatedAuthorizer
+	impersonated *impersonatedSigner
+	// accessList is attached to the next transaction built, see SetAccessList
+	accessList types.AccessList
+	// Hooks are optional callbacks invoked as transactions are signed and sent
+	Hooks *Hooks
+	// GasLimitMultiplier scales gas limits estimated by the send helpers, such that 1.3 bumps
+	// them by 30%. Values of 1 or less, including the zero value, leave estimates unchanged.
+	// An explicitly set GasLimit is used as is, see EstimateAndSetGas
+	GasLimitMultiplier float64
+	// DryRun makes the send helpers simulate transactions with eth_call and log them instead of
+	// broadcasting, returning the signed transaction as if it was sent. Waiting for a dry run
+	// transaction returns a synthetic successful receipt with block number zero, once, after which
+	// the transaction is forgotten, as are the oldest of over 1024 not waited for. Transactions sent
+	// through contract bindings are simulated by their gas estimation, so aren't simulated again,
+	// and aren't simulated at all if GasLimit is set. Impersonated authorizers don't support it
+	DryRun bool
+	// MaxGasPriceWei caps the gas price of legacy transactions and max fee per gas of EIP-1559
+	// transactions signed by the authorizer, failing with ErrGasPriceTooHigh rather than signing
+	// and broadcasting transactions above it. Nil leaves the price uncapped
+	MaxGasPriceWei *big.Int
+	// TxType overrides the type of the transactions sent, which by default is picked from the gas
+	// settings and the chain. Gas settings incompatible with the type, such as GasPrice with
+	// TxTypeDynamicFee, fail with ErrTxTypeIncompatible. Access list transactions built with BuildTx
+	// without one set with SetAccessList get it generated with eth_createAccessList, which requires a
+	// backend implementing CallContext like *rpc.Client. The bindings don't support access lists so
+	// only legacy transactions, when GasPrice is set, and dynamic fee transactions can be sent through them
+	TxType TxType
+	// PollInterval is the delay between polls when waiting for transactions sent with the
+	// authorizer by TxQueue and the helpers of the protocol wrappers taking wait options, such as
+	// minting uniswap v3 positions. Zero uses DefaultPollInterval, and WithPollInterval overrides it
+	PollInterval time.Duration
+	// Metrics accumulates counters of the transactions sent, and of those mined when waited on with
+	// WaitDefaults. Nil disables them, and clones share the metrics of the authorizer
+	Metrics *Metrics
+	// dryRunSigned is the last transaction signed through the bindings in dry run mode
+	dryRunSigned *types.Transaction
+	// gasRefresh is the loop started by StartGasRefresh, which clones don't inherit
+	gasRefresh *gasRefresh
+	// legacyBackend is the backend AutoGas last found not to support EIP-1559
+	legacyBackend Blockchain
 	*bind.TransactOpts
 }
 
-// NewAuthorizer returns an Authorizer object using a keyfile as the account source
+// NewAuthorizer returns an Authorizer object using a keyfile as the account source,
+// signing transactions for mainnet. Use NewAuthorizerWithChainID for other chains
 func NewAuthorizer(keyFile, keyPass string) (*Authorizer, error) {
+	return NewAuthorizerWithChainID(keyFile, keyPass, MainnetChainID())
+}
+
+// NewAuthorizerWithChainID returns an Authorizer object using a keyfile as the account
+// source, signing transactions for the given chain id
+func NewAuthorizerWithChainID(keyFile, keyPass string, chainID *big.Int) (*Authorizer, error) {
 	fileBytes, err := ioutil.ReadFile(keyFile)
 	if err != nil {
 		return nil, errors.Wrap(err, "read file")
@@ -32,36 +96,380 @@ func NewAuthorizer(keyFile, keyPass string) (*Authorizer, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "decrypt key")
 	}
-	return NewAuthorizerFromPK(pk.PrivateKey), nil
+	return NewAuthorizerFromPKWithChainID(pk.PrivateKey, chainID)
 }
 
-// NewAuthorizerFromPK returns an authorizer from a private key
+// NewAuthorizerFromPK returns an authorizer from a private key signing transactions for mainnet
+//
+// Deprecated: use NewAuthorizerFromPKWithChainID which binds transactions to an explicit chain id
 func NewAuthorizerFromPK(pk *ecdsa.PrivateKey) *Authorizer {
-	return &Authorizer{TransactOpts: bind.NewKeyedTransactor(pk)}
+	// the only error condition is a nil chain id
+	auth, _ := NewAuthorizerFromPKWithChainID(pk, MainnetChainID())
+	return auth
+}
+
+// NewAuthorizerFromPKWithChainID returns an authorizer from a private key which signs transactions
+// for the given chain id, providing EIP-155 replay protection against other chains. A nil chain id
+// returns ErrNilChainID
+func NewAuthorizerFromPKWithChainID(pk *ecdsa.PrivateKey, chainID *big.Int) (*Authorizer, error) {
+	auth, err := NewAuthorizerFromSigner(NewLocalSigner(pk), chainID)
+	if err != nil {
+		return nil, err
+	}
+	auth.pk = pk
+	return auth, nil
+}
+
+// MainnetChainID returns the chain id of ethereum mainnet which
+// is used by constructors that do not take an explicit chain id
+func MainnetChainID() *big.Int {
+	return big.NewInt(1)
+}
+
+// NewAuthorizerFromHex returns an authorizer from a hex encoded private key, which may
+// optionally be prefixed with 0x, signing transactions for mainnet. Use
+// NewAuthorizerFromHexWithChainID for other chains. ErrInvalidKeyLength is returned if the key is
+// not 32 bytes long, while ErrInvalidKey is returned if the key is not a valid secp256k1 scalar
+func NewAuthorizerFromHex(hexKey string) (*Authorizer, error) {
+	return NewAuthorizerFromHexWithChainID(hexKey, MainnetChainID())
+}
+
+// NewAuthorizerFromHexWithChainID is like NewAuthorizerFromHex but signs transactions for the
+// given chain id, returning ErrNilChainID if it is nil
+func NewAuthorizerFromHexWithChainID(hexKey string, chainID *big.Int) (*Authorizer, error) {
+	hexKey = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"), "0X")
+	keyBytes, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode hex")
+	}
+	if len(keyBytes) != 32 {
+		return nil, ErrInvalidKeyLength
+	}
+	pk, err := crypto.ToECDSA(keyBytes)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidKey, err.Error())
+	}
+	return NewAuthorizerFromPKWithChainID(pk, chainID)
+}
+
+// NewAuthorizerFromEnv is a wrapper around NewAuthorizerFromHex which reads
+// the hex encoded private key from the environment variable envVar
+func NewAuthorizerFromEnv(envVar string) (*Authorizer, error) {
+	return NewAuthorizerFromEnvWithChainID(envVar, MainnetChainID())
+}
+
+// NewAuthorizerFromEnvWithChainID is like NewAuthorizerFromEnv but signs transactions for the
+// given chain id
+func NewAuthorizerFromEnvWithChainID(envVar string, chainID *big.Int) (*Authorizer, error) {
+	hexKey, ok := os.LookupEnv(envVar)
+	if !ok || hexKey == "" {
+		return nil, errors.Errorf("environment variable %s is not set", envVar)
+	}
+	return NewAuthorizerFromHexWithChainID(hexKey, chainID)
 }
 
 // NewKeyFile returns a new ethereum account as generated by `geth account new`
 func NewKeyFile(keyFileDir, keyPass string) (accounts.Account, error) {
-	return keystore.StoreKey(keyFileDir, keyPass, keystore.StandardScryptN, keystore.StandardScryptP)
+	return NewKeyFileWithParams(keyFileDir, keyPass, keystore.StandardScryptN, keystore.StandardScryptP)
 }
 
-// NewAccount creates a new ethereum private key, and associated transactor
+// NewKeyFileWithParams is like NewKeyFile but allows specifying the scrypt parameters used to
+// encrypt the key. keystore.LightScryptN and keystore.LightScryptP are significantly faster than
+// the standard parameters which makes them suitable for tests and resource constrained devices
+func NewKeyFileWithParams(keyFileDir, keyPass string, n, p int) (accounts.Account, error) {
+	if err := (ScryptParams{N: n, P: p}).validate(); err != nil {
+		return accounts.Account{}, err
+	}
+	return keystore.StoreKey(keyFileDir, keyPass, n, p)
+}
+
+// NewAccount creates a new ethereum private key, and associated transactor signing transactions
+// for mainnet. Use NewAccountWithChainID for other chains
 func NewAccount() (*bind.TransactOpts, *ecdsa.PrivateKey, error) {
+	return NewAccountWithChainID(MainnetChainID())
+}
+
+// NewAccountWithChainID is like NewAccount but the transactor signs transactions for the given
+// chain id, returning ErrNilChainID if it is nil
+func NewAccountWithChainID(chainID *big.Int) (*bind.TransactOpts, *ecdsa.PrivateKey, error) {
+	if chainID == nil {
+		return nil, nil, ErrNilChainID
+	}
 	key, err := crypto.GenerateKey()
 	if err != nil {
 		return nil, nil, err
 	}
-	return bind.NewKeyedTransactor(key), key, err
+	opts, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "new keyed transactor")
+	}
+	return opts, key, nil
+}
+
+// NewDeterministicAccount derives a private key and associated transactor from seed, always
+// returning the same account for the same seed so that test fixtures are reproducible. The key is
+// the keccak256 hash of the seed, rehashed until it is a valid secp256k1 private key. The
+// transactor signs transactions for mainnet, use NewDeterministicAccountWithChainID for other
+// chains such as the simulated backend.
+//
+// This is for testing only and must never be used for accounts holding real funds, as anyone
+// knowing or guessing the seed can derive the key.
+func NewDeterministicAccount(seed []byte) (*bind.TransactOpts, *ecdsa.PrivateKey, error) {
+	return NewDeterministicAccountWithChainID(seed, MainnetChainID())
+}
+
+// NewDeterministicAccountWithChainID is like NewDeterministicAccount but the transactor signs
+// transactions for the given chain id, returning ErrNilChainID if it is nil
+func NewDeterministicAccountWithChainID(seed []byte, chainID *big.Int) (*bind.TransactOpts, *ecdsa.PrivateKey, error) {
+	if chainID == nil {
+		return nil, nil, ErrNilChainID
+	}
+	if len(seed) == 0 {
+		return nil, nil, errors.New("seed must not be empty")
+	}
+	hash := crypto.Keccak256(seed)
+	for {
+		key, err := crypto.ToECDSA(hash)
+		if err == nil {
+			opts, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "new keyed transactor")
+			}
+			return opts, key, nil
+		}
+		hash = crypto.Keccak256(hash)
+	}
 }
 
 // Lock is used to claim a lock on the authorizer type
 // and must be called before using it for transaction signing
 func (a *Authorizer) Lock() {
-	a.mx.Lock()
+	a.lock() <- struct{}{}
+}
+
+// LockContext is like Lock except it returns ctx.Err() if the
+// context is cancelled before the lock could be claimed
+func (a *Authorizer) LockContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case a.lock() <- struct{}{}:
+		return nil
+	}
 }
 
 // Unlock is used to release a lock on the authorizer type
 // and must be called after using it for transaction signing
 func (a *Authorizer) Unlock() {
-	a.mx.Unlock()
+	select {
+	case <-a.lock():
+	default:
+		panic("utils: unlock of unlocked authorizer")
+	}
+}
+
+// lock returns the channel backing the lock, lazily creating it
+// so that a zero value Authorizer remains usable
+func (a *Authorizer) lock() chan struct{} {
+	a.semOnce.Do(func() {
+		a.sem = make(chan struct{}, 1)
+	})
+	return a.sem
+}
+
+// SetDynamicFees configures the authorizer to send EIP-1559 (type-2) transactions
+// using the given fee caps. The legacy gas price is cleared as go-ethereum rejects
+// transactions which specify both a gas price and fee caps. This claims the lock
+// and must not be called while the lock is already held.
+func (a *Authorizer) SetDynamicFees(maxFeePerGas, maxPriorityFeePerGas *big.Int) {
+	a.Lock()
+	defer a.Unlock()
+	a.GasPrice = nil
+	a.GasFeeCap = maxFeePerGas
+	a.GasTipCap = maxPriorityFeePerGas
+}
+
+// UseLegacyGas configures the authorizer to send legacy (type-0) transactions
+// using the given gas price, clearing any previously set EIP-1559 fee caps. This
+// claims the lock and must not be called while the lock is already held.
+func (a *Authorizer) UseLegacyGas(gasPrice *big.Int) {
+	a.Lock()
+	defer a.Unlock()
+	a.GasFeeCap = nil
+	a.GasTipCap = nil
+	a.GasPrice = gasPrice
+}
+
+// SetDynamicFeesGwei is like SetDynamicFees but takes the fee caps in gwei, which prevents passing
+// an amount in gwei where wei are expected. This claims the lock and must not be called while the
+// lock is already held.
+func (a *Authorizer) SetDynamicFeesGwei(maxFeePerGas, maxPriorityFeePerGas GasPriceGwei) {
+	a.SetDynamicFees(maxFeePerGas.Wei(), maxPriorityFeePerGas.Wei())
+}
+
+// UseLegacyGasGwei is like UseLegacyGas but takes the gas price in gwei. This claims the
+// lock and must not be called while the lock is already held.
+func (a *Authorizer) UseLegacyGasGwei(gasPrice GasPriceGwei) {
+	a.UseLegacyGas(gasPrice.Wei())
+}
+
+// IsDynamicFee returns true if the authorizer is configured to send EIP-1559
+// transactions. This claims the lock and must not be called while the lock is already held.
+func (a *Authorizer) IsDynamicFee() bool {
+	a.Lock()
+	defer a.Unlock()
+	return a.isDynamicFee()
+}
+
+// isDynamicFee is like IsDynamicFee but expects the caller to hold the lock
+func (a *Authorizer) isDynamicFee() bool {
+	return a.GasFeeCap != nil || a.GasTipCap != nil
+}
+
+// Address returns the address of the account used by the authorizer
+func (a *Authorizer) Address() common.Address {
+	return a.From
+}
+
+// PublicKey returns the public key of the authorizer, or nil if the
+// authorizer signs using an external signer such as a KMS or hardware wallet
+func (a *Authorizer) PublicKey() *ecdsa.PublicKey {
+	if a.pk == nil {
+		return nil
+	}
+	return &a.pk.PublicKey
+}
+
+// ExportHex returns the 0x prefixed hex encoding of the private key, which must be kept secret
+// as it grants full control of the account. Authorizers backed by hardware wallets or a KMS
+// never hold the key and return ErrKeyNotExportable
+func (a *Authorizer) ExportHex() (string, error) {
+	if a.pk == nil {
+		return "", ErrKeyNotExportable
+	}
+	keyBytes := crypto.FromECDSA(a.pk)
+	encoded := make([]byte, hex.EncodedLen(len(keyBytes)))
+	hex.Encode(encoded, keyBytes)
+	// the returned string is a copy so the intermediate buffers are zeroed
+	exported := "0x" + string(encoded)
+	zeroBytes(keyBytes)
+	zeroBytes(encoded)
+	return exported, nil
+}
+
+// zeroBytes overwrites b with zeros
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// sign signs a 32 byte digest returning a 65 byte [R || S || V] signature with V being 0 or 1.
+// ErrSignerNotSupported is returned if the authorizer doesn't hold the private key
+func (a *Authorizer) sign(hash []byte) ([]byte, error) {
+	if a.pk == nil {
+		return nil, ErrSignerNotSupported
+	}
+	return crypto.Sign(hash, a.pk)
+}
+
+// WithValue sets the value sent with the next transaction, returning a function restoring
+// the previous value. This expects the caller to hold the lock for the lifetime of the value
+// so that it doesn't leak into unrelated transactions, which is most easily done by deferring
+// the restore immediately after claiming the lock:
+//
+//	auth.Lock()
+//	defer auth.Unlock()
+//	defer auth.WithValue(amount)()
+func (a *Authorizer) WithValue(v *big.Int) (restore func()) {
+	prev := a.Value
+	a.Value = v
+	return func() { a.Value = prev }
+}
+
+// Clone returns a copy of the authorizer with its own lock and transaction options, allowing a
+// goroutine to customize the nonce, gas and value settings of a single transaction without racing
+// with or leaking into other users of the authorizer. Cloned authorizers share the signing key,
+// signer and hooks, but have independent nonce, gas and value fields, which are copied so they can
+// safely be modified in place. Any access list set with SetAccessList is copied as well. As nonces
+// aren't coordinated between clones, either set the nonce explicitly or leave it to the node.
+// This claims the lock and must not be called while the lock is already held.
+func (a *Authorizer) Clone() *Authorizer {
+	a.Lock()
+	defer a.Unlock()
+	opts := *a.TransactOpts
+	opts.Nonce = copyBig(opts.Nonce)
+	opts.Value = copyBig(opts.Value)
+	opts.GasPrice = copyBig(opts.GasPrice)
+	opts.GasFeeCap = copyBig(opts.GasFeeCap)
+	opts.GasTipCap = copyBig(opts.GasTipCap)
+	clone := &Authorizer{
+		hardware:           a.hardware,
+		pk:                 a.pk,
+		Hooks:              a.Hooks,
+		GasLimitMultiplier: a.GasLimitMultiplier,
+		DryRun:             a.DryRun,
+		MaxGasPriceWei:     copyBig(a.MaxGasPriceWei),
+		TxType:             a.TxType,
+		PollInterval:       a.PollInterval,
+		Metrics:            a.Metrics,
+		TransactOpts:       &opts,
+	}
+	if a.accessList != nil {
+		clone.accessList = append(types.AccessList{}, a.accessList...)
+	}
+	if a.impersonated != nil {
+		// the impersonated signer tracks submissions under the lock so the clone needs its own
+		signer := &impersonatedSigner{client: a.impersonated.client, address: a.impersonated.address, auth: clone}
+		clone.impersonated = signer
+		clone.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(tx, nil)
+		}
+	}
+	return clone
+}
+
+// copyBig returns a copy of v, or nil if v is nil
+func copyBig(v *big.Int) *big.Int {
+	if v == nil {
+		return nil
+	}
+	return new(big.Int).Set(v)
+}
+
+// Transact claims the lock and invokes fn with the authorizer's transaction options, using ctx
+// for the duration of the call. This is the preferred way of sending transactions through
+// contract bindings as it guarantees the lock is held while signing. Errors returned by fn
+// which are recognized by ClassifySendError are wrapped in a *SendError. This claims the lock
+// and must not be called while the lock is already held.
+func (a *Authorizer) Transact(ctx context.Context, fn func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	if err := a.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer a.Unlock()
+	prev := a.Context
+	a.Context = ctx
+	defer func() { a.Context = prev }()
+	defer a.wrapSigner()()
+	if a.impersonated != nil {
+		a.impersonated.submitted = nil
+	}
+	tx, err := fn(a.TransactOpts)
+	if dry, ok := a.takeDryRun(err); ok {
+		return dry, nil
+	}
+	if err != nil && a.impersonated != nil && a.impersonated.submitted != nil {
+		// the node broadcast the transaction when it was signed so the error is from sending it again
+		tx, err = a.impersonated.submitted, nil
+	}
+	if err != nil && ClassifySendError(err) != SendErrorUnknown {
+		err = newSendError(err)
+	}
+	if err == nil && tx != nil {
+		a.sent(tx)
+	}
+	return tx, err
 }
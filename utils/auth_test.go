@@ -1,9 +1,18 @@
 package utils
 
 import (
+	"context"
+	"math/big"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,3 +26,236 @@ func TestNewAuthorizer(t *testing.T) {
 	_, err = NewAuthorizer(acct.URL.Path, "password123")
 	require.NoError(t, err)
 }
+
+func TestNewAuthorizerFromPKWithChainID(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = NewAuthorizerFromPKWithChainID(pk, nil)
+	require.ErrorIs(t, err, ErrNilChainID)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(0), 21000, big.NewInt(1), nil))
+	require.NoError(t, err)
+	require.True(t, tx.Protected())
+	require.Equal(t, big.NewInt(1337), tx.ChainId())
+}
+
+func TestNewKeyFileWithParams(t *testing.T) {
+	t.Cleanup(func() {
+		os.RemoveAll("testParamsDir")
+	})
+	require.NoError(t, os.MkdirAll("testParamsDir", os.ModePerm))
+	acct, err := NewKeyFileWithParams("testParamsDir", "password123", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+	_, err = NewAuthorizer(acct.URL.Path, "password123")
+	require.NoError(t, err)
+	_, err = NewKeyFileWithParams("testParamsDir", "password123", 1000, keystore.LightScryptP)
+	require.Error(t, err)
+	_, err = NewKeyFileWithParams("testParamsDir", "password123", 1, keystore.LightScryptP)
+	require.Error(t, err)
+}
+
+func TestAuthorizerGasModes(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	require.False(t, auth.IsDynamicFee())
+
+	auth.SetDynamicFees(big.NewInt(100), big.NewInt(2))
+	require.True(t, auth.IsDynamicFee())
+	require.Nil(t, auth.GasPrice)
+	require.Equal(t, big.NewInt(100), auth.GasFeeCap)
+	require.Equal(t, big.NewInt(2), auth.GasTipCap)
+
+	auth.UseLegacyGas(big.NewInt(50))
+	require.False(t, auth.IsDynamicFee())
+	require.Nil(t, auth.GasFeeCap)
+	require.Nil(t, auth.GasTipCap)
+	require.Equal(t, big.NewInt(50), auth.GasPrice)
+}
+
+func TestAuthorizerLockContext(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	require.NoError(t, auth.LockContext(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	require.ErrorIs(t, auth.LockContext(ctx), context.DeadlineExceeded)
+	auth.Unlock()
+	require.NoError(t, auth.LockContext(context.Background()))
+	auth.Unlock()
+}
+
+func TestNewAuthorizerFromHex(t *testing.T) {
+	const hexKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	want := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	auth, err := NewAuthorizerFromHex(hexKey)
+	require.NoError(t, err)
+	require.Equal(t, want, auth.From)
+	auth, err = NewAuthorizerFromHex("0x" + hexKey)
+	require.NoError(t, err)
+	require.Equal(t, want, auth.From)
+
+	_, err = NewAuthorizerFromHex("0xabcd")
+	require.ErrorIs(t, err, ErrInvalidKeyLength)
+	_, err = NewAuthorizerFromHex("0x0000000000000000000000000000000000000000000000000000000000000000")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = NewAuthorizerFromHex("not hex")
+	require.Error(t, err)
+
+	// legacy transactions are replay protected for the chain of the authorizer
+	auth, err = NewAuthorizerFromHexWithChainID(hexKey, big.NewInt(1337))
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, want, big.NewInt(1), 21000, big.NewInt(1), nil))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1337), tx.ChainId())
+	_, err = NewAuthorizerFromHexWithChainID(hexKey, nil)
+	require.ErrorIs(t, err, ErrNilChainID)
+
+	require.NoError(t, os.Setenv("GO_DEFI_TEST_KEY", hexKey))
+	defer os.Unsetenv("GO_DEFI_TEST_KEY")
+	auth, err = NewAuthorizerFromEnv("GO_DEFI_TEST_KEY")
+	require.NoError(t, err)
+	require.Equal(t, want, auth.From)
+	_, err = NewAuthorizerFromEnv("GO_DEFI_TEST_KEY_UNSET")
+	require.Error(t, err)
+}
+
+func TestAuthorizerTransact(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "transact")
+	prev := auth.Context
+	_, err = auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		require.Equal(t, ctx, opts.Context)
+		// the lock must be held while fn runs
+		lockCtx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+		require.ErrorIs(t, auth.LockContext(lockCtx), context.DeadlineExceeded)
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, prev, auth.Context)
+	require.NoError(t, auth.LockContext(context.Background()))
+	auth.Unlock()
+}
+
+func TestAuthorizerKeyAccessors(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), auth.Address())
+	require.Equal(t, &pk.PublicKey, auth.PublicKey())
+	// authorizers without a private key, such as kms and hardware wallets
+	external := &Authorizer{TransactOpts: auth.TransactOpts}
+	require.Equal(t, auth.Address(), external.Address())
+	require.Nil(t, external.PublicKey())
+	_, err = external.sign(make([]byte, 32))
+	require.ErrorIs(t, err, ErrSignerNotSupported)
+	_, err = external.ExportHex()
+	require.ErrorIs(t, err, ErrKeyNotExportable)
+}
+
+func TestAuthorizerExportHex(t *testing.T) {
+	hexKey := hexutil.Encode(crypto.Keccak256([]byte("cow")))
+	auth, err := NewAuthorizerFromHex(hexKey)
+	require.NoError(t, err)
+	exported, err := auth.ExportHex()
+	require.NoError(t, err)
+	require.Equal(t, hexKey, exported)
+	reloaded, err := NewAuthorizerFromHex(exported)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, reloaded.From)
+}
+
+func TestAuthorizerWithValue(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	auth.Lock()
+	restore := auth.WithValue(big.NewInt(1))
+	require.Equal(t, big.NewInt(1), auth.Value)
+	restoreNested := auth.WithValue(big.NewInt(2))
+	require.Equal(t, big.NewInt(2), auth.Value)
+	restoreNested()
+	require.Equal(t, big.NewInt(1), auth.Value)
+	restore()
+	require.Nil(t, auth.Value)
+	auth.Unlock()
+}
+
+func TestAuthorizerClone(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	auth.SetDynamicFees(big.NewInt(100000000000), big.NewInt(1000000000))
+	auth.Hooks = &Hooks{}
+
+	clone := auth.Clone()
+	require.Equal(t, auth.From, clone.From)
+	require.Equal(t, auth.Hooks, clone.Hooks)
+	require.Equal(t, "1000000000", clone.GasTipCap.String())
+	// the clone has its own lock and fields
+	auth.Lock()
+	require.NoError(t, clone.LockContext(ctx))
+	clone.GasTipCap.SetInt64(2000000000)
+	clone.GasFeeCap = big.NewInt(200000000000)
+	clone.GasLimit = 50000
+	clone.Unlock()
+	auth.Unlock()
+	require.Equal(t, "1000000000", auth.GasTipCap.String())
+	require.Equal(t, "100000000000", auth.GasFeeCap.String())
+	require.Equal(t, uint64(0), auth.GasLimit)
+
+	// while signing with the same key
+	sim := newSimulatedBackend(t, auth.From)
+	tx, err := clone.BuildTx(ctx, sim, auth.From, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "2000000000", tx.GasTipCap().String())
+	require.Equal(t, uint64(50000), tx.Gas())
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, sender)
+	sig, err := clone.SignMessage([]byte("hello"))
+	require.NoError(t, err)
+	signer, err := VerifyMessage([]byte("hello"), sig)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, signer)
+}
+
+func TestNewDeterministicAccount(t *testing.T) {
+	opts, key, err := NewDeterministicAccount([]byte("go-defi test fixture"))
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0x3e20abeef5dc71249227418b94dbef1f85077964"), opts.From)
+	require.Equal(t, opts.From, crypto.PubkeyToAddress(key.PublicKey))
+	// the same seed always yields the same account
+	again, _, err := NewDeterministicAccount([]byte("go-defi test fixture"))
+	require.NoError(t, err)
+	require.Equal(t, opts.From, again.From)
+	other, _, err := NewDeterministicAccount([]byte("another fixture"))
+	require.NoError(t, err)
+	require.NotEqual(t, opts.From, other.From)
+	_, _, err = NewDeterministicAccount(nil)
+	require.Error(t, err)
+
+	for _, chainID := range []*big.Int{MainnetChainID(), big.NewInt(1337)} {
+		opts, _, err := NewDeterministicAccountWithChainID([]byte("go-defi test fixture"), chainID)
+		require.NoError(t, err)
+		tx, err := opts.Signer(opts.From, types.NewTransaction(0, opts.From, big.NewInt(1), 21000, big.NewInt(1), nil))
+		require.NoError(t, err)
+		require.Equal(t, chainID, tx.ChainId())
+		opts, _, err = NewAccountWithChainID(chainID)
+		require.NoError(t, err)
+		tx, err = opts.Signer(opts.From, types.NewTransaction(0, opts.From, big.NewInt(1), 21000, big.NewInt(1), nil))
+		require.NoError(t, err)
+		require.Equal(t, chainID, tx.ChainId())
+	}
+	_, _, err = NewAccountWithChainID(nil)
+	require.ErrorIs(t, err, ErrNilChainID)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuthorizerConfig holds the defaults of an authorizer created with NewAuthorizerWithConfig, with
+// zero fields using the default of the corresponding Authorizer field
+type AuthorizerConfig struct {
+	// ChainID is the chain transactions are signed for, defaulting to mainnet when nil
+	ChainID *big.Int
+	// GasLimitMultiplier scales estimated gas limits, see Authorizer.GasLimitMultiplier. Zero
+	// leaves estimates unchanged, otherwise it must be at least 1
+	GasLimitMultiplier float64
+	// MaxGasPriceWei caps the gas price of transactions, see Authorizer.MaxGasPriceWei. Nil leaves
+	// the price uncapped, otherwise it must be positive
+	MaxGasPriceWei *big.Int
+	// FeeMode selects the type, and so the pricing, of the transactions sent, see
+	// Authorizer.TxType. Zero uses TxTypeAuto
+	FeeMode FeeMode
+	// PollInterval is the delay between polls when waiting for transactions, see
+	// Authorizer.PollInterval. Zero uses DefaultPollInterval
+	PollInterval time.Duration
+}
+
+// validate returns an error if any field of the config is invalid
+func (c AuthorizerConfig) validate() error {
+	if c.ChainID != nil && c.ChainID.Sign() <= 0 {
+		return errors.Errorf("chain id %s must be positive", c.ChainID)
+	}
+	if math.IsNaN(c.GasLimitMultiplier) || math.IsInf(c.GasLimitMultiplier, 0) || (c.GasLimitMultiplier != 0 && c.GasLimitMultiplier < 1) {
+		return errors.Errorf("gas limit multiplier %v must be at least 1", c.GasLimitMultiplier)
+	}
+	if c.MaxGasPriceWei != nil && c.MaxGasPriceWei.Sign() <= 0 {
+		return errors.Errorf("max gas price %s must be positive", c.MaxGasPriceWei)
+	}
+	if c.FeeMode > TxTypeDynamicFee {
+		return errors.Wrapf(ErrTxTypeIncompatible, "unknown fee mode %d", c.FeeMode)
+	}
+	if c.PollInterval < 0 {
+		return errors.Errorf("poll interval %s must not be negative", c.PollInterval)
+	}
+	return nil
+}
+
+// NewAuthorizerWithConfig returns an authorizer from a private key configured with cfg, rather than
+// setting each field of the authorizer after creating it with NewAuthorizerFromPKWithChainID. An
+// error is returned if any field of cfg is invalid
+func NewAuthorizerWithConfig(key *ecdsa.PrivateKey, cfg AuthorizerConfig) (*Authorizer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid authorizer config")
+	}
+	chainID := cfg.ChainID
+	if chainID == nil {
+		chainID = MainnetChainID()
+	}
+	auth, err := NewAuthorizerFromPKWithChainID(key, chainID)
+	if err != nil {
+		return nil, err
+	}
+	auth.GasLimitMultiplier = cfg.GasLimitMultiplier
+	auth.MaxGasPriceWei = copyBig(cfg.MaxGasPriceWei)
+	auth.TxType = cfg.FeeMode
+	auth.PollInterval = cfg.PollInterval
+	return auth, nil
+}
+
+// WaitDefaults returns opts preceded by the wait options configured on the authorizer, its
+// PollInterval and Metrics, such that opts take precedence. Helpers waiting for transactions sent
+// with the authorizer pass their options through it
+func (a *Authorizer) WaitDefaults(opts ...WaitOption) []WaitOption {
+	var defaults []WaitOption
+	if a.PollInterval > 0 {
+		defaults = append(defaults, WithPollInterval(a.PollInterval))
+	}
+	if a.Metrics != nil {
+		defaults = append(defaults, WithMetrics(a.Metrics))
+	}
+	return append(defaults, opts...)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuthorizerWithConfig(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// zero fields use the defaults
+	auth, err := NewAuthorizerWithConfig(pk, AuthorizerConfig{})
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), auth.From)
+	require.Zero(t, auth.GasLimitMultiplier)
+	require.Nil(t, auth.MaxGasPriceWei)
+	require.Equal(t, TxTypeAuto, auth.TxType)
+	require.Equal(t, DefaultPollInterval, newWaitOptions(auth.WaitDefaults()).PollInterval)
+	sig, err := auth.SignMessage([]byte("hello"))
+	require.NoError(t, err)
+	signer, err := VerifyMessage([]byte("hello"), sig)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, signer)
+
+	maxGasPrice := big.NewInt(100000000000)
+	auth, err = NewAuthorizerWithConfig(pk, AuthorizerConfig{
+		ChainID:            big.NewInt(1337),
+		GasLimitMultiplier: 1.3,
+		MaxGasPriceWei:     maxGasPrice,
+		FeeMode:            TxTypeDynamicFee,
+		PollInterval:       time.Millisecond * 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1.3, auth.GasLimitMultiplier)
+	require.Equal(t, maxGasPrice.String(), auth.MaxGasPriceWei.String())
+	require.Equal(t, TxTypeDynamicFee, auth.TxType)
+	require.Equal(t, time.Millisecond*100, newWaitOptions(auth.WaitDefaults()).PollInterval)
+	// explicit wait options take precedence
+	require.Equal(t, time.Second, newWaitOptions(auth.WaitDefaults(WithPollInterval(time.Second))).PollInterval)
+	require.Equal(t, time.Millisecond*100, auth.Clone().PollInterval)
+	// the cap is copied
+	maxGasPrice.SetInt64(1)
+	require.Equal(t, "100000000000", auth.MaxGasPriceWei.String())
+
+	for _, cfg := range []AuthorizerConfig{
+		{ChainID: big.NewInt(0)},
+		{GasLimitMultiplier: 0.5},
+		{GasLimitMultiplier: math.NaN()},
+		{MaxGasPriceWei: big.NewInt(0)},
+		{FeeMode: TxTypeDynamicFee + 1},
+		{PollInterval: -time.Second},
+	} {
+		_, err := NewAuthorizerWithConfig(pk, cfg)
+		require.Error(t, err, "%+v", cfg)
+	}
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// simulatedBackend wraps the simulated backend so that it satisfies the Blockchain interface
+type simulatedBackend struct {
+	*backends.SimulatedBackend
+}
+
+func (s simulatedBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return s.Blockchain().CurrentBlock().NumberU64(), nil
+}
+
+// newSimulatedBackend returns a simulated backend where each of the given addresses is funded with 1000 ETH
+func newSimulatedBackend(t *testing.T, funded ...common.Address) simulatedBackend {
+	alloc := make(core.GenesisAlloc)
+	for _, addr := range funded {
+		alloc[addr] = core.GenesisAccount{Balance: ToWei("1000", 18)}
+	}
+	sim := backends.NewSimulatedBackend(alloc, 8000000)
+	t.Cleanup(func() { sim.Close() })
+	return simulatedBackend{sim}
+}
+
+// deployCode deploys a contract from its hex encoded init code returning its address
+func deployCode(t *testing.T, sim simulatedBackend, auth *Authorizer, code string) common.Address {
+	ctx := context.Background()
+	nonce, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewContractCreation(nonce, big.NewInt(0), 1000000, gasPrice, common.FromHex(code)))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, tx))
+	sim.Commit()
+	return crypto.CreateAddress(auth.From, nonce)
+}
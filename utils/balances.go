@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// BatchBalances returns the balance of every holder for every token using a single aggregated call,
+// keyed by token and then by holder. The zero address as a token returns native ETH balances.
+// Balances which couldn't be read, such as for addresses which aren't tokens, are omitted from
+// the result rather than failing the whole batch, so callers should check for missing entries
+func BatchBalances(ctx context.Context, mc *Multicall, tokens []common.Address, holders []common.Address) (map[common.Address]map[common.Address]*big.Int, error) {
+	tokenABI, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	calls := make([]Call, 0, len(tokens)*len(holders))
+	for _, token := range tokens {
+		for _, holder := range holders {
+			call := Call{Target: token}
+			if token == (common.Address{}) {
+				call.Target = mc.address
+				call.CallData, err = mc.abi.Pack("getEthBalance", holder)
+			} else {
+				call.CallData, err = tokenABI.Pack("balanceOf", holder)
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "pack balance call")
+			}
+			calls = append(calls, call)
+		}
+	}
+	results, err := mc.TryAggregate(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBalances(tokens, holders, results), nil
+}
+
+// pendingBalanceReader is implemented by backends able to report ETH balances at both the latest
+// block and in the pending state, such as *ethclient.Client
+type pendingBalanceReader interface {
+	balanceReader
+	PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error)
+}
+
+// Balances returns the ETH balance of addr at the latest block as confirmed, and in the pending state
+// as pending, which includes the effect of transactions still in the mempool such as for display in
+// a wallet. The pending balance is only as accurate as the view of the mempool of the node, which may
+// not have seen every transaction, and is usually the same as the confirmed balance on nodes without
+// a mempool such as many RPC providers
+func Balances(ctx context.Context, bc Blockchain, addr common.Address) (confirmed, pending *big.Int, err error) {
+	reader, ok := bc.(pendingBalanceReader)
+	if !ok {
+		return nil, nil, errors.New("backend can't report pending eth balances")
+	}
+	if confirmed, err = reader.BalanceAt(ctx, addr, nil); err != nil {
+		return nil, nil, errors.Wrap(err, "balance at")
+	}
+	if pending, err = reader.PendingBalanceAt(ctx, addr); err != nil {
+		return nil, nil, errors.Wrap(err, "pending balance at")
+	}
+	return confirmed, pending, nil
+}
+
+// decodeBalances decodes the results of the balance calls made by BatchBalances, which are
+// ordered by token and then by holder, skipping failed calls
+func decodeBalances(tokens []common.Address, holders []common.Address, results []CallResult) map[common.Address]map[common.Address]*big.Int {
+	balances := make(map[common.Address]map[common.Address]*big.Int, len(tokens))
+	for i, token := range tokens {
+		if balances[token] == nil {
+			balances[token] = make(map[common.Address]*big.Int, len(holders))
+		}
+		for j, holder := range holders {
+			n := i*len(holders) + j
+			// calls to accounts without code succeed with no return data
+			if n >= len(results) || !results[n].Success || len(results[n].ReturnData) != common.HashLength {
+				continue
+			}
+			balances[token][holder] = new(big.Int).SetBytes(results[n].ReturnData)
+		}
+	}
+	return balances
+}
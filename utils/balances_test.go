@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBalances(t *testing.T) {
+	eth := common.Address{}
+	dai := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	holders := []common.Address{
+		common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+		common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+	}
+	results := []CallResult{
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(1).Bytes(), 32)},
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(2).Bytes(), 32)},
+		{Success: false},
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(4).Bytes(), 32)},
+	}
+	balances := decodeBalances([]common.Address{eth, dai}, holders, results)
+	require.Equal(t, "1", balances[eth][holders[0]].String())
+	require.Equal(t, "2", balances[eth][holders[1]].String())
+	// failed calls are omitted while the rest of the grid is returned
+	_, ok := balances[dai][holders[0]]
+	require.False(t, ok)
+	require.Equal(t, "4", balances[dai][holders[1]].String())
+
+	// calls to accounts without code return no data
+	results[3] = CallResult{Success: true}
+	balances = decodeBalances([]common.Address{eth, dai}, holders, results)
+	require.Empty(t, balances[dai])
+}
+
+// mempoolBackend reports a pending balance reduced by a transaction waiting in the mempool
+type mempoolBackend struct {
+	Blockchain
+	confirmed, pending *big.Int
+}
+
+func (b *mempoolBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return b.confirmed, nil
+}
+
+func (b *mempoolBackend) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return b.pending, nil
+}
+
+func TestBalances(t *testing.T) {
+	ctx := context.Background()
+	addr := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	confirmed, pending, err := Balances(ctx, &mempoolBackend{confirmed: big.NewInt(100), pending: big.NewInt(60)}, addr)
+	require.NoError(t, err)
+	require.Equal(t, "100", confirmed.String())
+	require.Equal(t, "60", pending.String())
+
+	// backends must report pending balances
+	_, _, err = Balances(ctx, &allowanceBackend{}, addr)
+	require.Error(t, err)
+}
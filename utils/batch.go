@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// SendBatch invokes each builder in order with consecutive nonces starting from the pending nonce
+// of the authorizer, holding the lock for the whole batch so that no other transactions can claim
+// a nonce in between. Builders are typically closures calling contract binding methods with the
+// given options. If a builder fails the batch stops, returning the transactions built so far along
+// with an error identifying the index of the failed builder. The nonce and context of the
+// authorizer are restored afterwards. This claims the lock and must not be called while the lock
+// is already held.
+func SendBatch(ctx context.Context, bc Blockchain, auth *Authorizer, builders []func(*bind.TransactOpts) (*types.Transaction, error)) ([]*types.Transaction, error) {
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer auth.Unlock()
+	nonce, err := bc.PendingNonceAt(ctx, auth.From)
+	if err != nil {
+		return nil, errors.Wrap(err, "pending nonce at")
+	}
+	prevNonce, prevCtx := auth.Nonce, auth.Context
+	defer func() { auth.Nonce, auth.Context = prevNonce, prevCtx }()
+	auth.Context = ctx
+	defer auth.wrapSigner()()
+	txs := make([]*types.Transaction, 0, len(builders))
+	for i, build := range builders {
+		auth.Nonce = new(big.Int).SetUint64(nonce + uint64(i))
+		tx, err := build(auth.TransactOpts)
+		if dry, ok := auth.takeDryRun(err); ok {
+			txs = append(txs, dry)
+			continue
+		}
+		if err != nil {
+			return txs, errors.Wrapf(err, "batch transaction %d", i)
+		}
+		auth.sent(tx)
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
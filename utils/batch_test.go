@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendBatch(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	transfer := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := opts.Signer(opts.From, types.NewTransaction(opts.Nonce.Uint64(), opts.From, big.NewInt(1), 21000, gasPrice, nil))
+		if err != nil {
+			return nil, err
+		}
+		return tx, sim.SendTransaction(opts.Context, tx)
+	}
+
+	txs, err := SendBatch(ctx, sim, auth, []func(*bind.TransactOpts) (*types.Transaction, error){transfer, transfer, transfer})
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+	for i, tx := range txs {
+		require.Equal(t, uint64(i), tx.Nonce())
+	}
+	require.Nil(t, auth.Nonce)
+	sim.Commit()
+	nonce, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), nonce)
+
+	failing := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nil, errors.New("boom")
+	}
+	txs, err = SendBatch(ctx, sim, auth, []func(*bind.TransactOpts) (*types.Transaction, error){transfer, failing, transfer})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "batch transaction 1")
+	require.Len(t, txs, 1)
+	require.Equal(t, uint64(3), txs[0].Nonce())
+	require.Nil(t, auth.Nonce)
+}
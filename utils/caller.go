@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Caller wraps an embedded bind.CallOpts type providing a fluent way of building
+// options for read-only contract calls. Every method returns a modified copy leaving
+// the receiver untouched, so a base Caller can safely be shared and reused
+type Caller struct {
+	*bind.CallOpts
+}
+
+// NewCaller returns a Caller making calls from the given address against the latest block
+func NewCaller(from common.Address) *Caller {
+	return &Caller{CallOpts: &bind.CallOpts{From: from}}
+}
+
+// AtBlock returns a copy of the caller which queries contract state at block n.
+// A nil block number queries the latest block
+func (c *Caller) AtBlock(n *big.Int) *Caller {
+	cpy := c.copy()
+	cpy.BlockNumber = n
+	return cpy
+}
+
+// Pending returns a copy of the caller which queries against the pending state
+func (c *Caller) Pending(pending bool) *Caller {
+	cpy := c.copy()
+	cpy.CallOpts.Pending = pending
+	return cpy
+}
+
+// WithContext returns a copy of the caller using ctx for its calls
+func (c *Caller) WithContext(ctx context.Context) *Caller {
+	cpy := c.copy()
+	cpy.Context = ctx
+	return cpy
+}
+
+func (c *Caller) copy() *Caller {
+	opts := *c.CallOpts
+	return &Caller{CallOpts: &opts}
+}
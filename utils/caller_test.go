@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaller(t *testing.T) {
+	from := common.HexToAddress("0x323b5d4c32345ced77393b3530b1eed0f346429d")
+	base := NewCaller(from)
+	atBlock := base.AtBlock(big.NewInt(100)).Pending(true).WithContext(context.Background())
+	require.Equal(t, from, atBlock.From)
+	require.Equal(t, big.NewInt(100), atBlock.BlockNumber)
+	require.True(t, atBlock.CallOpts.Pending)
+	require.NotNil(t, atBlock.Context)
+	// the base caller must be left untouched
+	require.Nil(t, base.BlockNumber)
+	require.False(t, base.CallOpts.Pending)
+	require.Nil(t, base.Context)
+}
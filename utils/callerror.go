@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// CallError is returned by Contract.Call when a call fails, holding the call and, if it reverted,
+// the decoded reason. It matches ErrExecutionReverted with errors.Is when the call reverted, and
+// ErrPanic as well when it reverted with a solidity panic, distinguishing reverts from transport
+// failures which match neither
+type CallError struct {
+	Target common.Address
+	Method string
+	// Input is the packed calldata of the call
+	Input []byte
+	// Reverted is set if the call reverted rather than failing to reach the node
+	Reverted bool
+	// Reason is the revert reason decoded by DecodeRevert, empty if the revert had no data
+	Reason string
+	// PanicCode is the code of a solidity panic, nil for other reverts
+	PanicCode *big.Int
+	Err       error
+}
+
+// newCallError wraps err returned when calling method on target with input
+func newCallError(target common.Address, method string, input []byte, err error) *CallError {
+	ce := &CallError{Target: target, Method: method, Input: input, Err: err}
+	if data, ok := revertData(err); ok {
+		ce.Reverted = true
+		if reason, err := DecodeRevert(data); err == nil {
+			ce.Reason = reason
+		}
+		if len(data) >= 4 && bytes.Equal(data[:4], panicSelector) {
+			if code, err := unpackRevert("uint256", data[4:]); err == nil {
+				ce.PanicCode = code.(*big.Int)
+			}
+		}
+	} else if strings.Contains(err.Error(), "execution reverted") {
+		// reverts without data surface as a bare error message
+		ce.Reverted = true
+	}
+	return ce
+}
+
+// Error returns the call along with the message of the underlying error
+func (e *CallError) Error() string {
+	return fmt.Sprintf("call %s on %s: %s", e.Method, e.Target.Hex(), e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrExecutionReverted for reverted calls, or ErrPanic for panics
+func (e *CallError) Is(target error) bool {
+	switch target {
+	case ErrExecutionReverted:
+		return e.Reverted
+	case ErrPanic:
+		return e.PanicCode != nil
+	}
+	return false
+}
+
+// revertData returns the revert data attached to an rpc error
+func revertData(err error) ([]byte, bool) {
+	var de rpc.DataError
+	if !errors.As(err, &de) {
+		return nil, false
+	}
+	hexData, ok := de.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, err := hexutil.Decode(hexData)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// revertCode deploys a contract reverting every call with Error("nope")
+	revertCode = "0x6070600c60003960706000f36064600c60003960646000fd08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000"
+	// panicCode deploys a contract reverting every call with Panic(0x11)
+	panicCode = "0x6030600c60003960306000f36024600c60003960246000fd4e487b710000000000000000000000000000000000000000000000000000000000000011"
+	// emptyRevertCode deploys a contract reverting every call without data
+	emptyRevertCode = "0x6005600c60003960056000f360006000fd"
+)
+
+// failingCallBackend fails every call with err
+type failingCallBackend struct {
+	simulatedBackend
+	err error
+}
+
+func (b failingCallBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, b.err
+}
+
+func TestCallError(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+
+	tests := []struct {
+		name      string
+		code      string
+		reason    string
+		panicCode string
+	}{
+		{"error string", revertCode, "nope", ""},
+		{"panic", panicCode, "arithmetic overflow or underflow", "17"},
+		{"without data", emptyRevertCode, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := deployCode(t, sim, auth, tt.code)
+			contract, err := NewContractFromJSON(address, answerABI, sim)
+			require.NoError(t, err)
+			_, err = contract.Call(ctx, "answer")
+			var callErr *CallError
+			require.True(t, errors.As(err, &callErr))
+			require.Equal(t, address, callErr.Target)
+			require.Equal(t, "answer", callErr.Method)
+			require.Equal(t, contract.ABI().Methods["answer"].ID, callErr.Input)
+			require.True(t, callErr.Reverted)
+			require.Equal(t, tt.reason, callErr.Reason)
+			require.True(t, errors.Is(err, ErrExecutionReverted))
+			require.Equal(t, tt.panicCode != "", errors.Is(err, ErrPanic))
+			if tt.panicCode != "" {
+				require.Equal(t, tt.panicCode, callErr.PanicCode.String())
+			}
+		})
+	}
+
+	// transport failures aren't reverts
+	broken := failingCallBackend{simulatedBackend: sim, err: errors.New("connection refused")}
+	contract, err := NewContractFromJSON(deployCode(t, sim, auth, answerCode), answerABI, broken)
+	require.NoError(t, err)
+	_, err = contract.Call(ctx, "answer")
+	var callErr *CallError
+	require.True(t, errors.As(err, &callErr))
+	require.False(t, callErr.Reverted)
+	require.False(t, errors.Is(err, ErrExecutionReverted))
+	require.Equal(t, broken.err, callErr.Err)
+}
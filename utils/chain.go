@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Chain holds the well-known contract addresses of a chain, which wrapper constructors taking a
+// Chain use as defaults. Zero addresses mark contracts that aren't deployed on the chain
+type Chain struct {
+	ID   *big.Int
+	Name string
+	// WETH is the wrapped native token, such as WMATIC on polygon
+	WETH            common.Address
+	Multicall3      common.Address
+	UniswapV2Router common.Address
+	// UniswapV3Router is the uniswap v3 SwapRouter02
+	UniswapV3Router common.Address
+	// UniswapV3Quoter is the uniswap v3 QuoterV2
+	UniswapV3Quoter common.Address
+	// UniswapV3PositionManager is the uniswap v3 NonfungiblePositionManager
+	UniswapV3PositionManager common.Address
+}
+
+// Require returns an error wrapping ErrNotDeployed if addr, which is looked up
+// from the chain, is the zero address, naming the contract as name
+func (c Chain) Require(name string, addr common.Address) error {
+	if addr == (common.Address{}) {
+		return errors.Wrapf(ErrNotDeployed, "%s on %s", name, c.Name)
+	}
+	return nil
+}
+
+var (
+	// Mainnet holds the well-known addresses of ethereum mainnet
+	Mainnet = Chain{
+		ID:                       big.NewInt(1),
+		Name:                     "mainnet",
+		WETH:                     WETHAddress,
+		Multicall3:               Multicall3Address,
+		UniswapV2Router:          common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"),
+		UniswapV3Router:          common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"),
+		UniswapV3Quoter:          common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e"),
+		UniswapV3PositionManager: common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"),
+	}
+	// Polygon holds the well-known addresses of polygon PoS
+	Polygon = Chain{
+		ID:                       big.NewInt(137),
+		Name:                     "polygon",
+		WETH:                     common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270"),
+		Multicall3:               Multicall3Address,
+		UniswapV2Router:          common.HexToAddress("0xedf6066a2b290C185783862C7F4776A2C8077AD1"),
+		UniswapV3Router:          common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"),
+		UniswapV3Quoter:          common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e"),
+		UniswapV3PositionManager: common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"),
+	}
+	// Arbitrum holds the well-known addresses of arbitrum one
+	Arbitrum = Chain{
+		ID:                       big.NewInt(42161),
+		Name:                     "arbitrum",
+		WETH:                     common.HexToAddress("0x82aF49447D8a07e3bd95BD0d56f35241523fBab1"),
+		Multicall3:               Multicall3Address,
+		UniswapV2Router:          common.HexToAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24"),
+		UniswapV3Router:          common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"),
+		UniswapV3Quoter:          common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e"),
+		UniswapV3PositionManager: common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"),
+	}
+	// Optimism holds the well-known addresses of OP mainnet
+	Optimism = Chain{
+		ID:                       big.NewInt(10),
+		Name:                     "optimism",
+		WETH:                     common.HexToAddress("0x4200000000000000000000000000000000000006"),
+		Multicall3:               Multicall3Address,
+		UniswapV2Router:          common.HexToAddress("0x4A7b5Da61326A6379179b40d00F57E5bbDC962c2"),
+		UniswapV3Router:          common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"),
+		UniswapV3Quoter:          common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e"),
+		UniswapV3PositionManager: common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"),
+	}
+	// Base holds the well-known addresses of base
+	Base = Chain{
+		ID:                       big.NewInt(8453),
+		Name:                     "base",
+		WETH:                     common.HexToAddress("0x4200000000000000000000000000000000000006"),
+		Multicall3:               Multicall3Address,
+		UniswapV2Router:          common.HexToAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24"),
+		UniswapV3Router:          common.HexToAddress("0x2626664c2603336E57B271c5C0b26F421741e481"),
+		UniswapV3Quoter:          common.HexToAddress("0x3d4e44Eb1374240CE5F1B871ab261CD16335B76a"),
+		UniswapV3PositionManager: common.HexToAddress("0x03a520b32C04BF3bEEf7BEb72E919cf822Ed34f1"),
+	}
+)
+
+var (
+	chainsMx sync.Mutex
+	// chains are the registered chains keyed by chain id
+	chains = map[string]Chain{}
+)
+
+func init() {
+	for _, chain := range []Chain{Mainnet, Polygon, Arbitrum, Optimism, Base} {
+		RegisterChain(chain)
+	}
+}
+
+// RegisterChain adds a chain to the registry used by LookupChain, or replaces
+// the chain with the same id such as to override the built in entries
+func RegisterChain(chain Chain) {
+	chainsMx.Lock()
+	defer chainsMx.Unlock()
+	chains[chain.ID.String()] = chain
+}
+
+// LookupChain returns the registered chain with the given id, or an error wrapping ErrUnknownChain
+func LookupChain(chainID *big.Int) (Chain, error) {
+	chainsMx.Lock()
+	defer chainsMx.Unlock()
+	chain, ok := chains[chainID.String()]
+	if !ok {
+		return Chain{}, errors.Wrapf(ErrUnknownChain, "chain id %s", chainID)
+	}
+	return chain, nil
+}
+
+// NewWETHForChain is like NewWETH using the wrapped native token of chain
+func NewWETHForChain(chain Chain, bc Blockchain) (*WETH, error) {
+	if err := chain.Require("weth", chain.WETH); err != nil {
+		return nil, err
+	}
+	return NewWETH(chain.WETH, bc)
+}
+
+// NewMulticallForChain is like NewMulticall using the Multicall3 deployment of chain
+func NewMulticallForChain(chain Chain, bc Blockchain) (*Multicall, error) {
+	if err := chain.Require("multicall3", chain.Multicall3); err != nil {
+		return nil, err
+	}
+	return NewMulticall(bc, chain.Multicall3)
+}
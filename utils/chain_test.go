@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupChain(t *testing.T) {
+	for _, want := range []Chain{Mainnet, Polygon, Arbitrum, Optimism, Base} {
+		chain, err := LookupChain(want.ID)
+		require.NoError(t, err)
+		require.Equal(t, want, chain)
+		require.NotEqual(t, common.Address{}, chain.WETH)
+		require.Equal(t, Multicall3Address, chain.Multicall3)
+	}
+	require.Equal(t, WETHAddress, Mainnet.WETH)
+
+	_, err := LookupChain(big.NewInt(1337))
+	require.True(t, errors.Is(err, ErrUnknownChain))
+	local := Chain{ID: big.NewInt(1337), Name: "local", WETH: common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")}
+	RegisterChain(local)
+	chain, err := LookupChain(big.NewInt(1337))
+	require.NoError(t, err)
+	require.Equal(t, local, chain)
+
+	_, err = NewWETHForChain(chain, nil)
+	require.NoError(t, err)
+	_, err = NewMulticallForChain(chain, nil)
+	require.True(t, errors.Is(err, ErrNotDeployed))
+	require.EqualError(t, err, "multicall3 on local: contract not deployed on chain")
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// clientOptions configures the http client used by NewClient
+type clientOptions struct {
+	timeout time.Duration
+	headers http.Header
+	proxy   string
+}
+
+// ClientOption configures the connection made by NewClient
+type ClientOption func(*clientOptions)
+
+// WithHTTPTimeout bounds the duration of each request, including reading the response.
+// By default requests are only bounded by the context of each call
+func WithHTTPTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) { o.timeout = timeout }
+}
+
+// WithHeader sets a header sent with every request, such as an API key required by the provider
+func WithHeader(key, value string) ClientOption {
+	return func(o *clientOptions) { o.headers.Set(key, value) }
+}
+
+// WithProxy sends requests through the proxy at proxyURL, such as http://localhost:8080, instead
+// of the proxy configured by the HTTP_PROXY and HTTPS_PROXY environment variables
+func WithProxy(proxyURL string) ClientOption {
+	return func(o *clientOptions) { o.proxy = proxyURL }
+}
+
+// rpcEthClient is an ethclient.Client which also makes raw JSON-RPC calls through the rpc client it
+// wraps, so that helpers relying on methods ethclient lacks such as eth_createAccessList work
+type rpcEthClient struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+// CallContext makes a raw JSON-RPC call as by rpc.Client
+func (c rpcEthClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return c.rpc.CallContext(ctx, result, method, args...)
+}
+
+// NewClient connects to the RPC endpoint at rpcURL. For http and https endpoints the options
+// configure the underlying http client, while other endpoints such as websockets and IPC are
+// dialed as is by go-ethereum, returning an error if any option is given. The context only
+// bounds dialing, which doesn't make any requests for http endpoints
+func NewClient(ctx context.Context, rpcURL string, opts ...ClientOption) (*ethclient.Client, error) {
+	o := clientOptions{headers: make(http.Header)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !strings.HasPrefix(rpcURL, "http://") && !strings.HasPrefix(rpcURL, "https://") {
+		if len(opts) > 0 {
+			return nil, errors.Errorf("client options are only supported for http endpoints, not %s", rpcURL)
+		}
+		client, err := rpc.DialContext(ctx, rpcURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "dial %s", rpcURL)
+		}
+		return ethclient.NewClient(client), nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.proxy != "" {
+		proxy, err := url.Parse(o.proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse proxy url")
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	client, err := rpc.DialHTTPWithClient(rpcURL, &http.Client{Timeout: o.timeout, Transport: transport})
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial %s", rpcURL)
+	}
+	for key, values := range o.headers {
+		client.SetHeader(key, values[0])
+	}
+	return ethclient.NewClient(client), nil
+}
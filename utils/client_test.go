@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// chainIDService serves eth_chainId, sleeping for delay before responding
+type chainIDService struct {
+	delay time.Duration
+}
+
+func (s *chainIDService) ChainId() *hexutil.Big {
+	time.Sleep(s.delay)
+	return (*hexutil.Big)(MainnetChainID())
+}
+
+// requestLog records the host and api key header of each request
+type requestLog struct {
+	mx   sync.Mutex
+	seen []string
+}
+
+func (l *requestLog) last() string {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.seen[len(l.seen)-1]
+}
+
+// newRPCServer returns an http rpc server serving eth_chainId which logs each request
+func newRPCServer(t *testing.T, service *chainIDService) (*httptest.Server, *requestLog) {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	t.Cleanup(server.Stop)
+	log := &requestLog{}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.mx.Lock()
+		log.seen = append(log.seen, r.Host+" "+r.Header.Get("X-Api-Key"))
+		log.mx.Unlock()
+		server.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+	return httpServer, log
+}
+
+func TestNewClient(t *testing.T) {
+	ctx := context.Background()
+	server, requests := newRPCServer(t, &chainIDService{})
+
+	client, err := NewClient(ctx, server.URL, WithHeader("X-Api-Key", "secret"), WithHTTPTimeout(time.Millisecond*500))
+	require.NoError(t, err)
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "1", chainID.String())
+	require.Equal(t, server.Listener.Addr().String()+" secret", requests.last())
+
+	// requests are bounded by the timeout
+	slowServer, _ := newRPCServer(t, &chainIDService{delay: time.Second})
+	slow, err := NewClient(ctx, slowServer.URL, WithHTTPTimeout(time.Millisecond*100))
+	require.NoError(t, err)
+	defer slow.Close()
+	_, err = slow.ChainID(ctx)
+	require.Error(t, err)
+
+	// the proxy receives requests for the endpoint
+	proxied, err := NewClient(ctx, "http://rpc.invalid", WithProxy(server.URL))
+	require.NoError(t, err)
+	defer proxied.Close()
+	_, err = proxied.ChainID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "rpc.invalid ", requests.last())
+
+	_, err = NewClient(ctx, "ws://localhost:8546", WithHeader("X-Api-Key", "secret"))
+	require.Error(t, err)
+}
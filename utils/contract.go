@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// Contract allows interacting with contracts the package has no bindings for, packing arguments
+// and unpacking results using the contract's ABI
+type Contract struct {
+	address  common.Address
+	abi      abi.ABI
+	contract *bind.BoundContract
+}
+
+// NewContract returns a wrapper for the contract at address described by parsedABI
+func NewContract(address common.Address, parsedABI abi.ABI, bc Blockchain) *Contract {
+	return &Contract{
+		address:  address,
+		abi:      parsedABI,
+		contract: bind.NewBoundContract(address, parsedABI, bc, bc, bc),
+	}
+}
+
+// NewContractFromJSON is like NewContract but parses the ABI from its JSON encoding, which may
+// contain only the methods and events being used
+func NewContractFromJSON(address common.Address, abiJSON string, bc Blockchain) (*Contract, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return NewContract(address, parsed, bc), nil
+}
+
+// Deploy deploys a contract from its creation bytecode with the constructor arguments packed using
+// parsedABI, waiting for the deployment to be mined. Once deployed the contract can be used with
+// NewContract at the returned address. If the deployment reverts the transaction is returned with
+// an error wrapping ErrTxReverted. The transaction is signed while holding the lock, which is
+// released before waiting, so this must not be called while the lock is already held.
+func Deploy(ctx context.Context, bc Blockchain, auth *Authorizer, bytecode []byte, parsedABI abi.ABI, constructorArgs ...interface{}) (common.Address, *types.Transaction, error) {
+	constructorArgs, err := convertTupleArgs(parsedABI.Constructor.Inputs, constructorArgs)
+	if err != nil {
+		return common.Address{}, nil, errors.Wrap(err, "constructor")
+	}
+	var address common.Address
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		deployed, tx, _, err := bind.DeployContract(opts, parsedABI, bytecode, bc, constructorArgs...)
+		address = deployed
+		return tx, err
+	})
+	if err != nil {
+		return common.Address{}, nil, errors.Wrap(err, "deploy contract")
+	}
+	receipt, err := waitReceipt(ctx, bc, tx.Hash(), tx, newWaitOptions(nil))
+	if err != nil {
+		return common.Address{}, tx, err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return common.Address{}, tx, revertError(ctx, bc, tx, receipt)
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		address = receipt.ContractAddress
+	}
+	return address, tx, nil
+}
+
+// Address returns the address of the contract
+func (c *Contract) Address() common.Address {
+	return c.address
+}
+
+// ABI returns the ABI of the contract
+func (c *Contract) ABI() abi.ABI {
+	return c.abi
+}
+
+// args converts the tuples created with NewTupleArg among the args of method
+func (c *Contract) args(method string, args []interface{}) ([]interface{}, error) {
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		// packing reports the missing method
+		return args, nil
+	}
+	converted, err := convertTupleArgs(m.Inputs, args)
+	if err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	return converted, nil
+}
+
+// Call invokes the constant method with args against the latest block, returning the unpacked outputs.
+// Tuple arguments can be given with NewTupleArg, and abi.ConvertType can be used to convert outputs
+// holding tuples into the corresponding struct. Failed calls return a *CallError, which matches
+// ErrExecutionReverted with errors.Is if the call reverted
+func (c *Contract) Call(ctx context.Context, method string, args ...interface{}) ([]interface{}, error) {
+	args, err := c.args(method, args)
+	if err != nil {
+		return nil, err
+	}
+	input, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	var out []interface{}
+	if err := c.contract.Call(&bind.CallOpts{Context: ctx}, &out, method, args...); err != nil {
+		return nil, newCallError(c.address, method, input, err)
+	}
+	return out, nil
+}
+
+// Transact signs and sends a transaction invoking method with args, where tuple arguments can be
+// given with NewTupleArg. Any value set with Authorizer.WithValue is sent along with it. This
+// claims the authorizer lock and must not be called while the lock is already held.
+func (c *Contract) Transact(ctx context.Context, auth *Authorizer, method string, args ...interface{}) (*types.Transaction, error) {
+	args, err := c.args(method, args)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.contract.Transact(opts, method, args...)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	return tx, nil
+}
+
+// DecodeInput decodes calldata such as that of a pending transaction into the name of the method it
+// invokes and a map from argument names to their values, naming unnamed arguments arg0, arg1 and so
+// on by position. An error wrapping ErrUnknownSelector is returned if the selector doesn't match any
+// method of the ABI, including when data is shorter than a selector
+func (c *Contract) DecodeInput(data []byte) (method string, args map[string]interface{}, err error) {
+	if len(data) < 4 {
+		return "", nil, errors.Wrapf(ErrUnknownSelector, "calldata of %d bytes", len(data))
+	}
+	m, err := c.abi.MethodById(data[:4])
+	if err != nil {
+		return "", nil, errors.Wrapf(ErrUnknownSelector, "selector %s", hexutil.Encode(data[:4]))
+	}
+	values, err := m.Inputs.Unpack(data[4:])
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "unpack %s", m.Name)
+	}
+	args = make(map[string]interface{}, len(values))
+	for i, value := range values {
+		args[argName(m.Inputs[i].Name, i)] = value
+	}
+	return m.Name, args, nil
+}
+
+// UnpackLog decodes log as an eventName event into a map from argument names to their values,
+// checking the first topic matches the event signature unless the event is anonymous. Indexed
+// strings, bytes, arrays and tuples are stored as the keccak256 hash of their value, which is
+// returned as a common.Hash since the value itself can't be recovered
+func (c *Contract) UnpackLog(eventName string, log types.Log) (map[string]interface{}, error) {
+	event, ok := c.abi.Events[eventName]
+	if !ok {
+		return nil, errors.Errorf("event %s not found in abi", eventName)
+	}
+	return unpackEvent(event, log)
+}
+
+// unpackEvent decodes log as event like UnpackLog
+func unpackEvent(event abi.Event, log types.Log) (map[string]interface{}, error) {
+	eventName := event.Name
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return nil, errors.Errorf("log is not a %s event", eventName)
+		}
+		topics = topics[1:]
+	}
+	var indexed, hashed abi.Arguments
+	var hashedTopics, indexedTopics []common.Hash
+	for _, arg := range event.Inputs {
+		if !arg.Indexed {
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, errors.Errorf("log has too few topics for a %s event", eventName)
+		}
+		switch arg.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			hashed, hashedTopics = append(hashed, arg), append(hashedTopics, topics[0])
+		default:
+			indexed, indexedTopics = append(indexed, arg), append(indexedTopics, topics[0])
+		}
+		topics = topics[1:]
+	}
+	if len(topics) != 0 {
+		return nil, errors.Errorf("log has too many topics for a %s event", eventName)
+	}
+	out := make(map[string]interface{})
+	if len(event.Inputs.NonIndexed()) > 0 {
+		if err := event.Inputs.UnpackIntoMap(out, log.Data); err != nil {
+			return nil, errors.Wrap(err, "unpack data")
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(out, indexed, indexedTopics); err != nil {
+		return nil, errors.Wrap(err, "parse topics")
+	}
+	for i, arg := range hashed {
+		out[arg.Name] = hashedTopics[i]
+	}
+	return out, nil
+}
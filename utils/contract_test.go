@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// answerCode deploys a contract returning 42 as a uint256 whenever it is called
+	answerCode = "0x600a600c600039600a6000f3602a60005260206000f3"
+	// answerABI describes the contract deployed by answerCode
+	answerABI = `[
+		{"inputs":[],"name":"answer","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+		{"inputs":[{"name":"value","type":"uint256"}],"name":"setAnswer","outputs":[],"stateMutability":"nonpayable","type":"function"}
+	]`
+)
+
+func TestContract(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	address := deployCode(t, sim, auth, answerCode)
+
+	_, err = NewContractFromJSON(address, "not json", sim)
+	require.Error(t, err)
+	contract, err := NewContractFromJSON(address, answerABI, sim)
+	require.NoError(t, err)
+	require.Equal(t, address, contract.Address())
+
+	out, err := contract.Call(ctx, "answer")
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	require.Equal(t, "42", out[0].(*big.Int).String())
+	_, err = contract.Call(ctx, "missing")
+	require.Error(t, err)
+
+	tx, err := contract.Transact(ctx, auth, "setAnswer", big.NewInt(7))
+	require.NoError(t, err)
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	require.Equal(t, contract.ABI().Methods["setAnswer"].ID, tx.Data()[:4])
+}
+
+func TestContractUnpackLog(t *testing.T) {
+	contract, err := NewContractFromJSON(common.Address{}, `[
+		{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"},
+		{"anonymous":true,"inputs":[{"indexed":true,"name":"tag","type":"string"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Note","type":"event"}
+	]`, nil)
+	require.NoError(t, err)
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	transfer := types.Log{
+		Topics: []common.Hash{
+			crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data: common.LeftPadBytes(big.NewInt(1000).Bytes(), 32),
+	}
+	out, err := contract.UnpackLog("Transfer", transfer)
+	require.NoError(t, err)
+	require.Equal(t, from, out["from"])
+	require.Equal(t, to, out["to"])
+	require.Equal(t, "1000", out["value"].(*big.Int).String())
+
+	// logs of other events are rejected
+	_, err = contract.UnpackLog("Transfer", types.Log{Topics: []common.Hash{{1}, transfer.Topics[1], transfer.Topics[2]}, Data: transfer.Data})
+	require.Error(t, err)
+	_, err = contract.UnpackLog("Transfer", types.Log{Topics: transfer.Topics[:2], Data: transfer.Data})
+	require.Error(t, err)
+	_, err = contract.UnpackLog("Approval", transfer)
+	require.Error(t, err)
+
+	// anonymous events have no signature topic and indexed strings are hashed
+	tag := crypto.Keccak256Hash([]byte("hello"))
+	out, err = contract.UnpackLog("Note", types.Log{Topics: []common.Hash{tag}, Data: common.LeftPadBytes([]byte{7}, 32)})
+	require.NoError(t, err)
+	require.Equal(t, tag, out["tag"])
+	require.Equal(t, "7", out["value"].(*big.Int).String())
+}
+
+func TestContractDecodeInput(t *testing.T) {
+	contract, err := NewContractFromJSON(common.Address{}, `[
+		{"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+		{"inputs":[{"name":"","type":"uint256"},{"name":"","type":"bytes"}],"name":"execute","outputs":[],"stateMutability":"nonpayable","type":"function"},
+		{"inputs":[],"name":"deposit","outputs":[],"stateMutability":"payable","type":"function"}
+	]`, nil)
+	require.NoError(t, err)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	data, err := contract.ABI().Pack("transfer", to, big.NewInt(1000))
+	require.NoError(t, err)
+	method, args, err := contract.DecodeInput(data)
+	require.NoError(t, err)
+	require.Equal(t, "transfer", method)
+	require.Len(t, args, 2)
+	require.Equal(t, to, args["to"])
+	require.Equal(t, "1000", args["value"].(*big.Int).String())
+
+	// unnamed arguments are named by position
+	data, err = contract.ABI().Pack("execute", big.NewInt(7), []byte{1, 2})
+	require.NoError(t, err)
+	method, args, err = contract.DecodeInput(data)
+	require.NoError(t, err)
+	require.Equal(t, "execute", method)
+	require.Equal(t, "7", args["arg0"].(*big.Int).String())
+	require.Equal(t, []byte{1, 2}, args["arg1"])
+
+	method, args, err = contract.DecodeInput(contract.ABI().Methods["deposit"].ID)
+	require.NoError(t, err)
+	require.Equal(t, "deposit", method)
+	require.Empty(t, args)
+
+	_, _, err = contract.DecodeInput([]byte{1, 2, 3, 4})
+	require.True(t, errors.Is(err, ErrUnknownSelector))
+	_, _, err = contract.DecodeInput([]byte{1, 2})
+	require.True(t, errors.Is(err, ErrUnknownSelector))
+	// truncated arguments fail to unpack
+	_, _, err = contract.DecodeInput(data[:40])
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrUnknownSelector))
+}
+
+func TestDeploy(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	parsed, err := abi.JSON(strings.NewReader(answerABI))
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		sim.Commit()
+	}()
+	address, tx, err := Deploy(ctx, sim, auth, common.FromHex(answerCode), parsed)
+	require.NoError(t, err)
+	require.Equal(t, crypto.CreateAddress(auth.From, tx.Nonce()), address)
+	out, err := NewContract(address, parsed, sim).Call(ctx, "answer")
+	require.NoError(t, err)
+	require.Equal(t, "42", out[0].(*big.Int).String())
+
+	// constructors which revert fail without sending a transaction
+	_, _, err = Deploy(ctx, sim, auth, common.FromHex("0x60006000fd"), parsed)
+	require.Error(t, err)
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"math/big"
+	"time"
+)
+
+// DefaultDeadline is how far in the future time-bound calls such as swaps expire by default
+const DefaultDeadline = time.Minute * 20
+
+// Deadline returns the unix timestamp in seconds d from now, for use as the deadline of a time-bound call
+func Deadline(d time.Duration) *big.Int {
+	return DeadlineFrom(time.Now().Add(d))
+}
+
+// DeadlineFrom returns t as a unix timestamp in seconds, for use as the deadline of a time-bound call
+func DeadlineFrom(t time.Time) *big.Int {
+	return big.NewInt(t.Unix())
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadline(t *testing.T) {
+	at := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, int64(1672531200), DeadlineFrom(at).Int64())
+	before := time.Now().Add(DefaultDeadline).Unix()
+	got := Deadline(DefaultDeadline).Int64()
+	after := time.Now().Add(DefaultDeadline).Unix()
+	// deadlines are in seconds rather than milliseconds
+	require.GreaterOrEqual(t, got, before)
+	require.LessOrEqual(t, got, after)
+}
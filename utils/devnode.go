@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// mineMethods are the methods of development nodes for mining several blocks at once, in order of preference
+var mineMethods = []string{"anvil_mine", "hardhat_mine"}
+
+// Snapshot saves the state of an Anvil or Hardhat development chain with evm_snapshot, returning
+// the id to pass to Revert. Nodes discard the snapshot once reverted, so take a new snapshot
+// after each revert to reset the chain between several test cases
+func Snapshot(ctx context.Context, client *rpc.Client) (string, error) {
+	var id string
+	if err := client.CallContext(ctx, &id, "evm_snapshot"); err != nil {
+		return "", errors.Wrap(err, "evm snapshot")
+	}
+	return id, nil
+}
+
+// Revert restores the state saved by Snapshot with evm_revert, returning an error if the node
+// doesn't know the snapshot, such as when it was already reverted
+func Revert(ctx context.Context, client *rpc.Client, snapshotID string) error {
+	var reverted bool
+	if err := client.CallContext(ctx, &reverted, "evm_revert", snapshotID); err != nil {
+		return errors.Wrap(err, "evm revert")
+	}
+	if !reverted {
+		return errors.Errorf("snapshot %s could not be reverted", snapshotID)
+	}
+	return nil
+}
+
+// Mine mines blocks empty blocks on a development chain, using whichever of anvil_mine and
+// hardhat_mine the node supports and falling back to calling evm_mine once per block
+func Mine(ctx context.Context, client *rpc.Client, blocks int) error {
+	if blocks < 0 {
+		return errors.Errorf("can't mine %d blocks", blocks)
+	}
+	if blocks == 0 {
+		return nil
+	}
+	for _, method := range mineMethods {
+		err := client.CallContext(ctx, nil, method, hexutil.Uint64(blocks))
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errors.Wrap(err, "mine")
+		}
+	}
+	for i := 0; i < blocks; i++ {
+		if err := client.CallContext(ctx, nil, "evm_mine"); err != nil {
+			return errors.Wrap(err, "evm mine")
+		}
+	}
+	return nil
+}
+
+// IncreaseTime moves the clock of a development chain forward by seconds with evm_increaseTime,
+// such as to pass the deadline of a swap or a vesting cliff. The new time only applies to blocks
+// mined afterwards, so call Mine to have the latest block reflect it
+func IncreaseTime(ctx context.Context, client *rpc.Client, seconds int64) error {
+	if seconds < 0 {
+		return errors.Errorf("can't move time back by %d seconds", -seconds)
+	}
+	if err := client.CallContext(ctx, nil, "evm_increaseTime", seconds); err != nil {
+		return errors.Wrap(err, "evm increase time")
+	}
+	return nil
+}
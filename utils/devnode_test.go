@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// evmService serves the evm namespace of a hardhat node, tracking the time and blocks mined
+type evmService struct {
+	blocks    int
+	time      int64
+	snapshots []evmState
+}
+
+// evmState is the state saved by a snapshot
+type evmState struct {
+	blocks int
+	time   int64
+}
+
+func (s *evmService) Snapshot() string {
+	s.snapshots = append(s.snapshots, evmState{s.blocks, s.time})
+	return hexutil.EncodeUint64(uint64(len(s.snapshots)))
+}
+
+func (s *evmService) Revert(id string) bool {
+	n, err := hexutil.DecodeUint64(id)
+	if err != nil || n == 0 || int(n) > len(s.snapshots) {
+		return false
+	}
+	state := s.snapshots[n-1]
+	s.blocks, s.time = state.blocks, state.time
+	// reverting discards the snapshot along with those taken after it
+	s.snapshots = s.snapshots[:n-1]
+	return true
+}
+
+func (s *evmService) Mine() {
+	s.blocks++
+}
+
+func (s *evmService) IncreaseTime(seconds int64) int64 {
+	s.time += seconds
+	return s.time
+}
+
+// hardhatMineService serves hardhat_mine
+type hardhatMineService struct {
+	evm *evmService
+}
+
+func (s *hardhatMineService) Mine(blocks hexutil.Uint64) {
+	s.evm.blocks += int(blocks)
+}
+
+func TestDevNode(t *testing.T) {
+	ctx := context.Background()
+	for _, hardhat := range []bool{false, true} {
+		t.Run(fmt.Sprintf("hardhat mine %v", hardhat), func(t *testing.T) {
+			evm := &evmService{}
+			server := rpc.NewServer()
+			require.NoError(t, server.RegisterName("evm", evm))
+			if hardhat {
+				require.NoError(t, server.RegisterName("hardhat", &hardhatMineService{evm}))
+			}
+			t.Cleanup(server.Stop)
+			client := rpc.DialInProc(server)
+			t.Cleanup(client.Close)
+
+			id, err := Snapshot(ctx, client)
+			require.NoError(t, err)
+			require.NoError(t, Mine(ctx, client, 3))
+			require.NoError(t, Mine(ctx, client, 0))
+			require.Error(t, Mine(ctx, client, -1))
+			require.NoError(t, IncreaseTime(ctx, client, 3600))
+			require.Error(t, IncreaseTime(ctx, client, -1))
+			require.Equal(t, 3, evm.blocks)
+			require.Equal(t, int64(3600), evm.time)
+
+			require.NoError(t, Revert(ctx, client, id))
+			require.Equal(t, 0, evm.blocks)
+			require.Equal(t, int64(0), evm.time)
+			// the snapshot is discarded once reverted
+			require.Error(t, Revert(ctx, client, id))
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// DisperseAddress is the address the Disperse contract of disperse.app is deployed at on mainnet
+var DisperseAddress = common.HexToAddress("0xD152f549545093347A162Dce210e7293f1452150")
+
+// disperseABI is the subset of the Disperse ABI used by DisperseETH
+const disperseABI = `[
+	{"inputs":[{"name":"recipients","type":"address[]"},{"name":"values","type":"uint256[]"}],"name":"disperseEther","outputs":[],"stateMutability":"payable","type":"function"}
+]`
+
+// transferGas is the gas used by a plain ETH transfer to an account without code
+const transferGas = 21000
+
+// DisperseResult is the outcome of the transfer to a single recipient sent by DisperseETH
+// without a Disperse contract. Tx is set once the transfer was broadcast, otherwise Err
+// holds the reason it failed
+type DisperseResult struct {
+	Recipient common.Address
+	Amount    *big.Int
+	Tx        *types.Transaction
+	Err       error
+}
+
+// DisperseETH sends amounts[i] of ETH to recipients[i] for every recipient, such as for payroll or
+// airdrops. When disperse is set, typically to DisperseAddress, every transfer is made in a single
+// call to disperseEther of the contract which is returned. Otherwise the transfers are sent one by
+// one with consecutive nonces handed out by a NonceManager, returning the result of each transfer
+// and an error if any of them failed, in which case the nonce is resynchronized before sending the
+// next. In either case nothing is sent unless the balance of the authorizer covers the total amount
+// along with the gas, which is estimated as that of plain transfers for sequential sends. Transfers
+// are only broadcast, so they must be waited on to know they were mined. This claims the lock and
+// must not be called while the lock is already held.
+func DisperseETH(
+	ctx context.Context,
+	bc Blockchain,
+	auth *Authorizer,
+	disperse common.Address,
+	recipients []common.Address,
+	amounts []*big.Int,
+) (*types.Transaction, []DisperseResult, error) {
+	if len(recipients) != len(amounts) {
+		return nil, nil, errors.Errorf("got %d recipients but %d amounts", len(recipients), len(amounts))
+	}
+	if len(recipients) == 0 {
+		return nil, nil, errors.New("no recipients to disperse to")
+	}
+	total := new(big.Int)
+	for i, amount := range amounts {
+		if amount == nil || amount.Sign() < 0 {
+			return nil, nil, errors.Errorf("invalid amount %v for recipient %d", amount, i)
+		}
+		total.Add(total, amount)
+	}
+	if disperse != (common.Address{}) {
+		tx, err := disperseContract(ctx, bc, auth, disperse, recipients, amounts, total)
+		return tx, nil, err
+	}
+	results, err := disperseSequential(ctx, bc, auth, recipients, amounts, total)
+	return nil, results, err
+}
+
+// disperseContract sends total to the Disperse contract at disperse in one transaction
+func disperseContract(
+	ctx context.Context,
+	bc Blockchain,
+	auth *Authorizer,
+	disperse common.Address,
+	recipients []common.Address,
+	amounts []*big.Int,
+	total *big.Int,
+) (*types.Transaction, error) {
+	parsed, err := abi.JSON(strings.NewReader(disperseABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	data, err := parsed.Pack("disperseEther", recipients, amounts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack disperseEther")
+	}
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer auth.Unlock()
+	tx, err := auth.buildTx(ctx, bc, auth.Nonce, auth.GasLimit, disperse, total, data)
+	if err != nil {
+		return nil, err
+	}
+	// the cost of the signed transaction includes its value and the most it can pay for gas
+	if err := checkETHBalance(ctx, bc, auth, tx.Cost()); err != nil {
+		return nil, err
+	}
+	if err := Send(ctx, bc, tx); err != nil {
+		return nil, err
+	}
+	auth.sent(tx)
+	return tx, nil
+}
+
+// disperseSequential sends a transfer to each recipient using nonces handed out by a NonceManager
+func disperseSequential(
+	ctx context.Context,
+	bc Blockchain,
+	auth *Authorizer,
+	recipients []common.Address,
+	amounts []*big.Int,
+	total *big.Int,
+) ([]DisperseResult, error) {
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	inner, err := auth.txData(ctx, bc, 0, transferGas, recipients[0], nil, nil)
+	prevNonce := auth.Nonce
+	auth.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	fees := new(big.Int).Mul(types.NewTx(inner).Cost(), big.NewInt(int64(len(recipients))))
+	if err := checkETHBalance(ctx, bc, auth, new(big.Int).Add(total, fees)); err != nil {
+		return nil, err
+	}
+	// the nonce manager sets the nonce of the authorizer, which is restored once done
+	defer func() {
+		auth.Lock()
+		auth.Nonce = prevNonce
+		auth.Unlock()
+	}()
+	nm := NewNonceManager(auth, bc)
+	results := make([]DisperseResult, len(recipients))
+	var failed int
+	for i, recipient := range recipients {
+		results[i] = DisperseResult{Recipient: recipient, Amount: amounts[i]}
+		if results[i].Tx, results[i].Err = transferETH(ctx, bc, auth, nm, recipient, amounts[i]); results[i].Err == nil {
+			continue
+		}
+		failed++
+		if ctx.Err() != nil {
+			// the remaining transfers would fail in the same way
+			for j := i + 1; j < len(recipients); j++ {
+				results[j] = DisperseResult{Recipient: recipients[j], Amount: amounts[j], Err: ctx.Err()}
+			}
+			failed += len(recipients) - i - 1
+			break
+		}
+		// the nonce was never used so it is handed out again for the next transfer
+		if err := nm.Reset(ctx); err != nil {
+			return results, err
+		}
+	}
+	if failed > 0 {
+		return results, errors.Errorf("%d of %d transfers failed", failed, len(recipients))
+	}
+	return results, nil
+}
+
+// transferETH sends amount to recipient using the next nonce of nm
+func transferETH(ctx context.Context, bc Blockchain, auth *Authorizer, nm *NonceManager, recipient common.Address, amount *big.Int) (*types.Transaction, error) {
+	nonce, err := nm.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer auth.Unlock()
+	tx, err := auth.buildTx(ctx, bc, nonce, auth.GasLimit, recipient, amount, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := Send(ctx, bc, tx); err != nil {
+		return nil, err
+	}
+	auth.sent(tx)
+	return tx, nil
+}
+
+// balanceReader is implemented by backends able to report the ETH balance of an account, such as *ethclient.Client
+type balanceReader interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// checkETHBalance returns an error wrapping ErrInsufficientBalance if the authorizer holds less than required
+func checkETHBalance(ctx context.Context, bc Blockchain, auth *Authorizer, required *big.Int) error {
+	reader, ok := bc.(balanceReader)
+	if !ok {
+		return errors.New("backend can't report eth balances")
+	}
+	balance, err := reader.BalanceAt(ctx, auth.From, nil)
+	if err != nil {
+		return errors.Wrap(err, "balance at")
+	}
+	if balance.Cmp(required) < 0 {
+		return errors.Wrapf(ErrInsufficientBalance, "eth balance %s is less than the %s required", balance, required)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisperseETH(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	recipients := []common.Address{
+		common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		common.HexToAddress("0x2000000000000000000000000000000000000002"),
+		common.HexToAddress("0x3000000000000000000000000000000000000003"),
+	}
+	amounts := []*big.Int{big.NewInt(100), big.NewInt(200), big.NewInt(300)}
+
+	_, _, err = DisperseETH(ctx, sim, auth, common.Address{}, recipients, amounts[:2])
+	require.EqualError(t, err, "got 3 recipients but 2 amounts")
+	_, _, err = DisperseETH(ctx, sim, auth, common.Address{}, recipients[:1], []*big.Int{ToWei("2000", 18)})
+	require.True(t, errors.Is(err, ErrInsufficientBalance))
+
+	tx, results, err := DisperseETH(ctx, sim, auth, common.Address{}, recipients, amounts)
+	require.NoError(t, err)
+	require.Nil(t, tx)
+	require.Len(t, results, 3)
+	sim.Commit()
+	for i, result := range results {
+		require.NoError(t, result.Err)
+		require.Equal(t, recipients[i], result.Recipient)
+		require.Equal(t, uint64(i), result.Tx.Nonce())
+		balance, err := sim.BalanceAt(ctx, recipients[i], nil)
+		require.NoError(t, err)
+		require.Equal(t, amounts[i], balance)
+	}
+	// the nonce handed out by the nonce manager doesn't leak into later transactions
+	require.Nil(t, auth.Nonce)
+
+	// a contract stopping without doing anything stands in for Disperse
+	disperse := deployCode(t, sim, auth, "6001600c60003960016000f300")
+	tx, results, err = DisperseETH(ctx, sim, auth, disperse, recipients, amounts)
+	require.NoError(t, err)
+	require.Nil(t, results)
+	sim.Commit()
+	require.Equal(t, disperse, *tx.To())
+	require.Equal(t, "600", tx.Value().String())
+	parsed, err := abi.JSON(strings.NewReader(disperseABI))
+	require.NoError(t, err)
+	require.Equal(t, "e63d38ed", common.Bytes2Hex(tx.Data()[:4]))
+	args, err := parsed.Methods["disperseEther"].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	require.Equal(t, recipients, args[0])
+	require.Equal(t, amounts, args[1])
+}
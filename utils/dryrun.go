@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// errDryRun is returned by the signer of dry run authorizers to stop contract bindings from
+// broadcasting, and is replaced with the signed transaction by Transact and SendBatch
+var errDryRun = errors.New("dry run")
+
+// maxDryRunTxs bounds the dry run transactions remembered until waited for, beyond which the
+// oldest are forgotten
+const maxDryRunTxs = 1024
+
+// dryRunTx is a transaction signed by a dry run authorizer
+type dryRunTx struct {
+	hash common.Hash
+	from common.Address
+	// receipt is the synthetic receipt returned when waiting for the transaction, set once it was
+	// passed to a send helper
+	receipt *types.Receipt
+}
+
+var (
+	dryRunMx sync.Mutex
+	// dryRunTxs are the elements of dryRunOrder keyed by the hash of their transaction
+	dryRunTxs = map[common.Hash]*list.Element{}
+	// dryRunOrder holds the transactions signed by dry run authorizers from oldest to newest, which
+	// are removed once their receipt was returned by a wait or when over maxDryRunTxs
+	dryRunOrder = list.New()
+)
+
+// recordDryRun records tx as signed by a dry run authorizer for from along with its receipt if
+// set, forgetting the oldest transaction if too many are recorded
+func recordDryRun(from common.Address, tx *types.Transaction, receipt *types.Receipt) {
+	dryRunMx.Lock()
+	defer dryRunMx.Unlock()
+	record := &dryRunTx{hash: tx.Hash(), from: from, receipt: receipt}
+	if elem, ok := dryRunTxs[record.hash]; ok {
+		elem.Value = record
+		return
+	}
+	dryRunTxs[record.hash] = dryRunOrder.PushBack(record)
+	if dryRunOrder.Len() > maxDryRunTxs {
+		oldest := dryRunOrder.Remove(dryRunOrder.Front()).(*dryRunTx)
+		delete(dryRunTxs, oldest.hash)
+	}
+}
+
+// markDryRun records tx as signed by a dry run authorizer for from
+func markDryRun(from common.Address, tx *types.Transaction) {
+	recordDryRun(from, tx, nil)
+}
+
+// lookupDryRun returns the record of tx if it was signed by a dry run authorizer
+func lookupDryRun(hash common.Hash) (*dryRunTx, bool) {
+	dryRunMx.Lock()
+	defer dryRunMx.Unlock()
+	if elem, ok := dryRunTxs[hash]; ok {
+		return elem.Value.(*dryRunTx), true
+	}
+	return nil, false
+}
+
+// takeDryRunReceipt returns the synthetic receipt of a dry run transaction passed to a send helper,
+// after which the transaction is forgotten
+func takeDryRunReceipt(hash common.Hash) (*types.Receipt, bool) {
+	dryRunMx.Lock()
+	defer dryRunMx.Unlock()
+	elem, ok := dryRunTxs[hash]
+	if !ok || elem.Value.(*dryRunTx).receipt == nil {
+		return nil, false
+	}
+	dryRunOrder.Remove(elem)
+	delete(dryRunTxs, hash)
+	return elem.Value.(*dryRunTx).receipt, true
+}
+
+// isDryRunReceipt returns true if receipt is the synthetic receipt of a dry run transaction, which
+// unlike the receipts of mined transactions has block number zero
+func isDryRunReceipt(receipt *types.Receipt) bool {
+	return receipt.BlockNumber != nil && receipt.BlockNumber.Sign() == 0
+}
+
+// completeDryRun logs tx instead of broadcasting it and records its synthetic receipt, which is
+// successful with block number zero and all of its gas used
+func completeDryRun(tx *types.Transaction, from common.Address) {
+	to := "contract creation"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	zap.L().Info("dry run transaction",
+		zap.String("hash", tx.Hash().Hex()),
+		zap.String("from", from.Hex()),
+		zap.String("to", to),
+		zap.Uint64("nonce", tx.Nonce()),
+		zap.Uint64("gas", tx.Gas()),
+		zap.Stringer("value", tx.Value()),
+		zap.String("data", hexutil.Encode(tx.Data())),
+	)
+	recordDryRun(from, tx, &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: tx.Gas(),
+		GasUsed:           tx.Gas(),
+		TxHash:            tx.Hash(),
+		BlockNumber:       new(big.Int),
+		Logs:              []*types.Log{},
+	})
+}
+
+// sendDryRun simulates a dry run transaction with eth_call against the pending state instead of
+// broadcasting it, returning an error wrapping ErrWouldRevert if it would revert
+func sendDryRun(ctx context.Context, bc Blockchain, tx *types.Transaction, record *dryRunTx) error {
+	// the gas price is omitted so the call isn't rejected due to the sender balance
+	_, err := bc.PendingCallContract(ctx, ethereum.CallMsg{
+		From:  record.from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	})
+	if err != nil {
+		if reason, ok := revertReason(err); ok {
+			return errors.Wrapf(ErrWouldRevert, "execution reverted: %s", reason)
+		}
+		return errors.Wrap(err, "dry run")
+	}
+	completeDryRun(tx, record.from)
+	return nil
+}
+
+// takeDryRun returns the transaction signed by a dry run authorizer if err is the error returned
+// by its signer to stop the bindings from broadcasting, and expects the caller to hold the lock
+func (a *Authorizer) takeDryRun(err error) (*types.Transaction, bool) {
+	if !errors.Is(err, errDryRun) || a.dryRunSigned == nil {
+		return nil, false
+	}
+	tx := a.dryRunSigned
+	a.dryRunSigned = nil
+	// the bindings execute the call when estimating the gas limit, so the simulation is skipped
+	completeDryRun(tx, a.From)
+	return tx, true
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	address := deployCode(t, sim, auth, answerCode)
+	reverting := deployCode(t, sim, auth, revertCode)
+	contract, err := NewContractFromJSON(address, answerABI, sim)
+	require.NoError(t, err)
+	nonce, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	var sent []*types.Transaction
+	auth.Hooks = &Hooks{OnSend: func(tx *types.Transaction) { sent = append(sent, tx) }}
+	auth.DryRun = true
+
+	// transactions sent through the bindings are signed but not broadcast
+	tx, err := contract.Transact(ctx, auth, "setAnswer", big.NewInt(7))
+	require.NoError(t, err)
+	require.Equal(t, address, *tx.To())
+	receipt, err := WaitConfirmations(ctx, sim, tx.Hash(), 3)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	require.Equal(t, tx.Hash(), receipt.TxHash)
+
+	// as are transactions built and sent with the helpers
+	tx, err = auth.BuildTx(ctx, sim, common.HexToAddress("0x000000000000000000000000000000000000dEaD"), big.NewInt(1), nil)
+	require.NoError(t, err)
+	receipt, err = SendAndWait(ctx, sim, tx)
+	require.NoError(t, err)
+	require.Equal(t, tx.Hash(), receipt.TxHash)
+	// the transaction is forgotten once waited for
+	_, ok := lookupDryRun(tx.Hash())
+	require.False(t, ok)
+
+	sim.Commit()
+	pending, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	require.Equal(t, nonce, pending)
+	require.Empty(t, sent)
+	// reads are unaffected
+	out, err := contract.Call(ctx, "answer")
+	require.NoError(t, err)
+	require.Equal(t, "42", out[0].(*big.Int).String())
+
+	// the simulation catches reverts, skipping the estimate which would catch them as well
+	auth.GasLimit = 100000
+	tx, err = auth.BuildTx(ctx, sim, reverting, nil, nil)
+	require.NoError(t, err)
+	err = Send(ctx, sim, tx)
+	require.True(t, errors.Is(err, ErrWouldRevert))
+
+	// transactions signed outside dry run mode are broadcast as usual
+	auth.GasLimit = 0
+	auth.DryRun = false
+	tx, err = auth.BuildTx(ctx, sim, common.HexToAddress("0x000000000000000000000000000000000000dEaD"), big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.NoError(t, Send(ctx, sim, tx))
+	pending, err = sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	require.Equal(t, nonce+1, pending)
+}
+
+func TestDryRunBound(t *testing.T) {
+	var txs []*types.Transaction
+	for i := 0; i <= maxDryRunTxs; i++ {
+		tx := types.NewTransaction(uint64(i), common.Address{}, nil, 21000, nil, nil)
+		markDryRun(common.Address{1}, tx)
+		txs = append(txs, tx)
+	}
+	// the oldest transaction not waited for is forgotten
+	_, ok := lookupDryRun(txs[0].Hash())
+	require.False(t, ok)
+	record, ok := lookupDryRun(txs[1].Hash())
+	require.True(t, ok)
+	require.Equal(t, common.Address{1}, record.from)
+	require.True(t, len(dryRunTxs) <= maxDryRunTxs)
+
+	// receipts are only returned for transactions passed to a send helper
+	_, ok = takeDryRunReceipt(txs[1].Hash())
+	require.False(t, ok)
+	completeDryRun(txs[1], common.Address{1})
+	receipt, ok := takeDryRunReceipt(txs[1].Hash())
+	require.True(t, ok)
+	require.True(t, isDryRunReceipt(receipt))
+	_, ok = takeDryRunReceipt(txs[1].Hash())
+	require.False(t, ok)
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// encryptedKeyScryptN is the scrypt cost used to derive the encryption key from the passphrase
+	encryptedKeyScryptN = 1 << 15
+	// encryptedKeySaltSize is the size of the random salt used with scrypt
+	encryptedKeySaltSize = 32
+)
+
+// PassphraseFunc returns the passphrase of an EncryptedKey, for example by prompting for it or
+// reading it from a secret manager. It must return a new slice on each call, as the passphrase is
+// zeroed once the key has been derived from it
+type PassphraseFunc func() ([]byte, error)
+
+// EncryptedKey holds a private key in memory encrypted with AES-GCM, using a key derived from a
+// passphrase with scrypt, so that the plaintext key is only present in memory while it is used
+// inside WithKey. This limits exposure through memory dumps and swap in short lived processes
+// which don't keep keys on disk. Neither the passphrase nor the derived encryption key are held by
+// the EncryptedKey: the passphrase is fetched from its PassphraseFunc and the encryption key is
+// derived again on each use, which makes each signature cost an scrypt derivation. EncryptedKey
+// implements Signer, allowing it to be used with NewAuthorizerFromSigner. It is safe for concurrent
+// use if its PassphraseFunc is
+type EncryptedKey struct {
+	address    common.Address
+	passphrase PassphraseFunc
+	salt       []byte
+	nonce      []byte
+	ciphertext []byte
+}
+
+// NewEncryptedKey encrypts pk with a key derived from the passphrase returned by passphrase, which
+// is called again each time the key is used. The caller should discard pk afterwards, for example
+// by zeroing pk.D, so that only the encrypted copy remains
+func NewEncryptedKey(pk *ecdsa.PrivateKey, passphrase PassphraseFunc) (*EncryptedKey, error) {
+	salt := make([]byte, encryptedKeySaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "generate salt")
+	}
+	k := &EncryptedKey{
+		address:    crypto.PubkeyToAddress(pk.PublicKey),
+		passphrase: passphrase,
+		salt:       salt,
+	}
+	aead, err := k.cipher()
+	if err != nil {
+		return nil, err
+	}
+	k.nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(k.nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+	plaintext := math.PaddedBigBytes(pk.D, 32)
+	defer zeroBytes(plaintext)
+	k.ciphertext = aead.Seal(nil, k.nonce, plaintext, nil)
+	return k, nil
+}
+
+// cipher derives the encryption key from the passphrase and returns the AES-GCM cipher using it,
+// zeroing the passphrase and the derived key before returning
+func (k *EncryptedKey) cipher() (cipher.AEAD, error) {
+	passphrase, err := k.passphrase()
+	if err != nil {
+		return nil, errors.Wrap(err, "get passphrase")
+	}
+	defer zeroBytes(passphrase)
+	derived, err := scrypt.Key(passphrase, k.salt, encryptedKeyScryptN, 8, 1, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive key")
+	}
+	defer zeroBytes(derived)
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, errors.Wrap(err, "new cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "new gcm")
+	}
+	return aead, nil
+}
+
+// Address returns the address of the key
+func (k *EncryptedKey) Address() common.Address {
+	return k.address
+}
+
+// WithKey decrypts the key with the passphrase returned by its PassphraseFunc and invokes fn with
+// it, zeroing the decrypted key once fn returns. The key must not be retained or copied by fn
+func (k *EncryptedKey) WithKey(fn func(*ecdsa.PrivateKey) error) error {
+	aead, err := k.cipher()
+	if err != nil {
+		return err
+	}
+	plaintext, err := aead.Open(nil, k.nonce, k.ciphertext, nil)
+	if err != nil {
+		return errors.Wrap(err, "decrypt key")
+	}
+	defer zeroBytes(plaintext)
+	pk, err := crypto.ToECDSA(plaintext)
+	if err != nil {
+		return errors.Wrap(err, "decode key")
+	}
+	defer zeroInt(pk.D)
+	return fn(pk)
+}
+
+// SignTx implements Signer, decrypting the key only for the duration of signing
+func (k *EncryptedKey) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var signed *types.Transaction
+	err := k.WithKey(func(pk *ecdsa.PrivateKey) error {
+		var err error
+		signed, err = types.SignTx(tx, types.LatestSignerForChainID(chainID), pk)
+		return err
+	})
+	return signed, err
+}
+
+// zeroInt overwrites the words backing n with zeros
+func zeroInt(n *big.Int) {
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedKey(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	var (
+		calls      int
+		passphrase = "passphrase"
+		returned   [][]byte
+	)
+	ek, err := NewEncryptedKey(pk, func() ([]byte, error) {
+		calls++
+		pass := []byte(passphrase)
+		returned = append(returned, pass)
+		return pass, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), ek.Address())
+	require.Equal(t, 1, calls)
+	// the plaintext key isn't held by the encrypted key
+	require.NotContains(t, string(ek.ciphertext), string(crypto.FromECDSA(pk)))
+
+	var leaked *ecdsa.PrivateKey
+	require.NoError(t, ek.WithKey(func(key *ecdsa.PrivateKey) error {
+		require.Equal(t, pk.D.String(), key.D.String())
+		leaked = key
+		return nil
+	}))
+	// the decrypted key is zeroed once the callback returns
+	require.Equal(t, 0, leaked.D.Sign())
+	// the passphrase is fetched for each use and zeroed afterwards
+	require.Equal(t, 2, calls)
+	for _, pass := range returned {
+		require.Equal(t, make([]byte, len(passphrase)), pass)
+	}
+
+	// it can be used as the signer of an authorizer
+	auth, err := NewAuthorizerFromSigner(ek, big.NewInt(1337))
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	require.NoError(t, err)
+	require.Equal(t, ek.Address(), sender)
+
+	// a wrong or unavailable passphrase fails to decrypt the key
+	passphrase = "wrong"
+	require.Error(t, ek.WithKey(func(*ecdsa.PrivateKey) error {
+		t.Fatal("callback invoked with a wrong passphrase")
+		return nil
+	}))
+	_, err = NewEncryptedKey(pk, func() ([]byte, error) {
+		return nil, errors.New("no passphrase")
+	})
+	require.EqualError(t, err, "get passphrase: no passphrase")
+}
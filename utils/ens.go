@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"golang.org/x/net/idna"
+)
+
+// ENSRegistryAddress points to the ENS registry, deployed at the same address on mainnet and its testnets
+var ENSRegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// ensABI contains the methods of the ENS registry and public resolver used for lookups
+const ensABI = `[
+	{"inputs":[{"name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"node","type":"bytes32"}],"name":"addr","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"node","type":"bytes32"}],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+var (
+	ensMx sync.Mutex
+	// ensRegistries caches the registry address by chain id
+	ensRegistries = map[string]common.Address{
+		"1": ENSRegistryAddress,
+		"3": ENSRegistryAddress,
+		"4": ENSRegistryAddress,
+		"5": ENSRegistryAddress,
+	}
+	// ensProfile normalizes names following UTS-46 as required by ENSIP-1
+	ensProfile = idna.New(idna.MapForLookup(), idna.Transitional(false))
+)
+
+// chainIDReader is implemented by backends able to report the chain id, such as *ethclient.Client
+type chainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// SetENSRegistry sets the registry used to resolve names on the given chain, such
+// as for local deployments or chains with their own ENS deployment
+func SetENSRegistry(chainID *big.Int, registry common.Address) {
+	ensMx.Lock()
+	defer ensMx.Unlock()
+	ensRegistries[chainID.String()] = registry
+}
+
+// NormalizeENS normalizes an ENS name following UTS-46, lower casing and mapping it to the
+// form whose namehash is stored by the registry
+func NormalizeENS(name string) (string, error) {
+	normalized, err := ensProfile.ToUnicode(strings.TrimSpace(name))
+	if err != nil {
+		return "", errors.Wrapf(err, "normalize %s", name)
+	}
+	return normalized, nil
+}
+
+// NameHash returns the EIP-137 namehash of a normalized ENS name
+func NameHash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// ResolveENS returns the address an ENS name such as vitalik.eth resolves to. The name is
+// normalized before being hashed, and an error wrapping ErrNoResolver is returned if the name
+// has no resolver or no address record. The registry is looked up by the chain id of bc, with
+// backends unable to report it using ENSRegistryAddress. An error wrapping ErrNoENSRegistry is
+// returned on chains without a registry, see SetENSRegistry
+func ResolveENS(ctx context.Context, bc Blockchain, name string) (common.Address, error) {
+	normalized, err := NormalizeENS(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	node := NameHash(normalized)
+	resolver, err := ensResolver(ctx, bc, node)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, normalized)
+	}
+	addr, err := callAddress(ctx, resolver, "addr", [32]byte(node))
+	if err != nil {
+		return common.Address{}, err
+	}
+	if addr == (common.Address{}) {
+		return common.Address{}, errors.Wrapf(ErrNoResolver, "%s has no address record", normalized)
+	}
+	return addr, nil
+}
+
+// ReverseENS returns the primary ENS name of addr for display. As anyone can claim any name in
+// their reverse record, the name is only returned if it resolves back to addr, otherwise an
+// error is returned. An error wrapping ErrNoResolver is returned if no primary name is set
+func ReverseENS(ctx context.Context, bc Blockchain, addr common.Address) (string, error) {
+	reverse := strings.ToLower(strings.TrimPrefix(addr.Hex(), "0x")) + ".addr.reverse"
+	node := NameHash(reverse)
+	resolver, err := ensResolver(ctx, bc, node)
+	if err != nil {
+		return "", errors.Wrap(err, reverse)
+	}
+	out, err := resolver.Call(ctx, "name", [32]byte(node))
+	if err != nil {
+		return "", err
+	}
+	name := *abi.ConvertType(out[0], new(string)).(*string)
+	if name == "" {
+		return "", errors.Wrapf(ErrNoResolver, "%s has no name record", reverse)
+	}
+	resolved, err := ResolveENS(ctx, bc, name)
+	if err != nil {
+		return "", errors.Wrap(err, "verify reverse record")
+	}
+	if resolved != addr {
+		return "", errors.Errorf("reverse record %s resolves to %s not %s", name, resolved, addr)
+	}
+	return name, nil
+}
+
+// ensResolver returns the resolver of node set in the registry of the chain
+func ensResolver(ctx context.Context, bc Blockchain, node common.Hash) (*Contract, error) {
+	registry, err := ensRegistry(ctx, bc)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := callAddress(ctx, registry, "resolver", [32]byte(node))
+	if err != nil {
+		return nil, err
+	}
+	if resolver == (common.Address{}) {
+		return nil, ErrNoResolver
+	}
+	return NewContractFromJSON(resolver, ensABI, bc)
+}
+
+// ensRegistry returns the registry of the chain bc is connected to
+func ensRegistry(ctx context.Context, bc Blockchain) (*Contract, error) {
+	registry := ENSRegistryAddress
+	if reader, ok := bc.(chainIDReader); ok {
+		chainID, err := reader.ChainID(ctx)
+		if errors.Is(err, ErrBackendUnsupported) {
+			return NewContractFromJSON(registry, ensABI, bc)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "chain id")
+		}
+		ensMx.Lock()
+		cached, ok := ensRegistries[chainID.String()]
+		ensMx.Unlock()
+		if !ok {
+			return nil, errors.Wrapf(ErrNoENSRegistry, "chain id %s", chainID)
+		}
+		registry = cached
+	}
+	return NewContractFromJSON(registry, ensABI, bc)
+}
+
+// callAddress calls a method of contract returning a single address
+func callAddress(ctx context.Context, contract *Contract, method string, args ...interface{}) (common.Address, error) {
+	out, err := contract.Call(ctx, method, args...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
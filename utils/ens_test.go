@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// ensBackend answers calls to a registry at ENSRegistryAddress and a single resolver
+type ensBackend struct {
+	Blockchain
+	t         *testing.T
+	abi       abi.ABI
+	chainID   int64
+	resolver  common.Address
+	resolvers map[common.Hash]common.Address
+	addrs     map[common.Hash]common.Address
+	names     map[common.Hash]string
+}
+
+func newENSBackend(t *testing.T) *ensBackend {
+	parsed, err := abi.JSON(strings.NewReader(ensABI))
+	require.NoError(t, err)
+	return &ensBackend{
+		t:         t,
+		abi:       parsed,
+		chainID:   1,
+		resolver:  common.HexToAddress("0x4976fb03C32e5B8cfe2b6cCB31c09Ba78EBaBa41"),
+		resolvers: make(map[common.Hash]common.Address),
+		addrs:     make(map[common.Hash]common.Address),
+		names:     make(map[common.Hash]string),
+	}
+}
+
+func (b *ensBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(b.chainID), nil
+}
+
+func (b *ensBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	method, err := b.abi.MethodById(call.Data[:4])
+	require.NoError(b.t, err)
+	node := common.BytesToHash(call.Data[4:36])
+	switch {
+	case *call.To == ENSRegistryAddress && method.Name == "resolver":
+		return method.Outputs.Pack(b.resolvers[node])
+	case *call.To == b.resolver && method.Name == "addr":
+		return method.Outputs.Pack(b.addrs[node])
+	case *call.To == b.resolver && method.Name == "name":
+		return method.Outputs.Pack(b.names[node])
+	default:
+		return nil, errors.Errorf("unexpected call to %s on %s", method.Name, call.To)
+	}
+}
+
+// register sets the address record of name along with the reverse record of addr
+func (b *ensBackend) register(name string, addr common.Address, reverse string) {
+	b.resolvers[NameHash(name)] = b.resolver
+	b.addrs[NameHash(name)] = addr
+	reverseNode := NameHash(strings.ToLower(addr.Hex()[2:]) + ".addr.reverse")
+	b.resolvers[reverseNode] = b.resolver
+	b.names[reverseNode] = reverse
+}
+
+func TestNameHash(t *testing.T) {
+	require.Equal(t, common.Hash{}, NameHash(""))
+	require.Equal(t, "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", NameHash("eth").Hex())
+	require.Equal(t, "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f", NameHash("foo.eth").Hex())
+
+	normalized, err := NormalizeENS(" Vitalik.ETH ")
+	require.NoError(t, err)
+	require.Equal(t, "vitalik.eth", normalized)
+	_, err = NormalizeENS("foo bar.eth")
+	require.Error(t, err)
+}
+
+func TestResolveENS(t *testing.T) {
+	ctx := context.Background()
+	bc := newENSBackend(t)
+	vitalik := common.HexToAddress("0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045")
+	bc.register("vitalik.eth", vitalik, "vitalik.eth")
+
+	addr, err := ResolveENS(ctx, bc, "Vitalik.eth")
+	require.NoError(t, err)
+	require.Equal(t, vitalik, addr)
+	name, err := ReverseENS(ctx, bc, vitalik)
+	require.NoError(t, err)
+	require.Equal(t, "vitalik.eth", name)
+
+	_, err = ResolveENS(ctx, bc, "unregistered.eth")
+	require.True(t, errors.Is(err, ErrNoResolver))
+	_, err = ReverseENS(ctx, bc, common.HexToAddress("0x000000000000000000000000000000000000dEaD"))
+	require.True(t, errors.Is(err, ErrNoResolver))
+
+	// reverse records not resolving back to the address aren't trusted
+	impostor := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bc.register("impostor.eth", impostor, "vitalik.eth")
+	_, err = ReverseENS(ctx, bc, impostor)
+	require.Error(t, err)
+
+	bc.chainID = 1337
+	_, err = ResolveENS(ctx, bc, "vitalik.eth")
+	require.True(t, errors.Is(err, ErrNoENSRegistry))
+	SetENSRegistry(big.NewInt(1337), ENSRegistryAddress)
+	addr, err = ResolveENS(ctx, bc, "vitalik.eth")
+	require.NoError(t, err)
+	require.Equal(t, vitalik, addr)
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// ERC20 provides helpers for interacting with an ERC20 token contract
+type ERC20 struct {
+	address common.Address
+	bc      Blockchain
+	abi     abi.ABI
+	token   *erc20.Erc20
+
+	// decimals and symbol are immutable so are cached after the first fetch
+	mx          sync.Mutex
+	decimals    uint8
+	hasDecimals bool
+	symbol      string
+	hasSymbol   bool
+}
+
+// NewERC20 returns an ERC20 helper for the token at address
+func NewERC20(address common.Address, bc Blockchain) (*ERC20, error) {
+	token, err := erc20.NewErc20(address, bc)
+	if err != nil {
+		return nil, errors.Wrap(err, "new erc20")
+	}
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &ERC20{address: address, bc: bc, abi: parsed, token: token}, nil
+}
+
+// Address returns the address of the token contract
+func (e *ERC20) Address() common.Address {
+	return e.address
+}
+
+// BalanceOf returns the token balance of owner
+func (e *ERC20) BalanceOf(ctx context.Context, owner common.Address) (*big.Int, error) {
+	return e.balanceAt(ctx, owner, nil)
+}
+
+// balanceAt returns the token balance of owner as of block, or the latest block if it is nil
+func (e *ERC20) balanceAt(ctx context.Context, owner common.Address, block *big.Int) (*big.Int, error) {
+	balance, err := e.token.BalanceOf(&bind.CallOpts{Context: ctx, BlockNumber: block}, owner)
+	if err != nil {
+		return nil, errors.Wrap(err, "balance of")
+	}
+	return balance, nil
+}
+
+// Allowance returns the amount of tokens spender is allowed to transfer on behalf of owner
+func (e *ERC20) Allowance(ctx context.Context, owner, spender common.Address) (*big.Int, error) {
+	allowance, err := e.token.Allowance(&bind.CallOpts{Context: ctx}, owner, spender)
+	if err != nil {
+		return nil, errors.Wrap(err, "allowance")
+	}
+	return allowance, nil
+}
+
+// Decimals returns the number of decimals used by the token, caching it after the first fetch
+func (e *ERC20) Decimals(ctx context.Context) (uint8, error) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if e.hasDecimals {
+		return e.decimals, nil
+	}
+	decimals, err := e.token.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, errors.Wrap(err, "decimals")
+	}
+	e.decimals, e.hasDecimals = decimals, true
+	return decimals, nil
+}
+
+// Symbol returns the symbol of the token, caching it after the first fetch
+func (e *ERC20) Symbol(ctx context.Context) (string, error) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if e.hasSymbol {
+		return e.symbol, nil
+	}
+	symbol, err := e.token.Symbol(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return "", errors.Wrap(err, "symbol")
+	}
+	e.symbol, e.hasSymbol = symbol, true
+	return symbol, nil
+}
+
+// Approve signs and sends a transaction approving spender to transfer amount
+// tokens on behalf of the authorizer. This claims the authorizer lock and must
+// not be called while the lock is already held.
+func (e *ERC20) Approve(ctx context.Context, auth *Authorizer, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return e.token.Approve(opts, spender, amount)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "approve")
+	}
+	return tx, nil
+}
+
+// Transfer signs and sends a transaction transferring amount tokens from the authorizer
+// to the given address. This claims the authorizer lock and must not be called while the
+// lock is already held.
+func (e *ERC20) Transfer(ctx context.Context, auth *Authorizer, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return e.token.Transfer(opts, to, amount)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "transfer")
+	}
+	return tx, nil
+}
+
+// TransferHuman is like Transfer taking a human readable decimal amount such as "1.5", which is
+// scaled by the decimals of the token as fetched with Decimals. An error wrapping ErrTooManyDecimals
+// is returned if the amount has more fractional digits than the token supports, and one wrapping
+// ErrInvalidAmount if it isn't a positive decimal number, without sending anything. This claims the
+// authorizer lock and must not be called while the lock is already held.
+func (e *ERC20) TransferHuman(ctx context.Context, auth *Authorizer, to common.Address, humanAmount string) (*types.Transaction, error) {
+	decimals, err := e.Decimals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := ToWeiStrict(humanAmount, decimals)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
+		return nil, errors.Wrapf(ErrInvalidAmount, "transfer amount %s must be positive", humanAmount)
+	}
+	return e.Transfer(ctx, auth, to, amount)
+}
+
+// TransferAndMeasure transfers amount tokens from the authorizer to the given address and waits for
+// the transfer to be mined, returning the amount actually received. This differs from amount for
+// tokens taking a fee on transfer, and is measured as the change in the balance of the recipient
+// between the block before the transfer was mined and the block it was mined in, so other transfers
+// to the recipient in the same block are included. Balances are read at those blocks, which nodes
+// usually only serve for recent blocks unless they keep an archive. The receipt is waited for with
+// the wait defaults of the authorizer. If the transfer reverts the transaction is returned with an
+// error wrapping ErrTxReverted. The transaction is signed while holding the lock, which is
+// released before waiting, so this must not be called while the lock is already held.
+func TransferAndMeasure(ctx context.Context, auth *Authorizer, token *ERC20, to common.Address, amount *big.Int) (received *big.Int, tx *types.Transaction, err error) {
+	tx, err = token.Transfer(ctx, auth, to, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt, err := waitReceipt(ctx, token.bc, tx.Hash(), tx, newWaitOptions(auth.WaitDefaults()))
+	if err != nil {
+		return nil, tx, err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return nil, tx, revertError(ctx, token.bc, tx, receipt)
+	}
+	before, err := token.balanceAt(ctx, to, new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1)))
+	if err != nil {
+		return nil, tx, err
+	}
+	after, err := token.balanceAt(ctx, to, receipt.BlockNumber)
+	if err != nil {
+		return nil, tx, err
+	}
+	return new(big.Int).Sub(after, before), tx, nil
+}
+
+// approveReverts simulates approving amount for spender from owner, returning true if it reverts.
+// Other failures, such as the node being unreachable, are returned as errors
+func (e *ERC20) approveReverts(ctx context.Context, owner, spender common.Address, amount *big.Int) (bool, error) {
+	data, err := e.abi.Pack("approve", spender, amount)
+	if err != nil {
+		return false, errors.Wrap(err, "pack approve")
+	}
+	// the output is ignored as tokens such as USDT don't return a bool
+	_, err = e.bc.CallContract(ctx, ethereum.CallMsg{From: owner, To: &e.address, Data: data}, nil)
+	if err == nil {
+		return false, nil
+	}
+	if callErr := newCallError(e.address, "approve", data, err); callErr.Reverted {
+		return true, nil
+	}
+	return false, errors.Wrap(err, "simulate approve")
+}
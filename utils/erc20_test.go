@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// feeTokenBackend mines every transaction sent to it, emulating a token at token which takes
+// a 1% fee on transfers to recipient. If airdrop is set the recipient is also credited with it in
+// the block after each transfer
+type feeTokenBackend struct {
+	simulatedBackend
+	abi       abi.ABI
+	token     common.Address
+	recipient common.Address
+	balance   *big.Int
+	decimals  uint8
+	airdrop   *big.Int
+	changes   []balanceChange
+}
+
+// balanceChange records the balance of the recipient before it changed in block
+type balanceChange struct {
+	block  uint64
+	before *big.Int
+}
+
+// credit adds amount to the balance of the recipient in the latest block
+func (b *feeTokenBackend) credit(amount *big.Int) {
+	b.changes = append(b.changes, balanceChange{b.Blockchain().CurrentBlock().NumberU64(), b.balance})
+	b.balance = new(big.Int).Add(b.balance, amount)
+}
+
+// balanceAt returns the balance of the recipient as of block, or the latest block if it is nil
+func (b *feeTokenBackend) balanceAt(block *big.Int) *big.Int {
+	balance := b.balance
+	for i := len(b.changes) - 1; block != nil && i >= 0 && b.changes[i].block > block.Uint64(); i-- {
+		balance = b.changes[i].before
+	}
+	return balance
+}
+
+func (b *feeTokenBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if call.To == nil || *call.To != b.token {
+		return b.simulatedBackend.CallContract(ctx, call, blockNumber)
+	}
+	if bytes.Equal(call.Data[:4], b.abi.Methods["decimals"].ID) {
+		return b.abi.Methods["decimals"].Outputs.Pack(b.decimals)
+	}
+	return b.abi.Methods["balanceOf"].Outputs.Pack(b.balanceAt(blockNumber))
+}
+
+// PendingCodeAt returns non-empty code for the token so the bindings don't consider it undeployed
+func (b *feeTokenBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
+	if contract == b.token {
+		return []byte{1}, nil
+	}
+	return b.simulatedBackend.PendingCodeAt(ctx, contract)
+}
+
+func (b *feeTokenBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if contract == b.token {
+		return []byte{1}, nil
+	}
+	return b.simulatedBackend.CodeAt(ctx, contract, blockNumber)
+}
+
+func (b *feeTokenBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.simulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.Commit()
+	args, err := b.abi.Methods["transfer"].Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return err
+	}
+	if args[0].(common.Address) == b.recipient {
+		amount := args[1].(*big.Int)
+		b.credit(new(big.Int).Sub(amount, new(big.Int).Div(amount, big.NewInt(100))))
+		if b.airdrop != nil {
+			b.Commit()
+			b.credit(b.airdrop)
+		}
+	}
+	return nil
+}
+
+func TestTransferAndMeasure(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	bc := &feeTokenBackend{
+		simulatedBackend: newSimulatedBackend(t, auth.From),
+		abi:              parsed,
+		token:            common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		recipient:        common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+		balance:          big.NewInt(500),
+		airdrop:          big.NewInt(7),
+	}
+	token, err := NewERC20(bc.token, bc)
+	require.NoError(t, err)
+
+	// the airdrop in the following block isn't counted as the balances are read around the transfer
+	received, tx, err := TransferAndMeasure(ctx, auth, token, bc.recipient, big.NewInt(1000))
+	require.NoError(t, err)
+	require.Equal(t, "990", received.String())
+	require.Equal(t, bc.token, *tx.To())
+	require.Equal(t, parsed.Methods["transfer"].ID, tx.Data()[:4])
+}
+
+func TestTransferHuman(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	bc := &feeTokenBackend{
+		simulatedBackend: newSimulatedBackend(t, auth.From),
+		abi:              parsed,
+		token:            common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		recipient:        common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+		balance:          big.NewInt(0),
+		decimals:         6,
+	}
+	token, err := NewERC20(bc.token, bc)
+	require.NoError(t, err)
+
+	tx, err := token.TransferHuman(ctx, auth, bc.recipient, "1.5")
+	require.NoError(t, err)
+	args, err := parsed.Methods["transfer"].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	require.Equal(t, bc.recipient, args[0].(common.Address))
+	require.Equal(t, "1500000", args[1].(*big.Int).String())
+
+	for _, tt := range []struct {
+		amount string
+		err    error
+	}{
+		{"1.0000001", ErrTooManyDecimals},
+		{"1,5", ErrInvalidAmount},
+		{"0", ErrInvalidAmount},
+		{"-1", ErrInvalidAmount},
+	} {
+		_, err := token.TransferHuman(ctx, auth, bc.recipient, tt.amount)
+		require.ErrorIs(t, err, tt.err, tt.amount)
+	}
+}
@@ -0,0 +1,104 @@
+package utils
+
+import "errors"
+
+var (
+	// ErrInvalidKeyLength is returned when a private key is not 32 bytes long
+	ErrInvalidKeyLength = errors.New("invalid private key length, must be 32 bytes")
+	// ErrInvalidKey is returned when a private key is not a valid point on the secp256k1 curve
+	ErrInvalidKey = errors.New("invalid private key, not a valid secp256k1 scalar")
+	// ErrInvalidMnemonic is returned when a mnemonic phrase fails validation,
+	// which is typically the result of a typo or an invalid checksum word
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	// ErrAccountNotFound is returned when an account is not contained
+	// in any of the wallets provided by an account backend
+	ErrAccountNotFound = errors.New("account not found in any wallet")
+	// ErrNilChainID is returned when a nil chain id is given to a constructor requiring one
+	ErrNilChainID = errors.New("chain id must not be nil")
+	// ErrTxReverted is returned when a transaction was mined but reverted
+	ErrTxReverted = errors.New("transaction reverted")
+	// ErrAlreadyMined is returned when attempting to replace a transaction which has already been mined
+	ErrAlreadyMined = errors.New("transaction already mined")
+	// ErrNoRevertData is returned when attempting to decode the revert reason of empty return data
+	ErrNoRevertData = errors.New("no revert data")
+	// ErrAccountExists is returned when attempting to store a key
+	// for an address which already exists in a keystore directory
+	ErrAccountExists = errors.New("account already exists in keystore")
+	// ErrInvalidAmount is returned when a decimal amount can't be parsed
+	ErrInvalidAmount = errors.New("invalid decimal amount")
+	// ErrTooManyDecimals is returned when a decimal amount has more
+	// fractional digits than are supported by the token
+	ErrTooManyDecimals = errors.New("amount has more decimals than supported")
+	// ErrSignerNotSupported is returned when signing arbitrary data with an authorizer
+	// which doesn't hold its private key, such as those backed by a KMS or hardware wallet
+	ErrSignerNotSupported = errors.New("not supported for this signer")
+	// ErrInvalidAddress is returned when parsing a string which isn't a hex encoded address
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrInvalidChecksum is returned when parsing a mixed case address with an invalid EIP-55 checksum
+	ErrInvalidChecksum = errors.New("invalid address checksum")
+	// ErrInvalidSlippage is returned when a slippage tolerance is not within [0, 10000] basis points
+	ErrInvalidSlippage = errors.New("invalid slippage tolerance")
+	// ErrReorged is returned when a mined transaction is no longer found after its block was reorganized out
+	ErrReorged = errors.New("transaction reorged out of the chain")
+	// ErrWrongPassword is returned when a keystore file can't be decrypted with the given password
+	ErrWrongPassword = errors.New("wrong keystore password")
+	// ErrKeyFileWrite is returned when writing a keystore file fails
+	ErrKeyFileWrite = errors.New("failed to write keystore file")
+	// ErrKeyNotExportable is returned when exporting the key of an authorizer backed by a hardware wallet or KMS
+	ErrKeyNotExportable = errors.New("private key is not exportable")
+	// ErrWouldRevert is returned when simulating a transaction shows it would revert
+	ErrWouldRevert = errors.New("transaction would revert")
+	// ErrAccessListLegacy is returned when using an access list with an authorizer sending legacy transactions
+	ErrAccessListLegacy = errors.New("access lists require dynamic fee transactions")
+	// ErrChainIDMismatch is returned when a transaction is signed for a different chain than expected
+	ErrChainIDMismatch = errors.New("chain id mismatch")
+	// ErrInsufficientBalance is returned when an account holds fewer tokens than are being spent
+	ErrInsufficientBalance = errors.New("insufficient token balance")
+	// ErrInsufficientAllowance is returned when a spender is allowed to transfer fewer tokens than are being spent
+	ErrInsufficientAllowance = errors.New("insufficient token allowance")
+	// ErrNoResolver is returned when an ENS name has no resolver or the record being looked up is unset
+	ErrNoResolver = errors.New("no ens resolver")
+	// ErrNoENSRegistry is returned when resolving ENS names on a chain without a known registry
+	ErrNoENSRegistry = errors.New("no ens registry for chain")
+	// ErrQueueClosed is returned when enqueueing a transaction onto a closed TxQueue
+	ErrQueueClosed = errors.New("transaction queue closed")
+	// ErrUnknownChain is returned when looking up a chain which isn't registered
+	ErrUnknownChain = errors.New("unknown chain")
+	// ErrNotDeployed is returned when a contract isn't deployed on the chain it is looked up for
+	ErrNotDeployed = errors.New("contract not deployed on chain")
+	// ErrSIWEExpired is returned when verifying a Sign-In With Ethereum message past its expiration time
+	ErrSIWEExpired = errors.New("siwe message expired")
+	// ErrSIWENotYetValid is returned when verifying a Sign-In With Ethereum message before its not before time
+	ErrSIWENotYetValid = errors.New("siwe message not yet valid")
+	// ErrEventNotFound is returned when a mined transaction didn't emit the event being waited for
+	ErrEventNotFound = errors.New("event not found in transaction")
+	// ErrUnknownSelector is returned when decoding calldata whose selector matches no method of the abi
+	ErrUnknownSelector = errors.New("unknown method selector")
+	// ErrExecutionReverted is matched by errors returned when a contract call reverts
+	ErrExecutionReverted = errors.New("execution reverted")
+	// ErrPanic is matched by errors returned when a contract call reverts with a solidity panic
+	ErrPanic = errors.New("solidity panic")
+	// ErrGasPriceTooHigh is returned when a transaction would pay more than the gas price cap of the authorizer
+	ErrGasPriceTooHigh = errors.New("gas price too high")
+	// ErrTxUnsigned is returned when recovering the sender of a transaction which carries no signature
+	ErrTxUnsigned = errors.New("transaction is not signed")
+	// ErrUnknownContract is returned when decoding a log emitted by a contract without a registered ABI
+	ErrUnknownContract = errors.New("unknown contract")
+	// ErrUnknownEvent is returned when decoding a log whose signature matches no event of the abi
+	ErrUnknownEvent = errors.New("unknown event")
+	// ErrTxTypeIncompatible is returned when the transaction type selected with TxType can't be
+	// used with the gas settings of the authorizer
+	ErrTxTypeIncompatible = errors.New("transaction type incompatible with gas settings")
+	// ErrNotInTree is returned when requesting the merkle proof of a leaf which isn't in the tree
+	ErrNotInTree = errors.New("leaf not in merkle tree")
+	// ErrTraceUnsupported is returned when tracing a transaction with a node which doesn't expose the debug namespace
+	ErrTraceUnsupported = errors.New("node does not support transaction tracing")
+	// ErrNoQuote is returned when none of the venues a swap is quoted on return a quote
+	ErrNoQuote = errors.New("no quote for swap")
+	// ErrNotProxy is returned when resolving the implementation of a contract which isn't an upgradeable proxy
+	ErrNotProxy = errors.New("contract is not a proxy")
+	// ErrTxNotFound is returned when waiting for a transaction dropped from the mempool, such as after its nonce was used by another transaction
+	ErrTxNotFound = errors.New("transaction not found")
+	// ErrBackendUnsupported is returned by client wrappers such as FailoverClient when the backend they wrap lacks an optional method such as NonceAt
+	ErrBackendUnsupported = errors.New("not supported by the backend")
+)
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// BalanceMismatch is a balance which didn't change by the amount expected by ExpectBalanceChanges
+type BalanceMismatch struct {
+	// Token is the zero address for native ETH balances
+	Token    common.Address
+	Holder   common.Address
+	Expected *big.Int
+	Actual   *big.Int
+}
+
+// BalanceChangeError is returned by ExpectBalanceChanges listing every balance which didn't change
+// by the expected amount, ordered by token and then by holder
+type BalanceChangeError struct {
+	Mismatches []BalanceMismatch
+}
+
+// Error implements the error interface
+func (e *BalanceChangeError) Error() string {
+	lines := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		token := "eth"
+		if m.Token != (common.Address{}) {
+			token = "token " + m.Token.Hex()
+		}
+		lines[i] = fmt.Sprintf("%s balance of %s changed by %s, expected %s", token, m.Holder.Hex(), m.Actual, m.Expected)
+	}
+	return fmt.Sprintf("%d unexpected balance changes: %s", len(lines), strings.Join(lines, "; "))
+}
+
+// ExpectBalanceChanges reads the balances of the expectations, runs fn and returns a
+// *BalanceChangeError unless every balance changed by exactly its expected delta. Expectations are
+// keyed by token and then by holder as returned by BatchBalances, with the zero address as a token
+// for native ETH balances, and negative deltas for balances which decrease. Balances are read at
+// the latest block, so fn must send the transactions and wait for them to be mined, or commit them
+// with a simulated backend. Note ETH balances of senders include the fees paid. Errors returned by
+// fn are returned as is without checking any balance
+func ExpectBalanceChanges(ctx context.Context, bc Blockchain, fn func() error, expectations map[common.Address]map[common.Address]*big.Int) error {
+	before, err := expectedBalances(ctx, bc, expectations)
+	if err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	after, err := expectedBalances(ctx, bc, expectations)
+	if err != nil {
+		return err
+	}
+	var mismatches []BalanceMismatch
+	for token, holders := range expectations {
+		for holder, expected := range holders {
+			if expected == nil {
+				expected = new(big.Int)
+			}
+			actual := new(big.Int).Sub(after[token][holder], before[token][holder])
+			if actual.Cmp(expected) != 0 {
+				mismatches = append(mismatches, BalanceMismatch{Token: token, Holder: holder, Expected: expected, Actual: actual})
+			}
+		}
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		if c := bytes.Compare(mismatches[i].Token.Bytes(), mismatches[j].Token.Bytes()); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(mismatches[i].Holder.Bytes(), mismatches[j].Holder.Bytes()) < 0
+	})
+	return &BalanceChangeError{Mismatches: mismatches}
+}
+
+// expectedBalances returns the current balance of every holder of every token of expectations
+func expectedBalances(ctx context.Context, bc Blockchain, expectations map[common.Address]map[common.Address]*big.Int) (map[common.Address]map[common.Address]*big.Int, error) {
+	balances := make(map[common.Address]map[common.Address]*big.Int, len(expectations))
+	for token, holders := range expectations {
+		balances[token] = make(map[common.Address]*big.Int, len(holders))
+		if token == (common.Address{}) {
+			reader, ok := bc.(balanceReader)
+			if !ok {
+				return nil, errors.New("backend can't report eth balances")
+			}
+			for holder := range holders {
+				balance, err := reader.BalanceAt(ctx, holder, nil)
+				if err != nil {
+					return nil, errors.Wrapf(err, "eth balance of %s", holder.Hex())
+				}
+				balances[token][holder] = balance
+			}
+			continue
+		}
+		erc20, err := NewERC20(token, bc)
+		if err != nil {
+			return nil, err
+		}
+		for holder := range holders {
+			balance, err := erc20.BalanceOf(ctx, holder)
+			if err != nil {
+				return nil, errors.Wrapf(err, "token %s", token.Hex())
+			}
+			balances[token][holder] = balance
+		}
+	}
+	return balances, nil
+}
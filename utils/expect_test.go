@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectBalanceChanges(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	bc := &feeTokenBackend{
+		simulatedBackend: newSimulatedBackend(t, auth.From),
+		abi:              parsed,
+		token:            common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		recipient:        common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+		balance:          big.NewInt(500),
+	}
+	token, err := NewERC20(bc.token, bc)
+	require.NoError(t, err)
+	transfer := func() error {
+		_, err := token.Transfer(ctx, auth, bc.recipient, big.NewInt(1000))
+		return err
+	}
+
+	require.NoError(t, ExpectBalanceChanges(ctx, bc, transfer, map[common.Address]map[common.Address]*big.Int{
+		bc.token:           {bc.recipient: big.NewInt(990)},
+		(common.Address{}): {bc.recipient: nil},
+	}))
+
+	// every mismatch is reported, noting the fake token reports the same balance for every holder
+	err = ExpectBalanceChanges(ctx, bc, transfer, map[common.Address]map[common.Address]*big.Int{
+		bc.token: {bc.recipient: big.NewInt(1000), auth.From: big.NewInt(-1000)},
+	})
+	var changeErr *BalanceChangeError
+	require.True(t, errors.As(err, &changeErr))
+	require.Len(t, changeErr.Mismatches, 2)
+	for _, m := range changeErr.Mismatches {
+		require.Equal(t, bc.token, m.Token)
+		require.Equal(t, "990", m.Actual.String())
+	}
+	require.Contains(t, err.Error(), "2 unexpected balance changes")
+
+	fail := errors.New("send failed")
+	require.Equal(t, fail, ExpectBalanceChanges(ctx, bc, func() error { return fail }, nil))
+}
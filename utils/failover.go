@@ -0,0 +1,426 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// initialFailoverBackoff is how long a failed endpoint is skipped after its first failure
+	initialFailoverBackoff = time.Second
+	// maxFailoverBackoff caps the exponential backoff of endpoints failing repeatedly
+	maxFailoverBackoff = time.Minute
+	// rpcLimitExceeded is the JSON-RPC error code returned by rate limited endpoints
+	rpcLimitExceeded = -32005
+)
+
+// EndpointHealth describes the health of an endpoint used by a FailoverClient
+type EndpointHealth struct {
+	URL string
+	// Failures is the number of consecutive failed calls, reset by a successful call
+	Failures  int
+	LastError error
+	// RetryAt is when the endpoint is next tried after failing
+	RetryAt time.Time
+}
+
+// Healthy returns true if the last call to the endpoint succeeded
+func (h EndpointHealth) Healthy() bool {
+	return h.Failures == 0
+}
+
+// endpoint is a backend along with its health, which is guarded by the client lock
+type endpoint struct {
+	backend Blockchain
+	health  EndpointHealth
+}
+
+// FailoverClient implements Blockchain on top of an ordered list of endpoints, trying each call
+// against them in order until one succeeds. Endpoints failing due to connectivity issues or rate
+// limiting are skipped with an exponential backoff before being retried, while errors returned
+// by a responsive node such as reverts are returned to the caller as is, since other endpoints
+// would return the same. When every endpoint is backing off the one due to be retried first is used
+type FailoverClient struct {
+	mx        sync.Mutex
+	endpoints []*endpoint
+}
+
+// NewFailoverClient dials each of the given RPC urls in order of preference. The context only
+// bounds dialing, which doesn't make any requests for http endpoints
+func NewFailoverClient(ctx context.Context, urls ...string) (*FailoverClient, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("at least one rpc url is required")
+	}
+	backends := make([]Blockchain, 0, len(urls))
+	for _, url := range urls {
+		client, err := rpc.DialContext(ctx, url)
+		if err != nil {
+			for _, backend := range backends {
+				backend.(rpcEthClient).Close()
+			}
+			return nil, errors.Wrapf(err, "dial %s", url)
+		}
+		backends = append(backends, rpcEthClient{Client: ethclient.NewClient(client), rpc: client})
+	}
+	fc := newFailoverClient(backends)
+	for i, url := range urls {
+		fc.endpoints[i].health.URL = url
+	}
+	return fc, nil
+}
+
+// newFailoverClient returns a failover client using the given backends in order of preference
+func newFailoverClient(backends []Blockchain) *FailoverClient {
+	fc := &FailoverClient{}
+	for _, backend := range backends {
+		fc.endpoints = append(fc.endpoints, &endpoint{backend: backend})
+	}
+	return fc
+}
+
+// Health returns the current health of each endpoint in order of preference
+func (fc *FailoverClient) Health() []EndpointHealth {
+	fc.mx.Lock()
+	defer fc.mx.Unlock()
+	health := make([]EndpointHealth, 0, len(fc.endpoints))
+	for _, ep := range fc.endpoints {
+		health = append(health, ep.health)
+	}
+	return health
+}
+
+// Close closes the connections of all endpoints dialed by NewFailoverClient
+func (fc *FailoverClient) Close() {
+	for _, ep := range fc.endpoints {
+		if client, ok := ep.backend.(rpcEthClient); ok {
+			client.Close()
+		}
+	}
+}
+
+// do invokes fn against each available endpoint in order until it doesn't fail with an endpoint error
+func (fc *FailoverClient) do(ctx context.Context, fn func(bc Blockchain) error) error {
+	var lastErr error
+	for _, ep := range fc.candidates(time.Now()) {
+		err := fn(ep.backend)
+		if ctx.Err() != nil {
+			// the caller gave up so this says nothing about the health of the endpoint
+			return err
+		}
+		if !isEndpointError(err) {
+			fc.succeeded(ep)
+			return err
+		}
+		fc.failed(ep, err)
+		lastErr = err
+	}
+	return errors.Wrap(lastErr, "all endpoints failed")
+}
+
+// candidates returns the endpoints which aren't backing off in order of preference, or the endpoint
+// due to be retried first if all of them are
+func (fc *FailoverClient) candidates(now time.Time) []*endpoint {
+	fc.mx.Lock()
+	defer fc.mx.Unlock()
+	var (
+		available []*endpoint
+		soonest   *endpoint
+	)
+	for _, ep := range fc.endpoints {
+		if !now.Before(ep.health.RetryAt) {
+			available = append(available, ep)
+		}
+		if soonest == nil || ep.health.RetryAt.Before(soonest.health.RetryAt) {
+			soonest = ep
+		}
+	}
+	if len(available) == 0 {
+		return []*endpoint{soonest}
+	}
+	return available
+}
+
+// succeeded resets the health of ep after a successful call
+func (fc *FailoverClient) succeeded(ep *endpoint) {
+	fc.mx.Lock()
+	defer fc.mx.Unlock()
+	ep.health.Failures = 0
+	ep.health.LastError = nil
+	ep.health.RetryAt = time.Time{}
+}
+
+// failed records a failed call to ep, doubling the time before it is retried
+func (fc *FailoverClient) failed(ep *endpoint, err error) {
+	fc.mx.Lock()
+	defer fc.mx.Unlock()
+	ep.health.Failures++
+	ep.health.LastError = err
+	backoff := initialFailoverBackoff
+	for i := 1; i < ep.health.Failures && backoff < maxFailoverBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxFailoverBackoff {
+		backoff = maxFailoverBackoff
+	}
+	ep.health.RetryAt = time.Now().Add(backoff)
+}
+
+// isEndpointError returns true if err indicates the endpoint is unavailable rather than being returned
+// by a responsive node, in which case the call should be tried against the next endpoint. Endpoints
+// lacking an optional method aren't considered unavailable
+func isEndpointError(err error) bool {
+	if err == nil || err == ethereum.NotFound || errors.Is(err, ErrBackendUnsupported) {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode() == rpcLimitExceeded
+	}
+	return true
+}
+
+// CodeAt implements Blockchain
+func (fc *FailoverClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		code, err = bc.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+// CallContract implements Blockchain
+func (fc *FailoverClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (out []byte, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		out, err = bc.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return out, err
+}
+
+// PendingCodeAt implements Blockchain
+func (fc *FailoverClient) PendingCodeAt(ctx context.Context, contract common.Address) (code []byte, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		code, err = bc.PendingCodeAt(ctx, contract)
+		return err
+	})
+	return code, err
+}
+
+// PendingCallContract implements Blockchain
+func (fc *FailoverClient) PendingCallContract(ctx context.Context, call ethereum.CallMsg) (out []byte, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		out, err = bc.PendingCallContract(ctx, call)
+		return err
+	})
+	return out, err
+}
+
+// PendingNonceAt implements Blockchain
+func (fc *FailoverClient) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		nonce, err = bc.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+// SuggestGasPrice implements Blockchain
+func (fc *FailoverClient) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		price, err = bc.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+// SuggestGasTipCap implements Blockchain
+func (fc *FailoverClient) SuggestGasTipCap(ctx context.Context) (tip *big.Int, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		tip, err = bc.SuggestGasTipCap(ctx)
+		return err
+	})
+	return tip, err
+}
+
+// EstimateGas implements Blockchain
+func (fc *FailoverClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (gas uint64, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		gas, err = bc.EstimateGas(ctx, call)
+		return err
+	})
+	return gas, err
+}
+
+// SendTransaction implements Blockchain. Errors such as an underpriced transaction or a nonce
+// that is too low are returned by the node that rejected it without trying other endpoints. If an
+// endpoint broadcast the transaction before failing, the next endpoint may report it as already known
+func (fc *FailoverClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return fc.do(ctx, func(bc Blockchain) error {
+		return bc.SendTransaction(ctx, tx)
+	})
+}
+
+// FilterLogs implements Blockchain
+func (fc *FailoverClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (logs []types.Log, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		logs, err = bc.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+// SubscribeFilterLogs implements Blockchain. The subscription is bound to the endpoint it was
+// created on and isn't moved to another endpoint if that endpoint fails later on
+func (fc *FailoverClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (sub ethereum.Subscription, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		sub, err = bc.SubscribeFilterLogs(ctx, query, ch)
+		return err
+	})
+	return sub, err
+}
+
+// TransactionReceipt implements Blockchain
+func (fc *FailoverClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		receipt, err = bc.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+// SubscribeNewHead implements Blockchain. The subscription is bound to the endpoint it was
+// created on and isn't moved to another endpoint if that endpoint fails later on
+func (fc *FailoverClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (sub ethereum.Subscription, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		sub, err = bc.SubscribeNewHead(ctx, ch)
+		return err
+	})
+	return sub, err
+}
+
+// TransactionByHash implements Blockchain
+func (fc *FailoverClient) TransactionByHash(ctx context.Context, txHash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		tx, isPending, err = bc.TransactionByHash(ctx, txHash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+// BlockNumber implements Blockchain
+func (fc *FailoverClient) BlockNumber(ctx context.Context) (number uint64, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		number, err = bc.BlockNumber(ctx)
+		return err
+	})
+	return number, err
+}
+
+// HeaderByNumber implements Blockchain
+func (fc *FailoverClient) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		header, err = bc.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+// NonceAt forwards to the endpoints implementing it such as *ethclient.Client, like the optional
+// methods below, which return an error wrapping ErrBackendUnsupported for endpoints lacking them
+func (fc *FailoverClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (nonce uint64, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		reader, ok := bc.(nonceReader)
+		if !ok {
+			return errors.Wrap(ErrBackendUnsupported, "nonce at")
+		}
+		nonce, err = reader.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
+
+// BalanceAt forwards to the endpoints implementing it
+func (fc *FailoverClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		reader, ok := bc.(balanceReader)
+		if !ok {
+			return errors.Wrap(ErrBackendUnsupported, "balance at")
+		}
+		balance, err = reader.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+// PendingBalanceAt forwards to the endpoints implementing it
+func (fc *FailoverClient) PendingBalanceAt(ctx context.Context, account common.Address) (balance *big.Int, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		reader, ok := bc.(pendingBalanceReader)
+		if !ok {
+			return errors.Wrap(ErrBackendUnsupported, "pending balance at")
+		}
+		balance, err = reader.PendingBalanceAt(ctx, account)
+		return err
+	})
+	return balance, err
+}
+
+// ChainID forwards to the endpoints implementing it
+func (fc *FailoverClient) ChainID(ctx context.Context) (chainID *big.Int, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		reader, ok := bc.(chainIDReader)
+		if !ok {
+			return errors.Wrap(ErrBackendUnsupported, "chain id")
+		}
+		chainID, err = reader.ChainID(ctx)
+		return err
+	})
+	return chainID, err
+}
+
+// StorageAt forwards to the endpoints implementing it
+func (fc *FailoverClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) (value []byte, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		reader, ok := bc.(storageReader)
+		if !ok {
+			return errors.Wrap(ErrBackendUnsupported, "storage at")
+		}
+		value, err = reader.StorageAt(ctx, account, key, blockNumber)
+		return err
+	})
+	return value, err
+}
+
+// FeeHistory forwards to the endpoints implementing it
+func (fc *FailoverClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (history *ethereum.FeeHistory, err error) {
+	err = fc.do(ctx, func(bc Blockchain) error {
+		reader, ok := bc.(FeeHistoryReader)
+		if !ok {
+			return errors.Wrap(ErrBackendUnsupported, "fee history")
+		}
+		history, err = reader.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+		return err
+	})
+	return history, err
+}
+
+// CallContext forwards raw JSON-RPC calls to the endpoints implementing it, which include those
+// dialed by NewFailoverClient
+func (fc *FailoverClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return fc.do(ctx, func(bc Blockchain) error {
+		caller, ok := bc.(rpcCaller)
+		if !ok {
+			return errors.Wrap(ErrBackendUnsupported, method)
+		}
+		return caller.CallContext(ctx, result, method, args...)
+	})
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenBackend fails every call to BlockNumber with err, counting the calls
+type brokenBackend struct {
+	Blockchain
+	err   error
+	calls int
+}
+
+func (b *brokenBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	b.calls++
+	return 0, b.err
+}
+
+// rpcError mimics a JSON-RPC error returned by a responsive node
+type rpcError struct {
+	code int
+}
+
+func (e rpcError) Error() string  { return "rpc error" }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestFailoverClient(t *testing.T) {
+	ctx := context.Background()
+	sim := newSimulatedBackend(t)
+	sim.Commit()
+	down := &brokenBackend{err: errors.New("connection refused")}
+	fc := newFailoverClient([]Blockchain{down, sim})
+
+	number, err := fc.BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), number)
+	health := fc.Health()
+	require.False(t, health[0].Healthy())
+	require.Equal(t, 1, health[0].Failures)
+	require.True(t, health[1].Healthy())
+
+	// the failed endpoint is skipped while backing off
+	_, err = fc.BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, down.calls)
+
+	// once every endpoint is backing off the one due first is retried
+	fc = newFailoverClient([]Blockchain{down})
+	_, err = fc.BlockNumber(ctx)
+	require.Error(t, err)
+	_, err = fc.BlockNumber(ctx)
+	require.Error(t, err)
+	require.Equal(t, 3, down.calls)
+	require.Equal(t, 2, fc.Health()[0].Failures)
+}
+
+func TestFailoverClientNodeErrors(t *testing.T) {
+	ctx := context.Background()
+	sim := newSimulatedBackend(t)
+	// errors returned by a responsive node are returned without failing over
+	reverted := &brokenBackend{err: rpcError{code: 3}}
+	fc := newFailoverClient([]Blockchain{reverted, sim})
+	_, err := fc.BlockNumber(ctx)
+	require.Equal(t, rpcError{code: 3}, err)
+	require.True(t, fc.Health()[0].Healthy())
+
+	// apart from rate limiting
+	limited := &brokenBackend{err: rpcError{code: rpcLimitExceeded}}
+	fc = newFailoverClient([]Blockchain{limited, sim})
+	_, err = fc.BlockNumber(ctx)
+	require.NoError(t, err)
+	require.False(t, fc.Health()[0].Healthy())
+}
+
+// requireOptionalMethods asserts that bc implements the optional methods helpers probe backends for
+func requireOptionalMethods(t *testing.T, bc Blockchain) {
+	for name, ok := range map[string]bool{
+		"NonceAt":          implements(bc, (*nonceReader)(nil)),
+		"BalanceAt":        implements(bc, (*balanceReader)(nil)),
+		"PendingBalanceAt": implements(bc, (*pendingBalanceReader)(nil)),
+		"ChainID":          implements(bc, (*chainIDReader)(nil)),
+		"StorageAt":        implements(bc, (*storageReader)(nil)),
+		"FeeHistory":       implements(bc, (*FeeHistoryReader)(nil)),
+		"CallContext":      implements(bc, (*rpcCaller)(nil)),
+	} {
+		require.True(t, ok, "%T doesn't implement %s", bc, name)
+	}
+}
+
+// implements returns true if bc implements the interface iface points to
+func implements(bc Blockchain, iface interface{}) bool {
+	return reflect.TypeOf(bc).Implements(reflect.TypeOf(iface).Elem())
+}
+
+// brokenNonceBackend fails every call to NonceAt with err
+type brokenNonceBackend struct {
+	brokenBackend
+}
+
+func (b *brokenNonceBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	b.calls++
+	return 0, b.err
+}
+
+func TestFailoverClientOptionalMethods(t *testing.T) {
+	ctx := context.Background()
+	sim := newSimulatedBackend(t)
+	down := &brokenNonceBackend{brokenBackend{err: errors.New("connection refused")}}
+	fc := newFailoverClient([]Blockchain{down, sim})
+	requireOptionalMethods(t, fc)
+
+	nonce, err := fc.NonceAt(ctx, common.Address{1}, nil)
+	require.NoError(t, err)
+	require.Zero(t, nonce)
+	require.Equal(t, 1, down.calls)
+	require.False(t, fc.Health()[0].Healthy())
+
+	// methods the endpoint lacks are reported without marking it unhealthy
+	fc = newFailoverClient([]Blockchain{sim})
+	_, err = fc.ChainID(ctx)
+	require.True(t, errors.Is(err, ErrBackendUnsupported))
+	require.True(t, fc.Health()[0].Healthy())
+}
+
+func TestIsEndpointError(t *testing.T) {
+	require.False(t, isEndpointError(nil))
+	require.False(t, isEndpointError(rpcError{code: -32000}))
+	require.True(t, isEndpointError(rpcError{code: rpcLimitExceeded}))
+	require.True(t, isEndpointError(errors.New("429 Too Many Requests")))
+	require.False(t, isEndpointError(errors.Wrap(ErrBackendUnsupported, "chain id")))
+}
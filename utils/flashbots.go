@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// FlashbotsRelayURL points to the flashbots relay on mainnet.
+const FlashbotsRelayURL = "https://relay.flashbots.net"
+
+// flashbotsSignatureHeader is the header carrying the signature of the request body
+const flashbotsSignatureHeader = "X-Flashbots-Signature"
+
+// BundleTxResult is the simulated outcome of a single transaction in a bundle
+type BundleTxResult struct {
+	TxHash       common.Hash
+	GasUsed      uint64
+	GasPrice     *big.Int
+	CoinbaseDiff *big.Int
+	// Error and Revert are set when the transaction failed, Revert holding the decoded reason
+	Error  string
+	Revert string
+}
+
+// BundleSimulation is the result of simulating a bundle with SimulateBundle
+type BundleSimulation struct {
+	BundleHash       string
+	StateBlockNumber uint64
+	TotalGasUsed     uint64
+	BundleGasPrice   *big.Int
+	CoinbaseDiff     *big.Int
+	Results          []BundleTxResult
+}
+
+// callBundleResult is the result of eth_callBundle, which encodes wei amounts as base 10 strings
+type callBundleResult struct {
+	BundleHash       string `json:"bundleHash"`
+	StateBlockNumber uint64 `json:"stateBlockNumber"`
+	TotalGasUsed     uint64 `json:"totalGasUsed"`
+	BundleGasPrice   string `json:"bundleGasPrice"`
+	CoinbaseDiff     string `json:"coinbaseDiff"`
+	Results          []struct {
+		TxHash       common.Hash `json:"txHash"`
+		GasUsed      uint64      `json:"gasUsed"`
+		GasPrice     string      `json:"gasPrice"`
+		CoinbaseDiff string      `json:"coinbaseDiff"`
+		Error        string      `json:"error"`
+		Revert       string      `json:"revert"`
+	} `json:"results"`
+}
+
+// bundleParams are the parameters of the eth_sendBundle and eth_callBundle methods
+type bundleParams struct {
+	Txs              []string `json:"txs"`
+	BlockNumber      string   `json:"blockNumber"`
+	StateBlockNumber string   `json:"stateBlockNumber,omitempty"`
+}
+
+// relayRequest is a JSON-RPC request sent to a relay
+type relayRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// relayResponse is a JSON-RPC response returned by a relay
+type relayResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SendBundle submits txs as a bundle to the private relay at relayURL, such as FlashbotsRelayURL,
+// to be included atomically in targetBlock. Bundles bypass the public mempool so swaps can't be
+// frontrun, and are dropped rather than included if targetBlock passes. The request is signed by
+// the authorizer, whose address builds reputation with the relay, and the bundle hash is returned.
+// Authorizers backed by hardware wallets or a KMS return ErrSignerNotSupported
+func SendBundle(ctx context.Context, relayURL string, auth *Authorizer, txs []*types.Transaction, targetBlock uint64) (string, error) {
+	params, err := newBundleParams(txs, targetBlock)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		BundleHash string `json:"bundleHash"`
+	}
+	if err := callRelay(ctx, relayURL, auth, "eth_sendBundle", params, &result); err != nil {
+		return "", errors.Wrap(err, "send bundle")
+	}
+	return result.BundleHash, nil
+}
+
+// SimulateBundle simulates txs as a bundle included in targetBlock on top of the latest state
+// using the relay at relayURL, allowing the results to be verified before calling SendBundle.
+// The per transaction results include any error or revert reason. Authorizers backed by hardware
+// wallets or a KMS return ErrSignerNotSupported
+func SimulateBundle(ctx context.Context, relayURL string, auth *Authorizer, txs []*types.Transaction, targetBlock uint64) (*BundleSimulation, error) {
+	params, err := newBundleParams(txs, targetBlock)
+	if err != nil {
+		return nil, err
+	}
+	params.StateBlockNumber = "latest"
+	var result callBundleResult
+	if err := callRelay(ctx, relayURL, auth, "eth_callBundle", params, &result); err != nil {
+		return nil, errors.Wrap(err, "simulate bundle")
+	}
+	sim := &BundleSimulation{
+		BundleHash:       result.BundleHash,
+		StateBlockNumber: result.StateBlockNumber,
+		TotalGasUsed:     result.TotalGasUsed,
+		BundleGasPrice:   parseDecimal(result.BundleGasPrice),
+		CoinbaseDiff:     parseDecimal(result.CoinbaseDiff),
+	}
+	for _, r := range result.Results {
+		sim.Results = append(sim.Results, BundleTxResult{
+			TxHash:       r.TxHash,
+			GasUsed:      r.GasUsed,
+			GasPrice:     parseDecimal(r.GasPrice),
+			CoinbaseDiff: parseDecimal(r.CoinbaseDiff),
+			Error:        r.Error,
+			Revert:       r.Revert,
+		})
+	}
+	return sim, nil
+}
+
+// newBundleParams encodes txs for inclusion in targetBlock
+func newBundleParams(txs []*types.Transaction, targetBlock uint64) (*bundleParams, error) {
+	if len(txs) == 0 {
+		return nil, errors.New("bundle must contain at least one transaction")
+	}
+	params := &bundleParams{BlockNumber: hexutil.EncodeUint64(targetBlock)}
+	for _, tx := range txs {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "encode transaction")
+		}
+		params.Txs = append(params.Txs, hexutil.Encode(raw))
+	}
+	return params, nil
+}
+
+// callRelay posts a signed JSON-RPC request to the relay, decoding the result into result
+func callRelay(ctx context.Context, relayURL string, auth *Authorizer, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(relayRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: []interface{}{params}})
+	if err != nil {
+		return errors.Wrap(err, "encode request")
+	}
+	signature, err := signRelayRequest(auth, body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, relayURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(flashbotsSignatureHeader, signature)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "post request")
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response")
+	}
+	var decoded relayResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return errors.Errorf("relay returned status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if decoded.Error != nil {
+		return errors.Errorf("relay error %d: %s", decoded.Error.Code, decoded.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("relay returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(decoded.Result, result); err != nil {
+		return errors.Wrap(err, "decode result")
+	}
+	return nil
+}
+
+// signRelayRequest returns the value of the signature header for body, which is the authorizers
+// address and its EIP-191 signature of the hex encoded keccak256 hash of body
+func signRelayRequest(auth *Authorizer, body []byte) (string, error) {
+	sig, err := auth.SignMessage([]byte(hexutil.Encode(crypto.Keccak256(body))))
+	if err != nil {
+		return "", errors.Wrap(err, "sign request")
+	}
+	return auth.From.Hex() + ":" + hexutil.Encode(sig), nil
+}
+
+// parseDecimal parses a base 10 integer returned by the relay, returning nil if it is invalid
+func parseDecimal(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// newRelay returns a relay server which checks the signature of each request against auth,
+// passing the decoded request to handle and responding with the result it returns
+func newRelay(t *testing.T, auth *Authorizer, handle func(req relayRequest) string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		parts := strings.Split(r.Header.Get(flashbotsSignatureHeader), ":")
+		require.Len(t, parts, 2)
+		require.Equal(t, auth.From.Hex(), parts[0])
+		signer, err := VerifyMessage([]byte(hexutil.Encode(crypto.Keccak256(body))), common.FromHex(parts[1]))
+		require.NoError(t, err)
+		require.Equal(t, auth.From, signer)
+		var req relayRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + handle(req) + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newBundleTx(t *testing.T, auth *Authorizer) *types.Transaction {
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil))
+	require.NoError(t, err)
+	return tx
+}
+
+func TestSendBundle(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+	require.NoError(t, err)
+	tx := newBundleTx(t, auth)
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	srv := newRelay(t, auth, func(req relayRequest) string {
+		require.Equal(t, "eth_sendBundle", req.Method)
+		require.Equal(t, []interface{}{map[string]interface{}{
+			"txs":         []interface{}{hexutil.Encode(raw)},
+			"blockNumber": "0x64",
+		}}, req.Params)
+		return `{"bundleHash":"0x1234"}`
+	})
+	hash, err := SendBundle(context.Background(), srv.URL, auth, []*types.Transaction{tx}, 100)
+	require.NoError(t, err)
+	require.Equal(t, "0x1234", hash)
+
+	_, err = SendBundle(context.Background(), srv.URL, auth, nil, 100)
+	require.Error(t, err)
+}
+
+func TestSimulateBundle(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+	require.NoError(t, err)
+	tx := newBundleTx(t, auth)
+	srv := newRelay(t, auth, func(req relayRequest) string {
+		require.Equal(t, "eth_callBundle", req.Method)
+		params := req.Params[0].(map[string]interface{})
+		require.Equal(t, "latest", params["stateBlockNumber"])
+		return `{
+			"bundleHash": "0xabcd",
+			"stateBlockNumber": 99,
+			"totalGasUsed": 21000,
+			"bundleGasPrice": "1",
+			"coinbaseDiff": "21000",
+			"results": [{
+				"txHash": "` + tx.Hash().Hex() + `",
+				"gasUsed": 21000,
+				"gasPrice": "1",
+				"coinbaseDiff": "21000",
+				"revert": "too little received"
+			}]
+		}`
+	})
+	sim, err := SimulateBundle(context.Background(), srv.URL, auth, []*types.Transaction{tx}, 100)
+	require.NoError(t, err)
+	require.Equal(t, "0xabcd", sim.BundleHash)
+	require.Equal(t, uint64(99), sim.StateBlockNumber)
+	require.Equal(t, uint64(21000), sim.TotalGasUsed)
+	require.Equal(t, "21000", sim.CoinbaseDiff.String())
+	require.Len(t, sim.Results, 1)
+	require.Equal(t, tx.Hash(), sim.Results[0].TxHash)
+	require.Equal(t, "1", sim.Results[0].GasPrice.String())
+	require.Equal(t, "too little received", sim.Results[0].Revert)
+}
+
+func TestCallRelayError(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bundle too large"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	_, err = SendBundle(context.Background(), srv.URL, auth, []*types.Transaction{newBundleTx(t, auth)}, 1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bundle too large")
+}
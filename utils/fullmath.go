@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/pkg/errors"
+)
+
+// MulDiv returns a*b/denominator rounded down, computing the product with full precision like the
+// FullMath library of uniswap v3. As with FullMath the inputs and the result are uint256 values, so
+// an error is returned if any input is negative or exceeds 2^256-1, if denominator is zero, or if
+// the result doesn't fit in 256 bits, allowing on-chain math to be reproduced exactly off-chain
+func MulDiv(a, b, denominator *big.Int) (*big.Int, error) {
+	if err := checkMulDiv(a, b, denominator); err != nil {
+		return nil, err
+	}
+	result := new(big.Int).Mul(a, b)
+	result.Quo(result, denominator)
+	if result.Cmp(math.MaxBig256) > 0 {
+		return nil, errors.New("mul div overflows uint256")
+	}
+	return result, nil
+}
+
+// MulDivRoundingUp is like MulDiv but rounds the result up, as the mulDivRoundingUp function of
+// FullMath, returning an error if rounding up overflows
+func MulDivRoundingUp(a, b, denominator *big.Int) (*big.Int, error) {
+	if err := checkMulDiv(a, b, denominator); err != nil {
+		return nil, err
+	}
+	product := new(big.Int).Mul(a, b)
+	result, rem := new(big.Int).QuoRem(product, denominator, new(big.Int))
+	if rem.Sign() > 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	if result.Cmp(math.MaxBig256) > 0 {
+		return nil, errors.New("mul div overflows uint256")
+	}
+	return result, nil
+}
+
+// checkMulDiv returns an error if the inputs of MulDiv aren't uint256 values or denominator is zero
+func checkMulDiv(a, b, denominator *big.Int) error {
+	for _, v := range []*big.Int{a, b, denominator} {
+		if v.Sign() < 0 || v.Cmp(math.MaxBig256) > 0 {
+			return errors.Errorf("mul div input %s is not a uint256", v)
+		}
+	}
+	if denominator.Sign() == 0 {
+		return errors.New("mul div by zero")
+	}
+	return nil
+}
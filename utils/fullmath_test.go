@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/require"
+)
+
+// the vectors of the FullMath tests of uniswap v3-core
+func TestMulDiv(t *testing.T) {
+	q128 := new(big.Int).Lsh(big.NewInt(1), 128)
+	// frac returns n/d of q128
+	frac := func(n, d int64) *big.Int {
+		v := new(big.Int).Mul(q128, big.NewInt(n))
+		return v.Div(v, big.NewInt(d))
+	}
+	maxMinusOne := new(big.Int).Sub(math.MaxBig256, big.NewInt(1))
+	big535, _ := new(big.Int).SetString("535006138814359", 10)
+	big432, _ := new(big.Int).SetString("432862656469423142931042426214547535783388063929571229938474969", 10)
+	for _, tt := range []struct {
+		name           string
+		a, b, d        *big.Int
+		down, up       *big.Int
+		downErr, upErr bool
+	}{
+		{name: "zero denominator", a: q128, b: big.NewInt(5), d: big.NewInt(0), downErr: true, upErr: true},
+		{name: "numerator overflows", a: q128, b: q128, d: big.NewInt(0), downErr: true, upErr: true},
+		{name: "output overflows uint256", a: q128, b: q128, d: big.NewInt(1), downErr: true, upErr: true},
+		{name: "overflow with all max inputs", a: math.MaxBig256, b: math.MaxBig256, d: maxMinusOne, downErr: true, upErr: true},
+		{name: "all max inputs", a: math.MaxBig256, b: math.MaxBig256, d: math.MaxBig256, down: math.MaxBig256, up: math.MaxBig256},
+		{name: "accurate without phantom overflow", a: q128, b: frac(50, 100), d: frac(150, 100), down: frac(1, 3), up: new(big.Int).Add(frac(1, 3), big.NewInt(1))},
+		{name: "accurate with phantom overflow", a: q128, b: new(big.Int).Mul(big.NewInt(35), q128), d: new(big.Int).Mul(big.NewInt(8), q128), down: frac(4375, 1000), up: frac(4375, 1000)},
+		{name: "accurate with phantom overflow and repeating decimal", a: q128, b: new(big.Int).Mul(big.NewInt(1000), q128), d: new(big.Int).Mul(big.NewInt(3000), q128), down: frac(1, 3), up: new(big.Int).Add(frac(1, 3), big.NewInt(1))},
+		{name: "rounding up overflows", a: big535, b: big432, d: big.NewInt(2), down: new(big.Int).Div(new(big.Int).Mul(big535, big432), big.NewInt(2)), upErr: true},
+		{name: "negative input", a: big.NewInt(-1), b: big.NewInt(1), d: big.NewInt(1), downErr: true, upErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			down, err := MulDiv(tt.a, tt.b, tt.d)
+			if tt.downErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.down.String(), down.String())
+			}
+			up, err := MulDivRoundingUp(tt.a, tt.b, tt.d)
+			if tt.upErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.up.String(), up.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultGasBufferPercent is the safety buffer added on top of gas
+// estimates when no buffer, or a non-positive buffer is given
+const DefaultGasBufferPercent = 20
+
+// EstimateAndSetGas estimates the gas required by msg, adds a safety buffer of
+// bufferPercent on top of the estimate and stores the result as the gas limit
+// to use. If the estimation fails due to a revert, the revert reason is included
+// in the returned error. The gas limit set is explicit so GasLimitMultiplier isn't
+// applied on top of the buffer, and it is used by every transaction sent until the
+// gas limit is reset to zero. This claims the lock and must not be called while the
+// lock is already held.
+func (a *Authorizer) EstimateAndSetGas(ctx context.Context, bc Blockchain, msg ethereum.CallMsg, bufferPercent int) error {
+	if bufferPercent <= 0 {
+		bufferPercent = DefaultGasBufferPercent
+	}
+	gas, err := bc.EstimateGas(ctx, msg)
+	if err != nil {
+		if reason, ok := revertReason(err); ok {
+			return errors.Errorf("estimate gas: execution reverted: %s", reason)
+		}
+		return errors.Wrap(err, "estimate gas")
+	}
+	if err := a.LockContext(ctx); err != nil {
+		return err
+	}
+	defer a.Unlock()
+	a.GasLimit = gas * uint64(100+bufferPercent) / 100
+	return nil
+}
+
+// scaleGas applies GasLimitMultiplier to an estimated gas limit, and expects the
+// caller to hold the lock
+func (a *Authorizer) scaleGas(gas uint64) uint64 {
+	if a.GasLimitMultiplier <= 1 {
+		return gas
+	}
+	return uint64(float64(gas) * a.GasLimitMultiplier)
+}
+
+// withGas returns a copy of the unsigned transaction tx using the given gas limit
+func withGas(tx *types.Transaction, gas uint64) *types.Transaction {
+	switch tx.Type() {
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID: tx.ChainId(), Nonce: tx.Nonce(), GasPrice: tx.GasPrice(), Gas: gas,
+			To: tx.To(), Value: tx.Value(), Data: tx.Data(), AccessList: tx.AccessList(),
+		})
+	case types.DynamicFeeTxType:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID: tx.ChainId(), Nonce: tx.Nonce(), GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap(), Gas: gas,
+			To: tx.To(), Value: tx.Value(), Data: tx.Data(), AccessList: tx.AccessList(),
+		})
+	default:
+		return types.NewTx(&types.LegacyTx{
+			Nonce: tx.Nonce(), GasPrice: tx.GasPrice(), Gas: gas, To: tx.To(), Value: tx.Value(), Data: tx.Data(),
+		})
+	}
+}
+
+// AutoGas configures the authorizer for the fee model of the chain, using EIP-1559 fee caps when
+// the latest block has a base fee and a legacy gas price otherwise, as on chains which don't
+// support type-2 transactions. The prices are those suggested by the chain, with the fee cap
+// leaving room for the base fee to double as the bindings do. A backend found not to support
+// EIP-1559 is remembered by the authorizer, so calling this again with it only refreshes the gas
+// price until ResetAutoGas is called or another backend is used. Clones don't inherit it. This
+// claims the lock and must not be called while the lock is already held.
+func (a *Authorizer) AutoGas(ctx context.Context, bc Blockchain) error {
+	if err := a.LockContext(ctx); err != nil {
+		return err
+	}
+	dynamic := !a.isLegacyBackend(bc)
+	a.Unlock()
+	var baseFee *big.Int
+	if dynamic {
+		head, err := bc.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return errors.Wrap(err, "header by number")
+		}
+		baseFee, dynamic = head.BaseFee, head.BaseFee != nil
+	}
+	if !dynamic {
+		gasPrice, err := bc.SuggestGasPrice(ctx)
+		if err != nil {
+			return errors.Wrap(err, "suggest gas price")
+		}
+		if err := a.LockContext(ctx); err != nil {
+			return err
+		}
+		defer a.Unlock()
+		a.GasFeeCap, a.GasTipCap, a.GasPrice = nil, nil, gasPrice
+		if reflect.TypeOf(bc).Comparable() {
+			a.legacyBackend = bc
+		}
+		return nil
+	}
+	tip, err := bc.SuggestGasTipCap(ctx)
+	if err != nil {
+		return errors.Wrap(err, "suggest gas tip cap")
+	}
+	if err := a.LockContext(ctx); err != nil {
+		return err
+	}
+	defer a.Unlock()
+	a.GasPrice = nil
+	a.GasTipCap = tip
+	a.GasFeeCap = new(big.Int).Add(tip, new(big.Int).Mul(baseFee, big.NewInt(2)))
+	a.legacyBackend = nil
+	return nil
+}
+
+// ResetAutoGas forgets the backend AutoGas found not to support EIP-1559, so that the next call
+// probes the chain again, such as after the backend reconnected to another node. This claims the
+// lock and must not be called while the lock is already held.
+func (a *Authorizer) ResetAutoGas() {
+	a.Lock()
+	defer a.Unlock()
+	a.legacyBackend = nil
+}
+
+// isLegacyBackend returns true if AutoGas found bc not to support EIP-1559, and expects the caller
+// to hold the lock. Backends which can't be compared, such as those of non-pointer struct types with
+// slice fields, are never remembered
+func (a *Authorizer) isLegacyBackend(bc Blockchain) bool {
+	return a.legacyBackend != nil && reflect.TypeOf(bc).Comparable() && a.legacyBackend == bc
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/pkg/errors"
+)
+
+// Strategy determines how aggressively the GasOracle prices transactions
+type Strategy int
+
+const (
+	// Slow prices transactions to be included when blocks are less congested
+	Slow Strategy = iota
+	// Standard prices transactions to be included within a few blocks
+	Standard
+	// Fast prices transactions to be included in the next block
+	Fast
+)
+
+// String returns the name of the strategy
+func (s Strategy) String() string {
+	switch s {
+	case Slow:
+		return "slow"
+	case Standard:
+		return "standard"
+	case Fast:
+		return "fast"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	// strategyPercentiles are the priority fee percentiles sampled for each strategy
+	strategyPercentiles = []float64{10, 50, 90}
+	// strategyLegacyPercent scales the node suggested gas price on chains without EIP-1559
+	strategyLegacyPercent = []int64{90, 100, 125}
+)
+
+// feeHistoryBlocks is the number of recent blocks sampled with eth_feeHistory
+const feeHistoryBlocks = 20
+
+// FeeHistoryReader is implemented by clients supporting eth_feeHistory such as ethclient.Client
+type FeeHistoryReader interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// GasEstimate is a suggested gas pricing for a transaction, where MaxFee and
+// MaxPriorityFee are nil on chains which don't support EIP-1559
+type GasEstimate struct {
+	GasPrice       *big.Int
+	MaxFee         *big.Int
+	MaxPriorityFee *big.Int
+}
+
+// GasOracle suggests gas prices based on recent network conditions
+type GasOracle struct {
+	bc Blockchain
+}
+
+// NewGasOracle returns a gas oracle. When bc implements FeeHistoryReader priority fees are
+// derived from the fees paid in recent blocks, otherwise the node suggested tip is used
+func NewGasOracle(bc Blockchain) *GasOracle {
+	return &GasOracle{bc: bc}
+}
+
+// Suggest returns the suggested gas pricing for the given strategy. The EIP-1559 max fee is
+// twice the base fee of the next block plus the priority fee, allowing the transaction to remain
+// valid through several full blocks, while the legacy gas price is the next base fee plus the
+// priority fee. On chains without EIP-1559 the node suggested gas price is scaled by strategy
+func (o *GasOracle) Suggest(ctx context.Context, strategy Strategy) (*GasEstimate, error) {
+	if strategy < Slow || strategy > Fast {
+		return nil, errors.Errorf("unknown strategy %d", strategy)
+	}
+	head, err := o.bc.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "header by number")
+	}
+	if head.BaseFee == nil {
+		gasPrice, err := o.bc.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "suggest gas price")
+		}
+		gasPrice.Mul(gasPrice, big.NewInt(strategyLegacyPercent[strategy]))
+		gasPrice.Div(gasPrice, big.NewInt(100))
+		return &GasEstimate{GasPrice: gasPrice}, nil
+	}
+	baseFee, tip, err := o.feeHistory(ctx, strategy)
+	if err != nil {
+		return nil, err
+	}
+	if baseFee == nil {
+		baseFee = head.BaseFee
+	}
+	if tip == nil {
+		if tip, err = o.bc.SuggestGasTipCap(ctx); err != nil {
+			return nil, errors.Wrap(err, "suggest gas tip cap")
+		}
+	}
+	maxFee := new(big.Int).Mul(baseFee, big.NewInt(2))
+	maxFee.Add(maxFee, tip)
+	return &GasEstimate{
+		GasPrice:       new(big.Int).Add(baseFee, tip),
+		MaxFee:         maxFee,
+		MaxPriorityFee: tip,
+	}, nil
+}
+
+// ApplyTo configures auth to use the estimated gas pricing, sending EIP-1559 transactions
+// when the estimate includes fee caps. This claims the lock and must not be called while
+// the lock is already held.
+func (o *GasOracle) ApplyTo(auth *Authorizer, est *GasEstimate) {
+	if est.MaxFee != nil && est.MaxPriorityFee != nil {
+		auth.SetDynamicFees(est.MaxFee, est.MaxPriorityFee)
+		return
+	}
+	auth.UseLegacyGas(est.GasPrice)
+}
+
+// feeHistory samples recent blocks returning the base fee of the next block and the median of
+// the priority fees paid at the strategy percentile. Either is nil if it couldn't be determined
+func (o *GasOracle) feeHistory(ctx context.Context, strategy Strategy) (*big.Int, *big.Int, error) {
+	reader, ok := o.bc.(FeeHistoryReader)
+	if !ok {
+		return nil, nil, nil
+	}
+	history, err := reader.FeeHistory(ctx, feeHistoryBlocks, nil, strategyPercentiles)
+	if errors.Is(err, ErrBackendUnsupported) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fee history")
+	}
+	var baseFee *big.Int
+	// the base fees include that of the block following the newest sampled block
+	if len(history.BaseFee) > 0 {
+		baseFee = history.BaseFee[len(history.BaseFee)-1]
+	}
+	var tips []*big.Int
+	for _, rewards := range history.Reward {
+		// empty blocks report zero rewards which would drag the median down
+		if int(strategy) < len(rewards) && rewards[strategy].Sign() > 0 {
+			tips = append(tips, rewards[strategy])
+		}
+	}
+	if len(tips) == 0 {
+		return baseFee, nil, nil
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	return baseFee, new(big.Int).Set(tips[len(tips)/2]), nil
+}
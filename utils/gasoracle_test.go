@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// feeHistoryBackend adds canned eth_feeHistory responses to the simulated backend
+type feeHistoryBackend struct {
+	simulatedBackend
+	history *ethereum.FeeHistory
+}
+
+func (f feeHistoryBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return f.history, nil
+}
+
+func TestGasOracle(t *testing.T) {
+	sim := newSimulatedBackend(t)
+	ctx := context.Background()
+	head, err := sim.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	require.NotNil(t, head.BaseFee)
+
+	// without fee history the node suggested tip is used
+	est, err := NewGasOracle(sim).Suggest(ctx, Standard)
+	require.NoError(t, err)
+	tip, err := sim.SuggestGasTipCap(ctx)
+	require.NoError(t, err)
+	require.Equal(t, tip, est.MaxPriorityFee)
+	require.Equal(t, new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip), est.MaxFee)
+	require.Equal(t, new(big.Int).Add(head.BaseFee, tip), est.GasPrice)
+
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9)) }
+	oracle := NewGasOracle(feeHistoryBackend{simulatedBackend: sim, history: &ethereum.FeeHistory{
+		BaseFee: []*big.Int{gwei(90), gwei(95), gwei(100)},
+		Reward: [][]*big.Int{
+			{gwei(1), gwei(2), gwei(5)},
+			// empty blocks are ignored
+			{big.NewInt(0), big.NewInt(0), big.NewInt(0)},
+			{gwei(1), gwei(3), gwei(10)},
+		},
+	}})
+	tests := []struct {
+		strategy Strategy
+		tip      *big.Int
+	}{
+		{Slow, gwei(1)},
+		{Standard, gwei(3)},
+		{Fast, gwei(10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			est, err := oracle.Suggest(ctx, tt.strategy)
+			require.NoError(t, err)
+			require.Equal(t, tt.tip, est.MaxPriorityFee)
+			require.Equal(t, new(big.Int).Add(gwei(200), tt.tip), est.MaxFee)
+			require.Equal(t, new(big.Int).Add(gwei(100), tt.tip), est.GasPrice)
+		})
+	}
+	_, err = oracle.Suggest(ctx, Strategy(5))
+	require.Error(t, err)
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	oracle.ApplyTo(auth, est)
+	require.True(t, auth.IsDynamicFee())
+	oracle.ApplyTo(auth, &GasEstimate{GasPrice: gwei(50)})
+	require.False(t, auth.IsDynamicFee())
+	require.Equal(t, gwei(50), auth.GasPrice)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// gasRefresh is a running gas refresh loop of an authorizer
+type gasRefresh struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stop cancels the loop and waits for it to return
+func (r *gasRefresh) stop() {
+	if r == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// StartGasRefresh keeps the gas settings of the authorizer up to date with the market, applying the
+// pricing suggested by oracle for strategy every interval until ctx is cancelled or StopGasRefresh is
+// called. Any refresh already running is replaced, and the authorizer is priced before returning.
+// Failed refreshes, including the first, are logged and keep the previous settings, which remain
+// subject to MaxGasPriceWei, and are retried at the next interval. Each refresh claims the lock, so
+// this must not be called while the lock is already held, and gas settings set manually are
+// overwritten by the next refresh. Clones of the authorizer aren't refreshed. This panics if
+// interval isn't positive
+func (a *Authorizer) StartGasRefresh(ctx context.Context, oracle *GasOracle, interval time.Duration, strategy Strategy) {
+	if interval <= 0 {
+		panic("utils: gas refresh interval must be positive")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	refresh := &gasRefresh{cancel: cancel, done: make(chan struct{})}
+	a.Lock()
+	previous := a.gasRefresh
+	a.gasRefresh = refresh
+	a.Unlock()
+	// the previous loop is stopped before pricing so it can't overwrite the new settings
+	previous.stop()
+	a.applyGas(ctx, oracle, strategy)
+	go a.refreshGas(ctx, refresh, oracle, interval, strategy)
+}
+
+// StopGasRefresh stops the refresh started by StartGasRefresh, waiting for any refresh in progress
+// to complete, and does nothing if none is running. The gas settings last applied are kept. This
+// claims the lock and must not be called while the lock is already held.
+func (a *Authorizer) StopGasRefresh() {
+	a.Lock()
+	refresh := a.gasRefresh
+	a.gasRefresh = nil
+	a.Unlock()
+	refresh.stop()
+}
+
+// refreshGas applies the pricing suggested by oracle every interval until ctx is done
+func (a *Authorizer) refreshGas(ctx context.Context, refresh *gasRefresh, oracle *GasOracle, interval time.Duration, strategy Strategy) {
+	defer close(refresh.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		a.applyGas(ctx, oracle, strategy)
+	}
+}
+
+// applyGas applies the pricing suggested by oracle for strategy, logging failures unless ctx is done
+func (a *Authorizer) applyGas(ctx context.Context, oracle *GasOracle, strategy Strategy) {
+	est, err := oracle.Suggest(ctx, strategy)
+	if err != nil {
+		if ctx.Err() == nil {
+			zap.L().Warn("gas refresh failed", zap.String("from", a.From.Hex()), zap.Error(err))
+		}
+		return
+	}
+	oracle.ApplyTo(a, est)
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// risingTipBackend answers eth_feeHistory with a priority fee of one more gwei on every call, failing
+// to return the head for as many calls as failures
+type risingTipBackend struct {
+	simulatedBackend
+	calls    int64
+	failures int64
+}
+
+func (b *risingTipBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if atomic.AddInt64(&b.failures, -1) >= 0 {
+		return nil, errors.New("connection refused")
+	}
+	return b.simulatedBackend.HeaderByNumber(ctx, number)
+}
+
+func (b *risingTipBackend) FeeHistory(context.Context, uint64, *big.Int, []float64) (*ethereum.FeeHistory, error) {
+	tip := new(big.Int).Mul(big.NewInt(atomic.AddInt64(&b.calls, 1)), big.NewInt(1e9))
+	return &ethereum.FeeHistory{
+		BaseFee: []*big.Int{big.NewInt(1e9)},
+		Reward:  [][]*big.Int{{tip, tip, tip}},
+	}, nil
+}
+
+func TestGasRefresh(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	backend := &risingTipBackend{simulatedBackend: newSimulatedBackend(t)}
+	oracle := NewGasOracle(backend)
+	tipCap := func() *big.Int {
+		auth.Lock()
+		defer auth.Unlock()
+		return auth.GasTipCap
+	}
+
+	require.Panics(t, func() { auth.StartGasRefresh(ctx, oracle, 0, Standard) })
+	// failing refreshes keep the settings as they are
+	auth.StartGasRefresh(ctx, oracle, time.Millisecond, Strategy(5))
+	time.Sleep(20 * time.Millisecond)
+	require.Nil(t, tipCap())
+	auth.StopGasRefresh()
+	// stopping without a refresh running does nothing
+	auth.StopGasRefresh()
+
+	// the authorizer is priced before returning
+	auth.StartGasRefresh(ctx, oracle, 5*time.Millisecond, Fast)
+	require.True(t, auth.IsDynamicFee())
+	require.Equal(t, big.NewInt(1e9), tipCap())
+	require.Eventually(t, func() bool { return tipCap().Cmp(big.NewInt(3e9)) >= 0 }, time.Second, time.Millisecond)
+
+	// restarting replaces the running refresh
+	auth.StartGasRefresh(ctx, oracle, time.Hour, Fast)
+	tip := tipCap()
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, tip, tipCap())
+	auth.StopGasRefresh()
+
+	// cancelling the context stops the refresh as well
+	cctx, cancel := context.WithCancel(ctx)
+	auth.StartGasRefresh(cctx, oracle, time.Millisecond, Fast)
+	cancel()
+	require.Eventually(t, func() bool {
+		calls := atomic.LoadInt64(&backend.calls)
+		time.Sleep(20 * time.Millisecond)
+		return atomic.LoadInt64(&backend.calls) == calls
+	}, time.Second, time.Millisecond)
+	auth.StopGasRefresh()
+	calls := atomic.LoadInt64(&backend.calls)
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, calls, atomic.LoadInt64(&backend.calls))
+
+	// a failed first pricing is retried at the next interval
+	auth, err = NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	atomic.StoreInt64(&backend.failures, 1)
+	auth.StartGasRefresh(ctx, oracle, 5*time.Millisecond, Fast)
+	require.Nil(t, tipCap())
+	require.Eventually(t, func() bool { return tipCap() != nil }, time.Second, time.Millisecond)
+	auth.StopGasRefresh()
+}
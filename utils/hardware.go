@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// NewLedgerAuthorizer returns an authorizer which delegates transaction signing to a
+// hardware wallet such as a Ledger device, signing transactions for the given chain id. The
+// hub is typically the *usbwallet.Hub returned by usbwallet.NewLedgerHub, and account must
+// have been derived on one of its wallets. As signing requires confirmation on the device,
+// calls to the Signer function block until the user approves or rejects the transaction.
+// Because the Authorizer lock is held while signing, callers should use longer timeouts when
+// IsHardware returns true. ErrNilChainID is returned if chainID is nil
+func NewLedgerAuthorizer(hub accounts.Backend, account accounts.Account, chainID *big.Int) (*Authorizer, error) {
+	if chainID == nil {
+		return nil, ErrNilChainID
+	}
+	var wallet accounts.Wallet
+	for _, w := range hub.Wallets() {
+		if w.Contains(account) {
+			wallet = w
+			break
+		}
+	}
+	if wallet == nil {
+		return nil, ErrAccountNotFound
+	}
+	if err := wallet.Open(""); err != nil && err != accounts.ErrWalletAlreadyOpen {
+		return nil, errors.Wrap(err, "open wallet")
+	}
+	auth, err := NewAuthorizerFromWallet(wallet, account, chainID)
+	if err != nil {
+		return nil, err
+	}
+	auth.hardware = true
+	return auth, nil
+}
+
+// NewAuthorizerFromWallet returns an authorizer which delegates transaction signing to account of
+// wallet, which may come from any backend of an accounts.Manager such as a keystore, a USB hardware
+// wallet or a smartcard. Transactions are signed for the given chain id, and the wallet must be
+// opened and unlocked as its backend requires beforehand. Wallets of backends other than the
+// keystore are taken to require confirmation on the device, see IsHardware. ErrAccountNotFound is
+// returned if account isn't contained in wallet, and ErrNilChainID if chainID is nil
+func NewAuthorizerFromWallet(wallet accounts.Wallet, account accounts.Account, chainID *big.Int) (*Authorizer, error) {
+	if chainID == nil {
+		return nil, ErrNilChainID
+	}
+	if !wallet.Contains(account) {
+		return nil, ErrAccountNotFound
+	}
+	return &Authorizer{
+		hardware: wallet.URL().Scheme != keystore.KeyStoreScheme,
+		TransactOpts: &bind.TransactOpts{
+			From: account.Address,
+			Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+				if address != account.Address {
+					return nil, bind.ErrNotAuthorized
+				}
+				return wallet.SignTx(account, tx, chainID)
+			},
+			Context: context.Background(),
+		},
+	}, nil
+}
+
+// IsHardware returns true if transaction signing is delegated to a hardware wallet,
+// in which case signing blocks on user confirmation from the device
+func (a *Authorizer) IsHardware() bool {
+	return a.hardware
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuthorizerFromWallet(t *testing.T) {
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("password123")
+	require.NoError(t, err)
+	require.NoError(t, ks.Unlock(account, "password123"))
+	wallets := ks.Wallets()
+	require.Len(t, wallets, 1)
+
+	chainID := big.NewInt(1337)
+	auth, err := NewAuthorizerFromWallet(wallets[0], account, chainID)
+	require.NoError(t, err)
+	require.Equal(t, account.Address, auth.From)
+	require.False(t, auth.IsHardware())
+
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	for _, tx := range []*types.Transaction{
+		types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, To: &to, Gas: 21000, GasFeeCap: big.NewInt(2), GasTipCap: big.NewInt(1)}),
+	} {
+		signed, err := auth.Signer(auth.From, tx)
+		require.NoError(t, err)
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		require.NoError(t, err)
+		require.Equal(t, account.Address, sender)
+		require.Equal(t, chainID, signed.ChainId())
+	}
+	_, err = auth.Signer(to, types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil))
+	require.Equal(t, bind.ErrNotAuthorized, err)
+
+	_, err = NewAuthorizerFromWallet(wallets[0], accounts.Account{Address: to}, chainID)
+	require.Equal(t, ErrAccountNotFound, err)
+	_, err = NewAuthorizerFromWallet(wallets[0], account, nil)
+	require.Equal(t, ErrNilChainID, err)
+}
+
+func TestNewLedgerAuthorizer(t *testing.T) {
+	// the keystore stands in for the hub of a hardware wallet
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("password123")
+	require.NoError(t, err)
+	require.NoError(t, ks.Unlock(account, "password123"))
+
+	chainID := big.NewInt(1337)
+	auth, err := NewLedgerAuthorizer(ks, account, chainID)
+	require.NoError(t, err)
+	require.True(t, auth.IsHardware())
+	require.NotNil(t, auth.Context)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	// legacy transactions are replay protected
+	signed, err := auth.Signer(auth.From, types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil))
+	require.NoError(t, err)
+	require.True(t, signed.Protected())
+	require.Equal(t, chainID, signed.ChainId())
+
+	_, err = NewLedgerAuthorizer(ks, accounts.Account{Address: to}, chainID)
+	require.Equal(t, ErrAccountNotFound, err)
+	_, err = NewLedgerAuthorizer(ks, account, nil)
+	require.Equal(t, ErrNilChainID, err)
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// HeadTracker follows the head of the chain in the background, allowing helpers needing the current
+// head to share a single subscription rather than each polling the node. New heads are subscribed
+// to, falling back to polling the block number when the backend doesn't support subscriptions, such
+// as over http, or once the subscription fails. It is safe for concurrent use
+type HeadTracker struct {
+	bc       Blockchain
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mx     sync.RWMutex
+	latest *types.Header
+	// updated is closed and replaced whenever a new head is seen or the tracker stops
+	updated chan struct{}
+	err     error
+}
+
+// NewHeadTracker fetches the latest header and starts tracking new heads until ctx is cancelled
+// or Close is called. When polling, the block number is checked every DefaultPollInterval unless
+// changed with WithPollInterval, while other wait options are ignored
+func NewHeadTracker(ctx context.Context, bc Blockchain, opts ...WaitOption) (*HeadTracker, error) {
+	head, err := bc.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "header by number")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	ht := &HeadTracker{
+		bc:       bc,
+		interval: newWaitOptions(opts).PollInterval,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		latest:   head,
+		updated:  make(chan struct{}),
+	}
+	go ht.run(ctx)
+	return ht, nil
+}
+
+// Latest returns the most recent head seen, which is replaced by the new head after a reorg
+func (ht *HeadTracker) Latest() *types.Header {
+	ht.mx.RLock()
+	defer ht.mx.RUnlock()
+	return ht.latest
+}
+
+// Wait blocks until a head with at least block number n has been seen, returning immediately if
+// it already was. An error is returned if ctx is cancelled first, or once the tracker stops
+func (ht *HeadTracker) Wait(ctx context.Context, n uint64) error {
+	for {
+		ht.mx.RLock()
+		latest, updated, err := ht.latest, ht.updated, ht.err
+		ht.mx.RUnlock()
+		if latest.Number.Uint64() >= n {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close stops tracking new heads, failing any calls to Wait still blocked
+func (ht *HeadTracker) Close() {
+	ht.cancel()
+	<-ht.done
+}
+
+// run tracks heads until ctx is cancelled, subscribing if possible and polling otherwise
+func (ht *HeadTracker) run(ctx context.Context) {
+	defer close(ht.done)
+	if err := ht.subscribe(ctx); err != nil {
+		err = ht.poll(ctx)
+		ht.stop(err)
+		return
+	}
+	ht.stop(ctx.Err())
+}
+
+// subscribe records heads from a subscription until ctx is cancelled, returning an error if the
+// subscription couldn't be created or failed
+func (ht *HeadTracker) subscribe(ctx context.Context) error {
+	heads := make(chan *types.Header, 16)
+	sub, err := ht.bc.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return err
+		case head := <-heads:
+			ht.set(head)
+		}
+	}
+}
+
+// poll records the latest header whenever the block number changes until ctx is cancelled
+func (ht *HeadTracker) poll(ctx context.Context) error {
+	for {
+		if err := sleep(ctx, ht.interval); err != nil {
+			return err
+		}
+		number, err := ht.bc.BlockNumber(ctx)
+		if err != nil {
+			// transient failures are retried on the next poll
+			continue
+		}
+		if number == ht.Latest().Number.Uint64() {
+			continue
+		}
+		head, err := ht.bc.HeaderByNumber(ctx, nil)
+		if err != nil {
+			continue
+		}
+		ht.set(head)
+	}
+}
+
+// set records head as the latest and wakes any waiters
+func (ht *HeadTracker) set(head *types.Header) {
+	ht.mx.Lock()
+	defer ht.mx.Unlock()
+	ht.latest = head
+	close(ht.updated)
+	ht.updated = make(chan struct{})
+}
+
+// stop records the error returned by Wait once the tracker stopped and wakes any waiters
+func (ht *HeadTracker) stop(err error) {
+	ht.mx.Lock()
+	defer ht.mx.Unlock()
+	ht.err = errors.Wrap(err, "head tracker stopped")
+	close(ht.updated)
+	ht.updated = make(chan struct{})
+}
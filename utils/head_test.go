@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// pollingBackend doesn't support subscriptions, as with http endpoints
+type pollingBackend struct {
+	simulatedBackend
+}
+
+func (pollingBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, rpc.ErrNotificationsUnsupported
+}
+
+func TestHeadTracker(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend func(simulatedBackend) Blockchain
+	}{
+		{"subscription", func(sim simulatedBackend) Blockchain { return sim }},
+		{"polling", func(sim simulatedBackend) Blockchain { return pollingBackend{sim} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sim := newSimulatedBackend(t)
+			ht, err := NewHeadTracker(ctx, tt.backend(sim), WithPollInterval(time.Millisecond*10))
+			require.NoError(t, err)
+			require.Equal(t, uint64(0), ht.Latest().Number.Uint64())
+			require.NoError(t, ht.Wait(ctx, 0))
+
+			waited := make(chan error, 1)
+			go func() { waited <- ht.Wait(ctx, 2) }()
+			sim.Commit()
+			sim.Commit()
+			select {
+			case err := <-waited:
+				require.NoError(t, err)
+			case <-time.After(time.Second * 5):
+				t.Fatal("head not seen")
+			}
+			require.Equal(t, uint64(2), ht.Latest().Number.Uint64())
+
+			timeout, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+			defer cancel()
+			require.Equal(t, context.DeadlineExceeded, ht.Wait(timeout, 3))
+
+			// waiters are released once the tracker stops
+			go func() { waited <- ht.Wait(ctx, 3) }()
+			ht.Close()
+			require.Error(t, <-waited)
+			require.Error(t, ht.Wait(ctx, 3))
+		})
+	}
+}
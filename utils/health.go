@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// HealthStatus reports the state of a node as seen by HealthCheck
+type HealthStatus struct {
+	ChainID     *big.Int
+	BlockNumber uint64
+	// Syncing is true while the node is catching up with the chain, in which case
+	// SyncProgress holds the progress reported by eth_syncing
+	Syncing      bool
+	SyncProgress *ethereum.SyncProgress
+	// PeerCount is nil when the node doesn't expose the net namespace, as is common for providers
+	PeerCount *uint64
+}
+
+// HealthCheck is a preflight check of the node behind client, returning its chain id, latest block,
+// syncing status and peer count. An error wrapping ErrChainIDMismatch is returned if the node is on
+// a chain other than expectedChainID, catching misconfigured endpoints before sending transactions.
+// A nil expectedChainID skips the check. A node which is still syncing doesn't cause an error,
+// leaving it to the caller to decide whether it may be used
+func HealthCheck(ctx context.Context, client *rpc.Client, expectedChainID *big.Int) (*HealthStatus, error) {
+	ec := ethclient.NewClient(client)
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "chain id")
+	}
+	if expectedChainID != nil && chainID.Cmp(expectedChainID) != 0 {
+		return nil, errors.Wrapf(ErrChainIDMismatch, "node is on chain %s, expected %s", chainID, expectedChainID)
+	}
+	status := &HealthStatus{ChainID: chainID}
+	if status.BlockNumber, err = ec.BlockNumber(ctx); err != nil {
+		return nil, errors.Wrap(err, "block number")
+	}
+	if status.SyncProgress, err = ec.SyncProgress(ctx); err != nil {
+		return nil, errors.Wrap(err, "syncing")
+	}
+	status.Syncing = status.SyncProgress != nil
+	var peers hexutil.Uint64
+	if err := client.CallContext(ctx, &peers, "net_peerCount"); err == nil {
+		count := uint64(peers)
+		status.PeerCount = &count
+	} else if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return status, nil
+}
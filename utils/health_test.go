@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// healthService serves the eth methods used by HealthCheck
+type healthService struct {
+	syncing bool
+}
+
+func (s *healthService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(137))
+}
+
+func (s *healthService) BlockNumber() hexutil.Uint64 {
+	return 100
+}
+
+func (s *healthService) Syncing() interface{} {
+	if !s.syncing {
+		return false
+	}
+	return map[string]hexutil.Uint64{"startingBlock": 0, "currentBlock": 100, "highestBlock": 200}
+}
+
+// peerService serves net_peerCount
+type peerService struct{}
+
+func (peerService) PeerCount() hexutil.Uint64 {
+	return 25
+}
+
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		syncing bool
+		peers   bool
+	}{
+		{"synced", false, true},
+		{"syncing", true, true},
+		{"without net namespace", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			require.NoError(t, server.RegisterName("eth", &healthService{syncing: tt.syncing}))
+			if tt.peers {
+				require.NoError(t, server.RegisterName("net", peerService{}))
+			}
+			t.Cleanup(server.Stop)
+			client := rpc.DialInProc(server)
+			t.Cleanup(client.Close)
+
+			status, err := HealthCheck(ctx, client, big.NewInt(137))
+			require.NoError(t, err)
+			require.Equal(t, "137", status.ChainID.String())
+			require.Equal(t, uint64(100), status.BlockNumber)
+			require.Equal(t, tt.syncing, status.Syncing)
+			if tt.syncing {
+				require.Equal(t, uint64(200), status.SyncProgress.HighestBlock)
+			} else {
+				require.Nil(t, status.SyncProgress)
+			}
+			if tt.peers {
+				require.Equal(t, uint64(25), *status.PeerCount)
+			} else {
+				require.Nil(t, status.PeerCount)
+			}
+
+			// a nil chain id skips the check
+			_, err = HealthCheck(ctx, client, nil)
+			require.NoError(t, err)
+			_, err = HealthCheck(ctx, client, MainnetChainID())
+			require.True(t, errors.Is(err, ErrChainIDMismatch))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// Hooks are optional callbacks invoked during the lifecycle of a transaction, allowing metrics
+// and tracing to be plugged in. Any of the functions may be left nil. Hooks set on an Authorizer
+// are invoked when it signs and sends transactions, while the wait helpers invoke hooks passed
+// with WithHooks. The callbacks are invoked synchronously, in some cases while holding the
+// authorizer lock, so they should return quickly and must not use the authorizer
+type Hooks struct {
+	// OnSign is called with each transaction signed
+	OnSign func(tx *types.Transaction)
+	// OnSend is called with each transaction after it was broadcast
+	OnSend func(tx *types.Transaction)
+	// OnMined is called with the receipt of each transaction waited on once it was mined
+	OnMined func(receipt *types.Receipt)
+}
+
+// signed invokes OnSign if set
+func (h *Hooks) signed(tx *types.Transaction) {
+	if h != nil && h.OnSign != nil {
+		h.OnSign(tx)
+	}
+}
+
+// sent invokes OnSend if set
+func (h *Hooks) sent(tx *types.Transaction) {
+	if h != nil && h.OnSend != nil {
+		h.OnSend(tx)
+	}
+}
+
+// mined invokes OnMined if set
+func (h *Hooks) mined(receipt *types.Receipt) {
+	if h != nil && h.OnMined != nil {
+		h.OnMined(receipt)
+	}
+}
+
+// signTx signs tx invoking the OnSign hook, and expects the caller to hold the lock
+func (a *Authorizer) signTx(tx *types.Transaction) (*types.Transaction, error) {
+	if a.DryRun && a.impersonated != nil {
+		return nil, errors.New("dry run is not supported by impersonated authorizers")
+	}
+	if err := a.checkGasPrice(tx); err != nil {
+		return nil, err
+	}
+	signed, err := a.Signer(a.From, tx)
+	if err != nil {
+		return nil, err
+	}
+	a.Hooks.signed(signed)
+	if a.DryRun {
+		markDryRun(a.From, signed)
+	}
+	return signed, nil
+}
+
+// wrapSigner wraps the signer for transactions signed through contract bindings, scaling their
+// estimated gas limit by GasLimitMultiplier and invoking the OnSign hook, returning a function
+// restoring the signer. Transactions above MaxGasPriceWei fail before being signed, and in dry
+// run mode signing fails with errDryRun once the transaction is signed, stopping the bindings
+// from broadcasting it. This expects the caller to hold the lock
+func (a *Authorizer) wrapSigner() (restore func()) {
+	if (a.Hooks == nil || a.Hooks.OnSign == nil) && a.GasLimitMultiplier <= 1 && !a.DryRun && a.MaxGasPriceWei == nil && a.TxType == TxTypeAuto {
+		return func() {}
+	}
+	prev := a.Signer
+	a.Signer = func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if a.DryRun && a.impersonated != nil {
+			return nil, errors.New("dry run is not supported by impersonated authorizers")
+		}
+		// bindings only estimate the gas limit when none is set
+		if a.GasLimit == 0 {
+			if gas := a.scaleGas(tx.Gas()); gas != tx.Gas() {
+				tx = withGas(tx, gas)
+			}
+		}
+		if err := a.checkGasPrice(tx); err != nil {
+			return nil, err
+		}
+		if err := a.checkBoundTxType(tx); err != nil {
+			return nil, err
+		}
+		signed, err := prev(from, tx)
+		if err != nil {
+			return nil, err
+		}
+		a.Hooks.signed(signed)
+		if a.DryRun {
+			markDryRun(from, signed)
+			a.dryRunSigned = signed
+			return nil, errDryRun
+		}
+		return signed, nil
+	}
+	return func() { a.Signer = prev }
+}
+
+// checkGasPrice returns an error wrapping ErrGasPriceTooHigh if the gas price of a legacy tx, or
+// the max fee per gas of a dynamic fee tx, exceeds MaxGasPriceWei. This expects the caller to hold
+// the lock
+func (a *Authorizer) checkGasPrice(tx *types.Transaction) error {
+	// the fee cap of legacy and access list transactions is their gas price
+	if a.MaxGasPriceWei != nil && tx.GasFeeCap().Cmp(a.MaxGasPriceWei) > 0 {
+		return errors.Wrapf(ErrGasPriceTooHigh, "%s wei exceeds the cap of %s wei", tx.GasFeeCap(), a.MaxGasPriceWei)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHooks returns hooks recording the hashes of the transactions passed to them
+func recordingHooks() (*Hooks, *[]common.Hash, *[]common.Hash, *[]common.Hash) {
+	var signed, sent, mined []common.Hash
+	return &Hooks{
+		OnSign:  func(tx *types.Transaction) { signed = append(signed, tx.Hash()) },
+		OnSend:  func(tx *types.Transaction) { sent = append(sent, tx.Hash()) },
+		OnMined: func(receipt *types.Receipt) { mined = append(mined, receipt.TxHash) },
+	}, &signed, &sent, &mined
+}
+
+func TestHooks(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	address := deployCode(t, sim, auth, answerCode)
+	contract, err := NewContractFromJSON(address, answerABI, sim)
+	require.NoError(t, err)
+
+	// unset hooks are skipped
+	_, err = contract.Transact(ctx, auth, "setAnswer", big.NewInt(1))
+	require.NoError(t, err)
+	auth.Hooks = &Hooks{}
+	_, err = contract.Transact(ctx, auth, "setAnswer", big.NewInt(2))
+	require.NoError(t, err)
+	sim.Commit()
+
+	hooks, signed, sent, mined := recordingHooks()
+	auth.Hooks = hooks
+	tx, err := contract.Transact(ctx, auth, "setAnswer", big.NewInt(3))
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{tx.Hash()}, *signed)
+	require.Equal(t, []common.Hash{tx.Hash()}, *sent)
+	sim.Commit()
+	_, err = WaitConfirmations(ctx, sim, tx.Hash(), 0, WithPollInterval(time.Millisecond*10), WithHooks(auth.Hooks))
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{tx.Hash()}, *mined)
+
+	built, err := auth.BuildTx(ctx, sim, auth.From, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, built.Hash(), (*signed)[1])
+	require.Len(t, *sent, 1)
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		sim.Commit()
+	}()
+	_, err = SendAndWait(ctx, sim, built, WithPollInterval(time.Millisecond*10), WithHooks(auth.Hooks))
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{tx.Hash(), built.Hash()}, *sent)
+	require.Equal(t, []common.Hash{tx.Hash(), built.Hash()}, *mined)
+
+	// the signer isn't left wrapped once done
+	auth.Hooks = nil
+	_, err = contract.Transact(ctx, auth, "setAnswer", big.NewInt(4))
+	require.NoError(t, err)
+	require.Len(t, *signed, 2)
+}
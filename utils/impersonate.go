@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// impersonationMethods are the methods of development nodes for impersonating accounts, in order of preference
+var impersonationMethods = []string{"anvil_impersonateAccount", "hardhat_impersonateAccount"}
+
+// impersonatedSigner is a Signer submitting transactions with eth_sendTransaction, leaving the
+// node to sign them on behalf of an impersonated account
+type impersonatedSigner struct {
+	client  *rpc.Client
+	address common.Address
+	auth    *Authorizer
+	// submitted is the last transaction submitted, guarded by the authorizer lock
+	submitted *types.Transaction
+}
+
+// NewImpersonatedAuthorizer returns an authorizer sending transactions from addr without its key,
+// for testing against a fork of mainnet run by Anvil or Hardhat. The account is impersonated using
+// whichever of anvil_impersonateAccount and hardhat_impersonateAccount the node supports, after
+// which transactions are submitted with eth_sendTransaction when they are signed, as the node signs
+// them itself. The second broadcast made by the contract bindings is then redundant, so Transact
+// returns the submitted transaction even if that broadcast fails. Likewise transactions returned
+// by BuildTx have already been broadcast and must not be passed to Send. The authorizer doesn't
+// hold a private key, so signing messages returns ErrSignerNotSupported. This must never be used
+// against a live network, which doesn't support impersonation.
+func NewImpersonatedAuthorizer(client *rpc.Client, addr common.Address, chainID *big.Int) (*Authorizer, error) {
+	if chainID == nil {
+		return nil, ErrNilChainID
+	}
+	var err error
+	for _, method := range impersonationMethods {
+		if err = client.Call(nil, method, addr); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "impersonate account")
+	}
+	signer := &impersonatedSigner{client: client, address: addr}
+	auth, err := NewAuthorizerFromSigner(signer, chainID)
+	if err != nil {
+		return nil, err
+	}
+	signer.auth = auth
+	auth.impersonated = signer
+	return auth, nil
+}
+
+// Address returns the impersonated address
+func (s *impersonatedSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx submits tx with eth_sendTransaction returning the transaction as signed by the node
+func (s *impersonatedSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	ctx := s.auth.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	args := map[string]interface{}{
+		"from":  s.address,
+		"gas":   hexutil.Uint64(tx.Gas()),
+		"value": (*hexutil.Big)(tx.Value()),
+		"data":  hexutil.Bytes(tx.Data()),
+		"nonce": hexutil.Uint64(tx.Nonce()),
+	}
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	if tx.Type() == types.LegacyTxType {
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	}
+	if len(tx.AccessList()) > 0 {
+		args["accessList"] = tx.AccessList()
+	}
+	var hash common.Hash
+	if err := s.client.CallContext(ctx, &hash, "eth_sendTransaction", args); err != nil {
+		return nil, errors.Wrap(err, "send transaction")
+	}
+	sent, _, err := ethclient.NewClient(s.client).TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "transaction %s was sent but couldn't be fetched", hash.Hex())
+	}
+	s.submitted = sent
+	return sent, nil
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// impersonationService serves the impersonation api of a hardhat node
+type impersonationService struct {
+	impersonated []common.Address
+}
+
+func (s *impersonationService) ImpersonateAccount(addr common.Address) {
+	s.impersonated = append(s.impersonated, addr)
+}
+
+// nodeSigningService serves eth_sendTransaction, signing transactions with its own key
+type nodeSigningService struct {
+	t    *testing.T
+	args map[string]interface{}
+	sent *types.Transaction
+}
+
+func (s *nodeSigningService) SendTransaction(args map[string]interface{}) common.Hash {
+	s.args = args
+	pk, err := crypto.GenerateKey()
+	require.NoError(s.t, err)
+	nonce, err := hexutil.DecodeUint64(args["nonce"].(string))
+	require.NoError(s.t, err)
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.HexToAddress(args["to"].(string)), big.NewInt(0), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, pk)
+	require.NoError(s.t, err)
+	s.sent = tx
+	return tx.Hash()
+}
+
+func (s *nodeSigningService) GetTransactionByHash(hash common.Hash) *types.Transaction {
+	if s.sent != nil && s.sent.Hash() == hash {
+		return s.sent
+	}
+	return nil
+}
+
+func TestNewImpersonatedAuthorizer(t *testing.T) {
+	ctx := context.Background()
+	whale := common.HexToAddress("0x47ac0Fb4F2D84898e4D9E7b4DaB3C24507a6D503")
+	impersonation := &impersonationService{}
+	node := &nodeSigningService{t: t}
+	server := rpc.NewServer()
+	// the anvil namespace isn't available so hardhat's method is used
+	require.NoError(t, server.RegisterName("hardhat", impersonation))
+	require.NoError(t, server.RegisterName("eth", node))
+	t.Cleanup(server.Stop)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+
+	_, err := NewImpersonatedAuthorizer(client, whale, nil)
+	require.Equal(t, ErrNilChainID, err)
+	auth, err := NewImpersonatedAuthorizer(client, whale, big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{whale}, impersonation.impersonated)
+	require.Equal(t, whale, auth.From)
+
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if _, err := opts.Signer(opts.From, types.NewTransaction(7, to, big.NewInt(0), 21000, big.NewInt(1), nil)); err != nil {
+			return nil, err
+		}
+		// the bindings broadcast the signed transaction again, which the node rejects
+		return nil, errors.New("already known")
+	})
+	require.NoError(t, err)
+	require.Equal(t, node.sent.Hash(), tx.Hash())
+	require.Equal(t, whale.Hex(), common.HexToAddress(node.args["from"].(string)).Hex())
+	require.Equal(t, "0x7", node.args["nonce"])
+	require.Equal(t, "0x1", node.args["gasPrice"])
+
+	// errors before the transaction is submitted are returned
+	_, err = auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nil, errors.New("estimate gas")
+	})
+	require.Error(t, err)
+
+	// nodes which don't support impersonation are rejected
+	_, err = NewImpersonatedAuthorizer(rpc.DialInProc(rpc.NewServer()), whale, big.NewInt(1))
+	require.Error(t, err)
+}
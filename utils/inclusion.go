@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// inclusionBlocks is the number of recent blocks sampled by EstimateInclusion without a mempool
+const inclusionBlocks = 20
+
+// InclusionEstimate is how a gas price ranks against the pending pool, or recent blocks when the
+// node doesn't expose its pool
+type InclusionEstimate struct {
+	// Percentile is the percentage of pending transactions priced below the gas price, or of
+	// recent blocks whose cheapest transaction paid at most the gas price
+	Percentile float64
+	// Blocks is a rough estimate of the number of blocks until a transaction with the gas price is
+	// included, one being the next block. It is zero when the gas price is below the base fee, as
+	// the transaction can't be included until the base fee drops, or no recent block included a
+	// transaction as cheap
+	Blocks int
+	// FromMempool is true when estimated from the pending pool and false from recent blocks
+	FromMempool bool
+	// Samples is the number of pending transactions or blocks the estimate is based on
+	Samples int
+}
+
+// EstimateInclusion ranks gasPrice, the price per gas a transaction would pay such as its legacy gas
+// price or the base fee plus its priority fee, against the executable transactions in the pool of
+// the node using txpool_content. Transactions in the pool are compared at the base fee of the latest
+// block, and the number of blocks is estimated from the gas of the transactions paying more than gas
+// price, which are expected to be included first. When txpool_content is unavailable or the pool is
+// empty, as with many RPC providers, the price is instead compared to the cheapest transaction of
+// each of the last 20 blocks. This only reflects the view of the node, and takes an *rpc.Client
+// rather than an *ethclient.Client since the latter doesn't expose the txpool namespace
+func EstimateInclusion(ctx context.Context, client *rpc.Client, gasPrice *big.Int) (*InclusionEstimate, error) {
+	ec := ethclient.NewClient(client)
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "header by number")
+	}
+	var content txpoolContent
+	if err := client.CallContext(ctx, &content, "txpool_content"); err == nil && len(content["pending"]) > 0 {
+		return estimateFromPool(content["pending"], head, gasPrice), nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	blocks := make([]*types.Block, 0, inclusionBlocks)
+	for number := head.Number.Int64(); number >= 0 && len(blocks) < inclusionBlocks; number-- {
+		block, err := ec.BlockByNumber(ctx, big.NewInt(number))
+		if err != nil {
+			return nil, errors.Wrapf(err, "block %d", number)
+		}
+		blocks = append(blocks, block)
+	}
+	return estimateFromBlocks(blocks, head, gasPrice), nil
+}
+
+// estimateFromPool ranks gasPrice against the pending transactions of a pool, keyed by sender and nonce
+func estimateFromPool(pending map[string]map[string]*types.Transaction, head *types.Header, gasPrice *big.Int) *InclusionEstimate {
+	estimate := &InclusionEstimate{FromMempool: true}
+	var below int
+	gasAhead := new(big.Int)
+	for _, txs := range pending {
+		for _, tx := range txs {
+			estimate.Samples++
+			switch priceAtBaseFee(tx, head.BaseFee).Cmp(gasPrice) {
+			case -1:
+				below++
+			case 1:
+				gasAhead.Add(gasAhead, new(big.Int).SetUint64(tx.Gas()))
+			}
+		}
+	}
+	if estimate.Samples > 0 {
+		estimate.Percentile = 100 * float64(below) / float64(estimate.Samples)
+	}
+	if belowBaseFee(head, gasPrice) {
+		return estimate
+	}
+	estimate.Blocks = 1
+	if head.GasLimit > 0 {
+		estimate.Blocks += int(new(big.Int).Div(gasAhead, new(big.Int).SetUint64(head.GasLimit)).Int64())
+	}
+	return estimate
+}
+
+// estimateFromBlocks ranks gasPrice against the cheapest transaction of each of blocks, where blocks
+// without transactions are taken to include any price
+func estimateFromBlocks(blocks []*types.Block, head *types.Header, gasPrice *big.Int) *InclusionEstimate {
+	estimate := &InclusionEstimate{Samples: len(blocks)}
+	var included int
+	for _, block := range blocks {
+		var cheapest *big.Int
+		for _, tx := range block.Transactions() {
+			if price := priceAtBaseFee(tx, block.BaseFee()); cheapest == nil || price.Cmp(cheapest) < 0 {
+				cheapest = price
+			}
+		}
+		if cheapest == nil || cheapest.Cmp(gasPrice) <= 0 {
+			included++
+		}
+	}
+	if len(blocks) > 0 {
+		estimate.Percentile = 100 * float64(included) / float64(len(blocks))
+	}
+	if included == 0 || belowBaseFee(head, gasPrice) {
+		return estimate
+	}
+	// with a share of blocks including the price, one is expected every len/included blocks
+	estimate.Blocks = (len(blocks) + included - 1) / included
+	return estimate
+}
+
+// belowBaseFee returns true if gasPrice is below the base fee of head
+func belowBaseFee(head *types.Header, gasPrice *big.Int) bool {
+	return head.BaseFee != nil && gasPrice.Cmp(head.BaseFee) < 0
+}
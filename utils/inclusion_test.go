@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// headService serves the latest header of a chain
+type headService struct {
+	head *types.Header
+}
+
+func (s *headService) GetBlockByNumber(number string, full bool) *types.Header {
+	return s.head
+}
+
+// pricedTxs returns transactions signed by a single key paying each of the gas prices
+func pricedTxs(t *testing.T, prices ...int64) []*types.Transaction {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	txs := make([]*types.Transaction, len(prices))
+	for i, price := range prices {
+		txs[i], err = types.SignTx(types.NewTransaction(uint64(i), to, nil, 21000, big.NewInt(price), nil), signer, key)
+		require.NoError(t, err)
+	}
+	return txs
+}
+
+func TestEstimateInclusion(t *testing.T) {
+	ctx := context.Background()
+	txs := pricedTxs(t, 10, 20, 30, 40)
+	pool := &pendingService{txs: map[common.Address][]*types.Transaction{{1}: txs}}
+	head := &types.Header{Number: big.NewInt(100), GasLimit: 30000, BaseFee: big.NewInt(5), Difficulty: big.NewInt(0)}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("txpool", pool))
+	require.NoError(t, server.RegisterName("eth", &headService{head: head}))
+	t.Cleanup(server.Stop)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+
+	// two transactions paying more need more gas than a single block holds
+	estimate, err := EstimateInclusion(ctx, client, big.NewInt(25))
+	require.NoError(t, err)
+	require.True(t, estimate.FromMempool)
+	require.Equal(t, 4, estimate.Samples)
+	require.Equal(t, 50.0, estimate.Percentile)
+	require.Equal(t, 2, estimate.Blocks)
+
+	estimate, err = EstimateInclusion(ctx, client, big.NewInt(50))
+	require.NoError(t, err)
+	require.Equal(t, 100.0, estimate.Percentile)
+	require.Equal(t, 1, estimate.Blocks)
+
+	// transactions below the base fee aren't included
+	estimate, err = EstimateInclusion(ctx, client, big.NewInt(4))
+	require.NoError(t, err)
+	require.Equal(t, 0.0, estimate.Percentile)
+	require.Equal(t, 0, estimate.Blocks)
+}
+
+func TestEstimateFromBlocks(t *testing.T) {
+	head := &types.Header{Number: big.NewInt(3), BaseFee: big.NewInt(5)}
+	blocks := []*types.Block{
+		types.NewBlockWithHeader(&types.Header{Number: big.NewInt(3), BaseFee: big.NewInt(5)}).WithBody(pricedTxs(t, 30, 40), nil),
+		types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2), BaseFee: big.NewInt(5)}).WithBody(pricedTxs(t, 15, 50), nil),
+		types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(5)}).WithBody(pricedTxs(t, 12), nil),
+		// empty blocks include any price
+		types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), BaseFee: big.NewInt(5)}),
+	}
+	estimate := estimateFromBlocks(blocks, head, big.NewInt(20))
+	require.False(t, estimate.FromMempool)
+	require.Equal(t, 4, estimate.Samples)
+	require.Equal(t, 75.0, estimate.Percentile)
+	require.Equal(t, 2, estimate.Blocks)
+
+	estimate = estimateFromBlocks(blocks[:1], head, big.NewInt(20))
+	require.Equal(t, 0.0, estimate.Percentile)
+	require.Equal(t, 0, estimate.Blocks)
+}
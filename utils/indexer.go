@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultIndexerChunkSize is the number of blocks indexed at a time unless overridden
+const DefaultIndexerChunkSize = 2000
+
+// Checkpoint persists the progress of an Indexer, such as in a database or file
+type Checkpoint interface {
+	// Load returns the next block to index, or zero if nothing was indexed yet
+	Load() uint64
+	// Save records that every block before block was indexed
+	Save(block uint64) error
+}
+
+// MemoryCheckpoint is a Checkpoint held in memory, which is lost on restart and is mostly useful for tests
+type MemoryCheckpoint struct {
+	mx    sync.Mutex
+	block uint64
+}
+
+// Load implements Checkpoint
+func (c *MemoryCheckpoint) Load() uint64 {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.block
+}
+
+// Save implements Checkpoint
+func (c *MemoryCheckpoint) Save(block uint64) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.block = block
+	return nil
+}
+
+// Indexer processes the logs matching a filter query block range by block range, from the
+// checkpoint or query.FromBlock, whichever is later, up to query.ToBlock or the head of the chain.
+// The checkpoint is only advanced once the handler succeeds, so that after a crash indexing resumes
+// from the first range which wasn't handled. Ranges may therefore be handled again after a crash
+// between handling them and saving the checkpoint, so handlers should be idempotent
+type Indexer struct {
+	bc         Blockchain
+	query      ethereum.FilterQuery
+	handler    func([]types.Log) error
+	checkpoint Checkpoint
+	// Confirmations is the number of blocks behind the head indexing stops at, so that logs
+	// of blocks which may still be reorganized aren't indexed
+	Confirmations uint64
+	// ChunkSize is the maximum number of blocks whose logs are passed to a single handler call
+	ChunkSize uint64
+	// PollInterval is the delay between checks for new blocks once Run catches up with the head
+	PollInterval time.Duration
+}
+
+// NewIndexer returns an indexer passing the logs matching query to handler, in order and with
+// progress persisted to checkpoint. Only blocks with matching logs are passed to the handler
+func NewIndexer(bc Blockchain, query ethereum.FilterQuery, handler func([]types.Log) error, checkpoint Checkpoint) *Indexer {
+	return &Indexer{
+		bc:           bc,
+		query:        query,
+		handler:      handler,
+		checkpoint:   checkpoint,
+		ChunkSize:    DefaultIndexerChunkSize,
+		PollInterval: DefaultPollInterval,
+	}
+}
+
+// Sync indexes every block up to the head of the chain less the confirmations, or query.ToBlock if
+// it is earlier, returning once caught up or on the first error fetching or handling logs
+func (ix *Indexer) Sync(ctx context.Context) error {
+	_, err := ix.sync(ctx)
+	return err
+}
+
+// Run indexes blocks as they are built until ctx is cancelled, returning the context error, or
+// until query.ToBlock is indexed in which case nil is returned. Errors are returned as with Sync
+func (ix *Indexer) Run(ctx context.Context) error {
+	for {
+		done, err := ix.sync(ctx)
+		if err != nil || done {
+			return err
+		}
+		if err := sleep(ctx, ix.PollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// sync is like Sync but also returns true once query.ToBlock was indexed
+func (ix *Indexer) sync(ctx context.Context) (bool, error) {
+	chunk := ix.ChunkSize
+	if chunk == 0 {
+		chunk = DefaultIndexerChunkSize
+	}
+	next := ix.checkpoint.Load()
+	if ix.query.FromBlock != nil && ix.query.FromBlock.Uint64() > next {
+		next = ix.query.FromBlock.Uint64()
+	}
+	if ix.query.ToBlock != nil && next > ix.query.ToBlock.Uint64() {
+		return true, nil
+	}
+	head, err := ix.bc.BlockNumber(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "block number")
+	}
+	if head < ix.Confirmations {
+		return false, nil
+	}
+	target := head - ix.Confirmations
+	if ix.query.ToBlock != nil && ix.query.ToBlock.Uint64() < target {
+		target = ix.query.ToBlock.Uint64()
+	}
+	for next <= target {
+		end := next + chunk - 1
+		if end > target || end < next {
+			end = target
+		}
+		query := ix.query
+		query.FromBlock, query.ToBlock = new(big.Int).SetUint64(next), new(big.Int).SetUint64(end)
+		logs, err := FetchLogs(ctx, ix.bc, query, chunk)
+		if err != nil {
+			return false, err
+		}
+		if len(logs) > 0 {
+			if err := ix.handler(logs); err != nil {
+				return false, errors.Wrapf(err, "handle logs %d-%d", next, end)
+			}
+		}
+		if err := ix.checkpoint.Save(end + 1); err != nil {
+			return false, errors.Wrap(err, "save checkpoint")
+		}
+		next = end + 1
+	}
+	return ix.query.ToBlock != nil && next > ix.query.ToBlock.Uint64(), nil
+}
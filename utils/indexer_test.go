@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexer(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	emitter := deployCode(t, sim, auth, emitterCode)
+	// blocks 2 through 11 each contain a single log
+	for i := 0; i < 10; i++ {
+		emit(t, sim, auth, emitter)
+		sim.Commit()
+	}
+	ctx := context.Background()
+	query := ethereum.FilterQuery{Addresses: []common.Address{emitter}}
+	checkpoint := &MemoryCheckpoint{}
+	var (
+		indexed []uint64
+		fail    = true
+	)
+	ix := NewIndexer(sim, query, func(logs []types.Log) error {
+		if fail {
+			fail = false
+			return errors.New("database unavailable")
+		}
+		for _, log := range logs {
+			indexed = append(indexed, log.BlockNumber)
+		}
+		return nil
+	}, checkpoint)
+	ix.ChunkSize = 3
+	ix.Confirmations = 2
+
+	// the checkpoint isn't advanced past logs which weren't handled
+	require.Error(t, ix.Sync(ctx))
+	require.Equal(t, uint64(0), checkpoint.Load())
+	require.NoError(t, ix.Sync(ctx))
+	require.Equal(t, []uint64{2, 3, 4, 5, 6, 7, 8, 9}, indexed)
+	require.Equal(t, uint64(10), checkpoint.Load())
+
+	// indexing resumes from the checkpoint and stops once the end of the query is indexed
+	query.ToBlock = big.NewInt(11)
+	ix = NewIndexer(sim, query, func(logs []types.Log) error {
+		for _, log := range logs {
+			indexed = append(indexed, log.BlockNumber)
+		}
+		return nil
+	}, checkpoint)
+	ix.PollInterval = time.Millisecond * 10
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	require.NoError(t, ix.Run(ctx))
+	require.Equal(t, []uint64{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, indexed)
+	require.Equal(t, uint64(12), checkpoint.Load())
+}
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// SaveAccount encrypts the private key with pass and stores it in the keystore directory using the
+// same scrypt parameters as NewKeyFile. This allows keys generated with NewAccount to be reloaded
+// with NewAuthorizer. If a key for the same address already exists in the directory
+// ErrAccountExists is returned instead of overwriting it
+func SaveAccount(pk *ecdsa.PrivateKey, dir, pass string) (accounts.Account, error) {
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	acct, err := ks.ImportECDSA(pk, pass)
+	if err == keystore.ErrAccountAlreadyExists {
+		return accounts.Account{}, ErrAccountExists
+	} else if err != nil {
+		return accounts.Account{}, errors.Wrap(err, "import ecdsa")
+	}
+	return acct, nil
+}
+
+// LoadAllAccounts returns all accounts stored in the keystore directory
+func LoadAllAccounts(dir string) ([]accounts.Account, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, errors.Wrap(err, "stat")
+	}
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	return ks.Accounts(), nil
+}
+
+// ChangeKeyPassword re-encrypts the keystore file at keyFile with newPass, keeping the same key and
+// scrypt parameters. The new file is written alongside the original and then renamed over it, so
+// the original is left untouched if anything fails. ErrWrongPassword is returned if the file can't
+// be decrypted with oldPass, while failures writing the new file wrap ErrKeyFileWrite
+func ChangeKeyPassword(keyFile, oldPass, newPass string) error {
+	fileBytes, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return errors.Wrap(err, "read file")
+	}
+	key, err := keystore.DecryptKey(fileBytes, oldPass)
+	if err == keystore.ErrDecrypt {
+		return ErrWrongPassword
+	} else if err != nil {
+		return errors.Wrap(err, "decrypt key")
+	}
+	n, p := scryptParams(fileBytes)
+	encrypted, err := keystore.EncryptKey(key, newPass, n, p)
+	if err != nil {
+		return errors.Wrap(err, "encrypt key")
+	}
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		return errors.Wrap(err, "stat")
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(keyFile), "."+filepath.Base(keyFile)+".tmp")
+	if err != nil {
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	// the temp file is removed unless it was renamed over the original
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encrypted); err != nil {
+		tmp.Close()
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), keyFile); err != nil {
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	return nil
+}
+
+// scryptParams returns the scrypt parameters of a keystore file, falling back to the standard
+// parameters when the file was encrypted with a different key derivation function
+func scryptParams(keyJSON []byte) (n, p int) {
+	var parsed struct {
+		Crypto struct {
+			KDF       string `json:"kdf"`
+			KDFParams struct {
+				N int `json:"n"`
+				P int `json:"p"`
+			} `json:"kdfparams"`
+		} `json:"crypto"`
+	}
+	if err := json.Unmarshal(keyJSON, &parsed); err != nil || parsed.Crypto.KDF != "scrypt" ||
+		parsed.Crypto.KDFParams.N <= 1 || parsed.Crypto.KDFParams.P <= 0 {
+		return keystore.StandardScryptN, keystore.StandardScryptP
+	}
+	return parsed.Crypto.KDFParams.N, parsed.Crypto.KDFParams.P
+}
+
+// ScryptParams are the scrypt cost parameters used to encrypt keystore files, where N is the CPU and
+// memory cost which must be a power of two, and P the parallelization
+type ScryptParams struct {
+	N int
+	P int
+}
+
+var (
+	// StandardScryptParams are the parameters used by NewKeyFile and `geth account new`
+	StandardScryptParams = ScryptParams{N: keystore.StandardScryptN, P: keystore.StandardScryptP}
+	// LightScryptParams are significantly faster than the standard parameters, which makes them
+	// suitable for tests and resource constrained devices
+	LightScryptParams = ScryptParams{N: keystore.LightScryptN, P: keystore.LightScryptP}
+)
+
+// validate returns an error if the parameters can't be used with scrypt
+func (p ScryptParams) validate() error {
+	if p.N <= 1 || p.N&(p.N-1) != 0 {
+		return errors.Errorf("invalid scrypt n %d, must be a power of two greater than 1", p.N)
+	}
+	if p.P <= 0 {
+		return errors.Errorf("invalid scrypt p %d, must be greater than 0", p.P)
+	}
+	return nil
+}
+
+// NewKeyFileContext is like NewKeyFileWithParams but returns ctx.Err() if ctx is done before the
+// key is encrypted, such as when an interactive tool times out or is interrupted. The scrypt key
+// derivation can't be interrupted, so it is abandoned and runs to completion in the background
+// without writing anything. The key file is only written once the key is encrypted, replacing a
+// temporary file so that cancellation never leaves partially written files behind
+func NewKeyFileContext(ctx context.Context, dir, pass string, params ScryptParams) (accounts.Account, error) {
+	if err := params.validate(); err != nil {
+		return accounts.Account{}, err
+	}
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		return accounts.Account{}, errors.Wrap(err, "generate key")
+	}
+	address := crypto.PubkeyToAddress(pk.PublicKey)
+	type result struct {
+		crypto keystore.CryptoJSON
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		keyBytes := math.PaddedBigBytes(pk.D, 32)
+		defer zeroBytes(keyBytes)
+		encrypted, err := keystore.EncryptDataV3(keyBytes, []byte(pass), params.N, params.P)
+		done <- result{encrypted, err}
+	}()
+	var encrypted keystore.CryptoJSON
+	select {
+	case <-ctx.Done():
+		return accounts.Account{}, ctx.Err()
+	case res := <-done:
+		if res.err != nil {
+			return accounts.Account{}, errors.Wrap(res.err, "encrypt key")
+		}
+		encrypted = res.crypto
+	}
+	id, err := newKeyID()
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	keyJSON, err := json.Marshal(struct {
+		Address string              `json:"address"`
+		Crypto  keystore.CryptoJSON `json:"crypto"`
+		ID      string              `json:"id"`
+		Version int                 `json:"version"`
+	}{hex.EncodeToString(address.Bytes()), encrypted, id, 3})
+	if err != nil {
+		return accounts.Account{}, errors.Wrap(err, "marshal key")
+	}
+	// the last chance to give up before anything is written
+	if err := ctx.Err(); err != nil {
+		return accounts.Account{}, err
+	}
+	path := filepath.Join(dir, keyFileName(address, time.Now()))
+	if err := writeKeyFile(path, keyJSON); err != nil {
+		return accounts.Account{}, err
+	}
+	return accounts.Account{Address: address, URL: accounts.URL{Scheme: keystore.KeyStoreScheme, Path: path}}, nil
+}
+
+// keyFileName returns the name geth gives to the key file of address created at t
+func keyFileName(address common.Address, t time.Time) string {
+	return fmt.Sprintf("UTC--%s--%s", t.UTC().Format("2006-01-02T15-04-05.000000000Z"), hex.EncodeToString(address.Bytes()))
+}
+
+// newKeyID returns a random version 4 UUID identifying a key file
+func newKeyID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", errors.Wrap(err, "generate key id")
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
+}
+
+// writeKeyFile writes keyJSON to path readable only by the current user, creating the directory
+// if needed. The file is written alongside and then renamed into place
+func writeKeyFile(path string, keyJSON []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	// the temp file is removed unless it was renamed into place
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(keyJSON); err != nil {
+		tmp.Close()
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(ErrKeyFileWrite, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAccount(t *testing.T) {
+	t.Cleanup(func() {
+		os.RemoveAll("testKeystoreDir")
+	})
+	require.NoError(t, os.MkdirAll("testKeystoreDir", os.ModePerm))
+	auth, pk, err := NewAccount()
+	require.NoError(t, err)
+	acct, err := SaveAccount(pk, "testKeystoreDir", "password123")
+	require.NoError(t, err)
+	require.Equal(t, auth.From, acct.Address)
+	_, err = SaveAccount(pk, "testKeystoreDir", "password123")
+	require.ErrorIs(t, err, ErrAccountExists)
+
+	loaded, err := NewAuthorizer(acct.URL.Path, "password123")
+	require.NoError(t, err)
+	require.Equal(t, auth.From, loaded.From)
+
+	accts, err := LoadAllAccounts("testKeystoreDir")
+	require.NoError(t, err)
+	require.Len(t, accts, 1)
+	require.Equal(t, auth.From, accts[0].Address)
+}
+
+func TestChangeKeyPassword(t *testing.T) {
+	dir := t.TempDir()
+	acct, err := NewKeyFileWithParams(dir, "old", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, ChangeKeyPassword(acct.URL.Path, "wrong", "new"), ErrWrongPassword)
+	require.NoError(t, ChangeKeyPassword(acct.URL.Path, "old", "new"))
+
+	_, err = NewAuthorizer(acct.URL.Path, "old")
+	require.Error(t, err)
+	auth, err := NewAuthorizer(acct.URL.Path, "new")
+	require.NoError(t, err)
+	require.Equal(t, acct.Address, auth.From)
+
+	// the scrypt parameters are preserved and no temp files are left behind
+	keyJSON, err := ioutil.ReadFile(acct.URL.Path)
+	require.NoError(t, err)
+	n, p := scryptParams(keyJSON)
+	require.Equal(t, keystore.LightScryptN, n)
+	require.Equal(t, keystore.LightScryptP, p)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestNewKeyFileContext(t *testing.T) {
+	dir := t.TempDir()
+	acct, err := NewKeyFileContext(context.Background(), dir, "password123", LightScryptParams)
+	require.NoError(t, err)
+	auth, err := NewAuthorizer(acct.URL.Path, "password123")
+	require.NoError(t, err)
+	require.Equal(t, acct.Address, auth.From)
+	keyJSON, err := ioutil.ReadFile(acct.URL.Path)
+	require.NoError(t, err)
+	n, p := scryptParams(keyJSON)
+	require.Equal(t, keystore.LightScryptN, n)
+	require.Equal(t, keystore.LightScryptP, p)
+
+	// keys are found by the keystore like those created by geth
+	accts, err := LoadAllAccounts(dir)
+	require.NoError(t, err)
+	require.Len(t, accts, 1)
+	require.Equal(t, acct.Address, accts[0].Address)
+
+	// nothing is written once the context is done
+	cancelled := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewKeyFileContext(ctx, cancelled, "password123", LightScryptParams)
+	require.Equal(t, context.Canceled, err)
+	files, err := ioutil.ReadDir(cancelled)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	_, err = NewKeyFileContext(context.Background(), dir, "password123", ScryptParams{N: 1000, P: 1})
+	require.Error(t, err)
+}
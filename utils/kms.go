@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// KMSClient is the subset of a key management service needed to sign transactions with
+// secp256k1 keys which never leave the service. It is intentionally decoupled from any
+// particular SDK, for example an adapter around the aws-sdk-go-v2 kms.Client looks like
+//
+//	type awsKMS struct{ client *kms.Client }
+//
+//	func (k awsKMS) PublicKey(ctx context.Context, keyID string) ([]byte, error) {
+//		out, err := k.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.PublicKey, nil
+//	}
+//
+//	func (k awsKMS) Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+//		out, err := k.client.Sign(ctx, &kms.SignInput{
+//			KeyId:            aws.String(keyID),
+//			Message:          digest,
+//			MessageType:      kmstypes.MessageTypeDigest,
+//			SigningAlgorithm: kmstypes.SigningAlgorithmSpecEcdsaSha256,
+//		})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.Signature, nil
+//	}
+type KMSClient interface {
+	// PublicKey returns the DER encoded SubjectPublicKeyInfo of the key
+	PublicKey(ctx context.Context, keyID string) ([]byte, error)
+	// Sign signs a 32 byte digest returning a DER encoded ECDSA signature
+	Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+}
+
+// NewKMSAuthorizer returns an authorizer whose signing key is held by a key management service
+// such as AWS KMS. The ethereum address is derived from the public key of the KMS key, which
+// must use the ECC_SECG_P256K1 key spec. Every signature requires a network round trip to the
+// service while holding the authorizer lock, so callers should batch accordingly
+func NewKMSAuthorizer(ctx context.Context, client KMSClient, keyID string, chainID *big.Int) (*Authorizer, error) {
+	if chainID == nil {
+		return nil, ErrNilChainID
+	}
+	der, err := client.PublicKey(ctx, keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get public key")
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, errors.Wrap(err, "unmarshal public key")
+	}
+	pubBytes := spki.PublicKey.Bytes
+	pub, err := crypto.UnmarshalPubkey(pubBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal secp256k1 public key")
+	}
+	from := crypto.PubkeyToAddress(*pub)
+	signer := types.LatestSignerForChainID(chainID)
+	opts := &bind.TransactOpts{From: from}
+	opts.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != from {
+			return nil, bind.ErrNotAuthorized
+		}
+		signCtx := opts.Context
+		if signCtx == nil {
+			signCtx = context.Background()
+		}
+		sig, err := kmsSign(signCtx, client, keyID, signer.Hash(tx).Bytes(), pubBytes)
+		if err != nil {
+			return nil, err
+		}
+		return tx.WithSignature(signer, sig)
+	}
+	return &Authorizer{TransactOpts: opts}, nil
+}
+
+// kmsSign signs digest using the kms key, returning the signature in the 65 byte [R || S || V]
+// format used by ethereum. As KMS does not enforce low-S values the signature is normalized,
+// and the recovery id is reconstructed by recovering against the known public key
+func kmsSign(ctx context.Context, client KMSClient, keyID string, digest, pubBytes []byte) ([]byte, error) {
+	der, err := client.Sign(ctx, keyID, digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "kms sign")
+	}
+	var rs struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, errors.Wrap(err, "unmarshal signature")
+	}
+	curveN := crypto.S256().Params().N
+	if rs.S.Cmp(new(big.Int).Rsh(curveN, 1)) > 0 {
+		rs.S = new(big.Int).Sub(curveN, rs.S)
+	}
+	sig := make([]byte, 65)
+	rs.R.FillBytes(sig[0:32])
+	rs.S.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		recovered, err := crypto.Ecrecover(digest, sig)
+		if err == nil && bytes.Equal(recovered, pubBytes) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("unable to determine signature recovery id")
+}
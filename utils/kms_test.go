@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKMS implements KMSClient with a local key returning high-S signatures as KMS may
+type fakeKMS struct {
+	pk *ecdsa.PrivateKey
+}
+
+func (k fakeKMS) PublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	curve, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 10})
+	if err != nil {
+		return nil, err
+	}
+	pub := crypto.FromECDSAPub(&k.pk.PublicKey)
+	return asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1},
+			Parameters: asn1.RawValue{FullBytes: curve},
+		},
+		PublicKey: asn1.BitString{Bytes: pub, BitLength: len(pub) * 8},
+	})
+}
+
+func (k fakeKMS) Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	sig, err := crypto.Sign(digest, k.pk)
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int).SetBytes(sig[32:64])
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(sig[0:32]),
+		S: s.Sub(crypto.S256().Params().N, s),
+	})
+}
+
+func TestKMSAuthorizer(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	chainID := big.NewInt(1337)
+	auth, err := NewKMSAuthorizer(context.Background(), fakeKMS{pk}, "test-key", chainID)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), auth.From)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(0), 21000, big.NewInt(1), nil))
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, sender)
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// Closer is implemented by long running components which stop their background work on Close,
+// waiting for in-flight work to finish until ctx is done
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// CloserFunc adapts a function to a Closer, such as the Shutdown method of a TxQueue
+type CloserFunc func(ctx context.Context) error
+
+// Close implements Closer
+func (f CloserFunc) Close(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Lifecycle shuts down the components of a process deterministically, such as on SIGTERM, so that
+// goroutines aren't leaked and pending transactions aren't abandoned. Components are closed in the
+// reverse order they were added, so a component is closed before those it was built on, such as a
+// TxQueue before the HeadTracker it waits with. It is safe for concurrent use
+type Lifecycle struct {
+	mx      sync.Mutex
+	closers []Closer
+}
+
+// Add registers c to be closed by Close
+func (l *Lifecycle) Add(c Closer) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.closers = append(l.closers, c)
+}
+
+// AddFunc registers a close function which doesn't take a context, such as the Close method of a
+// HeadTracker, to be called by Close
+func (l *Lifecycle) AddFunc(fn func()) {
+	l.Add(CloserFunc(func(context.Context) error {
+		fn()
+		return nil
+	}))
+}
+
+// Close closes every registered component in the reverse order they were added, passing each ctx
+// so that a shutdown timeout bounds the whole sequence. Every component is closed even if some of
+// them fail, returning the first error. Components are only closed once, so closing again only
+// closes those added since
+func (l *Lifecycle) Close(ctx context.Context) error {
+	l.mx.Lock()
+	closers := l.closers
+	l.closers = nil
+	l.mx.Unlock()
+	var first error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].Close(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// RunGroup runs each component in its own goroutine until all of them returned, such as the Run
+// method of several indexers. The first component to fail cancels the context given to the rest,
+// and its error is returned once they have all stopped. Components returning nil don't affect the
+// others, so nil is only returned once every component finished without error
+func RunGroup(ctx context.Context, components ...func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, len(components))
+	for _, run := range components {
+		go func(run func(context.Context) error) {
+			errs <- run(ctx)
+		}(run)
+	}
+	var first error
+	for range components {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycle(t *testing.T) {
+	var closed []string
+	closer := func(name string, err error) Closer {
+		return CloserFunc(func(ctx context.Context) error {
+			require.NotNil(t, ctx)
+			closed = append(closed, name)
+			return err
+		})
+	}
+	var l Lifecycle
+	l.Add(closer("tracker", nil))
+	l.Add(closer("indexer", errors.New("indexer failed")))
+	l.AddFunc(func() { closed = append(closed, "streamer") })
+	l.Add(closer("queue", errors.New("queue failed")))
+
+	// components are closed in reverse order even after one fails
+	err := l.Close(context.Background())
+	require.EqualError(t, err, "queue failed")
+	require.Equal(t, []string{"queue", "streamer", "indexer", "tracker"}, closed)
+
+	closed = nil
+	require.NoError(t, l.Close(context.Background()))
+	require.Empty(t, closed)
+}
+
+func TestRunGroup(t *testing.T) {
+	ctx := context.Background()
+	// the failure cancels the components still running
+	stopped := make(chan struct{})
+	err := RunGroup(ctx,
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return ctx.Err()
+		},
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error {
+			time.Sleep(time.Millisecond * 10)
+			return errors.New("fatal")
+		},
+	)
+	require.EqualError(t, err, "fatal")
+	<-stopped
+
+	ran := 0
+	require.NoError(t, RunGroup(ctx,
+		func(ctx context.Context) error { ran++; return nil },
+	))
+	require.Equal(t, 1, ran)
+	require.NoError(t, RunGroup(ctx))
+}
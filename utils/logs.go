@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultResubscribeInterval is the delay before resubscribing after a subscription fails
+const DefaultResubscribeInterval = time.Second * 5
+
+// LogStreamer streams logs matching a filter query over a subscription, automatically
+// resubscribing when the connection drops. Logs emitted while disconnected are backfilled
+// from the last seen block, or the block after the head when first subscribing if no log was
+// seen yet, and logs are never delivered twice across a reconnect
+type LogStreamer struct {
+	bc    Blockchain
+	query ethereum.FilterQuery
+	// ResubscribeInterval is the delay before resubscribing after a failure
+	ResubscribeInterval time.Duration
+}
+
+// NewLogStreamer returns a log streamer for logs matching query. If query.FromBlock is set logs
+// starting at that block are backfilled before streaming new logs, while query.ToBlock is ignored
+func NewLogStreamer(bc Blockchain, query ethereum.FilterQuery) *LogStreamer {
+	return &LogStreamer{bc: bc, query: query, ResubscribeInterval: DefaultResubscribeInterval}
+}
+
+// Start begins streaming logs in the background until ctx is cancelled, at which point both
+// channels are closed. Subscription failures are reported on the error channel, which is
+// buffered and drops errors when full so it doesn't need to be drained, and are followed by
+// a resubscription after the resubscribe interval. Logs removed due to a reorg are delivered
+// with their Removed field set
+func (s *LogStreamer) Start(ctx context.Context) (<-chan types.Log, <-chan error) {
+	logs := make(chan types.Log)
+	errs := make(chan error, 1)
+	go s.run(ctx, logs, errs)
+	return logs, errs
+}
+
+func (s *LogStreamer) run(ctx context.Context, logs chan<- types.Log, errs chan<- error) {
+	defer close(logs)
+	defer close(errs)
+	var cursor logCursor
+	from := s.query.FromBlock
+	deliver := func(log types.Log) bool {
+		if !cursor.advance(log) {
+			return true
+		}
+		select {
+		case logs <- log:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for {
+		start, err := s.stream(ctx, from, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case errs <- err:
+		default:
+		}
+		if cursor.seen {
+			// the last seen block is backfilled again as not all of its logs may have been seen
+			from = new(big.Int).SetUint64(cursor.block)
+		} else if start != nil {
+			from = start
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.ResubscribeInterval):
+		}
+	}
+}
+
+// stream subscribes to new logs, backfills logs starting at from if it is not nil, and then
+// delivers logs from the subscription until it fails or deliver returns false. It returns the
+// block from which logs were streamed, which when from is nil is the block after the head at the
+// time of subscribing, so that a reconnect can backfill from it before any log was seen
+func (s *LogStreamer) stream(ctx context.Context, from *big.Int, deliver func(types.Log) bool) (*big.Int, error) {
+	query := s.query
+	query.FromBlock, query.ToBlock = nil, nil
+	start := from
+	if start == nil {
+		// the head is read before subscribing so that no block is mined in between unaccounted for
+		head, err := s.bc.BlockNumber(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "block number")
+		}
+		start = new(big.Int).SetUint64(head + 1)
+	}
+	ch := make(chan types.Log)
+	// subscribing before backfilling ensures no logs are missed in between,
+	// with any overlap filtered out by the cursor
+	sub, err := s.bc.SubscribeFilterLogs(ctx, query, ch)
+	if err != nil {
+		return start, errors.Wrap(err, "subscribe filter logs")
+	}
+	defer sub.Unsubscribe()
+	if from != nil {
+		query.FromBlock = from
+		past, err := s.bc.FilterLogs(ctx, query)
+		if err != nil {
+			return start, errors.Wrap(err, "filter logs")
+		}
+		for _, log := range past {
+			if !deliver(log) {
+				return start, nil
+			}
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return start, nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return start, errors.Wrap(err, "subscription")
+		case log := <-ch:
+			if !deliver(log) {
+				return start, nil
+			}
+		}
+	}
+}
+
+// logCursor tracks the position of the last delivered log to prevent duplicate delivery
+type logCursor struct {
+	seen  bool
+	block uint64
+	index uint
+}
+
+// advance returns true if log comes after the last delivered log and moves the cursor
+// to it. Removed logs are always delivered and don't move the cursor
+func (c *logCursor) advance(log types.Log) bool {
+	if log.Removed {
+		return true
+	}
+	if c.seen && (log.BlockNumber < c.block || log.BlockNumber == c.block && log.Index <= c.index) {
+		return false
+	}
+	c.seen, c.block, c.index = true, log.BlockNumber, log.Index
+	return true
+}
+
+// tooManyResultsErrors are substrings of the errors returned by common node implementations
+// and RPC providers when a log query matches too many results or spans too many blocks
+var tooManyResultsErrors = []string{
+	"query returned more than",
+	"limit exceeded",
+	"response size exceeded",
+	"block range is too wide",
+	"range too large",
+}
+
+// FetchLogs fetches all logs matching query by splitting [FromBlock, ToBlock] into windows of at
+// most maxRange blocks, returning the logs of all windows in order. A nil FromBlock starts at the
+// genesis block, and a nil ToBlock ends at the latest block. When a window fails because it matches
+// too many results it is halved and retried, with the reduced window size carried over to later
+// windows. If fetching fails the logs fetched so far are returned alongside the error, allowing
+// callers to resume from the block after the last returned log
+func FetchLogs(ctx context.Context, bc Blockchain, query ethereum.FilterQuery, maxRange uint64) ([]types.Log, error) {
+	if maxRange == 0 {
+		return nil, errors.New("max range must be greater than 0")
+	}
+	var from, to uint64
+	if query.FromBlock != nil {
+		from = query.FromBlock.Uint64()
+	}
+	if query.ToBlock != nil {
+		to = query.ToBlock.Uint64()
+	} else {
+		latest, err := bc.BlockNumber(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "block number")
+		}
+		to = latest
+	}
+	var logs []types.Log
+	window := maxRange
+	for from <= to {
+		end := from + window - 1
+		if end > to || end < from {
+			end = to
+		}
+		query.FromBlock = new(big.Int).SetUint64(from)
+		query.ToBlock = new(big.Int).SetUint64(end)
+		fetched, err := bc.FilterLogs(ctx, query)
+		if err != nil {
+			if isTooManyResults(err) && window > 1 {
+				window /= 2
+				continue
+			}
+			return logs, errors.Wrapf(err, "filter logs %d-%d", from, end)
+		}
+		logs = append(logs, fetched...)
+		if end == to {
+			break
+		}
+		from = end + 1
+	}
+	return logs, nil
+}
+
+// FilterLastN returns a query for the logs emitted by addresses matching topics in the last n blocks,
+// ending at the latest block. FromBlock is clamped to the genesis block on chains with fewer than n
+// blocks. Since the range is resolved when called, the query can be passed to FetchLogs as is, or
+// to NewLogStreamer to backfill the last n blocks before streaming new logs
+func FilterLastN(ctx context.Context, bc Blockchain, n uint64, addresses []common.Address, topics [][]common.Hash) (ethereum.FilterQuery, error) {
+	if n == 0 {
+		return ethereum.FilterQuery{}, errors.New("number of blocks must be greater than 0")
+	}
+	head, err := bc.BlockNumber(ctx)
+	if err != nil {
+		return ethereum.FilterQuery{}, errors.Wrap(err, "block number")
+	}
+	var from uint64
+	if head >= n {
+		from = head - n + 1
+	}
+	return ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(head),
+		Addresses: addresses,
+		Topics:    topics,
+	}, nil
+}
+
+// isTooManyResults returns true if err indicates a log query matched too many results
+func isTooManyResults(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, substr := range tooManyResultsErrors {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
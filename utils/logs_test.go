@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/require"
+)
+
+// emitterCode deploys a contract emitting an empty LOG0 whenever it is called
+const emitterCode = "0x6006600c60003960066000f360006000a000"
+
+// emit calls the emitter contract, emitting a log
+func emit(t *testing.T, sim simulatedBackend, auth *Authorizer, emitter common.Address) {
+	ctx := context.Background()
+	nonce, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(nonce, emitter, big.NewInt(0), 100000, gasPrice, nil))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, tx))
+}
+
+func TestLogStreamer(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	emitter := deployCode(t, sim, auth, emitterCode)
+	emit(t, sim, auth, emitter)
+	sim.Commit()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the first log is backfilled, while the others are streamed
+	logs, _ := NewLogStreamer(sim, ethereum.FilterQuery{
+		FromBlock: big.NewInt(0),
+		Addresses: []common.Address{emitter},
+	}).Start(ctx)
+	next := func() types.Log {
+		select {
+		case log := <-logs:
+			return log
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for log")
+		}
+		return types.Log{}
+	}
+	require.Equal(t, uint64(2), next().BlockNumber)
+	for block := uint64(3); block < 5; block++ {
+		emit(t, sim, auth, emitter)
+		sim.Commit()
+		log := next()
+		require.Equal(t, block, log.BlockNumber)
+		require.Equal(t, emitter, log.Address)
+	}
+	cancel()
+	for range logs {
+	}
+}
+
+// droppingBackend hands out a first log subscription which delivers nothing and fails once drop
+// is closed, as if the connection was lost, while later subscriptions are those of the backend
+type droppingBackend struct {
+	simulatedBackend
+	subscribed chan struct{}
+	drop       chan struct{}
+	subs       int
+}
+
+func (d *droppingBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	d.subs++
+	if d.subs > 1 {
+		return d.simulatedBackend.SubscribeFilterLogs(ctx, query, ch)
+	}
+	close(d.subscribed)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		select {
+		case <-quit:
+			return nil
+		case <-d.drop:
+			return errors.New("connection lost")
+		}
+	}), nil
+}
+
+func TestLogStreamerReconnect(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	emitter := deployCode(t, sim, auth, emitterCode)
+	// logs emitted before streaming starts aren't delivered
+	emit(t, sim, auth, emitter)
+	sim.Commit()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bc := &droppingBackend{simulatedBackend: sim, subscribed: make(chan struct{}), drop: make(chan struct{})}
+	streamer := NewLogStreamer(bc, ethereum.FilterQuery{Addresses: []common.Address{emitter}})
+	streamer.ResubscribeInterval = time.Millisecond * 10
+	logs, errs := streamer.Start(ctx)
+	<-bc.subscribed
+	// the log emitted while disconnected is backfilled from the head at the first subscription
+	emit(t, sim, auth, emitter)
+	sim.Commit()
+	close(bc.drop)
+	require.Error(t, <-errs)
+	select {
+	case log := <-logs:
+		require.Equal(t, uint64(3), log.BlockNumber)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for log")
+	}
+	cancel()
+	for range logs {
+	}
+}
+
+func TestLogCursor(t *testing.T) {
+	var cursor logCursor
+	require.True(t, cursor.advance(types.Log{BlockNumber: 1, Index: 0}))
+	require.False(t, cursor.advance(types.Log{BlockNumber: 1, Index: 0}))
+	require.True(t, cursor.advance(types.Log{BlockNumber: 1, Index: 1}))
+	require.False(t, cursor.advance(types.Log{BlockNumber: 0, Index: 5}))
+	require.True(t, cursor.advance(types.Log{BlockNumber: 2, Index: 0}))
+	// removed logs are always delivered without moving the cursor
+	require.True(t, cursor.advance(types.Log{BlockNumber: 1, Index: 1, Removed: true}))
+	require.False(t, cursor.advance(types.Log{BlockNumber: 2, Index: 0}))
+}
+
+// limitedBackend fails log queries spanning more than maxRange blocks like many rpc providers
+type limitedBackend struct {
+	simulatedBackend
+	maxRange uint64
+	queries  int
+}
+
+func (l *limitedBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	l.queries++
+	if query.ToBlock.Uint64()-query.FromBlock.Uint64()+1 > l.maxRange {
+		return nil, errors.New("query returned more than 10000 results")
+	}
+	return l.simulatedBackend.FilterLogs(ctx, query)
+}
+
+func TestFetchLogs(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	emitter := deployCode(t, sim, auth, emitterCode)
+	// blocks 2 through 11 each contain a single log
+	for i := 0; i < 10; i++ {
+		emit(t, sim, auth, emitter)
+		sim.Commit()
+	}
+	ctx := context.Background()
+	query := ethereum.FilterQuery{Addresses: []common.Address{emitter}}
+	for _, maxRange := range []uint64{1, 3, 100} {
+		logs, err := FetchLogs(ctx, sim, query, maxRange)
+		require.NoError(t, err)
+		require.Len(t, logs, 10)
+		for i, log := range logs {
+			require.Equal(t, uint64(i+2), log.BlockNumber)
+		}
+	}
+	query.FromBlock, query.ToBlock = big.NewInt(5), big.NewInt(7)
+	logs, err := FetchLogs(ctx, sim, query, 2)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+
+	// windows are halved until they are accepted
+	limited := &limitedBackend{simulatedBackend: sim, maxRange: 3}
+	logs, err = FetchLogs(ctx, limited, ethereum.FilterQuery{Addresses: []common.Address{emitter}}, 16)
+	require.NoError(t, err)
+	require.Len(t, logs, 10)
+	// 16 -> 8 -> 4 -> 2 followed by 6 windows of 2 blocks covering blocks 0-11
+	require.Equal(t, 9, limited.queries)
+
+	// partial results are returned when single block windows still fail
+	limited = &limitedBackend{simulatedBackend: sim, maxRange: 0}
+	logs, err = FetchLogs(ctx, limited, ethereum.FilterQuery{Addresses: []common.Address{emitter}}, 4)
+	require.Error(t, err)
+	require.Empty(t, logs)
+}
+
+func TestFilterLastN(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	emitter := deployCode(t, sim, auth, emitterCode)
+	// blocks 2 through 6 each contain a single log
+	for i := 0; i < 5; i++ {
+		emit(t, sim, auth, emitter)
+		sim.Commit()
+	}
+	ctx := context.Background()
+	addresses := []common.Address{emitter}
+
+	query, err := FilterLastN(ctx, sim, 3, addresses, nil)
+	require.NoError(t, err)
+	require.Equal(t, "4", query.FromBlock.String())
+	require.Equal(t, "6", query.ToBlock.String())
+	require.Equal(t, addresses, query.Addresses)
+	logs, err := FetchLogs(ctx, sim, query, 100)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+
+	// the range is clamped to the genesis block
+	query, err = FilterLastN(ctx, sim, 100, addresses, nil)
+	require.NoError(t, err)
+	require.Equal(t, "0", query.FromBlock.String())
+	logs, err = FetchLogs(ctx, sim, query, 100)
+	require.NoError(t, err)
+	require.Len(t, logs, 5)
+
+	_, err = FilterLastN(ctx, sim, 0, addresses, nil)
+	require.Error(t, err)
+}
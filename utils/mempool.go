@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// pendingBuffer is the number of matching transactions buffered before WatchPending blocks
+const pendingBuffer = 64
+
+// txpoolContent is the result of txpool_content, keyed by state, sender and nonce
+type txpoolContent map[string]map[string]map[string]*types.Transaction
+
+// WatchPending streams pending transactions sent to or from addr as they enter the mempool of the
+// node. Pending transaction hashes are subscribed to and each is fetched to check whether it
+// matches. When the node doesn't support the subscription, such as over http, the txpool_content
+// method is polled every DefaultPollInterval instead. Transactions may be missed if they are mined
+// or dropped before being fetched. The channel is closed once ctx is cancelled or the subscription fails
+func WatchPending(ctx context.Context, client *rpc.Client, addr common.Address) (<-chan *types.Transaction, error) {
+	hashes := make(chan common.Hash, pendingBuffer)
+	sub, err := client.EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if err != nil {
+		// fall back to polling if the txpool namespace is available
+		var content txpoolContent
+		if perr := client.CallContext(ctx, &content, "txpool_content"); perr != nil {
+			return nil, errors.Wrap(err, "subscribe pending transactions")
+		}
+		out := make(chan *types.Transaction, pendingBuffer)
+		go pollPending(ctx, client, addr, content, out)
+		return out, nil
+	}
+	out := make(chan *types.Transaction, pendingBuffer)
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+		ec := ethclient.NewClient(client)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sub.Err():
+				return
+			case hash := <-hashes:
+				tx, _, err := ec.TransactionByHash(ctx, hash)
+				if err != nil || !pendingMatches(tx, addr) {
+					continue
+				}
+				select {
+				case out <- tx:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// pollPending polls txpool_content sending newly seen transactions matching addr to out,
+// starting with the already fetched content
+func pollPending(ctx context.Context, client *rpc.Client, addr common.Address, content txpoolContent, out chan<- *types.Transaction) {
+	defer close(out)
+	seen := make(map[common.Hash]bool)
+	for {
+		if content != nil {
+			current := make(map[common.Hash]bool)
+			for _, senders := range content {
+				for sender, txs := range senders {
+					for _, tx := range txs {
+						current[tx.Hash()] = true
+						if seen[tx.Hash()] {
+							continue
+						}
+						// the sender is given by the pool so it doesn't need to be recovered
+						if !strings.EqualFold(sender, addr.Hex()) && (tx.To() == nil || *tx.To() != addr) {
+							continue
+						}
+						select {
+						case out <- tx:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+			}
+			// forget transactions which left the pool so the set doesn't grow unbounded
+			seen = current
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(DefaultPollInterval):
+		}
+		// failed polls are skipped keeping the transactions seen so far
+		content = nil
+		if err := client.CallContext(ctx, &content, "txpool_content"); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			content = nil
+		}
+	}
+}
+
+// pendingMatches returns true if tx is sent to or from addr
+func pendingMatches(tx *types.Transaction, addr common.Address) bool {
+	if to := tx.To(); to != nil && *to == addr {
+		return true
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	return err == nil && from == addr
+}
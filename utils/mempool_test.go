@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingService serves the pending transaction apis of a node holding txs in its pool
+type pendingService struct {
+	txs map[common.Address][]*types.Transaction
+}
+
+func (s *pendingService) Content() txpoolContent {
+	pending := make(map[string]map[string]*types.Transaction)
+	for sender, txs := range s.txs {
+		pending[sender.Hex()] = make(map[string]*types.Transaction)
+		for _, tx := range txs {
+			pending[sender.Hex()][big.NewInt(int64(tx.Nonce())).String()] = tx
+		}
+	}
+	return txpoolContent{"pending": pending, "queued": {}}
+}
+
+func (s *pendingService) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	for _, txs := range s.txs {
+		for _, tx := range txs {
+			_ = notifier.Notify(sub.ID, tx.Hash())
+		}
+	}
+	return sub, nil
+}
+
+func (s *pendingService) GetTransactionByHash(hash common.Hash) *types.Transaction {
+	for _, txs := range s.txs {
+		for _, tx := range txs {
+			if tx.Hash() == hash {
+				return tx
+			}
+		}
+	}
+	return nil
+}
+
+// newPendingTxs returns a pool holding transactions to the watched account, from it, and between
+// unrelated accounts, along with the transactions involving the watched account
+func newPendingTxs(t *testing.T) (common.Address, *pendingService, []*types.Transaction) {
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys[i] = pk
+	}
+	watched := crypto.PubkeyToAddress(keys[0].PublicKey)
+	other := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	pool := &pendingService{txs: make(map[common.Address][]*types.Transaction)}
+	var matching []*types.Transaction
+	for i, send := range []struct {
+		key *ecdsa.PrivateKey
+		to  common.Address
+	}{{keys[1], watched}, {keys[0], other}, {keys[2], other}} {
+		tx, err := types.SignTx(types.NewTransaction(0, send.to, big.NewInt(1), 21000, big.NewInt(1), nil), signer, send.key)
+		require.NoError(t, err)
+		from := crypto.PubkeyToAddress(send.key.PublicKey)
+		pool.txs[from] = append(pool.txs[from], tx)
+		if i < 2 {
+			matching = append(matching, tx)
+		}
+	}
+	return watched, pool, matching
+}
+
+// receivePending reads n transactions from ch returning their hashes
+func receivePending(t *testing.T, ch <-chan *types.Transaction, n int) map[common.Hash]bool {
+	hashes := make(map[common.Hash]bool)
+	for i := 0; i < n; i++ {
+		select {
+		case tx := <-ch:
+			hashes[tx.Hash()] = true
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for pending transaction")
+		}
+	}
+	return hashes
+}
+
+func TestWatchPending(t *testing.T) {
+	for _, namespace := range []string{"eth", "txpool"} {
+		t.Run(namespace, func(t *testing.T) {
+			watched, pool, matching := newPendingTxs(t)
+			server := rpc.NewServer()
+			// nodes without the eth namespace don't support the subscription so the pool is polled
+			require.NoError(t, server.RegisterName(namespace, pool))
+			t.Cleanup(server.Stop)
+			client := rpc.DialInProc(server)
+			t.Cleanup(client.Close)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			ch, err := WatchPending(ctx, client, watched)
+			require.NoError(t, err)
+			hashes := receivePending(t, ch, len(matching))
+			for _, tx := range matching {
+				require.True(t, hashes[tx.Hash()])
+			}
+			cancel()
+			select {
+			case _, ok := <-ch:
+				require.False(t, ok)
+			case <-time.After(time.Second * 5):
+				t.Fatal("channel not closed after cancel")
+			}
+		})
+	}
+}
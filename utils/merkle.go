@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// MerkleTree is a merkle tree of keccak256 hashes laid out like the StandardMerkleTree of
+// OpenZeppelin's merkle-tree library, with pairs of nodes sorted before being hashed as expected by
+// the MerkleProof verifier of OpenZeppelin contracts. Leaves are sorted before building the tree, so
+// the root only depends on the set of leaves and not their order
+type MerkleTree struct {
+	// nodes holds the tree as an array where the children of node i are 2i+1 and 2i+2, with the
+	// root first and the leaves last
+	nodes []common.Hash
+	// index maps each leaf to its position in nodes
+	index map[common.Hash]int
+}
+
+// NewMerkleTree builds the tree of leaves, which are typically hashed with AirdropLeaf, returning an
+// error if there are no leaves or the same leaf appears twice
+func NewMerkleTree(leaves []common.Hash) (*MerkleTree, error) {
+	if len(leaves) == 0 {
+		return nil, errors.New("merkle tree requires at least one leaf")
+	}
+	sorted := append([]common.Hash{}, leaves...)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	t := &MerkleTree{nodes: make([]common.Hash, 2*len(sorted)-1), index: make(map[common.Hash]int, len(sorted))}
+	for i, leaf := range sorted {
+		if _, ok := t.index[leaf]; ok {
+			return nil, errors.Errorf("duplicate merkle leaf %s", leaf.Hex())
+		}
+		// leaves are stored from the end in reverse order, as by OpenZeppelin
+		pos := len(t.nodes) - 1 - i
+		t.nodes[pos] = leaf
+		t.index[leaf] = pos
+	}
+	for i := len(t.nodes) - 1 - len(sorted); i >= 0; i-- {
+		t.nodes[i] = hashMerklePair(t.nodes[2*i+1], t.nodes[2*i+2])
+	}
+	return t, nil
+}
+
+// Root returns the root of the tree, which is stored by the contract verifying claims
+func (t *MerkleTree) Root() common.Hash {
+	return t.nodes[0]
+}
+
+// Proof returns the proof of leaf as expected by MerkleProof.verify, ordered from the sibling of the
+// leaf up to the children of the root. An error wrapping ErrNotInTree is returned if leaf isn't in
+// the tree
+func (t *MerkleTree) Proof(leaf common.Hash) ([][32]byte, error) {
+	i, ok := t.index[leaf]
+	if !ok {
+		return nil, errors.Wrapf(ErrNotInTree, "leaf %s", leaf.Hex())
+	}
+	var proof [][32]byte
+	for i > 0 {
+		// the sibling of odd nodes follows them and that of even nodes precedes them
+		sibling := i + 1
+		if i%2 == 0 {
+			sibling = i - 1
+		}
+		proof = append(proof, t.nodes[sibling])
+		i = (i - 1) / 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof returns true if proof proves leaf is in the tree with the given root, in the
+// same way as MerkleProof.verify
+func VerifyMerkleProof(root, leaf common.Hash, proof [][32]byte) bool {
+	computed := leaf
+	for _, node := range proof {
+		computed = hashMerklePair(computed, node)
+	}
+	return computed == root
+}
+
+// AirdropLeaf returns the leaf of an airdrop of amount tokens to addr as hashed by OpenZeppelin's
+// StandardMerkleTree with the address and uint256 leaf encoding, which is the keccak256 hash of the
+// keccak256 hash of the ABI encoded address and amount. Hashing twice prevents leaves from being
+// mistaken for intermediate nodes. Contracts verify claims by computing the same leaf with
+// keccak256(bytes.concat(keccak256(abi.encode(account, amount)))). The amount must not be negative
+func AirdropLeaf(addr common.Address, amount *big.Int) common.Hash {
+	encoded := append(common.LeftPadBytes(addr.Bytes(), 32), common.LeftPadBytes(amount.Bytes(), 32)...)
+	return crypto.Keccak256Hash(crypto.Keccak256(encoded))
+}
+
+// hashMerklePair returns the keccak256 hash of a and b concatenated in ascending order
+func hashMerklePair(a, b [32]byte) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleTree(t *testing.T) {
+	// the example of OpenZeppelin's merkle-tree library
+	leaves := []common.Hash{
+		AirdropLeaf(common.HexToAddress("0x1111111111111111111111111111111111111111"), ToWei("5", 18)),
+		AirdropLeaf(common.HexToAddress("0x2222222222222222222222222222222222222222"), ToWei("2.5", 18)),
+	}
+	tree, err := NewMerkleTree(leaves)
+	require.NoError(t, err)
+	require.Equal(t, "0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77", tree.Root().Hex())
+	proof, err := tree.Proof(leaves[0])
+	require.NoError(t, err)
+	require.Equal(t, [][32]byte{leaves[1]}, proof)
+	require.True(t, VerifyMerkleProof(tree.Root(), leaves[0], proof))
+	require.False(t, VerifyMerkleProof(tree.Root(), leaves[1], proof))
+
+	// the root doesn't depend on the order of the leaves
+	reversed, err := NewMerkleTree([]common.Hash{leaves[1], leaves[0]})
+	require.NoError(t, err)
+	require.Equal(t, tree.Root(), reversed.Root())
+
+	_, err = tree.Proof(common.Hash{1})
+	require.True(t, errors.Is(err, ErrNotInTree))
+	_, err = NewMerkleTree([]common.Hash{leaves[0], leaves[0]})
+	require.Error(t, err)
+	_, err = NewMerkleTree(nil)
+	require.Error(t, err)
+}
+
+func TestMerkleTreeProofs(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		leaves := make([]common.Hash, n)
+		for i := range leaves {
+			leaves[i] = AirdropLeaf(common.BigToAddress(big.NewInt(int64(i+1))), big.NewInt(int64(1000*(i+1))))
+		}
+		tree, err := NewMerkleTree(leaves)
+		require.NoError(t, err)
+		for _, leaf := range leaves {
+			proof, err := tree.Proof(leaf)
+			require.NoError(t, err)
+			require.True(t, VerifyMerkleProof(tree.Root(), leaf, proof), "%d leaves", n)
+		}
+		if n == 1 {
+			require.Equal(t, leaves[0], tree.Root())
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// MetricsSnapshot holds the counters of Metrics at a point in time
+type MetricsSnapshot struct {
+	// TxSent is the number of transactions broadcast
+	TxSent uint64
+	// TxMined is the number of transactions waited on which were mined, including reverted ones
+	TxMined uint64
+	// TxFailed is the number of mined transactions which reverted
+	TxFailed uint64
+	// TotalGasUsed is the gas used by the mined transactions
+	TotalGasUsed uint64
+	// TotalFeesWei is the amount paid for the gas used by the mined transactions
+	TotalFeesWei *big.Int
+}
+
+// Metrics accumulates counters of the transactions sent by an authorizer, allowing them to be
+// scraped periodically with Snapshot. Transactions are counted as sent by the authorizer send
+// helpers, such as Transact and the contract wrappers, as well as SendAndWait when passed the
+// metrics with WithMetrics. They are counted as mined by the wait helpers given WithMetrics, which
+// Authorizer.WaitDefaults adds, so waits not passed the metrics aren't counted. The zero value is
+// ready to use and Metrics is safe for concurrent use
+type Metrics struct {
+	mx       sync.Mutex
+	snapshot MetricsSnapshot
+}
+
+// Snapshot returns a copy of the current counters
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	snapshot := m.snapshot
+	snapshot.TotalFeesWei = new(big.Int)
+	if m.snapshot.TotalFeesWei != nil {
+		snapshot.TotalFeesWei.Set(m.snapshot.TotalFeesWei)
+	}
+	return snapshot
+}
+
+// sent counts a transaction as sent, doing nothing if m is nil
+func (m *Metrics) sent() {
+	if m == nil {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.snapshot.TxSent++
+}
+
+// mined counts the transaction of receipt as mined, doing nothing if m is nil. Fees are derived from
+// the effective gas price of tx, which is fetched if nil, and left out if it can't be determined
+func (m *Metrics) mined(ctx context.Context, bc Blockchain, tx *types.Transaction, receipt *types.Receipt) {
+	if m == nil {
+		return
+	}
+	if isDryRunReceipt(receipt) {
+		return
+	}
+	var fee *big.Int
+	if tx == nil {
+		tx, _, _ = bc.TransactionByHash(ctx, receipt.TxHash)
+	}
+	if tx != nil {
+		if price, err := effectiveGasPrice(ctx, bc, tx, receipt.BlockNumber); err == nil {
+			fee = new(big.Int).Mul(price, new(big.Int).SetUint64(receipt.GasUsed))
+		}
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.snapshot.TxMined++
+	if receipt.Status == types.ReceiptStatusFailed {
+		m.snapshot.TxFailed++
+	}
+	m.snapshot.TotalGasUsed += receipt.GasUsed
+	if fee != nil {
+		if m.snapshot.TotalFeesWei == nil {
+			m.snapshot.TotalFeesWei = new(big.Int)
+		}
+		m.snapshot.TotalFeesWei.Add(m.snapshot.TotalFeesWei, fee)
+	}
+}
+
+// sent invokes the OnSend hook and counts tx in the metrics of the authorizer
+func (a *Authorizer) sent(tx *types.Transaction) {
+	a.Hooks.sent(tx)
+	a.Metrics.sent()
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// revertingInitCode reverts contract creations with PUSH1 0 PUSH1 0 REVERT
+const revertingInitCode = "0x60006000fd"
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	auth.Metrics = &Metrics{}
+	sim := newSimulatedBackend(t, auth.From)
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "0", auth.Metrics.Snapshot().TotalFeesWei.String())
+
+	// sent by the authorizer and mined
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		signed, err := opts.Signer(opts.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil))
+		if err != nil {
+			return nil, err
+		}
+		return signed, sim.SendTransaction(ctx, signed)
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), auth.Metrics.Snapshot().TxSent)
+	sim.Commit()
+	_, err = WaitConfirmations(ctx, sim, tx.Hash(), 0, auth.WaitDefaults(WithPollInterval(time.Millisecond*10))...)
+	require.NoError(t, err)
+
+	// sent by SendAndWait and reverted
+	failing, err := auth.Signer(auth.From, types.NewContractCreation(1, big.NewInt(0), 100000, gasPrice, common.FromHex(revertingInitCode)))
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		sim.Commit()
+	}()
+	receipt, err := SendAndWait(ctx, sim, failing, auth.WaitDefaults(WithPollInterval(time.Millisecond*10))...)
+	require.ErrorIs(t, err, ErrTxReverted)
+
+	snapshot := auth.Metrics.Snapshot()
+	require.Equal(t, uint64(2), snapshot.TxSent)
+	require.Equal(t, uint64(2), snapshot.TxMined)
+	require.Equal(t, uint64(1), snapshot.TxFailed)
+	require.Equal(t, 21000+receipt.GasUsed, snapshot.TotalGasUsed)
+	fees := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(snapshot.TotalGasUsed))
+	require.Equal(t, fees.String(), snapshot.TotalFeesWei.String())
+	// snapshots are copies
+	snapshot.TotalFeesWei.SetInt64(0)
+	require.Equal(t, fees.String(), auth.Metrics.Snapshot().TotalFeesWei.String())
+	// clones share the metrics
+	require.Equal(t, auth.Metrics, auth.Clone().Metrics)
+}
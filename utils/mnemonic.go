@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultDerivationPath is the BIP-44 derivation path of the first ethereum
+// account, and is used whenever an empty derivation path is given
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+// hardenedKeyStart is the index at which BIP-32 hardened child keys begin
+const hardenedKeyStart = 0x80000000
+
+// NewAuthorizerFromMnemonic returns an authorizer using the key derived from a BIP-39
+// mnemonic at the given BIP-32 derivation path, defaulting to DefaultDerivationPath, which
+// signs transactions for mainnet. Use NewAuthorizerFromMnemonicWithChainID for other chains
+func NewAuthorizerFromMnemonic(mnemonic, derivationPath string) (*Authorizer, error) {
+	return NewAuthorizerFromMnemonicWithChainID(mnemonic, derivationPath, MainnetChainID())
+}
+
+// NewAuthorizerFromMnemonicWithChainID is like NewAuthorizerFromMnemonic but signs transactions
+// for the given chain id, returning ErrNilChainID if it is nil
+func NewAuthorizerFromMnemonicWithChainID(mnemonic, derivationPath string, chainID *big.Int) (*Authorizer, error) {
+	pk, err := DeriveKey(mnemonic, derivationPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuthorizerFromPKWithChainID(pk, chainID)
+}
+
+// DeriveKey derives the private key for a BIP-39 mnemonic at the given BIP-32
+// derivation path, defaulting to DefaultDerivationPath when path is empty
+func DeriveKey(mnemonic, path string) (*ecdsa.PrivateKey, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.Wrap(ErrInvalidMnemonic, "validate mnemonic")
+	}
+	if path == "" {
+		path = DefaultDerivationPath
+	}
+	dpath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse derivation path")
+	}
+	return deriveKey(bip39.NewSeed(mnemonic, ""), dpath)
+}
+
+// deriveKey performs BIP-32 private child key derivation from a seed
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	curveN := crypto.S256().Params().N
+	sum := hmacSHA512([]byte("Bitcoin seed"), seed)
+	key, chainCode := sum[:32], sum[32:]
+	for _, index := range path {
+		var data []byte
+		if index >= hardenedKeyStart {
+			data = append([]byte{0}, key...)
+		} else {
+			pk, err := crypto.ToECDSA(key)
+			if err != nil {
+				return nil, errors.Wrap(err, "to ecdsa")
+			}
+			data = crypto.CompressPubkey(&pk.PublicKey)
+		}
+		data = append(data, byte(index>>24), byte(index>>16), byte(index>>8), byte(index))
+		sum = hmacSHA512(chainCode, data)
+		child := new(big.Int).SetBytes(sum[:32])
+		if child.Cmp(curveN) >= 0 {
+			return nil, errors.Errorf("invalid child key at index %d", index)
+		}
+		child.Add(child, new(big.Int).SetBytes(key))
+		child.Mod(child, curveN)
+		if child.Sign() == 0 {
+			return nil, errors.Errorf("invalid child key at index %d", index)
+		}
+		key, chainCode = math.PaddedBigBytes(child, 32), sum[32:]
+	}
+	return crypto.ToECDSA(key)
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
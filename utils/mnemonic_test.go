@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testMnemonic is the default hardhat/anvil development mnemonic
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestDeriveKey(t *testing.T) {
+	pk, err := DeriveKey(testMnemonic, "")
+	require.NoError(t, err)
+	require.Equal(t, "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80", hexutil.Encode(crypto.FromECDSA(pk)))
+
+	auth, err := NewAuthorizerFromMnemonic(testMnemonic, "m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"), auth.From)
+	auth, err = NewAuthorizerFromMnemonicWithChainID(testMnemonic, "m/44'/60'/0'/0/1", big.NewInt(1337))
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"), auth.From)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, big.NewInt(1), nil))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1337), tx.ChainId())
+
+	_, err = DeriveKey("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "")
+	require.ErrorIs(t, err, ErrInvalidMnemonic)
+}
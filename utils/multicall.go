@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on most chains
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicall3ABI is the subset of the Multicall3 ABI used by the Multicall helper
+const multicall3ABI = `[
+	{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate","outputs":[{"name":"blockNumber","type":"uint256"},{"name":"returnData","type":"bytes[]"}],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"requireSuccess","type":"bool"},{"components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"tryAggregate","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"addr","type":"address"}],"name":"getEthBalance","outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+// Call is a single call to be aggregated by Multicall
+type Call struct {
+	Target   common.Address
+	CallData []byte
+}
+
+// CallResult is the result of a single call aggregated with Multicall.TryAggregate
+type CallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Multicall wraps a deployed Multicall3 contract allowing many read calls
+// to be batched into a single round trip
+type Multicall struct {
+	address  common.Address
+	abi      abi.ABI
+	contract *bind.BoundContract
+}
+
+// NewMulticall returns a Multicall helper for the Multicall3 contract at address
+func NewMulticall(bc Blockchain, address common.Address) (*Multicall, error) {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &Multicall{
+		address:  address,
+		abi:      parsed,
+		contract: bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Address returns the address of the Multicall3 contract
+func (m *Multicall) Address() common.Address {
+	return m.address
+}
+
+// Aggregate executes all calls in a single eth_call returning the raw return data of each call,
+// which is left to the caller to decode with the appropriate ABI. If any call fails the entire
+// aggregation fails, use TryAggregate to tolerate individual failures
+func (m *Multicall) Aggregate(ctx context.Context, calls []Call) ([][]byte, error) {
+	var out []interface{}
+	if err := m.contract.Call(&bind.CallOpts{Context: ctx}, &out, "aggregate", calls); err != nil {
+		return nil, errors.Wrap(err, "aggregate")
+	}
+	return *abi.ConvertType(out[1], new([][]byte)).(*[][]byte), nil
+}
+
+// TryAggregate is like Aggregate except individual call failures are tolerated,
+// with the success of each call reported alongside its return data
+func (m *Multicall) TryAggregate(ctx context.Context, calls []Call) ([]CallResult, error) {
+	var out []interface{}
+	if err := m.contract.Call(&bind.CallOpts{Context: ctx}, &out, "tryAggregate", false, calls); err != nil {
+		return nil, errors.Wrap(err, "try aggregate")
+	}
+	return *abi.ConvertType(out[0], new([]CallResult)).(*[]CallResult), nil
+}
+
+// TypedCall is a call aggregated by AggregateTyped, described by the ABI of its target
+type TypedCall struct {
+	ABI    abi.ABI
+	Method string
+	Target common.Address
+	Args   []interface{}
+}
+
+// TypedResult is the result of a TypedCall, holding the outputs unpacked using the ABI of the
+// call. Err is set if the call failed or its return data couldn't be unpacked, in which case
+// Outputs is nil. As with Contract.Call, abi.ConvertType can be used to convert the outputs
+type TypedResult struct {
+	Outputs []interface{}
+	Err     error
+}
+
+// AggregateTyped packs the calldata of each call using its ABI, executes them in a single eth_call
+// like TryAggregate, and unpacks the return data of each call using its declared outputs. This
+// allows heterogeneous calls such as reserves, balances and total supplies to be batched. Failed
+// calls are reported in their result, while an error is returned if any call can't be packed
+func (m *Multicall) AggregateTyped(ctx context.Context, calls []TypedCall) ([]TypedResult, error) {
+	packed, err := packTypedCalls(calls)
+	if err != nil {
+		return nil, err
+	}
+	results, err := m.TryAggregate(ctx, packed)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTypedResults(calls, results), nil
+}
+
+// packTypedCalls packs the calldata of each call
+func packTypedCalls(calls []TypedCall) ([]Call, error) {
+	packed := make([]Call, 0, len(calls))
+	for i, call := range calls {
+		data, err := call.ABI.Pack(call.Method, call.Args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "pack call %d to %s", i, call.Method)
+		}
+		packed = append(packed, Call{Target: call.Target, CallData: data})
+	}
+	return packed, nil
+}
+
+// decodeTypedResults unpacks the return data of each call
+func decodeTypedResults(calls []TypedCall, results []CallResult) []TypedResult {
+	decoded := make([]TypedResult, len(calls))
+	for i, call := range calls {
+		switch {
+		case i >= len(results):
+			decoded[i].Err = errors.Errorf("%s: missing result", call.Method)
+		case !results[i].Success:
+			decoded[i].Err = errors.Errorf("%s: call failed", call.Method)
+		default:
+			outputs, err := call.ABI.Unpack(call.Method, results[i].ReturnData)
+			if err != nil {
+				decoded[i].Err = errors.Wrapf(err, "unpack %s", call.Method)
+				continue
+			}
+			decoded[i].Outputs = outputs
+		}
+	}
+	return decoded
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// reservesABI describes the getReserves method of uniswap v2 pairs, returning several outputs
+const reservesABI = `[
+	{"inputs":[],"name":"getReserves","outputs":[{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"},{"name":"blockTimestampLast","type":"uint32"}],"stateMutability":"view","type":"function"}
+]`
+
+func TestTypedCalls(t *testing.T) {
+	answer, err := abi.JSON(strings.NewReader(answerABI))
+	require.NoError(t, err)
+	reserves, err := abi.JSON(strings.NewReader(reservesABI))
+	require.NoError(t, err)
+	target := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	calls := []TypedCall{
+		{ABI: answer, Method: "setAnswer", Target: target, Args: []interface{}{big.NewInt(7)}},
+		{ABI: reserves, Method: "getReserves", Target: target},
+	}
+	packed, err := packTypedCalls(calls)
+	require.NoError(t, err)
+	require.Len(t, packed, 2)
+	require.Equal(t, target, packed[0].Target)
+	require.Equal(t, "85a013e0", common.Bytes2Hex(packed[0].CallData[:4]))
+	require.Equal(t, common.LeftPadBytes(big.NewInt(7).Bytes(), 32), packed[0].CallData[4:])
+	require.Equal(t, "0902f1ac", common.Bytes2Hex(packed[1].CallData))
+
+	// args which don't match the method inputs fail before aggregating
+	_, err = packTypedCalls([]TypedCall{{ABI: answer, Method: "setAnswer", Target: target}})
+	require.Error(t, err)
+	_, err = packTypedCalls([]TypedCall{{ABI: answer, Method: "missing", Target: target}})
+	require.Error(t, err)
+
+	calls = []TypedCall{
+		{ABI: answer, Method: "answer", Target: target},
+		{ABI: reserves, Method: "getReserves", Target: target},
+		{ABI: answer, Method: "answer", Target: target},
+		{ABI: answer, Method: "answer", Target: target},
+	}
+	data, err := reserves.Methods["getReserves"].Outputs.Pack(big.NewInt(1000), big.NewInt(2000), uint32(1234))
+	require.NoError(t, err)
+	results := decodeTypedResults(calls, []CallResult{
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(42).Bytes(), 32)},
+		{Success: true, ReturnData: data},
+		{Success: false},
+		// calls to accounts without code succeed without returning data
+		{Success: true},
+	})
+	require.Len(t, results, 4)
+	require.NoError(t, results[0].Err)
+	require.Len(t, results[0].Outputs, 1)
+	require.Equal(t, "42", results[0].Outputs[0].(*big.Int).String())
+	require.NoError(t, results[1].Err)
+	require.Len(t, results[1].Outputs, 3)
+	require.Equal(t, "1000", results[1].Outputs[0].(*big.Int).String())
+	require.Equal(t, "2000", results[1].Outputs[1].(*big.Int).String())
+	require.Equal(t, uint32(1234), results[1].Outputs[2])
+	require.Error(t, results[2].Err)
+	require.Nil(t, results[2].Outputs)
+	require.Error(t, results[3].Err)
+	require.Nil(t, results[3].Outputs)
+}
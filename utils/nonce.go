@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// NonceManager hands out monotonically increasing nonces for the account of an
+// Authorizer, allowing multiple goroutines to send transactions from the same
+// account back to back without colliding on the same nonce. The pending nonce
+// is fetched from the chain once, after which nonces are tracked locally
+type NonceManager struct {
+	mx     sync.Mutex
+	auth   *Authorizer
+	bc     Blockchain
+	store  NonceStore
+	nonce  uint64
+	synced bool
+}
+
+// NewNonceManager returns a nonce manager for the given authorizer
+func NewNonceManager(auth *Authorizer, bc Blockchain) *NonceManager {
+	return &NonceManager{auth: auth, bc: bc}
+}
+
+// NewNonceManagerWithStore is like NewNonceManager but persists the next nonce to store as nonces
+// are handed out. On first use the higher of the stored nonce and the pending nonce of the chain is
+// used, so nonces of transactions sent before a restart which the node no longer reports as
+// pending aren't reused
+func NewNonceManagerWithStore(auth *Authorizer, bc Blockchain, store NonceStore) *NonceManager {
+	return &NonceManager{auth: auth, bc: bc, store: store}
+}
+
+// Next returns the next nonce to use, fetching the pending nonce from the chain
+// on first use. Before returning the nonce is set on the authorizer so that the
+// embedded bind.TransactOpts is ready to use
+func (nm *NonceManager) Next(ctx context.Context) (*big.Int, error) {
+	nm.mx.Lock()
+	defer nm.mx.Unlock()
+	if !nm.synced {
+		if err := nm.sync(ctx, true); err != nil {
+			return nil, err
+		}
+	}
+	// the lock is claimed first so that a cancelled context leaves the stored nonce as is, and the
+	// nonce is only handed out once the next one is persisted
+	if err := nm.auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	if nm.store != nil {
+		if err := nm.store.Set(nm.auth.From, nm.nonce+1); err != nil {
+			nm.auth.Unlock()
+			return nil, errors.Wrap(err, "store nonce")
+		}
+	}
+	nonce := new(big.Int).SetUint64(nm.nonce)
+	nm.auth.Nonce = new(big.Int).Set(nonce)
+	nm.auth.Unlock()
+	nm.nonce++
+	return nonce, nil
+}
+
+// Reset resynchronizes the nonce from the pending state of the chain. This should
+// be called after a failed broadcast as the nonce handed out for it was never used.
+// The stored nonce is ignored and replaced by that of the chain
+func (nm *NonceManager) Reset(ctx context.Context) error {
+	nm.mx.Lock()
+	defer nm.mx.Unlock()
+	return nm.sync(ctx, false)
+}
+
+// sync fetches the pending nonce, using the stored nonce instead if useStored is set and it is
+// higher, and expects the caller to hold the lock
+func (nm *NonceManager) sync(ctx context.Context, useStored bool) error {
+	nonce, err := nm.bc.PendingNonceAt(ctx, nm.auth.From)
+	if err != nil {
+		return errors.Wrap(err, "pending nonce at")
+	}
+	if nm.store != nil {
+		if stored, ok := nm.store.Get(nm.auth.From); ok && useStored && stored > nonce {
+			nonce = stored
+		}
+		if err := nm.store.Set(nm.auth.From, nonce); err != nil {
+			return errors.Wrap(err, "store nonce")
+		}
+	}
+	nm.nonce = nonce
+	nm.synced = true
+	return nil
+}
+
+// DetectNonceGap reports whether transactions of addr are stuck in the queue of the node behind a
+// missing nonce, which happens when a transaction is dropped from the mempool while transactions
+// with higher nonces remain. The pending nonce of the account is the first nonce not held by the
+// pool, so a gap exists at it whenever the pool queues transactions with higher nonces. This
+// requires the node to expose the txpool namespace
+func DetectNonceGap(ctx context.Context, client *rpc.Client, addr common.Address) (hasGap bool, missingNonce uint64, err error) {
+	ec := ethclient.NewClient(client)
+	latest, err := ec.NonceAt(ctx, addr, nil)
+	if err != nil {
+		return false, 0, errors.Wrap(err, "nonce at")
+	}
+	pending, err := ec.PendingNonceAt(ctx, addr)
+	if err != nil {
+		return false, 0, errors.Wrap(err, "pending nonce at")
+	}
+	var content txpoolContent
+	if err := client.CallContext(ctx, &content, "txpool_content"); err != nil {
+		return false, 0, errors.Wrap(err, "txpool content")
+	}
+	// the pending nonce can't be behind the mined nonce, but nodes may lag between the two calls
+	if pending < latest {
+		pending = latest
+	}
+	for sender, txs := range content["queued"] {
+		if !strings.EqualFold(sender, addr.Hex()) {
+			continue
+		}
+		for nonce := range txs {
+			if n, err := strconv.ParseUint(nonce, 10, 64); err == nil && n > pending {
+				return true, pending, nil
+			}
+		}
+	}
+	return false, 0, nil
+}
+
+// FillNonceGap sends a zero value transaction from the authorizer to itself using nonce, which
+// unblocks any queued transactions waiting on the nonce reported by DetectNonceGap. Fees are
+// populated from the chain unless set on the authorizer. This claims the lock and must not be
+// called while the lock is already held.
+func FillNonceGap(ctx context.Context, bc Blockchain, auth *Authorizer, nonce uint64) (*types.Transaction, error) {
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer auth.Unlock()
+	tx, err := auth.buildTx(ctx, bc, new(big.Int).SetUint64(nonce), 21000, auth.From, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := Send(ctx, bc, tx); err != nil {
+		return nil, err
+	}
+	auth.sent(tx)
+	return tx, nil
+}
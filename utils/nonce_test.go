@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceManager(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	nm := NewNonceManager(auth, newSimulatedBackend(t, auth.From))
+	for i := int64(0); i < 3; i++ {
+		nonce, err := nm.Next(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(i), nonce)
+		require.Equal(t, big.NewInt(i), auth.Nonce)
+	}
+	require.NoError(t, nm.Reset(context.Background()))
+	nonce, err := nm.Next(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), nonce)
+}
+
+// nonceService serves the nonces and queued transactions of a single account
+type nonceService struct {
+	addr            common.Address
+	latest, pending uint64
+	queued          []uint64
+}
+
+func (s *nonceService) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 {
+	if block == "pending" {
+		return hexutil.Uint64(s.pending)
+	}
+	return hexutil.Uint64(s.latest)
+}
+
+func (s *nonceService) Content() txpoolContent {
+	queued := make(map[string]*types.Transaction)
+	for _, nonce := range s.queued {
+		queued[strconv.FormatUint(nonce, 10)] = types.NewTransaction(nonce, s.addr, big.NewInt(0), 21000, big.NewInt(1), nil)
+	}
+	return txpoolContent{"pending": {}, "queued": {s.addr.Hex(): queued}}
+}
+
+func TestDetectNonceGap(t *testing.T) {
+	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tests := []struct {
+		name    string
+		service *nonceService
+		gap     bool
+		missing uint64
+	}{
+		{"nothing pending", &nonceService{latest: 5, pending: 5}, false, 0},
+		{"pending without gap", &nonceService{latest: 5, pending: 7}, false, 0},
+		{"queued behind gap", &nonceService{latest: 5, pending: 7, queued: []uint64{8, 9}}, true, 7},
+		{"queued behind mined nonce", &nonceService{latest: 5, pending: 5, queued: []uint64{6}}, true, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.service.addr = addr
+			server := rpc.NewServer()
+			require.NoError(t, server.RegisterName("eth", tt.service))
+			require.NoError(t, server.RegisterName("txpool", tt.service))
+			t.Cleanup(server.Stop)
+			client := rpc.DialInProc(server)
+			t.Cleanup(client.Close)
+			gap, missing, err := DetectNonceGap(context.Background(), client, addr)
+			require.NoError(t, err)
+			require.Equal(t, tt.gap, gap)
+			require.Equal(t, tt.missing, missing)
+		})
+	}
+}
+
+func TestFillNonceGap(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	tx, err := FillNonceGap(ctx, sim, auth, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), tx.Nonce())
+	require.Equal(t, auth.From, *tx.To())
+	require.Equal(t, "0", tx.Value().String())
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
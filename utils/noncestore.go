@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// NonceStore persists the next nonce of accounts managed by a NonceManager, allowing nonces to be
+// tracked across restarts while transactions sent before are still pending
+type NonceStore interface {
+	// Get returns the stored next nonce of addr, and false if none is stored
+	Get(addr common.Address) (uint64, bool)
+	// Set stores the next nonce of addr
+	Set(addr common.Address, nonce uint64) error
+}
+
+// MemoryNonceStore is a NonceStore keeping nonces in memory, which may be shared between
+// nonce managers of the same process. It is safe for concurrent use
+type MemoryNonceStore struct {
+	mx     sync.Mutex
+	nonces map[common.Address]uint64
+}
+
+// NewMemoryNonceStore returns an empty in-memory nonce store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[common.Address]uint64)}
+}
+
+// Get returns the stored next nonce of addr
+func (s *MemoryNonceStore) Get(addr common.Address) (uint64, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	nonce, ok := s.nonces[addr]
+	return nonce, ok
+}
+
+// Set stores the next nonce of addr
+func (s *MemoryNonceStore) Set(addr common.Address, nonce uint64) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.nonces[addr] = nonce
+	return nil
+}
+
+// FileNonceStore is a NonceStore persisting nonces to a JSON file mapping addresses to their next
+// nonce. The file is rewritten atomically on every update so a crash never leaves it truncated. It
+// is safe for concurrent use, but must not be shared between processes
+type FileNonceStore struct {
+	path string
+
+	mx     sync.Mutex
+	nonces map[common.Address]uint64
+}
+
+// NewFileNonceStore returns a nonce store persisting to path, loading the nonces stored by a
+// previous run. The file is created on the first update if it doesn't exist
+func NewFileNonceStore(path string) (*FileNonceStore, error) {
+	s := &FileNonceStore{path: path, nonces: make(map[common.Address]uint64)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read nonce store")
+	}
+	if err := json.Unmarshal(data, &s.nonces); err != nil {
+		return nil, errors.Wrap(err, "decode nonce store")
+	}
+	return s, nil
+}
+
+// Get returns the stored next nonce of addr
+func (s *FileNonceStore) Get(addr common.Address) (uint64, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	nonce, ok := s.nonces[addr]
+	return nonce, ok
+}
+
+// Set stores the next nonce of addr, writing the file before returning
+func (s *FileNonceStore) Set(addr common.Address, nonce uint64) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	prev, existed := s.nonces[addr]
+	s.nonces[addr] = nonce
+	if err := s.write(); err != nil {
+		// keep memory consistent with the file
+		if existed {
+			s.nonces[addr] = prev
+		} else {
+			delete(s.nonces, addr)
+		}
+		return err
+	}
+	return nil
+}
+
+// write replaces the file with the current nonces, and expects the caller to hold the lock
+func (s *FileNonceStore) write() error {
+	data, err := json.Marshal(s.nonces)
+	if err != nil {
+		return errors.Wrap(err, "encode nonce store")
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "write nonce store")
+	}
+	// the temp file is removed unless it was renamed over the original
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "write nonce store")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "write nonce store")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "write nonce store")
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrap(err, "write nonce store")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileNonceStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces.json")
+	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	store, err := NewFileNonceStore(path)
+	require.NoError(t, err)
+	_, ok := store.Get(addr)
+	require.False(t, ok)
+	require.NoError(t, store.Set(addr, 7))
+	nonce, ok := store.Get(addr)
+	require.True(t, ok)
+	require.Equal(t, uint64(7), nonce)
+
+	// nonces are loaded by later runs
+	store, err = NewFileNonceStore(path)
+	require.NoError(t, err)
+	nonce, ok = store.Get(addr)
+	require.True(t, ok)
+	require.Equal(t, uint64(7), nonce)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("not json"), 0600))
+	_, err = NewFileNonceStore(path)
+	require.Error(t, err)
+
+	// failed writes leave the stored nonce unchanged
+	store, err = NewFileNonceStore(filepath.Join(t.TempDir(), "missing", "nonces.json"))
+	require.NoError(t, err)
+	require.Error(t, store.Set(addr, 1))
+	_, ok = store.Get(addr)
+	require.False(t, ok)
+}
+
+func TestNonceManagerWithStore(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := NewAuthorizerFromPK(pk)
+	sim := newSimulatedBackend(t, auth.From)
+	store := NewMemoryNonceStore()
+
+	nm := NewNonceManagerWithStore(auth, sim, store)
+	nonce, err := nm.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), nonce)
+	stored, ok := store.Get(auth.From)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), stored)
+
+	// a restarted manager continues from the stored nonce rather than the chain
+	require.NoError(t, store.Set(auth.From, 5))
+	nm = NewNonceManagerWithStore(auth, sim, store)
+	nonce, err = nm.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(5), nonce)
+
+	// resetting trusts the chain over the store
+	require.NoError(t, nm.Reset(ctx))
+	stored, _ = store.Get(auth.From)
+	require.Equal(t, uint64(0), stored)
+	nonce, err = nm.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), nonce)
+
+	// a nonce that couldn't be claimed while the authorizer is busy isn't persisted
+	auth.Lock()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = nm.Next(cancelled)
+	auth.Unlock()
+	require.Error(t, err)
+	stored, _ = store.Get(auth.From)
+	require.Equal(t, uint64(1), stored)
+	nonce, err = nm.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1), nonce)
+}
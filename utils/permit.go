@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	// eip712DomainTypeHash is the type hash of the EIP-712 domain used by EIP-2612 tokens
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	// eip712SaltedDomainTypeHash is the type hash of the EIP-712 domain including a salt
+	eip712SaltedDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract,bytes32 salt)"))
+	// permitTypeHash is the type hash of the EIP-2612 Permit struct
+	permitTypeHash = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+)
+
+// DomainSeparator computes the EIP-712 domain separator used by most EIP-2612 tokens. Tokens
+// whose domain deviates from this, for example by omitting the version, expose the value
+// through their DOMAIN_SEPARATOR() method which should be used instead
+func DomainSeparator(name, version string, chainID *big.Int, verifyingContract common.Address) [32]byte {
+	return crypto.Keccak256Hash(
+		eip712DomainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(name)),
+		crypto.Keccak256([]byte(version)),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(verifyingContract.Bytes(), 32),
+	)
+}
+
+// DomainSeparatorWithSalt is like DomainSeparator for domains which also include a salt, which
+// some contracts use to distinguish deployments sharing the same name and address across chains
+func DomainSeparatorWithSalt(name, version string, chainID *big.Int, verifyingContract common.Address, salt [32]byte) [32]byte {
+	return crypto.Keccak256Hash(
+		eip712SaltedDomainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(name)),
+		crypto.Keccak256([]byte(version)),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(verifyingContract.Bytes(), 32),
+		salt[:],
+	)
+}
+
+// PermitDigest returns the EIP-712 digest of an EIP-2612 permit which is signed by the owner
+func PermitDigest(owner, spender common.Address, value, nonce, deadline *big.Int, domainSeparator [32]byte) [32]byte {
+	structHash := crypto.Keccak256(
+		permitTypeHash.Bytes(),
+		common.LeftPadBytes(owner.Bytes(), 32),
+		common.LeftPadBytes(spender.Bytes(), 32),
+		common.LeftPadBytes(value.Bytes(), 32),
+		common.LeftPadBytes(nonce.Bytes(), 32),
+		common.LeftPadBytes(deadline.Bytes(), 32),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator[:], structHash)
+}
+
+// SignPermit signs an EIP-2612 permit allowing spender to transfer value tokens on behalf of
+// the authorizer without an approval transaction. The nonce is the current value of the token's
+// nonces(owner) method, and the domain separator that of its DOMAIN_SEPARATOR() method. The
+// returned signature components are passed directly to the token's permit method, with v
+// being 27 or 28. ErrSignerNotSupported is returned for authorizers unable to sign digests
+func SignPermit(
+	auth *Authorizer,
+	token, spender common.Address,
+	value, nonce, deadline *big.Int,
+	domainSeparator [32]byte,
+) (v uint8, r, s [32]byte, err error) {
+	digest := PermitDigest(auth.From, spender, value, nonce, deadline, domainSeparator)
+	sig, err := auth.sign(digest[:])
+	if err != nil {
+		return 0, r, s, errors.Wrapf(err, "sign permit for %s", token)
+	}
+	copy(r[:], sig[0:32])
+	copy(s[:], sig[32:64])
+	return sig[64] + 27, r, s, nil
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignPermit(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	// matches DOMAIN_SEPARATOR() of the USDC contract on mainnet
+	domainSeparator := DomainSeparator("USD Coin", "2", big.NewInt(1), usdc)
+	require.Equal(t, common.HexToHash("0x06c37168a7db5138defc7866392bb87a741f9b3d104deb5094588ce041cae335"), common.Hash(domainSeparator))
+	require.Equal(t, common.HexToHash("0x6e71edae12b1b97f4d1f60370fef10105fa2faae0126114a169c64845d6126c9"), permitTypeHash)
+
+	auth, err := NewAuthorizerFromHex("0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	require.NoError(t, err)
+	spender := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	value, nonce, deadline := big.NewInt(1000000), big.NewInt(0), big.NewInt(1700000000)
+	digest := PermitDigest(auth.From, spender, value, nonce, deadline, domainSeparator)
+	require.Equal(t, common.HexToHash("0x3368ca109ea474392bce8aa2b852e284f41f4b066c353e2abaf41c9b7ccaf554"), common.Hash(digest))
+
+	v, r, s, err := SignPermit(auth, usdc, spender, value, nonce, deadline, domainSeparator)
+	require.NoError(t, err)
+	require.Contains(t, []uint8{27, 28}, v)
+	sig := append(append(r[:], s[:]...), v-27)
+	pub, err := crypto.SigToPub(digest[:], sig)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, crypto.PubkeyToAddress(*pub))
+
+	_, _, _, err = SignPermit(&Authorizer{TransactOpts: auth.TransactOpts}, usdc, spender, value, nonce, deadline, domainSeparator)
+	require.ErrorIs(t, err, ErrSignerNotSupported)
+}
+
+func TestDomainSeparator(t *testing.T) {
+	dai := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	// matches DOMAIN_SEPARATOR() of the DAI contract on mainnet
+	require.Equal(t,
+		common.HexToHash("0xdbb8cf42e1ecb028be3f3dbc922e1d878b963f411dc388ced501601c60f7c6f7"),
+		common.Hash(DomainSeparator("Dai Stablecoin", "1", big.NewInt(1), dai)),
+	)
+	salt := common.BigToHash(big.NewInt(1))
+	require.Equal(t,
+		common.HexToHash("0x1f601ecc4f9a294cb45ed699db3b2dd9510990d079aad20587718e9f2a0c1fd4"),
+		common.Hash(DomainSeparatorWithSalt("Dai Stablecoin", "1", big.NewInt(1), dai, salt)),
+	)
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AuthorizerPool rotates across multiple authorizers allowing for transactions
+// to be sent in parallel from different accounts, avoiding the nonce serialization
+// that comes with sending every transaction from a single account
+type AuthorizerPool struct {
+	auths []*Authorizer
+	// free holds the indices of authorizers which are not in use, and as
+	// released authorizers are placed at the back they are handed out round-robin
+	free chan int
+}
+
+// NewAuthorizerPool returns a pool handing out the given authorizers
+func NewAuthorizerPool(auths ...*Authorizer) *AuthorizerPool {
+	free := make(chan int, len(auths))
+	for i := range auths {
+		free <- i
+	}
+	return &AuthorizerPool{auths: auths, free: free}
+}
+
+// Acquire returns the next free authorizer, blocking until one is available or the
+// context is cancelled. The returned release function must be called once the caller
+// is done with the authorizer to return it to the pool, and is safe to call more than once
+func (p *AuthorizerPool) Acquire(ctx context.Context) (*Authorizer, func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case i := <-p.free:
+		var once sync.Once
+		return p.auths[i], func() {
+			once.Do(func() { p.free <- i })
+		}, nil
+	}
+}
+
+// Len returns the number of authorizers in the pool
+func (p *AuthorizerPool) Len() int {
+	return len(p.auths)
+}
+
+// Addresses returns the addresses of every authorizer in the pool
+func (p *AuthorizerPool) Addresses() []common.Address {
+	addrs := make([]common.Address, 0, len(p.auths))
+	for _, auth := range p.auths {
+		addrs = append(addrs, auth.From)
+	}
+	return addrs
+}
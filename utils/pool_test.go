@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizerPool(t *testing.T) {
+	var auths []*Authorizer
+	for i := 0; i < 2; i++ {
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		auths = append(auths, NewAuthorizerFromPK(pk))
+	}
+	pool := NewAuthorizerPool(auths...)
+	require.Equal(t, 2, pool.Len())
+	require.Equal(t, []common.Address{auths[0].From, auths[1].From}, pool.Addresses())
+
+	first, releaseFirst, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	second, releaseSecond, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, auths[0], first)
+	require.Equal(t, auths[1], second)
+
+	// all authorizers are in use so acquisition should block until the context expires
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, _, err = pool.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	releaseFirst()
+	releaseFirst()
+	releaseSecond()
+	next, release, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, auths[0], next)
+	release()
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+var (
+	// eip1967ImplementationSlot is the storage slot holding the implementation of EIP-1967 proxies,
+	// keccak256("eip1967.proxy.implementation") - 1
+	eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// eip1822ProxiableSlot is the storage slot holding the implementation of EIP-1822 proxies,
+	// keccak256("PROXIABLE")
+	eip1822ProxiableSlot = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+	// eip1967BeaconSlot is the storage slot holding the beacon of EIP-1967 beacon proxies,
+	// keccak256("eip1967.proxy.beacon") - 1
+	eip1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	// implementationSelector is the selector of implementation(), which beacons return their implementation from
+	implementationSelector = []byte{0x5c, 0x60, 0xda, 0x1b}
+)
+
+// storageReader is implemented by backends able to read contract storage, such as
+// *ethclient.Client and the simulated backend
+type storageReader interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// ResolveImplementation returns the implementation of the upgradeable proxy at proxy, whose ABI is
+// the one to call it with, such as when creating a Contract or registering it with an ABIRegistry.
+// The EIP-1967 implementation slot is read first, falling back to the EIP-1822 slot and then the
+// EIP-1967 beacon slot, whose beacon is called for the implementation. An error wrapping
+// ErrNotProxy is returned if every slot is empty. This requires a backend implementing StorageAt
+// such as *ethclient.Client
+func ResolveImplementation(ctx context.Context, bc Blockchain, proxy common.Address) (common.Address, error) {
+	reader, ok := bc.(storageReader)
+	if !ok {
+		return common.Address{}, errors.New("backend does not support reading storage")
+	}
+	for _, slot := range []common.Hash{eip1967ImplementationSlot, eip1822ProxiableSlot} {
+		impl, err := storageAddress(ctx, reader, proxy, slot)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if impl != (common.Address{}) {
+			return impl, nil
+		}
+	}
+	beacon, err := storageAddress(ctx, reader, proxy, eip1967BeaconSlot)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if beacon == (common.Address{}) {
+		return common.Address{}, errors.Wrapf(ErrNotProxy, "address %s", proxy.Hex())
+	}
+	out, err := bc.CallContract(ctx, ethereum.CallMsg{To: &beacon, Data: implementationSelector}, nil)
+	if err != nil {
+		return common.Address{}, errors.Wrapf(err, "implementation of beacon %s", beacon.Hex())
+	}
+	if len(out) != 32 {
+		return common.Address{}, errors.Errorf("beacon %s returned %d bytes for its implementation", beacon.Hex(), len(out))
+	}
+	return common.BytesToAddress(out), nil
+}
+
+// storageAddress reads the address stored in slot of account
+func storageAddress(ctx context.Context, reader storageReader, account common.Address, slot common.Hash) (common.Address, error) {
+	value, err := reader.StorageAt(ctx, account, slot, nil)
+	if err != nil {
+		return common.Address{}, errors.Wrapf(err, "storage slot %s of %s", slot.Hex(), account.Hex())
+	}
+	return common.BytesToAddress(value), nil
+}
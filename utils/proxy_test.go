@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyBackend serves the storage of contracts and the implementation of beacons
+type proxyBackend struct {
+	Blockchain
+	storage map[common.Address]map[common.Hash]common.Hash
+	beacons map[common.Address]common.Address
+}
+
+func (b *proxyBackend) StorageAt(_ context.Context, account common.Address, key common.Hash, _ *big.Int) ([]byte, error) {
+	value := b.storage[account][key]
+	return value[:], nil
+}
+
+func (b *proxyBackend) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	impl, ok := b.beacons[*msg.To]
+	if !ok || !bytes.Equal(msg.Data, implementationSelector) {
+		return nil, errors.New("execution reverted")
+	}
+	return common.LeftPadBytes(impl.Bytes(), 32), nil
+}
+
+func TestResolveImplementation(t *testing.T) {
+	impl := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	beacon := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	transparent := common.HexToAddress("0x3")
+	uups := common.HexToAddress("0x4")
+	beaconProxy := common.HexToAddress("0x5")
+	broken := common.HexToAddress("0x6")
+	backend := &proxyBackend{
+		storage: map[common.Address]map[common.Hash]common.Hash{
+			transparent: {eip1967ImplementationSlot: common.BytesToHash(impl.Bytes())},
+			uups:        {eip1822ProxiableSlot: common.BytesToHash(impl.Bytes())},
+			beaconProxy: {eip1967BeaconSlot: common.BytesToHash(beacon.Bytes())},
+			broken:      {eip1967BeaconSlot: common.BytesToHash(impl.Bytes())},
+		},
+		beacons: map[common.Address]common.Address{beacon: impl},
+	}
+	ctx := context.Background()
+	for _, proxy := range []common.Address{transparent, uups, beaconProxy} {
+		got, err := ResolveImplementation(ctx, backend, proxy)
+		require.NoError(t, err)
+		require.Equal(t, impl, got, proxy.Hex())
+	}
+	_, err := ResolveImplementation(ctx, backend, impl)
+	require.True(t, errors.Is(err, ErrNotProxy))
+	// the beacon slot doesn't point to a beacon
+	_, err = ResolveImplementation(ctx, backend, broken)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrNotProxy))
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Quoter quotes swaps on a single venue, such as a uniswap router, a curve pool or a balancer pool
+type Quoter interface {
+	// Quote returns the amount of tokenOut received when swapping amountIn of tokenIn
+	Quote(ctx context.Context, tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error)
+}
+
+// Quote is the quote of the venue offering the most output for a swap
+type Quote struct {
+	// Quoter is the venue the quote was made by
+	Quoter Quoter
+	// Index is the position of Quoter in the quoters given to BestQuote
+	Index int
+	// AmountOut is the amount of the output token received for the swap
+	AmountOut *big.Int
+}
+
+// BestQuote quotes swapping amountIn of tokenIn for tokenOut with each of quoters concurrently,
+// returning the quote with the highest output and preferring earlier quoters on ties. Quoters
+// failing, such as venues without a pool for the pair, are skipped, and an error wrapping ErrNoQuote
+// with the failure of each quoter is only returned if none succeed
+func BestQuote(ctx context.Context, quoters []Quoter, tokenIn, tokenOut common.Address, amountIn *big.Int) (*Quote, error) {
+	amounts := make([]*big.Int, len(quoters))
+	errs := make([]error, len(quoters))
+	var wg sync.WaitGroup
+	for i, quoter := range quoters {
+		wg.Add(1)
+		go func(i int, quoter Quoter) {
+			defer wg.Done()
+			amounts[i], errs[i] = quoter.Quote(ctx, tokenIn, tokenOut, amountIn)
+		}(i, quoter)
+	}
+	wg.Wait()
+	var best *Quote
+	var failures []string
+	for i, amount := range amounts {
+		if errs[i] != nil {
+			failures = append(failures, errors.Wrapf(errs[i], "quoter %d", i).Error())
+			continue
+		}
+		if best == nil || amount.Cmp(best.AmountOut) > 0 {
+			best = &Quote{Quoter: quoters[i], Index: i, AmountOut: amount}
+		}
+	}
+	if best == nil {
+		if len(failures) == 0 {
+			return nil, errors.Wrap(ErrNoQuote, "no quoters")
+		}
+		return nil, errors.Wrap(ErrNoQuote, strings.Join(failures, "; "))
+	}
+	return best, nil
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// staticQuoter quotes a fixed output amount, or fails with err
+type staticQuoter struct {
+	out int64
+	err error
+}
+
+func (q staticQuoter) Quote(context.Context, common.Address, common.Address, *big.Int) (*big.Int, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return big.NewInt(q.out), nil
+}
+
+func TestBestQuote(t *testing.T) {
+	ctx := context.Background()
+	tokenIn := common.HexToAddress("0x1")
+	tokenOut := common.HexToAddress("0x2")
+	noPool := errors.New("execution reverted")
+	for _, tt := range []struct {
+		name    string
+		quoters []Quoter
+		index   int
+		out     string
+	}{
+		{"highest output", []Quoter{staticQuoter{out: 900}, staticQuoter{out: 990}, staticQuoter{out: 950}}, 1, "990"},
+		{"failures skipped", []Quoter{staticQuoter{err: noPool}, staticQuoter{out: 950}, staticQuoter{err: noPool}}, 1, "950"},
+		{"ties prefer earlier quoters", []Quoter{staticQuoter{out: 950}, staticQuoter{out: 990}, staticQuoter{out: 990}}, 1, "990"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := BestQuote(ctx, tt.quoters, tokenIn, tokenOut, big.NewInt(1000))
+			require.NoError(t, err)
+			require.Equal(t, tt.index, quote.Index)
+			require.Equal(t, tt.quoters[tt.index], quote.Quoter)
+			require.Equal(t, tt.out, quote.AmountOut.String())
+		})
+	}
+
+	_, err := BestQuote(ctx, []Quoter{staticQuoter{err: noPool}, staticQuoter{err: errors.New("header not found")}}, tokenIn, tokenOut, big.NewInt(1000))
+	require.True(t, errors.Is(err, ErrNoQuote))
+	require.Contains(t, err.Error(), "quoter 1: header not found")
+	_, err = BestQuote(ctx, nil, tokenIn, tokenOut, big.NewInt(1000))
+	require.True(t, errors.Is(err, ErrNoQuote))
+}
@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// DefaultMethodWeights returns the weights used by a RateLimitedClient unless others are given,
+// keyed by JSON-RPC method. Methods which aren't listed have a weight of one
+func DefaultMethodWeights() map[string]int {
+	return map[string]int{
+		"eth_getLogs":     10,
+		"eth_estimateGas": 2,
+		"eth_call":        2,
+	}
+}
+
+// RateLimitedClient implements Blockchain on top of another Blockchain, such as an ethclient.Client
+// or FailoverClient, waiting for the limiter before each call so that requests stay within the
+// limits of throttled endpoints. Each call consumes as many tokens as the weight of its JSON-RPC
+// method, which is capped by the burst of the limiter so that heavy calls can always proceed
+type RateLimitedClient struct {
+	bc      Blockchain
+	limiter *rate.Limiter
+	weights map[string]int
+}
+
+// NewRateLimitedClient returns a client making calls to bc at the rate allowed by limiter. The weights
+// are keyed by JSON-RPC method such as eth_getLogs, with nil using DefaultMethodWeights
+func NewRateLimitedClient(bc Blockchain, limiter *rate.Limiter, weights map[string]int) *RateLimitedClient {
+	if weights == nil {
+		weights = DefaultMethodWeights()
+	}
+	return &RateLimitedClient{bc: bc, limiter: limiter, weights: weights}
+}
+
+// wait blocks until the limiter allows a call to method, returning the context error if ctx is
+// done first or the limiter determines the wait would exceed the deadline of ctx
+func (rc *RateLimitedClient) wait(ctx context.Context, method string) error {
+	n, ok := rc.weights[method]
+	if !ok {
+		n = 1
+	}
+	if burst := rc.limiter.Burst(); n > burst {
+		n = burst
+	}
+	if err := rc.limiter.WaitN(ctx, n); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, ok := ctx.Deadline(); ok {
+			// the limiter returns early when the wait would outlast the deadline
+			return context.DeadlineExceeded
+		}
+		return errors.Wrapf(err, "rate limit %s", method)
+	}
+	return nil
+}
+
+// CodeAt implements Blockchain
+func (rc *RateLimitedClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if err := rc.wait(ctx, "eth_getCode"); err != nil {
+		return nil, err
+	}
+	return rc.bc.CodeAt(ctx, contract, blockNumber)
+}
+
+// CallContract implements Blockchain
+func (rc *RateLimitedClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := rc.wait(ctx, "eth_call"); err != nil {
+		return nil, err
+	}
+	return rc.bc.CallContract(ctx, call, blockNumber)
+}
+
+// PendingCodeAt implements Blockchain
+func (rc *RateLimitedClient) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
+	if err := rc.wait(ctx, "eth_getCode"); err != nil {
+		return nil, err
+	}
+	return rc.bc.PendingCodeAt(ctx, contract)
+}
+
+// PendingCallContract implements Blockchain
+func (rc *RateLimitedClient) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	if err := rc.wait(ctx, "eth_call"); err != nil {
+		return nil, err
+	}
+	return rc.bc.PendingCallContract(ctx, call)
+}
+
+// PendingNonceAt implements Blockchain
+func (rc *RateLimitedClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if err := rc.wait(ctx, "eth_getTransactionCount"); err != nil {
+		return 0, err
+	}
+	return rc.bc.PendingNonceAt(ctx, account)
+}
+
+// SuggestGasPrice implements Blockchain
+func (rc *RateLimitedClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	if err := rc.wait(ctx, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return rc.bc.SuggestGasPrice(ctx)
+}
+
+// SuggestGasTipCap implements Blockchain
+func (rc *RateLimitedClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	if err := rc.wait(ctx, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return rc.bc.SuggestGasTipCap(ctx)
+}
+
+// EstimateGas implements Blockchain
+func (rc *RateLimitedClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	if err := rc.wait(ctx, "eth_estimateGas"); err != nil {
+		return 0, err
+	}
+	return rc.bc.EstimateGas(ctx, call)
+}
+
+// SendTransaction implements Blockchain
+func (rc *RateLimitedClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := rc.wait(ctx, "eth_sendRawTransaction"); err != nil {
+		return err
+	}
+	return rc.bc.SendTransaction(ctx, tx)
+}
+
+// FilterLogs implements Blockchain
+func (rc *RateLimitedClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if err := rc.wait(ctx, "eth_getLogs"); err != nil {
+		return nil, err
+	}
+	return rc.bc.FilterLogs(ctx, query)
+}
+
+// SubscribeFilterLogs implements Blockchain. Only creating the subscription is rate limited,
+// not the logs it delivers
+func (rc *RateLimitedClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	if err := rc.wait(ctx, "eth_subscribe"); err != nil {
+		return nil, err
+	}
+	return rc.bc.SubscribeFilterLogs(ctx, query, ch)
+}
+
+// TransactionReceipt implements Blockchain
+func (rc *RateLimitedClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if err := rc.wait(ctx, "eth_getTransactionReceipt"); err != nil {
+		return nil, err
+	}
+	return rc.bc.TransactionReceipt(ctx, txHash)
+}
+
+// SubscribeNewHead implements Blockchain. Only creating the subscription is rate limited,
+// not the headers it delivers
+func (rc *RateLimitedClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	if err := rc.wait(ctx, "eth_subscribe"); err != nil {
+		return nil, err
+	}
+	return rc.bc.SubscribeNewHead(ctx, ch)
+}
+
+// TransactionByHash implements Blockchain
+func (rc *RateLimitedClient) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	if err := rc.wait(ctx, "eth_getTransactionByHash"); err != nil {
+		return nil, false, err
+	}
+	return rc.bc.TransactionByHash(ctx, txHash)
+}
+
+// BlockNumber implements Blockchain
+func (rc *RateLimitedClient) BlockNumber(ctx context.Context) (uint64, error) {
+	if err := rc.wait(ctx, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return rc.bc.BlockNumber(ctx)
+}
+
+// HeaderByNumber implements Blockchain
+func (rc *RateLimitedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := rc.wait(ctx, "eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	return rc.bc.HeaderByNumber(ctx, number)
+}
+
+// NonceAt implements the optional NonceAt of backends such as *ethclient.Client, like the optional
+// methods below, returning an error wrapping ErrBackendUnsupported if the wrapped backend lacks it
+func (rc *RateLimitedClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	reader, ok := rc.bc.(nonceReader)
+	if !ok {
+		return 0, errors.Wrap(ErrBackendUnsupported, "nonce at")
+	}
+	if err := rc.wait(ctx, "eth_getTransactionCount"); err != nil {
+		return 0, err
+	}
+	return reader.NonceAt(ctx, account, blockNumber)
+}
+
+// BalanceAt implements the optional BalanceAt of backends
+func (rc *RateLimitedClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	reader, ok := rc.bc.(balanceReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "balance at")
+	}
+	if err := rc.wait(ctx, "eth_getBalance"); err != nil {
+		return nil, err
+	}
+	return reader.BalanceAt(ctx, account, blockNumber)
+}
+
+// PendingBalanceAt implements the optional PendingBalanceAt of backends
+func (rc *RateLimitedClient) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	reader, ok := rc.bc.(pendingBalanceReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "pending balance at")
+	}
+	if err := rc.wait(ctx, "eth_getBalance"); err != nil {
+		return nil, err
+	}
+	return reader.PendingBalanceAt(ctx, account)
+}
+
+// ChainID implements the optional ChainID of backends
+func (rc *RateLimitedClient) ChainID(ctx context.Context) (*big.Int, error) {
+	reader, ok := rc.bc.(chainIDReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "chain id")
+	}
+	if err := rc.wait(ctx, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return reader.ChainID(ctx)
+}
+
+// StorageAt implements the optional StorageAt of backends
+func (rc *RateLimitedClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	reader, ok := rc.bc.(storageReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "storage at")
+	}
+	if err := rc.wait(ctx, "eth_getStorageAt"); err != nil {
+		return nil, err
+	}
+	return reader.StorageAt(ctx, account, key, blockNumber)
+}
+
+// FeeHistory implements the optional FeeHistory of backends
+func (rc *RateLimitedClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	reader, ok := rc.bc.(FeeHistoryReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "fee history")
+	}
+	if err := rc.wait(ctx, "eth_feeHistory"); err != nil {
+		return nil, err
+	}
+	return reader.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
+// CallContext implements the optional CallContext of backends wrapping an *rpc.Client, weighing
+// the call by its method
+func (rc *RateLimitedClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	caller, ok := rc.bc.(rpcCaller)
+	if !ok {
+		return errors.Wrap(ErrBackendUnsupported, method)
+	}
+	if err := rc.wait(ctx, method); err != nil {
+		return err
+	}
+	return caller.CallContext(ctx, result, method, args...)
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitedClient(t *testing.T) {
+	sim := newSimulatedBackend(t)
+	// composes with the failover client as both implement Blockchain
+	var _ Blockchain = NewRateLimitedClient(newFailoverClient([]Blockchain{sim}), rate.NewLimiter(rate.Inf, 1), nil)
+
+	rc := NewRateLimitedClient(sim, rate.NewLimiter(rate.Every(time.Hour), 1), nil)
+	_, err := rc.BlockNumber(context.Background())
+	require.NoError(t, err)
+	// the next token is an hour away
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err = rc.BlockNumber(ctx)
+	require.Equal(t, context.DeadlineExceeded, err)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = rc.BlockNumber(ctx)
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestRateLimitedClientWeights(t *testing.T) {
+	sim := newSimulatedBackend(t)
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 10)
+	rc := NewRateLimitedClient(sim, limiter, map[string]int{"eth_blockNumber": 4, "eth_getBlockByNumber": 20})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err := rc.BlockNumber(ctx)
+	require.NoError(t, err)
+	_, err = rc.BlockNumber(ctx)
+	require.NoError(t, err)
+	// only 2 of the 10 tokens remain
+	_, err = rc.BlockNumber(ctx)
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	// weights larger than the burst are capped so the call can proceed once the bucket is full
+	rc = NewRateLimitedClient(sim, rate.NewLimiter(rate.Every(time.Hour), 10), map[string]int{"eth_getBlockByNumber": 20})
+	_, err = rc.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+}
+
+func TestRateLimitedClientOptionalMethods(t *testing.T) {
+	sim := newSimulatedBackend(t)
+	rc := NewRateLimitedClient(sim, rate.NewLimiter(rate.Every(time.Hour), 1), nil)
+	requireOptionalMethods(t, rc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	// methods the backend lacks are reported without waiting for the limiter
+	_, err := rc.ChainID(ctx)
+	require.True(t, errors.Is(err, ErrBackendUnsupported))
+	_, err = rc.NonceAt(ctx, common.Address{1}, nil)
+	require.NoError(t, err)
+	_, err = rc.BalanceAt(ctx, common.Address{1}, nil)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
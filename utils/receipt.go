@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// TxResult is the outcome of a mined transaction
+type TxResult struct {
+	Success bool
+	GasUsed uint64
+	// EffectiveGasPrice is the price per gas paid, which for EIP-1559 transactions
+	// is the base fee of the block plus the priority fee, capped by the max fee
+	EffectiveGasPrice *big.Int
+	// OutOfGas is true if the transaction failed having used its entire gas limit
+	OutOfGas bool
+	// RevertReason is the decoded revert reason of failed transactions, and is
+	// empty if the transaction ran out of gas or reverted without a reason
+	RevertReason string
+}
+
+// ClassifyReceipt determines the outcome of tx from its receipt. The effective gas price is
+// derived from the base fee of the block the transaction was mined in, and for failed
+// transactions the revert reason is recovered by replaying the transaction as a call at that block
+func ClassifyReceipt(ctx context.Context, bc Blockchain, tx *types.Transaction, receipt *types.Receipt) (*TxResult, error) {
+	result := &TxResult{
+		Success: receipt.Status == types.ReceiptStatusSuccessful,
+		GasUsed: receipt.GasUsed,
+	}
+	price, err := effectiveGasPrice(ctx, bc, tx, receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	result.EffectiveGasPrice = price
+	if result.Success {
+		return result, nil
+	}
+	result.OutOfGas = receipt.GasUsed == tx.Gas()
+	if reason, ok := replayRevertReason(ctx, bc, tx, receipt.BlockNumber); ok {
+		result.RevertReason = reason
+	}
+	return result, nil
+}
+
+// effectiveGasPrice returns the price per gas paid by tx when mined in the given block
+func effectiveGasPrice(ctx context.Context, bc Blockchain, tx *types.Transaction, blockNumber *big.Int) (*big.Int, error) {
+	if tx.Type() != types.DynamicFeeTxType {
+		return tx.GasPrice(), nil
+	}
+	header, err := bc.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "header by number")
+	}
+	return priceAtBaseFee(tx, header.BaseFee), nil
+}
+
+// priceAtBaseFee returns the price per gas paid by tx in a block with the given base fee, which is
+// nil for blocks before EIP-1559
+func priceAtBaseFee(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if tx.Type() != types.DynamicFeeTxType || baseFee == nil {
+		// the gas price of dynamic fee transactions is their fee cap
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		price.Set(tx.GasFeeCap())
+	}
+	return price
+}
+
+// GasReport breaks down what a mined transaction paid for gas. BaseFee is nil for transactions mined
+// before EIP-1559, in which case the entire gas price is reported as the priority fee
+type GasReport struct {
+	// BaseFee is the base fee per gas of the block, which is burnt
+	BaseFee *big.Int
+	// PriorityFee is the tip per gas paid to the block producer
+	PriorityFee *big.Int
+	// EffectiveGasPrice is the price per gas paid, the base fee plus the priority fee
+	EffectiveGasPrice *big.Int
+	GasUsed           uint64
+	// TotalCostWei is the effective gas price multiplied by the gas used
+	TotalCostWei *big.Int
+}
+
+// GasBreakdown reports what the transaction of receipt paid for gas, reading the base fee of the
+// block it was mined in along with the transaction itself. Legacy and access list transactions
+// pay their gas price, of which anything above the base fee is the priority fee, while EIP-1559
+// transactions pay the base fee plus their tip capped by their max fee. Fees charged outside of
+// the EVM, such as the L1 data fee of rollups, aren't included
+func GasBreakdown(ctx context.Context, bc Blockchain, receipt *types.Receipt) (*GasReport, error) {
+	tx, _, err := bc.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "transaction by hash")
+	}
+	header, err := bc.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "header by number")
+	}
+	report := &GasReport{GasUsed: receipt.GasUsed, EffectiveGasPrice: priceAtBaseFee(tx, header.BaseFee)}
+	report.PriorityFee = new(big.Int).Set(report.EffectiveGasPrice)
+	if header.BaseFee != nil {
+		report.BaseFee = new(big.Int).Set(header.BaseFee)
+		report.PriorityFee.Sub(report.PriorityFee, header.BaseFee)
+	}
+	report.TotalCostWei = new(big.Int).Mul(report.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	return report, nil
+}
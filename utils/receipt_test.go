@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// reverterCode deploys a contract reverting with Error("nope") whenever it is called
+	reverterCode = "0x6070600c60003960706000f36064600c60003960646000fd08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000"
+	// invalidCode deploys a contract executing the INVALID opcode, consuming all gas, whenever it is called
+	invalidCode = "0x6001600c60003960016000f3fe"
+)
+
+func TestClassifyReceipt(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	ctx := context.Background()
+	reverter := deployCode(t, sim, auth, reverterCode)
+	invalid := deployCode(t, sim, auth, invalidCode)
+
+	head, err := sim.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	tip := big.NewInt(1e9)
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	send := func(to common.Address) (*types.Transaction, *types.Receipt) {
+		nonce, err := sim.PendingNonceAt(ctx, auth.From)
+		require.NoError(t, err)
+		tx, err := auth.Signer(auth.From, types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(1337),
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       100000,
+			To:        &to,
+			Value:     big.NewInt(0),
+		}))
+		require.NoError(t, err)
+		require.NoError(t, sim.SendTransaction(ctx, tx))
+		sim.Commit()
+		receipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+		require.NoError(t, err)
+		return tx, receipt
+	}
+
+	tx, receipt := send(auth.From)
+	result, err := ClassifyReceipt(ctx, sim, tx, receipt)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	require.Equal(t, uint64(21000), result.GasUsed)
+	header, err := sim.HeaderByNumber(ctx, receipt.BlockNumber)
+	require.NoError(t, err)
+	require.Equal(t, new(big.Int).Add(header.BaseFee, tip).String(), result.EffectiveGasPrice.String())
+
+	tx, receipt = send(reverter)
+	result, err = ClassifyReceipt(ctx, sim, tx, receipt)
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	require.False(t, result.OutOfGas)
+	require.Equal(t, "nope", result.RevertReason)
+
+	tx, receipt = send(invalid)
+	result, err = ClassifyReceipt(ctx, sim, tx, receipt)
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	require.True(t, result.OutOfGas)
+	require.Empty(t, result.RevertReason)
+}
+
+func TestGasBreakdown(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	ctx := context.Background()
+	head, err := sim.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	tip := big.NewInt(1e9)
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	gasPrice := new(big.Int).Add(feeCap, tip)
+
+	for _, tt := range []struct {
+		name string
+		tx   types.TxData
+		// effective returns the expected price per gas given the base fee of the block
+		effective func(baseFee *big.Int) *big.Int
+	}{
+		{
+			name:      "dynamic fee",
+			tx:        &types.DynamicFeeTx{ChainID: big.NewInt(1337), GasTipCap: tip, GasFeeCap: feeCap, Gas: 21000, To: &auth.From},
+			effective: func(baseFee *big.Int) *big.Int { return new(big.Int).Add(baseFee, tip) },
+		},
+		{
+			name:      "legacy",
+			tx:        &types.LegacyTx{GasPrice: gasPrice, Gas: 21000, To: &auth.From},
+			effective: func(*big.Int) *big.Int { return gasPrice },
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce, err := sim.PendingNonceAt(ctx, auth.From)
+			require.NoError(t, err)
+			switch inner := tt.tx.(type) {
+			case *types.DynamicFeeTx:
+				inner.Nonce = nonce
+			case *types.LegacyTx:
+				inner.Nonce = nonce
+			}
+			before, err := sim.BalanceAt(ctx, auth.From, nil)
+			require.NoError(t, err)
+			tx, err := auth.Signer(auth.From, types.NewTx(tt.tx))
+			require.NoError(t, err)
+			require.NoError(t, sim.SendTransaction(ctx, tx))
+			sim.Commit()
+			receipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+			require.NoError(t, err)
+			header, err := sim.HeaderByNumber(ctx, receipt.BlockNumber)
+			require.NoError(t, err)
+
+			report, err := GasBreakdown(ctx, sim, receipt)
+			require.NoError(t, err)
+			effective := tt.effective(header.BaseFee)
+			require.Equal(t, uint64(21000), report.GasUsed)
+			require.Equal(t, header.BaseFee.String(), report.BaseFee.String())
+			require.Equal(t, effective.String(), report.EffectiveGasPrice.String())
+			require.Equal(t, new(big.Int).Sub(effective, header.BaseFee).String(), report.PriorityFee.String())
+			// the transfer is to self so the balance only decreased by the cost of gas
+			after, err := sim.BalanceAt(ctx, auth.From, nil)
+			require.NoError(t, err)
+			require.Equal(t, new(big.Int).Sub(before, after).String(), report.TotalCostWei.String())
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// ABIRegistry maps contract addresses to their ABIs, allowing a single loop to decode logs emitted
+// by many different contracts, such as those returned by FetchLogs for a query over several
+// addresses. It is safe for concurrent use
+type ABIRegistry struct {
+	mx sync.RWMutex
+	// events are the events of each registered contract keyed by their signature
+	events map[common.Address]map[common.Hash]abi.Event
+}
+
+// NewABIRegistry returns an empty registry
+func NewABIRegistry() *ABIRegistry {
+	return &ABIRegistry{events: make(map[common.Address]map[common.Hash]abi.Event)}
+}
+
+// Register associates parsed with the contract at addr, replacing any ABI registered for it before.
+// Anonymous events have no signature topic, so they can't be matched and are ignored
+func (r *ABIRegistry) Register(addr common.Address, parsed abi.ABI) {
+	events := make(map[common.Hash]abi.Event, len(parsed.Events))
+	for _, event := range parsed.Events {
+		if !event.Anonymous {
+			events[event.ID] = event
+		}
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.events[addr] = events
+}
+
+// DecodeLog decodes log using the ABI registered for the contract which emitted it, returning the
+// contract address along with the name and arguments of the event as decoded by Contract.UnpackLog.
+// An error wrapping ErrUnknownContract is returned if no ABI is registered for the address, and one
+// wrapping ErrUnknownEvent if its first topic matches none of the events of the ABI
+func (r *ABIRegistry) DecodeLog(log types.Log) (contract common.Address, event string, args map[string]interface{}, err error) {
+	r.mx.RLock()
+	events, ok := r.events[log.Address]
+	r.mx.RUnlock()
+	if !ok {
+		return log.Address, "", nil, errors.Wrapf(ErrUnknownContract, "address %s", log.Address.Hex())
+	}
+	if len(log.Topics) == 0 {
+		return log.Address, "", nil, errors.Wrapf(ErrUnknownEvent, "anonymous event of %s", log.Address.Hex())
+	}
+	ev, ok := events[log.Topics[0]]
+	if !ok {
+		return log.Address, "", nil, errors.Wrapf(ErrUnknownEvent, "topic %s of %s", log.Topics[0].Hex(), log.Address.Hex())
+	}
+	args, err = unpackEvent(ev, log)
+	if err != nil {
+		return log.Address, ev.Name, nil, err
+	}
+	return log.Address, ev.Name, args, nil
+}
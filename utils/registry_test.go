@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABIRegistry(t *testing.T) {
+	tokenABI, err := abi.JSON(strings.NewReader(`[
+		{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"}
+	]`))
+	require.NoError(t, err)
+	pairABI, err := abi.JSON(strings.NewReader(`[
+		{"anonymous":false,"inputs":[{"indexed":false,"name":"reserve0","type":"uint112"},{"indexed":false,"name":"reserve1","type":"uint112"}],"name":"Sync","type":"event"}
+	]`))
+	require.NoError(t, err)
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	pair := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+	registry := NewABIRegistry()
+	registry.Register(token, tokenABI)
+	registry.Register(pair, pairABI)
+
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	transfer := types.Log{
+		Address: token,
+		Topics:  []common.Hash{tokenABI.Events["Transfer"].ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.LeftPadBytes(big.NewInt(1000).Bytes(), 32),
+	}
+	sync := types.Log{
+		Address: pair,
+		Topics:  []common.Hash{crypto.Keccak256Hash([]byte("Sync(uint112,uint112)"))},
+		Data:    append(common.LeftPadBytes(big.NewInt(5).Bytes(), 32), common.LeftPadBytes(big.NewInt(7).Bytes(), 32)...),
+	}
+
+	contract, event, args, err := registry.DecodeLog(transfer)
+	require.NoError(t, err)
+	require.Equal(t, token, contract)
+	require.Equal(t, "Transfer", event)
+	require.Equal(t, from, args["from"])
+	require.Equal(t, to, args["to"])
+	require.Equal(t, "1000", args["value"].(*big.Int).String())
+
+	contract, event, args, err = registry.DecodeLog(sync)
+	require.NoError(t, err)
+	require.Equal(t, pair, contract)
+	require.Equal(t, "Sync", event)
+	require.Equal(t, "5", args["reserve0"].(*big.Int).String())
+	require.Equal(t, "7", args["reserve1"].(*big.Int).String())
+
+	// the Transfer event is only registered for the token
+	_, _, _, err = registry.DecodeLog(types.Log{Address: pair, Topics: transfer.Topics, Data: transfer.Data})
+	require.True(t, errors.Is(err, ErrUnknownEvent))
+	_, _, _, err = registry.DecodeLog(types.Log{Address: pair})
+	require.True(t, errors.Is(err, ErrUnknownEvent))
+	_, _, _, err = registry.DecodeLog(types.Log{Address: common.Address{1}, Topics: transfer.Topics, Data: transfer.Data})
+	require.True(t, errors.Is(err, ErrUnknownContract))
+}
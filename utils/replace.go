@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// MinReplacementBumpPercent is the minimum gas price increase geth requires
+// before it accepts a transaction replacing one with the same nonce
+const MinReplacementBumpPercent = 10
+
+// ReplaceUnderpriced rebuilds a pending transaction with the same nonce, recipient, value
+// and data but with its gas price bumped by bumpPercent, which is raised to at least
+// MinReplacementBumpPercent. For EIP-1559 transactions both fee caps are bumped. The
+// replacement is signed while holding the authorizer lock and then broadcast. If the
+// original transaction has already been mined ErrAlreadyMined is returned
+func ReplaceUnderpriced(ctx context.Context, bc Blockchain, auth *Authorizer, original *types.Transaction, bumpPercent int) (*types.Transaction, error) {
+	if receipt, err := bc.TransactionReceipt(ctx, original.Hash()); err == nil && receipt != nil {
+		return nil, ErrAlreadyMined
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(original.ChainId()), original)
+	if err != nil {
+		return nil, errors.Wrap(err, "sender")
+	}
+	if sender != auth.From {
+		return nil, errors.Errorf("transaction was sent by %s not %s", sender, auth.From)
+	}
+	if bumpPercent < MinReplacementBumpPercent {
+		bumpPercent = MinReplacementBumpPercent
+	}
+	var inner types.TxData
+	switch original.Type() {
+	case types.LegacyTxType:
+		inner = &types.LegacyTx{
+			Nonce:    original.Nonce(),
+			GasPrice: bumpGas(original.GasPrice(), bumpPercent),
+			Gas:      original.Gas(),
+			To:       original.To(),
+			Value:    original.Value(),
+			Data:     original.Data(),
+		}
+	case types.AccessListTxType:
+		inner = &types.AccessListTx{
+			ChainID:    original.ChainId(),
+			Nonce:      original.Nonce(),
+			GasPrice:   bumpGas(original.GasPrice(), bumpPercent),
+			Gas:        original.Gas(),
+			To:         original.To(),
+			Value:      original.Value(),
+			Data:       original.Data(),
+			AccessList: original.AccessList(),
+		}
+	case types.DynamicFeeTxType:
+		inner = &types.DynamicFeeTx{
+			ChainID:    original.ChainId(),
+			Nonce:      original.Nonce(),
+			GasTipCap:  bumpGas(original.GasTipCap(), bumpPercent),
+			GasFeeCap:  bumpGas(original.GasFeeCap(), bumpPercent),
+			Gas:        original.Gas(),
+			To:         original.To(),
+			Value:      original.Value(),
+			Data:       original.Data(),
+			AccessList: original.AccessList(),
+		}
+	default:
+		return nil, errors.Errorf("unsupported transaction type %d", original.Type())
+	}
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer auth.Unlock()
+	replacement, err := auth.signTx(types.NewTx(inner))
+	if err != nil {
+		return nil, errors.Wrap(err, "sign transaction")
+	}
+	if err := Send(ctx, bc, replacement); err != nil {
+		return nil, err
+	}
+	auth.sent(replacement)
+	return replacement, nil
+}
+
+// SpeedUpTx replaces a pending transaction with one with the same payload and its fees bumped
+// by bumpPercent, raised to at least MinReplacementBumpPercent, so that it is mined sooner.
+// This is ReplaceUnderpriced, returning ErrAlreadyMined if original has been mined
+func SpeedUpTx(ctx context.Context, bc Blockchain, auth *Authorizer, original *types.Transaction, bumpPercent int) (*types.Transaction, error) {
+	return ReplaceUnderpriced(ctx, bc, auth, original, bumpPercent)
+}
+
+// nonceReader is implemented by backends able to report the mined nonce of an account, such as *ethclient.Client
+type nonceReader interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// CancelTx cancels the pending transaction of the authorizer with the given nonce by replacing it
+// with a zero value transfer to the authorizer itself. As the pending transaction isn't known from
+// its nonce alone, the fees of the replacement are those of the authorizer, or suggested by the
+// chain when unset, bumped by bumpPercent which is raised to at least MinReplacementBumpPercent.
+// Use a larger bump if the pending transaction paid more than the current fees. ErrAlreadyMined
+// is returned if the nonce has been mined, when the backend can report it, and an error if no
+// transaction with the nonce is pending. This claims the lock and must not be called while the
+// lock is already held.
+func CancelTx(ctx context.Context, bc Blockchain, auth *Authorizer, nonce uint64, bumpPercent int) (*types.Transaction, error) {
+	if bumpPercent < MinReplacementBumpPercent {
+		bumpPercent = MinReplacementBumpPercent
+	}
+	if reader, ok := bc.(nonceReader); ok {
+		mined, err := reader.NonceAt(ctx, auth.From, nil)
+		if err != nil && !errors.Is(err, ErrBackendUnsupported) {
+			return nil, errors.Wrap(err, "nonce at")
+		}
+		if err == nil && nonce < mined {
+			return nil, ErrAlreadyMined
+		}
+	}
+	if err := auth.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer auth.Unlock()
+	pending, err := bc.PendingNonceAt(ctx, auth.From)
+	if err != nil {
+		return nil, errors.Wrap(err, "pending nonce at")
+	}
+	if nonce >= pending {
+		return nil, errors.Errorf("no pending transaction with nonce %d", nonce)
+	}
+	inner, err := auth.txData(ctx, bc, nonce, 21000, auth.From, new(big.Int), nil)
+	if err != nil {
+		return nil, err
+	}
+	switch inner := inner.(type) {
+	case *types.LegacyTx:
+		inner.GasPrice = bumpGas(inner.GasPrice, bumpPercent)
+	case *types.AccessListTx:
+		inner.GasPrice = bumpGas(inner.GasPrice, bumpPercent)
+		inner.AccessList = nil
+	case *types.DynamicFeeTx:
+		inner.GasTipCap = bumpGas(inner.GasTipCap, bumpPercent)
+		inner.GasFeeCap = bumpGas(inner.GasFeeCap, bumpPercent)
+		// an access list set for the next transaction is kept for it
+		inner.AccessList = nil
+	}
+	tx, err := auth.signTx(types.NewTx(inner))
+	if err != nil {
+		return nil, errors.Wrap(err, "sign transaction")
+	}
+	if err := Send(ctx, bc, tx); err != nil {
+		return nil, err
+	}
+	auth.sent(tx)
+	return tx, nil
+}
+
+// bumpGas increases a gas price by percent, always increasing it by at least one wei
+func bumpGas(price *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(price, big.NewInt(int64(100+percent)))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(price) <= 0 {
+		bumped.Add(price, big.NewInt(1))
+	}
+	return bumped
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpGas(t *testing.T) {
+	require.Equal(t, big.NewInt(110), bumpGas(big.NewInt(100), 10))
+	require.Equal(t, big.NewInt(125), bumpGas(big.NewInt(100), 25))
+	// small prices are always bumped by at least one wei
+	require.Equal(t, big.NewInt(2), bumpGas(big.NewInt(1), 10))
+}
+
+// recordingBackend records the transactions sent to it without broadcasting them
+type recordingBackend struct {
+	simulatedBackend
+	sent []*types.Transaction
+}
+
+func (b *recordingBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func TestCancelTx(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	original, err := auth.BuildTx(ctx, sim, to, big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.NoError(t, Send(ctx, sim, original))
+
+	bc := &recordingBackend{simulatedBackend: sim}
+	// the bump is raised to the minimum accepted by geth
+	cancel, err := CancelTx(ctx, bc, auth, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, bc.sent, 1)
+	require.Equal(t, cancel, bc.sent[0])
+	require.Equal(t, uint64(0), cancel.Nonce())
+	require.Equal(t, auth.From, *cancel.To())
+	require.Equal(t, "0", cancel.Value().String())
+	require.Empty(t, cancel.Data())
+	require.Equal(t, bumpGas(original.GasTipCap(), MinReplacementBumpPercent).String(), cancel.GasTipCap().String())
+	require.Equal(t, bumpGas(original.GasFeeCap(), MinReplacementBumpPercent).String(), cancel.GasFeeCap().String())
+
+	// only pending nonces can be cancelled
+	_, err = CancelTx(ctx, bc, auth, 1, 10)
+	require.Error(t, err)
+	sim.Commit()
+	_, err = CancelTx(ctx, bc, auth, 0, 10)
+	require.Equal(t, ErrAlreadyMined, err)
+
+	// access list transactions are bumped without carrying the access list
+	auth.TxType = TxTypeAccessList
+	auth.UseLegacyGas(big.NewInt(2e9))
+	accessList := types.AccessList{{Address: to}}
+	require.NoError(t, auth.SetAccessList(accessList))
+	original, err = auth.BuildTx(ctx, sim, to, big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.NoError(t, Send(ctx, sim, original))
+	require.NoError(t, auth.SetAccessList(accessList))
+	cancel, err = CancelTx(ctx, bc, auth, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.AccessListTxType), cancel.Type())
+	require.Equal(t, bumpGas(original.GasPrice(), MinReplacementBumpPercent).String(), cancel.GasPrice().String())
+	require.Empty(t, cancel.AccessList())
+}
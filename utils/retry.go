@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultRetryBaseDelay is the delay before the first retry made by WithRetry
+	DefaultRetryBaseDelay = 250 * time.Millisecond
+	// DefaultRetryMaxDelay caps the exponential backoff between retries made by WithRetry
+	DefaultRetryMaxDelay = 10 * time.Second
+)
+
+// transientErrorPatterns are lower cased substrings of errors caused by overloaded or unreachable
+// nodes, and nodes lagging behind the head of the chain
+var transientErrorPatterns = []string{
+	"header not found",
+	"timeout",
+	"timed out",
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"unexpected eof",
+	"too many requests",
+	"rate limit",
+	"bad gateway",
+	"service unavailable",
+	"gateway timeout",
+}
+
+// retryOptions configures the backoff of WithRetry
+type retryOptions struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// RetryOption configures the backoff of WithRetry
+type RetryOption func(*retryOptions)
+
+// WithRetryBaseDelay sets the delay before the first retry, which doubles after every attempt.
+// The default is DefaultRetryBaseDelay
+func WithRetryBaseDelay(delay time.Duration) RetryOption {
+	return func(o *retryOptions) { o.baseDelay = delay }
+}
+
+// WithRetryMaxDelay caps the delay between retries, by default DefaultRetryMaxDelay
+func WithRetryMaxDelay(delay time.Duration) RetryOption {
+	return func(o *retryOptions) { o.maxDelay = delay }
+}
+
+// IsTransient returns true if err is likely to succeed when retried, such as timeouts, 5xx and 429
+// responses, rate limiting and nodes which haven't imported the requested block yet. Errors returned
+// by a responsive node about the call itself, such as reverts or a nonce too low, aren't transient,
+// and neither are context errors as the caller gave up
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if ClassifySendError(err) != SendErrorUnknown {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcLimitExceeded {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range transientErrorPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRetry calls fn until it succeeds, returns an error which isn't transient according to
+// IsTransient, or has been called maxAttempts times, returning the last error. Retries back off
+// exponentially with jitter, and the context error is returned if ctx is done while waiting.
+// A maxAttempts below one calls fn once
+func WithRetry(ctx context.Context, maxAttempts int, fn func() error, opts ...RetryOption) error {
+	o := retryOptions{baseDelay: DefaultRetryBaseDelay, maxDelay: DefaultRetryMaxDelay}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	delay := o.baseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsTransient(err) {
+			return err
+		}
+		if attempt >= maxAttempts {
+			return errors.Wrapf(err, "failed after %d attempts", attempt)
+		}
+		if delay > o.maxDelay {
+			delay = o.maxDelay
+		}
+		timer := time.NewTimer(jitter(delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// jitter returns a random duration between half of d and d, so that clients failing at the same
+// time don't retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// RetrySendClient implements Blockchain on top of another Blockchain, retrying transient failures to
+// broadcast transactions with WithRetry. Using it as the backend of the send helpers and contract
+// bindings makes them retry broadcasts, while every other call is passed through as is, including
+// the optional methods such as NonceAt which helpers probe backends for
+type RetrySendClient struct {
+	Blockchain
+	maxAttempts int
+	opts        []RetryOption
+}
+
+// NewRetrySendClient returns a client broadcasting transactions through bc up to maxAttempts times
+func NewRetrySendClient(bc Blockchain, maxAttempts int, opts ...RetryOption) *RetrySendClient {
+	return &RetrySendClient{Blockchain: bc, maxAttempts: maxAttempts, opts: opts}
+}
+
+// SendTransaction implements Blockchain. A retry failing because the node already knows the
+// transaction succeeds, as the attempt which timed out may have reached the node
+func (c *RetrySendClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return c.retrySend(ctx, func() error {
+		return c.Blockchain.SendTransaction(ctx, tx)
+	})
+}
+
+// retrySend retries the broadcast made by send, succeeding once a retry fails because the node
+// already knows the transaction
+func (c *RetrySendClient) retrySend(ctx context.Context, send func() error) error {
+	attempt := 0
+	return WithRetry(ctx, c.maxAttempts, func() error {
+		attempt++
+		err := send()
+		if attempt > 1 && ClassifySendError(err) == SendErrorAlreadyKnown {
+			return nil
+		}
+		return err
+	}, c.opts...)
+}
+
+// NonceAt implements the optional NonceAt of backends such as *ethclient.Client, like the optional
+// methods below, returning an error wrapping ErrBackendUnsupported if the wrapped backend lacks it
+func (c *RetrySendClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	reader, ok := c.Blockchain.(nonceReader)
+	if !ok {
+		return 0, errors.Wrap(ErrBackendUnsupported, "nonce at")
+	}
+	return reader.NonceAt(ctx, account, blockNumber)
+}
+
+// BalanceAt implements the optional BalanceAt of backends
+func (c *RetrySendClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	reader, ok := c.Blockchain.(balanceReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "balance at")
+	}
+	return reader.BalanceAt(ctx, account, blockNumber)
+}
+
+// PendingBalanceAt implements the optional PendingBalanceAt of backends
+func (c *RetrySendClient) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	reader, ok := c.Blockchain.(pendingBalanceReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "pending balance at")
+	}
+	return reader.PendingBalanceAt(ctx, account)
+}
+
+// ChainID implements the optional ChainID of backends
+func (c *RetrySendClient) ChainID(ctx context.Context) (*big.Int, error) {
+	reader, ok := c.Blockchain.(chainIDReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "chain id")
+	}
+	return reader.ChainID(ctx)
+}
+
+// StorageAt implements the optional StorageAt of backends
+func (c *RetrySendClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	reader, ok := c.Blockchain.(storageReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "storage at")
+	}
+	return reader.StorageAt(ctx, account, key, blockNumber)
+}
+
+// FeeHistory implements the optional FeeHistory of backends
+func (c *RetrySendClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	reader, ok := c.Blockchain.(FeeHistoryReader)
+	if !ok {
+		return nil, errors.Wrap(ErrBackendUnsupported, "fee history")
+	}
+	return reader.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
+// CallContext implements the optional CallContext of backends wrapping an *rpc.Client. Raw
+// broadcasts made with eth_sendRawTransaction are retried like SendTransaction
+func (c *RetrySendClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	caller, ok := c.Blockchain.(rpcCaller)
+	if !ok {
+		return errors.Wrap(ErrBackendUnsupported, method)
+	}
+	if method != "eth_sendRawTransaction" {
+		return caller.CallContext(ctx, result, method, args...)
+	}
+	return c.retrySend(ctx, func() error {
+		return caller.CallContext(ctx, result, method, args...)
+	})
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySendBackend fails to broadcast with the queued errors before passing sends through
+type flakySendBackend struct {
+	simulatedBackend
+	errs  []error
+	sends int
+}
+
+func (b *flakySendBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sends++
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		return err
+	}
+	return b.simulatedBackend.SendTransaction(ctx, tx)
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, tt := range []struct {
+		err       error
+		transient bool
+	}{
+		{nil, false},
+		{errors.New("header not found"), true},
+		{errors.Wrap(errors.New("Post \"http://node\": dial tcp: connection refused"), "block number"), true},
+		{rpc.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}, true},
+		{rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}, true},
+		{rpc.HTTPError{StatusCode: 401, Status: "401 Unauthorized"}, false},
+		{errors.New("execution reverted"), false},
+		{errors.New("nonce too low"), false},
+		{errors.Wrap(context.DeadlineExceeded, "timeout"), false},
+	} {
+		require.Equal(t, tt.transient, IsTransient(tt.err), "%v", tt.err)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	transient := errors.New("header not found")
+	calls := 0
+	err := WithRetry(ctx, 3, func() error {
+		calls++
+		if calls < 3 {
+			return transient
+		}
+		return nil
+	}, WithRetryBaseDelay(time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	// gives up after the last attempt
+	calls = 0
+	err = WithRetry(ctx, 2, func() error { calls++; return transient }, WithRetryBaseDelay(time.Millisecond))
+	require.Equal(t, transient, errors.Cause(err))
+	require.Equal(t, 2, calls)
+
+	// logic errors aren't retried
+	calls = 0
+	reverted := errors.New("execution reverted")
+	err = WithRetry(ctx, 5, func() error { calls++; return reverted }, WithRetryBaseDelay(time.Millisecond))
+	require.Equal(t, reverted, err)
+	require.Equal(t, 1, calls)
+
+	// the context is honored between attempts
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = WithRetry(ctx, 5, func() error { return transient }, WithRetryBaseDelay(time.Hour))
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second)
+		require.True(t, d >= 500*time.Millisecond && d <= time.Second, d)
+	}
+}
+
+func TestRetrySendClient(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	bc := &flakySendBackend{simulatedBackend: newSimulatedBackend(t, auth.From)}
+	client := NewRetrySendClient(bc, 3, WithRetryBaseDelay(time.Millisecond))
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	bc.errs = []error{errors.New("i/o timeout")}
+	tx, err := auth.BuildTx(ctx, client, to, big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.NoError(t, Send(ctx, client, tx))
+	require.Equal(t, 2, bc.sends)
+
+	// the timed out attempt reached the node
+	bc.sends = 0
+	bc.errs = []error{errors.New("i/o timeout"), errors.New("already known")}
+	tx, err = auth.BuildTx(ctx, client, to, big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.NoError(t, Send(ctx, client, tx))
+	require.Equal(t, 2, bc.sends)
+}
+
+// flakyCallBackend fails raw calls with the queued errors, counting them by method
+type flakyCallBackend struct {
+	simulatedBackend
+	errs  []error
+	calls map[string]int
+}
+
+func (b *flakyCallBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	b.calls[method]++
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func TestRetrySendClientOptionalMethods(t *testing.T) {
+	ctx := context.Background()
+	bc := &flakyCallBackend{simulatedBackend: newSimulatedBackend(t), calls: make(map[string]int)}
+	client := NewRetrySendClient(bc, 3, WithRetryBaseDelay(time.Millisecond))
+	requireOptionalMethods(t, client)
+
+	_, err := client.NonceAt(ctx, common.Address{1}, nil)
+	require.NoError(t, err)
+	_, err = client.ChainID(ctx)
+	require.True(t, errors.Is(err, ErrBackendUnsupported))
+
+	// raw broadcasts are retried while other calls are passed through as is
+	bc.errs = []error{errors.New("i/o timeout")}
+	require.NoError(t, client.CallContext(ctx, nil, "eth_sendRawTransaction", "0x01"))
+	require.Equal(t, 2, bc.calls["eth_sendRawTransaction"])
+	bc.errs = []error{errors.New("i/o timeout")}
+	require.Error(t, client.CallContext(ctx, nil, "eth_createAccessList"))
+	require.Equal(t, 1, bc.calls["eth_createAccessList"])
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+var (
+	// errorSelector is the selector of the solidity Error(string) revert type
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+	// panicSelector is the selector of the solidity Panic(uint256) revert type
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+	// panicReasons maps solidity panic codes to a human readable description
+	panicReasons = map[uint64]string{
+		0x00: "generic compiler inserted panic",
+		0x01: "assertion failed",
+		0x11: "arithmetic overflow or underflow",
+		0x12: "division or modulo by zero",
+		0x21: "invalid enum value",
+		0x22: "invalid storage byte array encoding",
+		0x31: "pop on empty array",
+		0x32: "array index out of bounds",
+		0x41: "out of memory",
+		0x51: "call to zero-initialized function",
+	}
+)
+
+// DecodeRevert decodes the return data of a reverted call into a human readable reason. Reverts
+// using the standard Error(string) type return the string, while Panic(uint256) reverts return a
+// description of the panic code. If the data matches neither, it is returned hex encoded
+func DecodeRevert(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", ErrNoRevertData
+	}
+	if len(data) < 4 {
+		return hexutil.Encode(data), nil
+	}
+	switch selector := data[:4]; {
+	case bytes.Equal(selector, errorSelector):
+		reason, err := unpackRevert("string", data[4:])
+		if err != nil {
+			return "", err
+		}
+		return reason.(string), nil
+	case bytes.Equal(selector, panicSelector):
+		code, err := unpackRevert("uint256", data[4:])
+		if err != nil {
+			return "", err
+		}
+		return panicReason(code.(*big.Int)), nil
+	default:
+		return hexutil.Encode(data), nil
+	}
+}
+
+// panicReason returns the description of a solidity panic code
+func panicReason(code *big.Int) string {
+	if code.IsUint64() {
+		if reason, ok := panicReasons[code.Uint64()]; ok {
+			return reason
+		}
+	}
+	return fmt.Sprintf("unknown panic code 0x%x", code)
+}
+
+// unpackRevert unpacks a single abi encoded value of type typ
+func unpackRevert(typ string, data []byte) (interface{}, error) {
+	t, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new type")
+	}
+	out, err := abi.Arguments{{Type: t}}.Unpack(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "unpack revert")
+	}
+	return out[0], nil
+}
+
+// revertReason attempts to extract and decode the revert reason
+// from the data attached to an rpc error
+func revertReason(err error) (string, bool) {
+	data, ok := revertData(err)
+	if !ok {
+		return "", false
+	}
+	reason, err := DecodeRevert(data)
+	if err != nil {
+		return "", false
+	}
+	return reason, true
+}
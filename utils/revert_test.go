@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "0x", "", true},
+		{
+			"error-string",
+			"0x08c379a0" +
+				"0000000000000000000000000000000000000000000000000000000000000020" +
+				"000000000000000000000000000000000000000000000000000000000000001a" +
+				"4e6f7420656e6f7567682045746865722070726f76696465642e000000000000",
+			"Not enough Ether provided.",
+			false,
+		},
+		{
+			"panic-overflow",
+			"0x4e487b71" + "0000000000000000000000000000000000000000000000000000000000000011",
+			"arithmetic overflow or underflow",
+			false,
+		},
+		{
+			"panic-unknown",
+			"0x4e487b71" + "00000000000000000000000000000000000000000000000000000000000000ff",
+			"unknown panic code 0xff",
+			false,
+		},
+		{"malformed-error-string", "0x08c379a0" + "0000", "", true},
+		{"custom-error", "0xdeadbeef", "0xdeadbeef", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeRevert(hexutil.MustDecode(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeRevert() err %v, wantErr %v", err, tt.wantErr)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SendErrorKind classifies the reason a node rejected a transaction
+type SendErrorKind int
+
+const (
+	// SendErrorUnknown is any error which isn't otherwise classified
+	SendErrorUnknown SendErrorKind = iota
+	// SendErrorReplacementUnderpriced means a pending transaction with the same nonce exists
+	// and the gas price wasn't bumped enough to replace it, see ReplaceUnderpriced
+	SendErrorReplacementUnderpriced
+	// SendErrorUnderpriced means the gas price is below the minimum accepted by the node
+	SendErrorUnderpriced
+	// SendErrorAlreadyKnown means the transaction is already in the pool of the node, which
+	// is usually safe to treat as the transaction having been sent
+	SendErrorAlreadyKnown
+	// SendErrorNonceTooLow means a transaction with the same nonce has already been mined
+	SendErrorNonceTooLow
+	// SendErrorInsufficientFunds means the sender can't pay for the gas and value of the transaction
+	SendErrorInsufficientFunds
+)
+
+// String returns the name of the kind
+func (k SendErrorKind) String() string {
+	switch k {
+	case SendErrorReplacementUnderpriced:
+		return "replacement underpriced"
+	case SendErrorUnderpriced:
+		return "underpriced"
+	case SendErrorAlreadyKnown:
+		return "already known"
+	case SendErrorNonceTooLow:
+		return "nonce too low"
+	case SendErrorInsufficientFunds:
+		return "insufficient funds"
+	default:
+		return "unknown"
+	}
+}
+
+// sendErrorPatterns are lower cased substrings of the errors returned by geth, erigon and
+// nethermind, checked in order so that more specific messages are matched first
+var sendErrorPatterns = []struct {
+	pattern string
+	kind    SendErrorKind
+}{
+	// geth and erigon
+	{"replacement transaction underpriced", SendErrorReplacementUnderpriced},
+	// erigon
+	{"could not replace existing tx", SendErrorReplacementUnderpriced},
+	// nethermind
+	{"replacementnotallowed", SendErrorReplacementUnderpriced},
+	// geth and erigon
+	{"transaction underpriced", SendErrorUnderpriced},
+	{"underpriced", SendErrorUnderpriced},
+	// nethermind, including FeeTooLowToCompete when its pool is full
+	{"feetoolow", SendErrorUnderpriced},
+	// geth and erigon, with older geth versions returning "known transaction: <hash>"
+	{"already known", SendErrorAlreadyKnown},
+	{"known transaction", SendErrorAlreadyKnown},
+	// nethermind
+	{"alreadyknown", SendErrorAlreadyKnown},
+	// geth and erigon
+	{"nonce too low", SendErrorNonceTooLow},
+	// nethermind
+	{"oldnonce", SendErrorNonceTooLow},
+	// geth, erigon and nethermind, which returns "InsufficientFunds"
+	{"insufficient funds", SendErrorInsufficientFunds},
+	{"insufficientfunds", SendErrorInsufficientFunds},
+}
+
+// ClassifySendError returns the kind of error a node returned when broadcasting a transaction
+// by matching the messages of known clients, or SendErrorUnknown if it isn't recognized. A
+// SendError anywhere in the chain of err is used as is
+func ClassifySendError(err error) SendErrorKind {
+	if err == nil {
+		return SendErrorUnknown
+	}
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.Kind
+	}
+	msg := strings.ToLower(err.Error())
+	for _, p := range sendErrorPatterns {
+		if strings.Contains(msg, p.pattern) {
+			return p.kind
+		}
+	}
+	return SendErrorUnknown
+}
+
+// SendError is returned by the send helpers when a node rejects a transaction, allowing
+// callers to branch on the kind of error with errors.As rather than matching its message
+type SendError struct {
+	Kind SendErrorKind
+	Err  error
+}
+
+// newSendError wraps an error returned when broadcasting a transaction
+func newSendError(err error) *SendError {
+	return &SendError{Kind: ClassifySendError(err), Err: err}
+}
+
+// Error returns the message of the underlying error
+func (e *SendError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifySendError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want SendErrorKind
+	}{
+		{"nil", nil, SendErrorUnknown},
+		{"unknown", errors.New("connection refused"), SendErrorUnknown},
+		{"geth replacement", errors.New("replacement transaction underpriced"), SendErrorReplacementUnderpriced},
+		{"erigon replacement", errors.New("could not replace existing tx"), SendErrorReplacementUnderpriced},
+		{"nethermind replacement", errors.New("ReplacementNotAllowed"), SendErrorReplacementUnderpriced},
+		{"geth underpriced", errors.New("transaction underpriced"), SendErrorUnderpriced},
+		{"nethermind underpriced", errors.New("FeeTooLow"), SendErrorUnderpriced},
+		{"geth known", errors.New("already known"), SendErrorAlreadyKnown},
+		{"old geth known", errors.New("known transaction: 0x1234"), SendErrorAlreadyKnown},
+		{"nethermind known", errors.New("AlreadyKnown"), SendErrorAlreadyKnown},
+		{"geth nonce", errors.New("nonce too low"), SendErrorNonceTooLow},
+		{"nethermind nonce", errors.New("OldNonce"), SendErrorNonceTooLow},
+		{"geth funds", errors.New("insufficient funds for gas * price + value"), SendErrorInsufficientFunds},
+		{"nethermind funds", errors.New("InsufficientFunds"), SendErrorInsufficientFunds},
+		{"wrapped", errors.Wrap(errors.New("nonce too low"), "send transaction"), SendErrorNonceTooLow},
+		{"send error", &SendError{Kind: SendErrorAlreadyKnown, Err: errors.New("nonce too low")}, SendErrorAlreadyKnown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ClassifySendError(tt.err))
+		})
+	}
+}
+
+// rejectingBackend rejects every transaction sent to it with err
+type rejectingBackend struct {
+	Blockchain
+	err error
+}
+
+func (b *rejectingBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return b.err
+}
+
+func TestSendError(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	tx, err := auth.BuildTx(ctx, sim, common.HexToAddress("0x000000000000000000000000000000000000dEaD"), big.NewInt(1), nil)
+	require.NoError(t, err)
+
+	bc := &rejectingBackend{Blockchain: sim, err: errors.New("replacement transaction underpriced")}
+	err = Send(ctx, bc, tx)
+	var sendErr *SendError
+	require.True(t, errors.As(err, &sendErr))
+	require.Equal(t, SendErrorReplacementUnderpriced, sendErr.Kind)
+	require.Equal(t, "send transaction: replacement transaction underpriced", err.Error())
+
+	// unrecognized errors are wrapped as well
+	bc.err = errors.New("connection refused")
+	_, err = SendAndWait(ctx, bc, tx)
+	require.True(t, errors.As(err, &sendErr))
+	require.Equal(t, SendErrorUnknown, sendErr.Kind)
+	require.Equal(t, bc.err, errors.Unwrap(sendErr))
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SetCodeTxType is the type of EIP-7702 transactions, which carry an authorization list
+	SetCodeTxType = 0x04
+	// setCodeAuthorizationMagic prefixes the RLP encoded authorization tuple when hashing it
+	setCodeAuthorizationMagic = 0x05
+	// setCodeAuthorizationGas is the intrinsic gas charged for each authorization, added to gas
+	// estimates as nodes estimate without applying the authorizations
+	setCodeAuthorizationGas = 25000
+)
+
+// SetCodeAuthorization is a signed EIP-7702 authorization, delegating the code of the signing
+// account, its authority, to the code of Address. A ChainID of zero makes the authorization valid
+// on every chain, and Nonce must be the nonce of the authority when the authorization is applied.
+// The fields are in the order of the authorization tuple so that it RLP encodes as the spec
+// expects, with V being the y parity of the signature
+type SetCodeAuthorization struct {
+	ChainID *big.Int
+	Address common.Address
+	Nonce   uint64
+	V       uint8
+	R       *big.Int
+	S       *big.Int
+}
+
+// SigHash returns the digest signed by the authority, the keccak256 hash of the magic 0x05 followed
+// by the RLP encoded chain id, address and nonce
+func (auth SetCodeAuthorization) SigHash() common.Hash {
+	// encoding integers and addresses never fails
+	encoded, _ := rlp.EncodeToBytes([]interface{}{auth.ChainID, auth.Address, auth.Nonce})
+	return crypto.Keccak256Hash([]byte{setCodeAuthorizationMagic}, encoded)
+}
+
+// Authority recovers the account which signed the authorization, and whose code it delegates
+func (auth SetCodeAuthorization) Authority() (common.Address, error) {
+	if auth.V > 1 || auth.R == nil || auth.S == nil || auth.R.BitLen() > 256 || auth.S.BitLen() > 256 {
+		return common.Address{}, errors.New("invalid authorization signature")
+	}
+	sig := JoinSignature(common.BigToHash(auth.R), common.BigToHash(auth.S), auth.V)
+	pub, err := crypto.SigToPub(auth.SigHash().Bytes(), sig)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "recover public key")
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// SignAuthorization signs an EIP-7702 authorization delegating the code of the account of the
+// authorizer to the contract at delegate on the chain of chainID, or every chain if it is zero.
+// The nonce must be the nonce of the account once the authorization is applied, which is one more
+// than its current nonce when the account also sends the transaction carrying it, as the nonce of
+// the transaction is used first. Authorizers backed by hardware wallets or a KMS return
+// ErrSignerNotSupported
+func (a *Authorizer) SignAuthorization(chainID *big.Int, delegate common.Address, nonce uint64) (SetCodeAuthorization, error) {
+	if chainID == nil {
+		return SetCodeAuthorization{}, ErrNilChainID
+	}
+	if chainID.Sign() < 0 || chainID.BitLen() > 256 {
+		return SetCodeAuthorization{}, errors.Errorf("invalid chain id %s", chainID)
+	}
+	auth := SetCodeAuthorization{ChainID: new(big.Int).Set(chainID), Address: delegate, Nonce: nonce}
+	hash := auth.SigHash()
+	sig, err := a.sign(hash.Bytes())
+	if err != nil {
+		return SetCodeAuthorization{}, errors.Wrap(err, "sign authorization")
+	}
+	auth.R = new(big.Int).SetBytes(sig[:32])
+	auth.S = new(big.Int).SetBytes(sig[32:64])
+	auth.V = sig[64]
+	return auth, nil
+}
+
+// SendSetCodeTx signs and broadcasts an EIP-7702 transaction sending value and data to the to
+// address along with authorizations, which may be signed by other accounts than the authorizer,
+// returning the hash of the transaction. The nonce, gas limit and fees are used or populated as by
+// BuildTx, with 25000 gas added to estimates for each authorization. Estimates don't account for
+// the delegated code, so the gas limit should be set when the call relies on it. The go-ethereum
+// transaction types predate EIP-7702, so the transaction is encoded here and sent with
+// eth_sendRawTransaction, which is why this takes an *rpc.Client. For the same reason Hooks and
+// Metrics aren't invoked and DryRun isn't supported, and the receipt is best fetched with
+// TransactionReceipt rather than the wait helpers, which look the transaction up. Only authorizers
+// holding their private key can sign the transaction, others return ErrSignerNotSupported. The
+// transaction is signed while holding the lock, so this must not be called while the lock is
+// already held.
+func (a *Authorizer) SendSetCodeTx(
+	ctx context.Context,
+	client *rpc.Client,
+	to common.Address,
+	value *big.Int,
+	data []byte,
+	authorizations []SetCodeAuthorization,
+) (common.Hash, error) {
+	ec := ethclient.NewClient(client)
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "chain id")
+	}
+	raw, err := a.buildSetCodeTx(ctx, ec, chainID, to, value, data, authorizations)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := client.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(raw)); err != nil {
+		return common.Hash{}, errors.Wrap(newSendError(err), "send transaction")
+	}
+	// the hash of typed transactions covers the type and the payload
+	return crypto.Keccak256Hash(raw), nil
+}
+
+// buildSetCodeTx returns the signed EIP-7702 transaction for chainID encoded as sent over the wire
+func (a *Authorizer) buildSetCodeTx(
+	ctx context.Context,
+	bc Blockchain,
+	chainID *big.Int,
+	to common.Address,
+	value *big.Int,
+	data []byte,
+	authorizations []SetCodeAuthorization,
+) ([]byte, error) {
+	if len(authorizations) == 0 {
+		return nil, errors.New("set code transactions require at least one authorization")
+	}
+	if err := a.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer a.Unlock()
+	if a.DryRun {
+		return nil, errors.New("dry run is not supported by set code transactions")
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	nonce := a.Nonce
+	if nonce == nil {
+		pending, err := bc.PendingNonceAt(ctx, a.From)
+		if err != nil {
+			return nil, errors.Wrap(err, "pending nonce at")
+		}
+		nonce = new(big.Int).SetUint64(pending)
+	}
+	gas := a.GasLimit
+	if gas == 0 {
+		estimate, err := bc.EstimateGas(ctx, ethereum.CallMsg{From: a.From, To: &to, Value: value, Data: data, AccessList: a.accessList})
+		if err != nil {
+			if reason, ok := revertReason(err); ok {
+				return nil, errors.Errorf("estimate gas: execution reverted: %s", reason)
+			}
+			return nil, errors.Wrap(err, "estimate gas")
+		}
+		gas = a.scaleGas(estimate + setCodeAuthorizationGas*uint64(len(authorizations)))
+	}
+	inner, err := a.txData(ctx, bc, nonce.Uint64(), gas, to, value, data)
+	if err != nil {
+		return nil, err
+	}
+	fees, ok := inner.(*types.DynamicFeeTx)
+	if !ok {
+		return nil, errors.Wrap(ErrTxTypeIncompatible, "set code transactions require EIP-1559 fees")
+	}
+	if err := a.checkGasPrice(types.NewTx(fees)); err != nil {
+		return nil, err
+	}
+	accessList := fees.AccessList
+	if accessList == nil {
+		accessList = types.AccessList{}
+	}
+	fields := []interface{}{
+		chainID, fees.Nonce, fees.GasTipCap, fees.GasFeeCap, fees.Gas, to, value, data, accessList, authorizations,
+	}
+	unsigned, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode transaction")
+	}
+	sig, err := a.sign(crypto.Keccak256([]byte{SetCodeTxType}, unsigned))
+	if err != nil {
+		return nil, errors.Wrap(err, "sign transaction")
+	}
+	fields = append(fields, new(big.Int).SetUint64(uint64(sig[64])), new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]))
+	encoded, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode transaction")
+	}
+	a.accessList = nil
+	return append([]byte{SetCodeTxType}, encoded...), nil
+}
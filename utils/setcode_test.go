@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAuthorization(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+	require.NoError(t, err)
+	delegate := common.HexToAddress("0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B")
+
+	signed, err := auth.SignAuthorization(big.NewInt(1), delegate, 7)
+	require.NoError(t, err)
+	require.Equal(t, delegate, signed.Address)
+	require.Equal(t, uint64(7), signed.Nonce)
+	require.True(t, signed.V <= 1)
+	require.True(t, signed.S.Cmp(secp256k1HalfN) <= 0)
+	authority, err := signed.Authority()
+	require.NoError(t, err)
+	require.Equal(t, auth.From, authority)
+
+	// the digest is keccak256(0x05 || rlp([chain_id, address, nonce]))
+	expected := append([]byte{0x05, 0xd7, 0x01, 0x94}, delegate.Bytes()...)
+	require.Equal(t, crypto.Keccak256Hash(append(expected, 0x07)), signed.SigHash())
+
+	// tampering changes the recovered authority
+	signed.Nonce = 8
+	authority, err = signed.Authority()
+	require.NoError(t, err)
+	require.NotEqual(t, auth.From, authority)
+	signed.V = 27
+	_, err = signed.Authority()
+	require.Error(t, err)
+
+	// authorizations valid on any chain have a chain id of zero
+	signed, err = auth.SignAuthorization(new(big.Int), delegate, 0)
+	require.NoError(t, err)
+	require.Zero(t, signed.ChainID.Sign())
+	_, err = auth.SignAuthorization(nil, delegate, 0)
+	require.Equal(t, ErrNilChainID, err)
+	_, err = auth.SignAuthorization(big.NewInt(-1), delegate, 0)
+	require.Error(t, err)
+
+	external, err := NewAuthorizerFromSigner(NewLocalSigner(pk), big.NewInt(1))
+	require.NoError(t, err)
+	_, err = external.SignAuthorization(big.NewInt(1), delegate, 0)
+	require.True(t, errors.Is(err, ErrSignerNotSupported))
+}
+
+func TestBuildSetCodeTx(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	chainID := big.NewInt(1337)
+	delegate := common.HexToAddress("0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B")
+	// the sender authorizes itself, so the authorization nonce follows that of the transaction
+	authorization, err := auth.SignAuthorization(chainID, delegate, 1)
+	require.NoError(t, err)
+
+	_, err = auth.buildSetCodeTx(ctx, sim, chainID, auth.From, nil, nil, nil)
+	require.Error(t, err)
+	raw, err := auth.buildSetCodeTx(ctx, sim, chainID, auth.From, nil, []byte{0x01}, []SetCodeAuthorization{authorization})
+	require.NoError(t, err)
+	require.Equal(t, byte(SetCodeTxType), raw[0])
+
+	var tx struct {
+		ChainID        *big.Int
+		Nonce          uint64
+		GasTipCap      *big.Int
+		GasFeeCap      *big.Int
+		Gas            uint64
+		To             common.Address
+		Value          *big.Int
+		Data           []byte
+		AccessList     types.AccessList
+		Authorizations []SetCodeAuthorization
+		V, R, S        *big.Int
+	}
+	require.NoError(t, rlp.DecodeBytes(raw[1:], &tx))
+	require.Equal(t, chainID, tx.ChainID)
+	require.Zero(t, tx.Nonce)
+	require.Equal(t, auth.From, tx.To)
+	require.Equal(t, []byte{0x01}, tx.Data)
+	require.Equal(t, []SetCodeAuthorization{authorization}, tx.Authorizations)
+	require.True(t, tx.Gas >= 21000+setCodeAuthorizationGas)
+	require.True(t, tx.GasFeeCap.Cmp(tx.GasTipCap) >= 0)
+
+	unsigned, err := rlp.EncodeToBytes([]interface{}{
+		tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To, tx.Value, tx.Data, tx.AccessList, tx.Authorizations,
+	})
+	require.NoError(t, err)
+	sig := JoinSignature(common.BigToHash(tx.R), common.BigToHash(tx.S), uint8(tx.V.Uint64()))
+	pub, err := crypto.SigToPub(crypto.Keccak256([]byte{SetCodeTxType}, unsigned), sig)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, crypto.PubkeyToAddress(*pub))
+
+	// the fees are capped like other transactions
+	auth.MaxGasPriceWei = big.NewInt(1)
+	_, err = auth.buildSetCodeTx(ctx, sim, chainID, auth.From, nil, nil, []SetCodeAuthorization{authorization})
+	require.True(t, errors.Is(err, ErrGasPriceTooHigh))
+	auth.MaxGasPriceWei = nil
+	auth.UseLegacyGas(big.NewInt(1e9))
+	_, err = auth.buildSetCodeTx(ctx, sim, chainID, auth.From, nil, nil, []SetCodeAuthorization{authorization})
+	require.True(t, errors.Is(err, ErrTxTypeIncompatible))
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/pkg/errors"
+)
+
+// SignTypedData signs EIP-712 typed data, as used by protocols such as 0x, Gnosis Safe and
+// Seaport, returning the 65 byte [R || S || V] signature with V being 27 or 28. Authorizers
+// backed by hardware wallets or a KMS return ErrSignerNotSupported
+func (a *Authorizer) SignTypedData(typedData apitypes.TypedData) ([]byte, error) {
+	digest, err := TypedDataHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := a.sign(digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign typed data")
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// TypedDataHash returns the EIP-712 digest of typed data which is signed by SignTypedData
+func TypedDataHash(typedData apitypes.TypedData) ([]byte, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, errors.Wrap(err, "hash domain")
+	}
+	structHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, errors.Wrap(err, "hash message")
+	}
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash), nil
+}
+
+// SignMessage signs msg following EIP-191 as done by personal_sign and eth_sign, hashing it with
+// the "\x19Ethereum Signed Message:\n" prefix and the byte length of msg. The returned 65 byte
+// [R || S || V] signature has V set to 27 or 28 matching the signatures produced by MetaMask.
+// Authorizers backed by hardware wallets or a KMS return ErrSignerNotSupported
+func (a *Authorizer) SignMessage(msg []byte) ([]byte, error) {
+	sig, err := a.sign(accounts.TextHash(msg))
+	if err != nil {
+		return nil, errors.Wrap(err, "sign message")
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// VerifyMessage recovers the address which signed msg using SignMessage or personal_sign.
+// Signatures with a recovery id of either 0/1 or 27/28 are accepted
+func VerifyMessage(msg, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.Errorf("invalid signature length %d, must be %d bytes", len(sig), crypto.SignatureLength)
+	}
+	// copy so the callers signature isn't modified when normalizing the recovery id
+	normalized := make([]byte, crypto.SignatureLength)
+	copy(normalized, sig)
+	if normalized[64] >= 27 {
+		normalized[64] -= 27
+	}
+	if normalized[64] > 1 {
+		return common.Address{}, errors.Errorf("invalid signature recovery id %d", sig[64])
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(msg), normalized)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "recover public key")
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+var (
+	// secp256k1N is the order of the secp256k1 curve
+	secp256k1N = crypto.S256().Params().N
+	// secp256k1HalfN is half the order of the curve, the largest S value of a canonical signature
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// SplitSignature splits a 65 byte [R || S || V] signature into its components, such as to pass
+// them to a permit function. A recovery id of 0 or 1 is returned as 27 or 28 as contracts expect
+func SplitSignature(sig []byte) (r, s [32]byte, v uint8, err error) {
+	if len(sig) != crypto.SignatureLength {
+		return r, s, 0, errors.Errorf("invalid signature length %d, must be %d bytes", len(sig), crypto.SignatureLength)
+	}
+	copy(r[:], sig[:32])
+	copy(s[:], sig[32:64])
+	v = sig[64]
+	if v < 27 {
+		v += 27
+	}
+	return r, s, v, nil
+}
+
+// JoinSignature joins the components of a signature into the 65 byte [R || S || V] form
+func JoinSignature(r, s [32]byte, v uint8) []byte {
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig[:32], r[:])
+	copy(sig[32:64], s[:])
+	sig[64] = v
+	return sig
+}
+
+// NormalizeSignature returns a copy of the 65 byte [R || S || V] signature in the canonical low-S
+// form required by contracts such as OpenZeppelin's ECDSA library and by EIP-2. Signatures whose S
+// is in the upper half of the curve order, which are equally valid but rejected as malleable, have
+// S replaced by N - S and the recovery id flipped so they still recover the same signer. The
+// recovery id is kept in the convention of sig, either 0/1 or 27/28
+func NormalizeSignature(sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, errors.Errorf("invalid signature length %d, must be %d bytes", len(sig), crypto.SignatureLength)
+	}
+	v := sig[64]
+	if v != 0 && v != 1 && v != 27 && v != 28 {
+		return nil, errors.Errorf("invalid signature recovery id %d", v)
+	}
+	s := new(big.Int).SetBytes(sig[32:64])
+	if s.Sign() == 0 || s.Cmp(secp256k1N) >= 0 {
+		return nil, errors.New("invalid signature s value")
+	}
+	normalized := make([]byte, crypto.SignatureLength)
+	copy(normalized, sig)
+	if s.Cmp(secp256k1HalfN) <= 0 {
+		return normalized, nil
+	}
+	copy(normalized[32:64], common.LeftPadBytes(s.Sub(secp256k1N, s).Bytes(), 32))
+	// negating S negates the y coordinate of R, flipping the recovery id between 0 and 1 or 27 and 28
+	if v >= 27 {
+		normalized[64] = 27 + ((v - 27) ^ 1)
+	} else {
+		normalized[64] = v ^ 1
+	}
+	return normalized, nil
+}
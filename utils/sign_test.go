@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignTypedData(t *testing.T) {
+	// the example from the EIP-712 specification signed by keccak256("cow")
+	auth, err := NewAuthorizerFromHex(hexutil.Encode(crypto.Keccak256([]byte("cow"))))
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"), auth.From)
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+			"Mail": {
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainId:           math.NewHexOrDecimal256(1),
+			VerifyingContract: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+		},
+		Message: apitypes.TypedDataMessage{
+			"from": map[string]interface{}{
+				"name":   "Cow",
+				"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+			},
+			"to": map[string]interface{}{
+				"name":   "Bob",
+				"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+			},
+			"contents": "Hello, Bob!",
+		},
+	}
+	sig, err := auth.SignTypedData(typedData)
+	require.NoError(t, err)
+	require.Equal(t,
+		"0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d"+
+			"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562"+"1c",
+		hexutil.Encode(sig),
+	)
+
+	_, err = (&Authorizer{TransactOpts: auth.TransactOpts}).SignTypedData(typedData)
+	require.ErrorIs(t, err, ErrSignerNotSupported)
+}
+
+func TestSignMessage(t *testing.T) {
+	auth, err := NewAuthorizerFromHex("0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	require.NoError(t, err)
+	tests := []struct {
+		name string
+		msg  []byte
+		hash string
+	}{
+		{"empty", []byte{}, "0x5f35dce98ba4fba25530a026ed80b2cecdaa31091ba4958b99b52ea1d068adad"},
+		// the prefix must use the byte length of 13, not the rune length of 8
+		{"multi-byte-utf8", []byte("héllo 世界"), "0xcfddfd94f29027210dc27d1751554b99792bc72114bfc2fc8df1f23df1dcedb2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := auth.SignMessage(tt.msg)
+			require.NoError(t, err)
+			require.Len(t, sig, 65)
+			require.Contains(t, []byte{27, 28}, sig[64])
+			signer, err := VerifyMessage(tt.msg, sig)
+			require.NoError(t, err)
+			require.Equal(t, auth.From, signer)
+			// recovering against the independently computed hash proves the prefix is correct
+			raw := append([]byte{}, sig...)
+			raw[64] -= 27
+			pub, err := crypto.SigToPub(common.FromHex(tt.hash), raw)
+			require.NoError(t, err)
+			require.Equal(t, auth.From, crypto.PubkeyToAddress(*pub))
+			// 0/1 recovery ids are accepted too
+			signer, err = VerifyMessage(tt.msg, raw)
+			require.NoError(t, err)
+			require.Equal(t, auth.From, signer)
+			signer, err = VerifyMessage(append(tt.msg, '!'), sig)
+			require.NoError(t, err)
+			require.NotEqual(t, auth.From, signer)
+		})
+	}
+	_, err = VerifyMessage([]byte("hello"), make([]byte, 64))
+	require.Error(t, err)
+	_, err = (&Authorizer{TransactOpts: auth.TransactOpts}).SignMessage([]byte("hello"))
+	require.ErrorIs(t, err, ErrSignerNotSupported)
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	digest := crypto.Keccak256([]byte("permit"))
+	sig, err := crypto.Sign(digest, pk)
+	require.NoError(t, err)
+	// crypto.Sign always produces low-S signatures, so the malleable twin is derived from it
+	high := append([]byte{}, sig...)
+	s := new(big.Int).SetBytes(sig[32:64])
+	copy(high[32:64], common.LeftPadBytes(new(big.Int).Sub(secp256k1N, s).Bytes(), 32))
+	high[64] ^= 1
+	require.False(t, crypto.ValidateSignatureValues(high[64], new(big.Int).SetBytes(high[:32]), new(big.Int).SetBytes(high[32:64]), true))
+
+	for _, offset := range []byte{0, 27} {
+		low := append([]byte{}, sig...)
+		low[64] += offset
+		malleable := append([]byte{}, high...)
+		malleable[64] += offset
+
+		normalized, err := NormalizeSignature(malleable)
+		require.NoError(t, err)
+		require.Equal(t, low, normalized)
+		// canonical signatures are returned unchanged, and the input is never modified
+		normalized, err = NormalizeSignature(low)
+		require.NoError(t, err)
+		require.Equal(t, low, normalized)
+		normalized[0] ^= 1
+		require.Equal(t, sig[0], low[0])
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), crypto.PubkeyToAddress(*pub))
+
+	_, err = NormalizeSignature(sig[:64])
+	require.Error(t, err)
+	invalid := append([]byte{}, sig...)
+	invalid[64] = 2
+	_, err = NormalizeSignature(invalid)
+	require.Error(t, err)
+	copy(invalid[32:64], make([]byte, 32))
+	invalid[64] = 0
+	_, err = NormalizeSignature(invalid)
+	require.Error(t, err)
+}
+
+func TestSplitSignature(t *testing.T) {
+	sig := make([]byte, 65)
+	for i := range sig {
+		sig[i] = byte(i)
+	}
+	sig[64] = 1
+	r, s, v, err := SplitSignature(sig)
+	require.NoError(t, err)
+	require.Equal(t, sig[:32], r[:])
+	require.Equal(t, sig[32:64], s[:])
+	require.Equal(t, uint8(28), v)
+	joined := JoinSignature(r, s, v)
+	require.Equal(t, sig[:64], joined[:64])
+	require.Equal(t, byte(28), joined[64])
+
+	_, _, _, err = SplitSignature(sig[:64])
+	require.Error(t, err)
+}
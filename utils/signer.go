@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions on behalf of a single account. It allows custom signers, such as
+// remote signing services, to be used with an Authorizer without the package knowing their
+// internals. Implementations don't need to be thread-safe as the Authorizer lock is held
+// whenever transactions are signed
+type Signer interface {
+	// Address returns the address of the account transactions are signed for
+	Address() common.Address
+	// SignTx signs tx for the given chain id
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// LocalSigner is a Signer using an in-memory private key
+type LocalSigner struct {
+	pk      *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewLocalSigner returns a Signer using the given private key
+func NewLocalSigner(pk *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{pk: pk, address: crypto.PubkeyToAddress(pk.PublicKey)}
+}
+
+// Address returns the address of the private key
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx for the given chain id using the latest signer supporting all transaction types
+func (s *LocalSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.pk)
+}
+
+// NewAuthorizerFromSigner returns an authorizer which delegates transaction signing to signer,
+// signing transactions for the given chain id. A nil chain id returns ErrNilChainID
+func NewAuthorizerFromSigner(signer Signer, chainID *big.Int) (*Authorizer, error) {
+	if chainID == nil {
+		return nil, ErrNilChainID
+	}
+	from := signer.Address()
+	return &Authorizer{
+		TransactOpts: &bind.TransactOpts{
+			From: from,
+			Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+				if address != from {
+					return nil, bind.ErrNotAuthorized
+				}
+				return signer.SignTx(tx, chainID)
+			},
+			Context: context.Background(),
+		},
+	}, nil
+}
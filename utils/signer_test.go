@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSigner is a custom Signer recording how many transactions it signed
+type countingSigner struct {
+	*LocalSigner
+	signed int
+}
+
+func (s *countingSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	s.signed++
+	return s.LocalSigner.SignTx(tx, chainID)
+}
+
+func TestNewAuthorizerFromSigner(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := &countingSigner{LocalSigner: NewLocalSigner(pk)}
+	_, err = NewAuthorizerFromSigner(signer, nil)
+	require.ErrorIs(t, err, ErrNilChainID)
+	auth, err := NewAuthorizerFromSigner(signer, big.NewInt(1337))
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), auth.From)
+	// external signers don't expose their key
+	require.Nil(t, auth.PublicKey())
+
+	sim := newSimulatedBackend(t, auth.From)
+	gasPrice, err := sim.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	unsigned := types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil)
+	_, err = auth.Signer(common.Address{}, unsigned)
+	require.ErrorIs(t, err, bind.ErrNotAuthorized)
+	tx, err := auth.Signer(auth.From, unsigned)
+	require.NoError(t, err)
+	require.Equal(t, 1, signer.signed)
+	require.NoError(t, sim.SendTransaction(context.Background(), tx))
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
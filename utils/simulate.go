@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// Simulate executes tx as a call from the authorizer against the pending state, returning an error
+// wrapping ErrWouldRevert with the reason decoded by DecodeRevert if it would revert. This allows
+// catching doomed transactions before paying gas for them, although the outcome may still differ
+// once mined if the state changes in between
+func Simulate(ctx context.Context, bc Blockchain, auth *Authorizer, tx *types.Transaction) error {
+	// the gas price is omitted so the call isn't rejected due to the sender balance
+	_, err := bc.PendingCallContract(ctx, ethereum.CallMsg{
+		From:  auth.From,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	})
+	if err == nil {
+		return nil
+	}
+	if reason, ok := revertReason(err); ok {
+		return errors.Wrapf(ErrWouldRevert, "execution reverted: %s", reason)
+	}
+	return errors.Wrap(err, "simulate")
+}
+
+// SendIfSimulated broadcasts tx only if Simulate succeeds, returning the simulation error otherwise
+func SendIfSimulated(ctx context.Context, bc Blockchain, auth *Authorizer, tx *types.Transaction) error {
+	if err := Simulate(ctx, bc, auth, tx); err != nil {
+		return err
+	}
+	return Send(ctx, bc, tx)
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulate(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	reverter := deployCode(t, sim, auth, reverterCode)
+
+	// the gas limit is set as estimating gas for a reverting call fails
+	auth.GasLimit = 100000
+	tx, err := auth.BuildTx(ctx, sim, reverter, nil, nil)
+	require.NoError(t, err)
+	err = SendIfSimulated(ctx, sim, auth, tx)
+	require.ErrorIs(t, err, ErrWouldRevert)
+	require.Contains(t, err.Error(), "nope")
+	nonce, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	require.Equal(t, tx.Nonce(), nonce)
+
+	tx, err = auth.BuildTx(ctx, sim, auth.From, big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.NoError(t, SendIfSimulated(ctx, sim, auth, tx))
+	nonce, err = sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	require.Equal(t, tx.Nonce()+1, nonce)
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// siweMinNonceLength is the minimum number of alphanumeric characters of a SIWE nonce
+const siweMinNonceLength = 8
+
+// SIWEMessage is an EIP-4361 Sign-In With Ethereum message. Scheme, Statement, ExpirationTime,
+// NotBefore, RequestID and Resources are optional and omitted from the message when unset, while
+// an empty Version defaults to the only version defined, 1
+type SIWEMessage struct {
+	Scheme         string
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        *big.Int
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// Validate returns an error if a required field is unset or a field is malformed
+func (m SIWEMessage) Validate() error {
+	switch {
+	case m.Domain == "":
+		return errors.New("siwe domain is required")
+	case m.URI == "":
+		return errors.New("siwe uri is required")
+	case m.Version != "" && m.Version != "1":
+		return errors.Errorf("unsupported siwe version %s", m.Version)
+	case m.ChainID == nil:
+		return ErrNilChainID
+	case m.IssuedAt.IsZero():
+		return errors.New("siwe issued at time is required")
+	case strings.Contains(m.Statement, "\n"):
+		return errors.New("siwe statement must not contain newlines")
+	}
+	if len(m.Nonce) < siweMinNonceLength {
+		return errors.Errorf("siwe nonce must be at least %d characters", siweMinNonceLength)
+	}
+	for _, c := range m.Nonce {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return errors.New("siwe nonce must be alphanumeric")
+		}
+	}
+	return nil
+}
+
+// String returns the message in the canonical format which is signed, with the address
+// EIP-55 checksummed and times formatted as RFC 3339 in UTC
+func (m SIWEMessage) String() string {
+	var b strings.Builder
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + " wants you to sign in with your Ethereum account:\n")
+	b.WriteString(ChecksumAddress(m.Address) + "\n\n")
+	// the blank line after the address is kept when there is no statement
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	version := m.Version
+	if version == "" {
+		version = "1"
+	}
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + version + "\n")
+	b.WriteString("Chain ID: " + m.ChainID.String() + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + formatSIWETime(m.IssuedAt))
+	if m.ExpirationTime != nil {
+		b.WriteString("\nExpiration Time: " + formatSIWETime(*m.ExpirationTime))
+	}
+	if m.NotBefore != nil {
+		b.WriteString("\nNot Before: " + formatSIWETime(*m.NotBefore))
+	}
+	if m.RequestID != "" {
+		b.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+	return b.String()
+}
+
+// formatSIWETime formats t as an RFC 3339 timestamp in UTC
+func formatSIWETime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// SignSIWE signs the canonical form of msg following EIP-191, returning the 0x prefixed hex
+// encoded signature as expected by SIWE libraries. The address of msg must be that of auth.
+// Authorizers backed by hardware wallets or a KMS return ErrSignerNotSupported
+func SignSIWE(auth *Authorizer, msg SIWEMessage) (string, error) {
+	if err := msg.Validate(); err != nil {
+		return "", err
+	}
+	if msg.Address != auth.From {
+		return "", errors.Errorf("siwe address %s is not the authorizer address %s", msg.Address, auth.From)
+	}
+	sig, err := auth.SignMessage([]byte(msg.String()))
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(sig), nil
+}
+
+// VerifySIWE recovers the signer of msg from its hex encoded signature, returning an error if it
+// isn't the address of msg. An error wrapping ErrSIWEExpired is returned once the expiration time
+// has passed, and one wrapping ErrSIWENotYetValid before the not before time
+func VerifySIWE(msg SIWEMessage, sig string) (common.Address, error) {
+	if err := msg.Validate(); err != nil {
+		return common.Address{}, err
+	}
+	raw, err := hexutil.Decode(sig)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "decode signature")
+	}
+	signer, err := VerifyMessage([]byte(msg.String()), raw)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if signer != msg.Address {
+		return common.Address{}, errors.Errorf("siwe message signed by %s not %s", signer, msg.Address)
+	}
+	now := time.Now()
+	if msg.ExpirationTime != nil && !now.Before(*msg.ExpirationTime) {
+		return common.Address{}, errors.Wrapf(ErrSIWEExpired, "expired at %s", formatSIWETime(*msg.ExpirationTime))
+	}
+	if msg.NotBefore != nil && now.Before(*msg.NotBefore) {
+		return common.Address{}, errors.Wrapf(ErrSIWENotYetValid, "valid from %s", formatSIWETime(*msg.NotBefore))
+	}
+	return signer, nil
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSIWEMessageString(t *testing.T) {
+	issuedAt := time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC)
+	expiration := issuedAt.Add(time.Hour)
+	notBefore := time.Date(2021, 9, 30, 18, 25, 24, 500000000, time.FixedZone("", 2*60*60))
+	address := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	tests := []struct {
+		name string
+		msg  SIWEMessage
+		want string
+	}{
+		{
+			name: "required fields only",
+			msg: SIWEMessage{
+				Domain: "service.org", Address: address, URI: "https://service.org/login",
+				ChainID: big.NewInt(1), Nonce: "32891757", IssuedAt: issuedAt,
+			},
+			want: "service.org wants you to sign in with your Ethereum account:\n" +
+				"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\n" +
+				"URI: https://service.org/login\n" +
+				"Version: 1\n" +
+				"Chain ID: 1\n" +
+				"Nonce: 32891757\n" +
+				"Issued At: 2021-09-30T16:25:24Z",
+		},
+		{
+			name: "all fields",
+			msg: SIWEMessage{
+				Scheme: "https", Domain: "service.org", Address: address,
+				Statement: "I accept the ServiceOrg Terms of Service: https://service.org/tos",
+				URI:       "https://service.org/login", Version: "1", ChainID: big.NewInt(8453),
+				Nonce: "32891757", IssuedAt: issuedAt, ExpirationTime: &expiration, NotBefore: &notBefore,
+				RequestID: "some-id",
+				Resources: []string{"ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/", "https://example.com/my-web2-claim.json"},
+			},
+			want: "https://service.org wants you to sign in with your Ethereum account:\n" +
+				"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n" +
+				"I accept the ServiceOrg Terms of Service: https://service.org/tos\n\n" +
+				"URI: https://service.org/login\n" +
+				"Version: 1\n" +
+				"Chain ID: 8453\n" +
+				"Nonce: 32891757\n" +
+				"Issued At: 2021-09-30T16:25:24Z\n" +
+				"Expiration Time: 2021-09-30T17:25:24Z\n" +
+				"Not Before: 2021-09-30T16:25:24.5Z\n" +
+				"Request ID: some-id\n" +
+				"Resources:\n" +
+				"- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/\n" +
+				"- https://example.com/my-web2-claim.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.msg.Validate())
+			require.Equal(t, tt.want, tt.msg.String())
+		})
+	}
+}
+
+func TestSIWEMessageValidate(t *testing.T) {
+	valid := SIWEMessage{
+		Domain: "service.org", URI: "https://service.org", ChainID: big.NewInt(1),
+		Nonce: "abcdEFGH1234", IssuedAt: time.Now(),
+	}
+	require.NoError(t, valid.Validate())
+	for name, modify := range map[string]func(m *SIWEMessage){
+		"no domain":           func(m *SIWEMessage) { m.Domain = "" },
+		"no uri":              func(m *SIWEMessage) { m.URI = "" },
+		"bad version":         func(m *SIWEMessage) { m.Version = "2" },
+		"no chain id":         func(m *SIWEMessage) { m.ChainID = nil },
+		"no issued at":        func(m *SIWEMessage) { m.IssuedAt = time.Time{} },
+		"short nonce":         func(m *SIWEMessage) { m.Nonce = "abc123" },
+		"symbol nonce":        func(m *SIWEMessage) { m.Nonce = "abcd-1234" },
+		"multiline statement": func(m *SIWEMessage) { m.Statement = "line\nbreak" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			msg := valid
+			modify(&msg)
+			require.Error(t, msg.Validate())
+		})
+	}
+}
+
+func TestSignSIWE(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1))
+	require.NoError(t, err)
+	now := time.Now()
+	expiration := now.Add(time.Hour)
+	msg := SIWEMessage{
+		Domain: "service.org", Address: auth.From, Statement: "Sign in", URI: "https://service.org",
+		ChainID: big.NewInt(1), Nonce: "abcdEFGH1234", IssuedAt: now, ExpirationTime: &expiration,
+	}
+	sig, err := SignSIWE(auth, msg)
+	require.NoError(t, err)
+	signer, err := VerifySIWE(msg, sig)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, signer)
+
+	// any change to the message invalidates the signature
+	tampered := msg
+	tampered.Nonce = "abcdEFGH5678"
+	_, err = VerifySIWE(tampered, sig)
+	require.Error(t, err)
+
+	past := now.Add(-time.Minute)
+	expired := msg
+	expired.ExpirationTime = &past
+	sig, err = SignSIWE(auth, expired)
+	require.NoError(t, err)
+	_, err = VerifySIWE(expired, sig)
+	require.True(t, errors.Is(err, ErrSIWEExpired))
+
+	early := msg
+	early.NotBefore = &expiration
+	sig, err = SignSIWE(auth, early)
+	require.NoError(t, err)
+	_, err = VerifySIWE(early, sig)
+	require.True(t, errors.Is(err, ErrSIWENotYetValid))
+
+	other := msg
+	other.Address = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	_, err = SignSIWE(auth, other)
+	require.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// MaxSlippageBps is the maximum slippage tolerance in basis points
+const MaxSlippageBps = 10000
+
+// bpsDenominator is the number of basis points in 100%
+var bpsDenominator = big.NewInt(10000)
+
+// ApplySlippage returns expected reduced by bps basis points, rounding down, for use as the
+// minimum output of a swap. An error wrapping ErrInvalidSlippage is returned if bps is not
+// within [0, MaxSlippageBps]
+func ApplySlippage(expected *big.Int, bps int) (*big.Int, error) {
+	if err := validateSlippage(bps); err != nil {
+		return nil, err
+	}
+	amount := new(big.Int).Mul(expected, big.NewInt(int64(MaxSlippageBps-bps)))
+	return amount.Div(amount, bpsDenominator), nil
+}
+
+// ApplySlippageUp returns expected increased by bps basis points, rounding up, for use as the
+// maximum input of a swap. An error wrapping ErrInvalidSlippage is returned if bps is not
+// within [0, MaxSlippageBps]
+func ApplySlippageUp(expected *big.Int, bps int) (*big.Int, error) {
+	if err := validateSlippage(bps); err != nil {
+		return nil, err
+	}
+	amount := new(big.Int).Mul(expected, big.NewInt(int64(MaxSlippageBps+bps)))
+	amount.Add(amount, new(big.Int).Sub(bpsDenominator, big.NewInt(1)))
+	return amount.Div(amount, bpsDenominator), nil
+}
+
+// validateSlippage returns an error if bps is not a valid slippage tolerance
+func validateSlippage(bps int) error {
+	if bps < 0 || bps > MaxSlippageBps {
+		return errors.Wrapf(ErrInvalidSlippage, "%d bps", bps)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySlippage(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int64
+		bps      int
+		down     int64
+		up       int64
+	}{
+		{"no-slippage", 1000000, 0, 1000000, 1000000},
+		{"half-percent", 1000000, 50, 995000, 1005000},
+		{"rounds-conservatively", 999, 30, 996, 1002},
+		{"max", 1000000, 10000, 0, 2000000},
+		{"zero", 0, 100, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			down, err := ApplySlippage(big.NewInt(tt.expected), tt.bps)
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(tt.down).String(), down.String())
+			up, err := ApplySlippageUp(big.NewInt(tt.expected), tt.bps)
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(tt.up).String(), up.String())
+		})
+	}
+	for _, bps := range []int{-1, 10001} {
+		_, err := ApplySlippage(big.NewInt(1), bps)
+		require.ErrorIs(t, err, ErrInvalidSlippage)
+		_, err = ApplySlippageUp(big.NewInt(1), bps)
+		require.ErrorIs(t, err, ErrInvalidSlippage)
+	}
+	// the input is not modified
+	expected := big.NewInt(100)
+	_, err := ApplySlippage(expected, 100)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(100), expected)
+}
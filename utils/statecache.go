@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// minStateSweep is the number of cached entries from which expired entries are swept
+const minStateSweep = 64
+
+// StateCache caches the results of constant contract calls keyed by the contract and calldata, so
+// that read heavy applications such as dashboards don't repeat the same calls. Results expire once
+// older than the TTL of their method, with zero caching them forever as suits immutable values such
+// as decimals or token0, and concurrent calls for the same key share a single request. Failed calls
+// aren't cached. Expired entries are swept whenever the number of entries doubles, so the cache
+// holds at most about twice as many entries as haven't expired. It is safe for concurrent use
+type StateCache struct {
+	mx         sync.Mutex
+	defaultTTL time.Duration
+	ttls       map[stateMethod]time.Duration
+	entries    map[string]stateEntry
+	inflight   map[string]*stateCall
+	sweepAt    int
+	now        func() time.Time
+}
+
+// stateMethod identifies a method of a contract whose results have their own TTL
+type stateMethod struct {
+	target common.Address
+	method string
+}
+
+// stateEntry is a cached call result along with when it expires, with a zero time never expiring
+type stateEntry struct {
+	out     []interface{}
+	expires time.Time
+}
+
+// stateCall is a request shared by concurrent calls for the same key, with out and err set once
+// done is closed
+type stateCall struct {
+	done chan struct{}
+	out  []interface{}
+	err  error
+}
+
+// NewStateCache returns an empty cache expiring results after ttl unless the method has its own TTL
+// set with SetTTL, with zero caching them forever
+func NewStateCache(ttl time.Duration) *StateCache {
+	return &StateCache{
+		defaultTTL: ttl,
+		ttls:       make(map[stateMethod]time.Duration),
+		entries:    make(map[string]stateEntry),
+		inflight:   make(map[string]*stateCall),
+		sweepAt:    minStateSweep,
+		now:        time.Now,
+	}
+}
+
+// SetTTL sets the TTL of the results of method of the target contract, with zero caching them
+// forever. Results already cached keep their expiry
+func (c *StateCache) SetTTL(target common.Address, method string, ttl time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.ttls[stateMethod{target, method}] = ttl
+}
+
+// Get returns the outputs of calling method with args on the target contract described by
+// parsedABI, returning cached outputs unless they expired. Otherwise the call is made as by
+// Contract.Call, or the outputs are awaited if the same call is already in progress. A call failing
+// because the context of the caller making it was cancelled is retried for the others. The outputs
+// are shared between callers and must not be modified, such as *big.Int values
+func (c *StateCache) Get(
+	ctx context.Context,
+	bc Blockchain,
+	target common.Address,
+	parsedABI abi.ABI,
+	method string,
+	args ...interface{},
+) ([]interface{}, error) {
+	contract := NewContract(target, parsedABI, bc)
+	args, err := contract.args(method, args)
+	if err != nil {
+		return nil, err
+	}
+	input, err := parsedABI.Pack(method, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	key := string(target.Bytes()) + string(input)
+	for {
+		call, leader, out := c.lookup(key)
+		if call == nil {
+			return out, nil
+		}
+		if leader {
+			call.out, call.err = contract.Call(ctx, method, args...)
+			c.complete(key, stateMethod{target, method}, call)
+			return copyOutputs(call.out), call.err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-call.done:
+		}
+		if isContextError(call.err) && ctx.Err() == nil {
+			// the leader gave up rather than the call failing
+			continue
+		}
+		return copyOutputs(call.out), call.err
+	}
+}
+
+// lookup returns the cached outputs of key if they haven't expired. Otherwise it returns the call
+// in progress for key, or starts one returning true if the caller must make it
+func (c *StateCache) lookup(key string) (*stateCall, bool, []interface{}) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if entry, ok := c.entries[key]; ok {
+		if !entry.expired(c.now()) {
+			return nil, false, copyOutputs(entry.out)
+		}
+		delete(c.entries, key)
+	}
+	if call, ok := c.inflight[key]; ok {
+		return call, false, nil
+	}
+	call := &stateCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	return call, true, nil
+}
+
+// complete caches the outputs of call if it succeeded and releases the callers waiting for it
+func (c *StateCache) complete(key string, method stateMethod, call *stateCall) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	delete(c.inflight, key)
+	close(call.done)
+	if call.err != nil {
+		return
+	}
+	ttl, ok := c.ttls[method]
+	if !ok {
+		ttl = c.defaultTTL
+	}
+	entry := stateEntry{out: call.out}
+	if ttl > 0 {
+		entry.expires = c.now().Add(ttl)
+	}
+	c.entries[key] = entry
+	if len(c.entries) >= c.sweepAt {
+		c.sweep()
+	}
+}
+
+// sweep deletes the expired entries and expects the caller to hold the lock. The next sweep happens
+// once the number of remaining entries doubles, so that sweeping takes amortized constant time
+func (c *StateCache) sweep() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if entry.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+	c.sweepAt = 2 * len(c.entries)
+	if c.sweepAt < minStateSweep {
+		c.sweepAt = minStateSweep
+	}
+}
+
+// expired returns true if the entry expired as of now
+func (e stateEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// copyOutputs returns a copy of out so that callers can't replace the cached outputs
+func copyOutputs(out []interface{}) []interface{} {
+	if out == nil {
+		return nil
+	}
+	return append([]interface{}{}, out...)
+}
+
+// isContextError returns true if err is caused by a cancelled context or an expired deadline
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// stateBackend answers the calls of an ERC20 token counting them, holding them until release is
+// closed when it is set
+type stateBackend struct {
+	Blockchain
+	t       *testing.T
+	abi     abi.ABI
+	mx      sync.Mutex
+	calls   map[string]int
+	fail    bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *stateBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	method, err := b.abi.MethodById(call.Data[:4])
+	require.NoError(b.t, err)
+	b.mx.Lock()
+	b.calls[method.Name]++
+	fail, release := b.fail, b.release
+	b.mx.Unlock()
+	if release != nil {
+		b.entered <- struct{}{}
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if fail {
+		return nil, errors.New("connection refused")
+	}
+	switch method.Name {
+	case "decimals":
+		return method.Outputs.Pack(uint8(18))
+	case "symbol":
+		return method.Outputs.Pack("DAI")
+	case "balanceOf":
+		args, err := method.Inputs.Unpack(call.Data[4:])
+		require.NoError(b.t, err)
+		return method.Outputs.Pack(new(big.Int).SetBytes(args[0].(common.Address).Bytes()))
+	default:
+		return method.Outputs.Pack(big.NewInt(1000))
+	}
+}
+
+// count returns the number of calls made to method
+func (b *stateBackend) count(method string) int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.calls[method]
+}
+
+func TestStateCache(t *testing.T) {
+	ctx := context.Background()
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	bc := &stateBackend{t: t, abi: parsed, calls: make(map[string]int), entered: make(chan struct{}, 16)}
+	cache := NewStateCache(time.Minute)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+	token := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	cache.SetTTL(token, "decimals", 0)
+
+	for i := 0; i < 3; i++ {
+		out, err := cache.Get(ctx, bc, token, parsed, "decimals")
+		require.NoError(t, err)
+		require.Equal(t, uint8(18), out[0])
+		out[0] = uint8(0)
+		now = now.Add(time.Hour)
+	}
+	// immutable values are never fetched again
+	require.Equal(t, 1, bc.count("decimals"))
+
+	_, err = cache.Get(ctx, bc, token, parsed, "totalSupply")
+	require.NoError(t, err)
+	now = now.Add(30 * time.Second)
+	_, err = cache.Get(ctx, bc, token, parsed, "totalSupply")
+	require.NoError(t, err)
+	require.Equal(t, 1, bc.count("totalSupply"))
+	now = now.Add(time.Minute)
+	_, err = cache.Get(ctx, bc, token, parsed, "totalSupply")
+	require.NoError(t, err)
+	require.Equal(t, 2, bc.count("totalSupply"))
+
+	// arguments are part of the key
+	for _, holder := range []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x1")} {
+		out, err := cache.Get(ctx, bc, token, parsed, "balanceOf", holder)
+		require.NoError(t, err)
+		require.Equal(t, new(big.Int).SetBytes(holder.Bytes()), out[0])
+	}
+	require.Equal(t, 2, bc.count("balanceOf"))
+	_, err = cache.Get(ctx, bc, token, parsed, "balanceOf", "not an address")
+	require.Error(t, err)
+
+	// failures aren't cached
+	bc.fail = true
+	_, err = cache.Get(ctx, bc, token, parsed, "symbol")
+	require.Error(t, err)
+	bc.fail = false
+	out, err := cache.Get(ctx, bc, token, parsed, "symbol")
+	require.NoError(t, err)
+	require.Equal(t, "DAI", out[0])
+	require.Equal(t, 2, bc.count("symbol"))
+}
+
+func TestStateCacheSingleFlight(t *testing.T) {
+	ctx := context.Background()
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	bc := &stateBackend{t: t, abi: parsed, calls: make(map[string]int), entered: make(chan struct{}, 16), release: make(chan struct{})}
+	cache := NewStateCache(time.Minute)
+	token := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+
+	var wg sync.WaitGroup
+	results := make([][]interface{}, 10)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = cache.Get(ctx, bc, token, parsed, "totalSupply")
+		}(i)
+	}
+	<-bc.entered
+	// give the other callers time to join the call in progress
+	time.Sleep(20 * time.Millisecond)
+	close(bc.release)
+	wg.Wait()
+	for i := range results {
+		require.NoError(t, errs[i])
+		require.Equal(t, big.NewInt(1000), results[i][0])
+	}
+	require.Equal(t, 1, bc.count("totalSupply"))
+
+	// callers waiting on a call abandoned by its caller make it again
+	bc.release = make(chan struct{})
+	cctx, cancel := context.WithCancel(ctx)
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(cctx, bc, token, parsed, "decimals")
+		leaderErr <- err
+	}()
+	<-bc.entered
+	followerErr := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(ctx, bc, token, parsed, "decimals")
+		followerErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	require.True(t, errors.Is(<-leaderErr, context.Canceled))
+	<-bc.entered
+	close(bc.release)
+	require.NoError(t, <-followerErr)
+	require.Equal(t, 2, bc.count("decimals"))
+}
+
+func TestStateCacheSweep(t *testing.T) {
+	ctx := context.Background()
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	bc := &stateBackend{t: t, abi: parsed, calls: make(map[string]int)}
+	cache := NewStateCache(time.Minute)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+	token := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	cache.SetTTL(token, "decimals", 0)
+
+	_, err = cache.Get(ctx, bc, token, parsed, "decimals")
+	require.NoError(t, err)
+	for i := 1; i < minStateSweep-1; i++ {
+		_, err := cache.Get(ctx, bc, token, parsed, "balanceOf", common.BigToAddress(big.NewInt(int64(i))))
+		require.NoError(t, err)
+	}
+	require.Len(t, cache.entries, minStateSweep-1)
+
+	// expired entries which are never looked up again are swept once the cache fills up
+	now = now.Add(2 * time.Minute)
+	_, err = cache.Get(ctx, bc, token, parsed, "balanceOf", common.BigToAddress(big.NewInt(minStateSweep)))
+	require.NoError(t, err)
+	require.Len(t, cache.entries, 2)
+	require.Equal(t, minStateSweep, cache.sweepAt)
+	_, err = cache.Get(ctx, bc, token, parsed, "decimals")
+	require.NoError(t, err)
+	require.Equal(t, 1, bc.count("decimals"))
+}
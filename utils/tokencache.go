@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// TokenInfo is the metadata of an ERC20 token. The name, symbol and decimals are immutable,
+// while the total supply changes as tokens are minted and burned
+type TokenInfo struct {
+	Address  common.Address
+	Name     string
+	Symbol   string
+	Decimals uint8
+	// TotalSupply is refreshed once it is older than the TTL of the cache
+	TotalSupply *big.Int
+	// UpdatedAt is when TotalSupply was fetched
+	UpdatedAt time.Time
+}
+
+// TokenCache caches the metadata of ERC20 tokens, avoiding fetching it repeatedly. Immutable
+// metadata is cached forever while the total supply is refreshed once older than the TTL. It is
+// safe for concurrent use
+type TokenCache struct {
+	ttl time.Duration
+	abi abi.ABI
+
+	mx     sync.Mutex
+	tokens map[common.Address]TokenInfo
+	now    func() time.Time
+}
+
+// NewTokenCache returns an empty cache refreshing total supplies once older than ttl, with zero
+// refreshing them on every call
+func NewTokenCache(ttl time.Duration) (*TokenCache, error) {
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &TokenCache{ttl: ttl, abi: parsed, tokens: make(map[common.Address]TokenInfo), now: time.Now}, nil
+}
+
+// Get returns the metadata of token, fetching whatever isn't cached or is outdated. Tokens such as
+// MKR returning their name and symbol as bytes32 rather than string are supported
+func (c *TokenCache) Get(ctx context.Context, bc Blockchain, token common.Address) (*TokenInfo, error) {
+	info, cached, fresh := c.lookup(token)
+	if fresh {
+		return &info, nil
+	}
+	methods := tokenMethods(cached)
+	results := make([][]byte, len(methods))
+	for i, method := range methods {
+		data, err := c.abi.Pack(method)
+		if err != nil {
+			return nil, errors.Wrapf(err, "pack %s", method)
+		}
+		if results[i], err = bc.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil); err != nil {
+			return nil, errors.Wrap(err, method)
+		}
+	}
+	if err := c.decode(&info, methods, results); err != nil {
+		return nil, err
+	}
+	info.Address = token
+	info = c.store(info)
+	return &info, nil
+}
+
+// Preload fetches the metadata of every token which isn't cached or is outdated in a single
+// aggregated call, so that subsequent calls to Get don't make any requests. Tokens whose metadata
+// couldn't be fetched are left uncached and reported in the returned error, while the rest are cached
+func (c *TokenCache) Preload(ctx context.Context, mc *Multicall, tokens ...common.Address) error {
+	var (
+		calls   []Call
+		pending []TokenInfo
+		methods [][]string
+	)
+	for _, token := range tokens {
+		info, cached, fresh := c.lookup(token)
+		if fresh {
+			continue
+		}
+		tokenMethods := tokenMethods(cached)
+		for _, method := range tokenMethods {
+			data, err := c.abi.Pack(method)
+			if err != nil {
+				return errors.Wrapf(err, "pack %s", method)
+			}
+			calls = append(calls, Call{Target: token, CallData: data})
+		}
+		info.Address = token
+		pending = append(pending, info)
+		methods = append(methods, tokenMethods)
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	results, err := mc.TryAggregate(ctx, calls)
+	if err != nil {
+		return err
+	}
+	if len(results) != len(calls) {
+		return errors.Errorf("%d results returned for %d calls", len(results), len(calls))
+	}
+	var failed []string
+	for i, info := range pending {
+		tokenResults := make([][]byte, len(methods[i]))
+		ok := true
+		for j := range tokenResults {
+			result := results[0]
+			results = results[1:]
+			ok = ok && result.Success
+			tokenResults[j] = result.ReturnData
+		}
+		if !ok || c.decode(&info, methods[i], tokenResults) != nil {
+			failed = append(failed, info.Address.Hex())
+			continue
+		}
+		c.store(info)
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to load tokens %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// lookup returns the cached metadata of token along with whether it is cached and whether the
+// total supply is fresh
+func (c *TokenCache) lookup(token common.Address) (info TokenInfo, cached bool, fresh bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	info, cached = c.tokens[token]
+	if info.TotalSupply != nil {
+		// copied so that callers can't modify the cached value
+		info.TotalSupply = new(big.Int).Set(info.TotalSupply)
+	}
+	fresh = cached && c.now().Sub(info.UpdatedAt) < c.ttl
+	return info, cached, fresh
+}
+
+// tokenMethods returns the token methods to call, which only includes the total supply once the
+// immutable metadata is cached
+func tokenMethods(cached bool) []string {
+	if cached {
+		return []string{"totalSupply"}
+	}
+	return []string{"name", "symbol", "decimals", "totalSupply"}
+}
+
+// store caches info, returning it with the time the total supply was fetched
+func (c *TokenCache) store(info TokenInfo) TokenInfo {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	info.UpdatedAt = c.now()
+	cached := info
+	cached.TotalSupply = new(big.Int).Set(info.TotalSupply)
+	c.tokens[info.Address] = cached
+	return info
+}
+
+// decode sets the fields of info from the return data of the given methods
+func (c *TokenCache) decode(info *TokenInfo, methods []string, results [][]byte) error {
+	for i, method := range methods {
+		var err error
+		switch method {
+		case "name":
+			info.Name, err = c.decodeString(method, results[i])
+		case "symbol":
+			info.Symbol, err = c.decodeString(method, results[i])
+		case "decimals":
+			var out []interface{}
+			if out, err = c.abi.Unpack(method, results[i]); err == nil {
+				info.Decimals = *abi.ConvertType(out[0], new(uint8)).(*uint8)
+			}
+		case "totalSupply":
+			var out []interface{}
+			if out, err = c.abi.Unpack(method, results[i]); err == nil {
+				info.TotalSupply = *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "decode %s", method)
+		}
+	}
+	return nil
+}
+
+// decodeString decodes the string returned by method, falling back to a zero padded bytes32
+func (c *TokenCache) decodeString(method string, data []byte) (string, error) {
+	out, err := c.abi.Unpack(method, data)
+	if err == nil {
+		return *abi.ConvertType(out[0], new(string)).(*string), nil
+	}
+	if len(data) == common.HashLength {
+		return strings.TrimRight(string(data), "\x00"), nil
+	}
+	return "", err
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bonedaddy/go-defi/bindings/erc20"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenBackend answers calls to the metadata methods of a token returning its name as bytes32
+type tokenBackend struct {
+	Blockchain
+	t      *testing.T
+	abi    abi.ABI
+	mx     sync.Mutex
+	calls  map[string]int
+	supply int64
+}
+
+func (b *tokenBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	method, err := b.abi.MethodById(call.Data[:4])
+	require.NoError(b.t, err)
+	b.calls[method.Name]++
+	switch method.Name {
+	case "name":
+		return common.RightPadBytes([]byte("Maker"), 32), nil
+	case "symbol":
+		return method.Outputs.Pack("MKR")
+	case "decimals":
+		return method.Outputs.Pack(uint8(18))
+	default:
+		b.supply++
+		return method.Outputs.Pack(big.NewInt(b.supply))
+	}
+}
+
+func TestTokenCache(t *testing.T) {
+	ctx := context.Background()
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	bc := &tokenBackend{t: t, abi: parsed, calls: make(map[string]int)}
+	cache, err := NewTokenCache(time.Minute)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+	mkr := common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+
+	info, err := cache.Get(ctx, bc, mkr)
+	require.NoError(t, err)
+	require.Equal(t, mkr, info.Address)
+	require.Equal(t, "Maker", info.Name)
+	require.Equal(t, "MKR", info.Symbol)
+	require.Equal(t, uint8(18), info.Decimals)
+	require.Equal(t, "1", info.TotalSupply.String())
+	require.Equal(t, now, info.UpdatedAt)
+
+	// cached values are returned without calls until the ttl passes
+	info.TotalSupply.SetInt64(100)
+	info, err = cache.Get(ctx, bc, mkr)
+	require.NoError(t, err)
+	require.Equal(t, "1", info.TotalSupply.String())
+	require.Equal(t, 1, bc.calls["totalSupply"])
+
+	// then only the total supply is refreshed
+	now = now.Add(time.Minute)
+	info, err = cache.Get(ctx, bc, mkr)
+	require.NoError(t, err)
+	require.Equal(t, "2", info.TotalSupply.String())
+	require.Equal(t, "MKR", info.Symbol)
+	require.Equal(t, map[string]int{"name": 1, "symbol": 1, "decimals": 1, "totalSupply": 2}, bc.calls)
+
+	// concurrent use is safe
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Get(ctx, bc, mkr)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// rpcMethodNotFound is the JSON-RPC error code returned when calling a method the node doesn't expose
+const rpcMethodNotFound = -32601
+
+// CallFrame is a call made while executing a transaction as reported by the callTracer of geth,
+// with the calls it made in turn as Calls. Type is the opcode of the call such as CALL,
+// DELEGATECALL, STATICCALL or CREATE, and To is the created contract for CREATE frames. Error is
+// set when the call failed, in which case Output holds the revert data if any
+type CallFrame struct {
+	Type    string
+	From    common.Address
+	To      common.Address
+	Value   *big.Int
+	Gas     uint64
+	GasUsed uint64
+	Input   []byte
+	Output  []byte
+	Error   string
+	Calls   []*CallFrame
+}
+
+// callFrameJSON is a call frame as encoded by the callTracer
+type callFrameJSON struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      common.Address  `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Gas     hexutil.Uint64  `json:"gas"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Error   string          `json:"error"`
+	Calls   []callFrameJSON `json:"calls"`
+}
+
+// frame converts the decoded call frame and its subcalls
+func (f callFrameJSON) frame() *CallFrame {
+	frame := &CallFrame{
+		Type:    f.Type,
+		From:    f.From,
+		To:      f.To,
+		Gas:     uint64(f.Gas),
+		GasUsed: uint64(f.GasUsed),
+		Input:   f.Input,
+		Output:  f.Output,
+		Error:   f.Error,
+	}
+	if f.Value != nil {
+		frame.Value = f.Value.ToInt()
+	}
+	for _, call := range f.Calls {
+		frame.Calls = append(frame.Calls, call.frame())
+	}
+	return frame
+}
+
+// TraceTransaction replays the mined transaction txHash with debug_traceTransaction and the
+// callTracer, returning the tree of calls it made starting with the transaction itself. This
+// requires a node exposing the debug namespace, which most RPC providers don't, in which case an
+// error wrapping ErrTraceUnsupported is returned. It takes an *rpc.Client rather than an
+// *ethclient.Client since the latter doesn't expose the debug namespace
+func TraceTransaction(ctx context.Context, client *rpc.Client, txHash common.Hash) (*CallFrame, error) {
+	var result callFrameJSON
+	err := client.CallContext(ctx, &result, "debug_traceTransaction", txHash, map[string]string{"tracer": "callTracer"})
+	if err != nil {
+		if isMethodNotFound(err) {
+			return nil, errors.Wrap(ErrTraceUnsupported, err.Error())
+		}
+		return nil, errors.Wrapf(err, "trace transaction %s", txHash.Hex())
+	}
+	return result.frame(), nil
+}
+
+// Failed returns true if the call reverted or otherwise failed, such as by running out of gas
+func (f *CallFrame) Failed() bool {
+	return f.Error != ""
+}
+
+// DeepestRevert returns the deepest failed call along the path of failed calls starting at f, which
+// is where the failure of a multi-contract interaction originated, or nil if f didn't fail. When
+// several subcalls of a failed call failed, such as when earlier failures were caught with
+// try/catch, the last one is followed as the failure that propagated up
+func (f *CallFrame) DeepestRevert() *CallFrame {
+	if !f.Failed() {
+		return nil
+	}
+	for i := len(f.Calls) - 1; i >= 0; i-- {
+		if deepest := f.Calls[i].DeepestRevert(); deepest != nil {
+			return deepest
+		}
+	}
+	return f
+}
+
+// RevertReason returns the reason of the deepest failed call as decoded by DecodeRevert, falling
+// back to the error reported by the tracer such as "out of gas" when the call returned no revert
+// data. It returns an empty string if f didn't fail
+func (f *CallFrame) RevertReason() string {
+	deepest := f.DeepestRevert()
+	if deepest == nil {
+		return ""
+	}
+	if reason, err := DecodeRevert(deepest.Output); err == nil {
+		return reason
+	}
+	return deepest.Error
+}
+
+// isMethodNotFound returns true if err was returned by a node which doesn't expose the method called
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFound {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "method not found") || strings.Contains(msg, "does not exist/is not available")
+}
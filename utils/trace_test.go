@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// traceService serves debug_traceTransaction returning trace for the transaction hash
+type traceService struct {
+	hash  common.Hash
+	trace json.RawMessage
+}
+
+func (s *traceService) TraceTransaction(hash common.Hash, config map[string]interface{}) (json.RawMessage, error) {
+	if hash != s.hash {
+		return nil, errors.Errorf("transaction %s not found", hash.Hex())
+	}
+	if config["tracer"] != "callTracer" {
+		return nil, errors.Errorf("unexpected tracer %v", config["tracer"])
+	}
+	return s.trace, nil
+}
+
+// errorRevertData returns the revert data of Error(reason)
+func errorRevertData(t *testing.T, reason string) string {
+	typ, err := abi.NewType("string", "", nil)
+	require.NoError(t, err)
+	packed, err := abi.Arguments{{Type: typ}}.Pack(reason)
+	require.NoError(t, err)
+	return hexutil.Encode(append(append([]byte{}, errorSelector...), packed...))
+}
+
+func TestTraceTransaction(t *testing.T) {
+	ctx := context.Background()
+	hash := common.HexToHash("0x01")
+	// a router calling a pool which caught the revert of a first token transfer before the second reverted
+	trace := fmt.Sprintf(`{
+		"type": "CALL", "from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002",
+		"value": "0x0", "gas": "0x30000", "gasUsed": "0x20000", "input": "0x12345678", "output": "%[1]s", "error": "execution reverted",
+		"calls": [{
+			"type": "CALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000003",
+			"gas": "0x20000", "gasUsed": "0x10000", "input": "0x", "output": "%[1]s", "error": "execution reverted",
+			"calls": [
+				{"type": "CALL", "from": "0x0000000000000000000000000000000000000003", "to": "0x0000000000000000000000000000000000000004", "gas": "0x100", "gasUsed": "0x100", "input": "0x", "error": "out of gas"},
+				{"type": "STATICCALL", "from": "0x0000000000000000000000000000000000000003", "to": "0x0000000000000000000000000000000000000004", "gas": "0x100", "gasUsed": "0x10", "input": "0x", "output": "0x01"},
+				{"type": "CALL", "from": "0x0000000000000000000000000000000000000003", "to": "0x0000000000000000000000000000000000000005", "gas": "0x100", "gasUsed": "0x50", "input": "0x", "output": "%[2]s", "error": "execution reverted"}
+			]
+		}]
+	}`, errorRevertData(t, "Pool: swap failed"), errorRevertData(t, "ERC20: transfer amount exceeds balance"))
+
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("debug", &traceService{hash: hash, trace: json.RawMessage(trace)}))
+	t.Cleanup(server.Stop)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+
+	frame, err := TraceTransaction(ctx, client, hash)
+	require.NoError(t, err)
+	require.True(t, frame.Failed())
+	require.Equal(t, "0", frame.Value.String())
+	require.Equal(t, uint64(0x20000), frame.GasUsed)
+	require.Equal(t, "0x12345678", hexutil.Encode(frame.Input))
+	require.Len(t, frame.Calls, 1)
+	require.Nil(t, frame.Calls[0].Value)
+	require.Len(t, frame.Calls[0].Calls, 3)
+	require.Equal(t, "STATICCALL", frame.Calls[0].Calls[1].Type)
+	require.False(t, frame.Calls[0].Calls[1].Failed())
+
+	deepest := frame.DeepestRevert()
+	require.Equal(t, common.HexToAddress("0x5"), deepest.To)
+	require.Equal(t, "ERC20: transfer amount exceeds balance", frame.RevertReason())
+	// the tracer error is reported without revert data
+	require.Equal(t, "out of gas", frame.Calls[0].Calls[0].RevertReason())
+	require.Nil(t, frame.Calls[0].Calls[1].DeepestRevert())
+	require.Empty(t, frame.Calls[0].Calls[1].RevertReason())
+
+	_, err = TraceTransaction(ctx, client, common.HexToHash("0x02"))
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrTraceUnsupported))
+}
+
+func TestTraceTransactionUnsupported(t *testing.T) {
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+	_, err := TraceTransaction(context.Background(), client, common.HexToHash("0x01"))
+	require.True(t, errors.Is(err, ErrTraceUnsupported))
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/pkg/errors"
+)
+
+// tupleArg holds the components of a tuple argument in the order of the ABI, see NewTupleArg
+type tupleArg []interface{}
+
+// NewTupleArg returns a tuple, or solidity struct, argument for the methods of a Contract, such as
+// the params of exactInputSingle of the uniswap v3 router. The ABI encoder of go-ethereum expects
+// tuples as Go structs whose fields are named after the components of the tuple, with the field of
+// component amountIn being AmountIn or tagged abi:"amountIn", so field order alone isn't enough.
+// Contract builds that struct from the values of the tuple, given in the order of the components
+// in the ABI, when packing arguments. Each value must have the Go type the encoder expects for its
+// component, such as *big.Int for uint256 and uint24 or uint32 for uint32, with values of a type
+// convertible to it being converted, such as common.Hash for bytes32. Nested tuples are given with
+// NewTupleArg, and arrays of tuples as an []interface{} of them. Structs with the expected fields
+// can still be passed as is
+func NewTupleArg(values ...interface{}) interface{} {
+	return tupleArg(values)
+}
+
+// convertTupleArgs replaces the tuples created with NewTupleArg among args with the structs the
+// ABI encoder expects for inputs. Args are returned as is if their number doesn't match, leaving
+// the encoder to report it
+func convertTupleArgs(inputs abi.Arguments, args []interface{}) ([]interface{}, error) {
+	if len(args) != len(inputs) {
+		return args, nil
+	}
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := convertTupleArg(inputs[i].Type, arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "argument %s", argName(inputs[i].Name, i))
+		}
+		converted[i] = value
+	}
+	return converted, nil
+}
+
+// convertTupleArg converts arg into the Go type of typ if it is a tuple created with NewTupleArg,
+// or an array of them
+func convertTupleArg(typ abi.Type, arg interface{}) (interface{}, error) {
+	switch typ.T {
+	case abi.TupleTy:
+		tuple, ok := arg.(tupleArg)
+		if !ok {
+			return arg, nil
+		}
+		if len(tuple) != len(typ.TupleElems) {
+			return nil, errors.Errorf("got %d values for a tuple of %d components", len(tuple), len(typ.TupleElems))
+		}
+		// the fields of the struct type follow the order of the components
+		v := reflect.New(typ.GetType()).Elem()
+		for i, elem := range typ.TupleElems {
+			name := argName(typ.TupleRawNames[i], i)
+			value, err := convertTupleArg(*elem, tuple[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "component %s", name)
+			}
+			if err := setTupleValue(v.Field(i), value); err != nil {
+				return nil, errors.Wrapf(err, "component %s", name)
+			}
+		}
+		return v.Interface(), nil
+	case abi.SliceTy, abi.ArrayTy:
+		elems, ok := arg.([]interface{})
+		if !ok || typ.Elem.T != abi.TupleTy {
+			return arg, nil
+		}
+		var v reflect.Value
+		if typ.T == abi.SliceTy {
+			v = reflect.MakeSlice(typ.GetType(), len(elems), len(elems))
+		} else {
+			if len(elems) != typ.Size {
+				return nil, errors.Errorf("got %d tuples for an array of %d", len(elems), typ.Size)
+			}
+			v = reflect.New(typ.GetType()).Elem()
+		}
+		for i, elem := range elems {
+			value, err := convertTupleArg(*typ.Elem, elem)
+			if err != nil {
+				return nil, errors.Wrapf(err, "element %d", i)
+			}
+			if err := setTupleValue(v.Index(i), value); err != nil {
+				return nil, errors.Wrapf(err, "element %d", i)
+			}
+		}
+		return v.Interface(), nil
+	}
+	return arg, nil
+}
+
+// setTupleValue sets dst to value, converting it if it is of a different type of the same kind
+func setTupleValue(dst reflect.Value, value interface{}) error {
+	if value == nil {
+		return errors.Errorf("nil value for %s", dst.Type())
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(dst.Type()):
+		dst.Set(v)
+	case v.Kind() == dst.Kind() && v.Type().ConvertibleTo(dst.Type()):
+		dst.Set(v.Convert(dst.Type()))
+	default:
+		return errors.Errorf("cannot use %s as %s", v.Type(), dst.Type())
+	}
+	return nil
+}
+
+// argName returns name, or argN for unnamed arguments at position i as named by DecodeInput
+func argName(name string, i int) string {
+	if name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+	return name
+}
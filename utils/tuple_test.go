@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// tupleABI describes methods taking tuples, with exactInputSingle of the uniswap v3 router
+const tupleABI = `[
+	{"inputs":[{"components":[
+		{"name":"tokenIn","type":"address"},
+		{"name":"tokenOut","type":"address"},
+		{"name":"fee","type":"uint24"},
+		{"name":"recipient","type":"address"},
+		{"name":"amountIn","type":"uint256"},
+		{"name":"amountOutMinimum","type":"uint256"},
+		{"name":"sqrtPriceLimitX96","type":"uint160"}
+	],"name":"params","type":"tuple"}],"name":"exactInputSingle","outputs":[{"name":"amountOut","type":"uint256"}],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"components":[
+		{"name":"id","type":"bytes32"},
+		{"name":"amount","type":"uint256"},
+		{"name":"data","type":"bytes"}
+	],"name":"","type":"tuple[]"}],"name":"batch","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+func TestContractTupleArgs(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	// the answer contract returns 42 whatever the calldata
+	address := deployCode(t, sim, auth, answerCode)
+	contract, err := NewContractFromJSON(address, tupleABI, sim)
+	require.NoError(t, err)
+
+	tokenIn := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	tokenOut := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	params := NewTupleArg(tokenIn, tokenOut, big.NewInt(3000), auth.From, big.NewInt(1000), big.NewInt(900), big.NewInt(0))
+	out, err := contract.Call(ctx, "exactInputSingle", params)
+	require.NoError(t, err)
+	require.Equal(t, "42", out[0].(*big.Int).String())
+
+	tx, err := contract.Transact(ctx, auth, "exactInputSingle", params)
+	require.NoError(t, err)
+	// a static tuple is encoded in place as its seven words
+	expected := common.FromHex("04e45aaf")
+	for _, word := range [][]byte{
+		tokenIn.Bytes(), tokenOut.Bytes(), big.NewInt(3000).Bytes(), auth.From.Bytes(),
+		big.NewInt(1000).Bytes(), big.NewInt(900).Bytes(), nil,
+	} {
+		expected = append(expected, common.LeftPadBytes(word, 32)...)
+	}
+	require.Equal(t, expected, tx.Data())
+
+	_, err = contract.Call(ctx, "exactInputSingle", NewTupleArg(tokenIn, tokenOut))
+	require.Error(t, err)
+	_, err = contract.Transact(ctx, auth, "exactInputSingle", NewTupleArg(tokenIn, tokenOut, uint32(3000), auth.From, big.NewInt(1000), big.NewInt(900), big.NewInt(0)))
+	require.Error(t, err)
+}
+
+func TestConvertTupleArgs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(tupleABI))
+	require.NoError(t, err)
+	method := parsed.Methods["batch"]
+	require.Equal(t, "3c1ff5f9", common.Bytes2Hex(method.ID))
+	id := crypto.Keccak256Hash([]byte("id"))
+
+	for _, tt := range []struct {
+		name  string
+		batch interface{}
+		valid bool
+	}{
+		{"tuples", []interface{}{NewTupleArg(id, big.NewInt(1), []byte{1}), NewTupleArg(id, big.NewInt(2), []byte{})}, true},
+		{"empty", []interface{}{}, true},
+		{"components", []interface{}{NewTupleArg(id, big.NewInt(1))}, false},
+		{"nil component", []interface{}{NewTupleArg(id, nil, []byte{})}, false},
+		{"wrong type", []interface{}{NewTupleArg("id", big.NewInt(1), []byte{})}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := convertTupleArgs(method.Inputs, []interface{}{tt.batch})
+			if !tt.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			input, err := parsed.Pack("batch", args...)
+			require.NoError(t, err)
+			unpacked, err := method.Inputs.Unpack(input[4:])
+			require.NoError(t, err)
+			var batch []struct {
+				Id     [32]byte
+				Amount *big.Int
+				Data   []byte
+			}
+			abi.ConvertType(unpacked[0], &batch)
+			require.Len(t, batch, len(tt.batch.([]interface{})))
+			for i, tuple := range batch {
+				require.Equal(t, id, common.Hash(tuple.Id))
+				require.Equal(t, int64(i+1), tuple.Amount.Int64())
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// BuildTx assembles and signs a transaction sending value and data to the to address, without
+// broadcasting it so that it can be inspected or included in a bundle before calling Send. This
+// allows calling contracts the package has no bindings for. The nonce, gas limit and fees set on
+// the authorizer are used when present, otherwise they are populated from the chain in the same
+// way as the contract bindings, sending an EIP-1559 transaction when the chain supports it unless
+// another type is selected with TxType. An access list set with SetAccessList is attached to the
+// transaction and then cleared. The transaction is signed while holding the lock, so this must
+// not be called while the lock is already held.
+func (a *Authorizer) BuildTx(ctx context.Context, bc Blockchain, to common.Address, value *big.Int, data []byte) (*types.Transaction, error) {
+	if err := a.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer a.Unlock()
+	return a.buildTx(ctx, bc, a.Nonce, a.GasLimit, to, value, data)
+}
+
+// buildTx is like BuildTx but uses the given nonce and gas limit, populating them from the chain
+// when nil or zero respectively. This expects the caller to hold the lock
+func (a *Authorizer) buildTx(ctx context.Context, bc Blockchain, nonce *big.Int, gas uint64, to common.Address, value *big.Int, data []byte) (*types.Transaction, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if nonce == nil {
+		pending, err := bc.PendingNonceAt(ctx, a.From)
+		if err != nil {
+			return nil, errors.Wrap(err, "pending nonce at")
+		}
+		nonce = new(big.Int).SetUint64(pending)
+	}
+	if err := a.checkTxType(); err != nil {
+		return nil, err
+	}
+	if err := a.fillAccessList(ctx, bc, to, value, data); err != nil {
+		return nil, err
+	}
+	if gas == 0 {
+		estimate, err := bc.EstimateGas(ctx, ethereum.CallMsg{From: a.From, To: &to, Value: value, Data: data, AccessList: a.accessList})
+		if err != nil {
+			if reason, ok := revertReason(err); ok {
+				return nil, errors.Errorf("estimate gas: execution reverted: %s", reason)
+			}
+			return nil, errors.Wrap(err, "estimate gas")
+		}
+		gas = a.scaleGas(estimate)
+	}
+	inner, err := a.txData(ctx, bc, nonce.Uint64(), gas, to, value, data)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := a.signTx(types.NewTx(inner))
+	if err != nil {
+		return nil, errors.Wrap(err, "sign transaction")
+	}
+	a.accessList = nil
+	return tx, nil
+}
+
+// txData returns the unsigned transaction using the configured fees or those suggested by
+// the chain, and expects the caller to hold the lock
+func (a *Authorizer) txData(ctx context.Context, bc Blockchain, nonce, gas uint64, to common.Address, value *big.Int, data []byte) (types.TxData, error) {
+	if err := a.checkTxType(); err != nil {
+		return nil, err
+	}
+	if a.TxType == TxTypeLegacy || a.TxType == TxTypeAccessList {
+		gasPrice := a.GasPrice
+		if gasPrice == nil {
+			var err error
+			if gasPrice, err = bc.SuggestGasPrice(ctx); err != nil {
+				return nil, errors.Wrap(err, "suggest gas price")
+			}
+		}
+		if a.TxType == TxTypeLegacy {
+			return &types.LegacyTx{Nonce: nonce, GasPrice: gasPrice, Gas: gas, To: &to, Value: value, Data: data}, nil
+		}
+		// the chain id is filled in by the signer
+		return &types.AccessListTx{
+			Nonce:      nonce,
+			GasPrice:   gasPrice,
+			Gas:        gas,
+			To:         &to,
+			Value:      value,
+			Data:       data,
+			AccessList: a.accessList,
+		}, nil
+	}
+	if a.isLegacy() {
+		return &types.LegacyTx{Nonce: nonce, GasPrice: a.GasPrice, Gas: gas, To: &to, Value: value, Data: data}, nil
+	}
+	head, err := bc.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "header by number")
+	}
+	if head.BaseFee == nil {
+		if a.TxType == TxTypeDynamicFee || a.isDynamicFee() || a.accessList != nil {
+			return nil, errors.New("chain does not support EIP-1559 transactions")
+		}
+		gasPrice, err := bc.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "suggest gas price")
+		}
+		return &types.LegacyTx{Nonce: nonce, GasPrice: gasPrice, Gas: gas, To: &to, Value: value, Data: data}, nil
+	}
+	tip := a.GasTipCap
+	if tip == nil {
+		if tip, err = bc.SuggestGasTipCap(ctx); err != nil {
+			return nil, errors.Wrap(err, "suggest gas tip cap")
+		}
+	}
+	feeCap := a.GasFeeCap
+	if feeCap == nil {
+		// matches the bindings, leaving room for the base fee to double
+		feeCap = new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	}
+	if feeCap.Cmp(tip) < 0 {
+		return nil, errors.Errorf("max fee per gas %s is less than max priority fee per gas %s", feeCap, tip)
+	}
+	// the chain id is filled in by the signer as with the bindings
+	return &types.DynamicFeeTx{
+		Nonce:      nonce,
+		GasTipCap:  tip,
+		GasFeeCap:  feeCap,
+		Gas:        gas,
+		To:         &to,
+		Value:      value,
+		Data:       data,
+		AccessList: a.accessList,
+	}, nil
+}
+
+// Send broadcasts a transaction built with BuildTx, or any other signed transaction. Errors
+// returned by the node are wrapped in a *SendError, see ClassifySendError. Transactions signed
+// by an authorizer in dry run mode are simulated instead, see Authorizer.DryRun
+func Send(ctx context.Context, bc Blockchain, tx *types.Transaction) error {
+	if record, ok := lookupDryRun(tx.Hash()); ok {
+		return sendDryRun(ctx, bc, tx, record)
+	}
+	if err := bc.SendTransaction(ctx, tx); err != nil {
+		return errors.Wrap(newSendError(err), "send transaction")
+	}
+	return nil
+}
+
+// SignRawTx signs tx returning the RLP encoded signed transaction as 0x prefixed hex, which can be
+// broadcast from another machine with BroadcastRaw without the key ever leaving this one. The
+// transaction must be complete, including its nonce, gas and fees, as nothing is populated from the
+// chain. An error wrapping ErrChainIDMismatch is returned if tx specifies a different chain id, or
+// if the authorizer signs for a chain other than chainID. This claims the lock and must not be
+// called while the lock is already held.
+func (a *Authorizer) SignRawTx(tx *types.Transaction, chainID *big.Int) (string, error) {
+	if chainID == nil {
+		return "", ErrNilChainID
+	}
+	// unsigned legacy transactions don't encode a chain id
+	if tx.Type() != types.LegacyTxType && tx.ChainId().Sign() != 0 && tx.ChainId().Cmp(chainID) != 0 {
+		return "", errors.Wrapf(ErrChainIDMismatch, "transaction is for chain %s not %s", tx.ChainId(), chainID)
+	}
+	a.Lock()
+	defer a.Unlock()
+	signed, err := a.signTx(tx)
+	if err != nil {
+		return "", errors.Wrap(err, "sign transaction")
+	}
+	if signed.ChainId().Cmp(chainID) != 0 {
+		return "", errors.Wrapf(ErrChainIDMismatch, "authorizer signed for chain %s not %s", signed.ChainId(), chainID)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return "", errors.Wrap(err, "encode transaction")
+	}
+	return hexutil.Encode(raw), nil
+}
+
+// BroadcastRaw decodes a transaction signed with SignRawTx and broadcasts it, returning its hash
+func BroadcastRaw(ctx context.Context, bc Blockchain, rawHex string) (common.Hash, error) {
+	raw, err := hexutil.Decode(rawHex)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "decode hex")
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, errors.Wrap(err, "decode transaction")
+	}
+	if err := Send(ctx, bc, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// SenderOf recovers the address which signed tx, such as to verify the sender of a raw transaction
+// before relaying it with BroadcastRaw. Legacy transactions, with or without EIP-155 replay
+// protection, and typed transactions such as EIP-1559 ones are all supported. An error wrapping
+// ErrTxUnsigned is returned if tx carries no signature, and one wrapping ErrChainIDMismatch if it
+// was signed for a chain other than chainID
+func SenderOf(tx *types.Transaction, chainID *big.Int) (common.Address, error) {
+	if chainID == nil {
+		return common.Address{}, ErrNilChainID
+	}
+	if v, r, s := tx.RawSignatureValues(); v.Sign() == 0 && r.Sign() == 0 && s.Sign() == 0 {
+		return common.Address{}, errors.Wrapf(ErrTxUnsigned, "transaction %s", tx.Hash().Hex())
+	}
+	// unprotected legacy transactions are valid on every chain
+	if tx.Protected() && tx.ChainId().Cmp(chainID) != 0 {
+		return common.Address{}, errors.Wrapf(ErrChainIDMismatch, "transaction is for chain %s not %s", tx.ChainId(), chainID)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "recover sender")
+	}
+	return from, nil
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTx(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	tx, err := auth.BuildTx(ctx, sim, to, big.NewInt(1000), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	require.Equal(t, uint64(0), tx.Nonce())
+	require.Equal(t, uint64(21000), tx.Gas())
+	require.Equal(t, &to, tx.To())
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, sender)
+
+	// building doesn't broadcast the transaction
+	nonce, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), nonce)
+
+	require.NoError(t, Send(ctx, sim, tx))
+	sim.Commit()
+	balance, err := sim.BalanceAt(ctx, to, nil)
+	require.NoError(t, err)
+	require.Equal(t, "1000", balance.String())
+
+	// explicitly configured gas settings take precedence
+	auth.UseLegacyGas(big.NewInt(2000000000))
+	auth.GasLimit = 30000
+	tx, err = auth.BuildTx(ctx, sim, to, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.LegacyTxType), tx.Type())
+	require.Equal(t, uint64(1), tx.Nonce())
+	require.Equal(t, uint64(30000), tx.Gas())
+	require.Equal(t, "2000000000", tx.GasPrice().String())
+	require.Equal(t, "0", tx.Value().String())
+}
+
+func TestSignRawTx(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx := types.NewTransaction(0, to, big.NewInt(1000), 21000, gasPrice, nil)
+
+	raw, err := auth.SignRawTx(tx, big.NewInt(1337))
+	require.NoError(t, err)
+	require.Equal(t, "0x", raw[:2])
+	hash, err := BroadcastRaw(ctx, sim, raw)
+	require.NoError(t, err)
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	// signing for another chain than the authorizer's or the transaction's is rejected
+	_, err = auth.SignRawTx(tx, big.NewInt(1))
+	require.True(t, errors.Is(err, ErrChainIDMismatch))
+	_, err = auth.SignRawTx(types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(5), Gas: 21000, To: &to}), big.NewInt(1337))
+	require.True(t, errors.Is(err, ErrChainIDMismatch))
+	_, err = BroadcastRaw(ctx, sim, "0x1234")
+	require.Error(t, err)
+}
+
+func TestSenderOf(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(pk.PublicKey)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	chainID := big.NewInt(1337)
+	for _, tt := range []struct {
+		name   string
+		tx     *types.Transaction
+		signer types.Signer
+	}{
+		{"homestead", types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}},
+		{"eip155", types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil), types.NewEIP155Signer(chainID)},
+		{"access list", types.NewTx(&types.AccessListTx{ChainID: chainID, Gas: 21000, GasPrice: big.NewInt(1), To: &to}), types.NewEIP2930Signer(chainID)},
+		{"dynamic fee", types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Gas: 21000, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), To: &to}), types.NewLondonSigner(chainID)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SenderOf(tt.tx, chainID)
+			require.True(t, errors.Is(err, ErrTxUnsigned))
+			signed, err := types.SignTx(tt.tx, tt.signer, pk)
+			require.NoError(t, err)
+			sender, err := SenderOf(signed, chainID)
+			require.NoError(t, err)
+			require.Equal(t, from, sender)
+			_, err = SenderOf(signed, nil)
+			require.Equal(t, ErrNilChainID, err)
+			// only replay protected transactions are bound to a chain
+			sender, err = SenderOf(signed, big.NewInt(1))
+			if tt.name == "homestead" {
+				require.NoError(t, err)
+				require.Equal(t, from, sender)
+			} else {
+				require.True(t, errors.Is(err, ErrChainIDMismatch))
+			}
+		})
+	}
+}
+
+func TestGasLimitMultiplier(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	address := deployCode(t, sim, auth, answerCode)
+	contract, err := NewContractFromJSON(address, answerABI, sim)
+	require.NoError(t, err)
+	estimated, err := contract.Transact(ctx, auth, "setAnswer", big.NewInt(1))
+	require.NoError(t, err)
+	sim.Commit()
+
+	auth.GasLimitMultiplier = 1.5
+	tx, err := auth.BuildTx(ctx, sim, auth.From, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(31500), tx.Gas())
+
+	// estimates made by bindings are scaled as well
+	tx, err = contract.Transact(ctx, auth, "setAnswer", big.NewInt(2))
+	require.NoError(t, err)
+	require.Equal(t, uint64(float64(estimated.Gas())*1.5), tx.Gas())
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, sender)
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	// explicit gas limits bypass the multiplier
+	auth.GasLimit = 30000
+	tx, err = auth.BuildTx(ctx, sim, auth.From, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(30000), tx.Gas())
+	tx, err = contract.Transact(ctx, auth, "setAnswer", big.NewInt(3))
+	require.NoError(t, err)
+	require.Equal(t, uint64(30000), tx.Gas())
+}
+
+// legacyBackend serves blocks without a base fee, as on chains not supporting EIP-1559
+type legacyBackend struct {
+	simulatedBackend
+	headers int
+}
+
+func (b *legacyBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.headers++
+	head, err := b.simulatedBackend.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	head.BaseFee = nil
+	return head, nil
+}
+
+func TestAutoGas(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+
+	auth.UseLegacyGas(big.NewInt(1))
+	require.NoError(t, auth.AutoGas(ctx, sim))
+	require.True(t, auth.IsDynamicFee())
+	require.Nil(t, auth.GasPrice)
+	head, err := sim.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	tip, err := sim.SuggestGasTipCap(ctx)
+	require.NoError(t, err)
+	require.Equal(t, tip.String(), auth.GasTipCap.String())
+	require.Equal(t, new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2))).String(), auth.GasFeeCap.String())
+
+	legacy := &legacyBackend{simulatedBackend: sim}
+	require.NoError(t, auth.AutoGas(ctx, legacy))
+	require.False(t, auth.IsDynamicFee())
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	require.Equal(t, gasPrice.String(), auth.GasPrice.String())
+	// the fee model of the backend is only probed once per authorizer
+	require.NoError(t, auth.AutoGas(ctx, legacy))
+	require.Equal(t, 1, legacy.headers)
+	require.NoError(t, auth.Clone().AutoGas(ctx, legacy))
+	require.Equal(t, 2, legacy.headers)
+	auth.ResetAutoGas()
+	require.NoError(t, auth.AutoGas(ctx, legacy))
+	require.Equal(t, 3, legacy.headers)
+	tx, err := auth.BuildTx(ctx, sim, common.HexToAddress("0x000000000000000000000000000000000000dEaD"), big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.LegacyTxType), tx.Type())
+}
+
+func TestMaxGasPrice(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	address := deployCode(t, sim, auth, answerCode)
+	contract, err := NewContractFromJSON(address, answerABI, sim)
+	require.NoError(t, err)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	auth.MaxGasPriceWei = big.NewInt(1)
+	_, err = auth.BuildTx(ctx, sim, to, big.NewInt(1), nil)
+	require.True(t, errors.Is(err, ErrGasPriceTooHigh))
+	_, err = contract.Transact(ctx, auth, "setAnswer", big.NewInt(1))
+	require.True(t, errors.Is(err, ErrGasPriceTooHigh))
+	pending, err := sim.PendingNonceAt(ctx, auth.From)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), pending)
+
+	auth.MaxGasPriceWei = Ether(1)
+	tx, err := auth.BuildTx(ctx, sim, to, big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.NoError(t, Send(ctx, sim, tx))
+	_, err = contract.Transact(ctx, auth, "setAnswer", big.NewInt(1))
+	require.NoError(t, err)
+
+	// legacy gas prices are capped as well
+	auth.UseLegacyGas(Gwei(100))
+	auth.MaxGasPriceWei = Gwei(99)
+	_, err = auth.BuildTx(ctx, sim, to, big.NewInt(1), nil)
+	require.True(t, errors.Is(err, ErrGasPriceTooHigh))
+	auth.MaxGasPriceWei = Gwei(100)
+	_, err = auth.BuildTx(ctx, sim, to, big.NewInt(1), nil)
+	require.NoError(t, err)
+}
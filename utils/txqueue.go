@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// QueueResult is the outcome of a transaction enqueued on a TxQueue. Tx is set once the
+// transaction was sent, and Receipt once it was mined, including when it reverted in
+// which case Err wraps ErrTxReverted. It isn't named TxResult, which is the outcome of a
+// mined transaction as classified by ClassifyReceipt
+type QueueResult struct {
+	Tx      *types.Transaction
+	Receipt *types.Receipt
+	Err     error
+}
+
+// txJob is a builder waiting in the queue along with the channel its result is sent on
+type txJob struct {
+	build  func(*bind.TransactOpts) (*types.Transaction, error)
+	result chan QueueResult
+}
+
+// TxQueue sends transactions from a single account strictly in order using a background
+// worker, which assigns the pending nonce, builds and sends each transaction and waits for
+// it to be mined before moving on to the next. This decouples producers from the rate at
+// which transactions are mined, as enqueueing never blocks
+type TxQueue struct {
+	bc     Blockchain
+	auth   *Authorizer
+	opts   WaitOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	// wake is signalled whenever a job is enqueued or the queue is closed
+	wake chan struct{}
+	done chan struct{}
+
+	mx     sync.Mutex
+	jobs   []txJob
+	closed bool
+}
+
+// NewTxQueue starts a queue sending transactions signed by auth, waiting for each to be mined
+// using opts on top of the PollInterval of auth. Cancelling ctx stops the worker, failing the
+// transaction in flight and those still queued with the context error. Close must be called to
+// release the worker
+func NewTxQueue(ctx context.Context, bc Blockchain, auth *Authorizer, opts ...WaitOption) *TxQueue {
+	ctx, cancel := context.WithCancel(ctx)
+	q := &TxQueue{
+		bc:     bc,
+		auth:   auth,
+		opts:   newWaitOptions(auth.WaitDefaults(opts...)),
+		ctx:    ctx,
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue adds builder to the end of the queue, returning a channel receiving its result once
+// the transaction is mined or fails. The builder is invoked while holding the authorizer lock
+// with the nonce set, and is typically a closure calling a contract binding method with the
+// given options. Enqueueing onto a closed queue fails with ErrQueueClosed
+func (q *TxQueue) Enqueue(builder func(*bind.TransactOpts) (*types.Transaction, error)) <-chan QueueResult {
+	result := make(chan QueueResult, 1)
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if q.closed {
+		result <- QueueResult{Err: ErrQueueClosed}
+		close(result)
+		return result
+	}
+	q.jobs = append(q.jobs, txJob{build: builder, result: result})
+	q.signal()
+	return result
+}
+
+// Len returns the number of transactions waiting to be sent, excluding the one in flight
+func (q *TxQueue) Len() int {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return len(q.jobs)
+}
+
+// Close stops accepting transactions and blocks until those already enqueued have been
+// processed. Cancel the context given to NewTxQueue to stop without draining the queue
+func (q *TxQueue) Close() {
+	_ = q.Shutdown(context.Background())
+}
+
+// Shutdown is like Close but stops waiting for the queue to drain once ctx is done, such as when
+// a shutdown timeout passes. The worker is then stopped, failing the transaction in flight and
+// those still queued with the context error, and the error of ctx is returned once it exited.
+// Transactions already broadcast may still be mined afterwards
+func (q *TxQueue) Shutdown(ctx context.Context) error {
+	q.mx.Lock()
+	if !q.closed {
+		q.closed = true
+		q.signal()
+	}
+	q.mx.Unlock()
+	defer q.cancel()
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-q.done
+		return ctx.Err()
+	}
+}
+
+// signal wakes the worker without blocking, and expects the caller to hold the lock
+func (q *TxQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run processes jobs in order until the queue is closed and drained, or the context is cancelled
+func (q *TxQueue) run() {
+	defer close(q.done)
+	for {
+		q.mx.Lock()
+		if len(q.jobs) == 0 {
+			closed := q.closed
+			q.mx.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-q.wake:
+			case <-q.ctx.Done():
+			}
+			if q.ctx.Err() != nil {
+				q.fail(q.ctx.Err())
+				return
+			}
+			continue
+		}
+		job := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		q.mx.Unlock()
+		job.result <- q.process(job.build)
+		close(job.result)
+	}
+}
+
+// fail closes the queue failing every job still queued with err
+func (q *TxQueue) fail(err error) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.closed = true
+	for _, job := range q.jobs {
+		job.result <- QueueResult{Err: err}
+		close(job.result)
+	}
+	q.jobs = nil
+}
+
+// process sends the transaction built by build using the pending nonce and waits for it to be mined
+func (q *TxQueue) process(build func(*bind.TransactOpts) (*types.Transaction, error)) QueueResult {
+	if err := q.ctx.Err(); err != nil {
+		return QueueResult{Err: err}
+	}
+	// the previous transaction was mined so the pending nonce is the next one
+	nonce, err := q.bc.PendingNonceAt(q.ctx, q.auth.From)
+	if err != nil {
+		return QueueResult{Err: errors.Wrap(err, "pending nonce at")}
+	}
+	tx, err := q.auth.Transact(q.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		prev := opts.Nonce
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		defer func() { opts.Nonce = prev }()
+		return build(opts)
+	})
+	if err != nil {
+		return QueueResult{Err: errors.Wrap(err, "send transaction")}
+	}
+	receipt, err := waitReceipt(q.ctx, q.bc, tx.Hash(), tx, q.opts)
+	if err != nil {
+		return QueueResult{Tx: tx, Err: err}
+	}
+	q.opts.mined(q.ctx, q.bc, tx, receipt)
+	if receipt.Status == types.ReceiptStatusFailed {
+		return QueueResult{Tx: tx, Receipt: receipt, Err: revertError(q.ctx, q.bc, tx, receipt)}
+	}
+	return QueueResult{Tx: tx, Receipt: receipt}
+}
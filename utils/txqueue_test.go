@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// miningBackend mines a block with every transaction sent to it
+type miningBackend struct {
+	simulatedBackend
+}
+
+func (b miningBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.simulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.Commit()
+	return nil
+}
+
+func TestTxQueue(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	address := deployCode(t, sim, auth, answerCode)
+	bc := miningBackend{sim}
+	contract, err := NewContractFromJSON(address, answerABI, bc)
+	require.NoError(t, err)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	q := NewTxQueue(ctx, bc, auth, WithPollInterval(time.Millisecond*10))
+	var results []<-chan QueueResult
+	for i := 0; i < 3; i++ {
+		results = append(results, q.Enqueue(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			tx, err := auth.buildTx(ctx, bc, opts.Nonce, 0, to, big.NewInt(1), nil)
+			if err != nil {
+				return nil, err
+			}
+			return tx, bc.SendTransaction(ctx, tx)
+		}))
+	}
+	failed := q.Enqueue(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nil, errors.New("build failed")
+	})
+	results = append(results, q.Enqueue(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.contract.Transact(opts, "setAnswer", big.NewInt(1))
+	}))
+	q.Close()
+
+	// transactions are sent in order with consecutive nonces once the previous one is mined
+	for i, ch := range results {
+		result := <-ch
+		require.NoError(t, result.Err)
+		require.Equal(t, uint64(i+1), result.Tx.Nonce())
+		require.Equal(t, types.ReceiptStatusSuccessful, result.Receipt.Status)
+		require.Equal(t, uint64(i+2), result.Receipt.BlockNumber.Uint64())
+	}
+	result := <-failed
+	require.EqualError(t, result.Err, "send transaction: build failed")
+	require.Nil(t, result.Tx)
+
+	result = <-q.Enqueue(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		t.Fatal("builder invoked after close")
+		return nil, nil
+	})
+	require.Equal(t, ErrQueueClosed, result.Err)
+}
+
+func TestTxQueueCancel(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+
+	// transactions are never mined as the backend doesn't commit blocks
+	ctx, cancel := context.WithCancel(context.Background())
+	q := NewTxQueue(ctx, sim, auth, WithPollInterval(time.Millisecond*10))
+	send := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := auth.buildTx(ctx, sim, opts.Nonce, 21000, auth.From, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return tx, sim.SendTransaction(ctx, tx)
+	}
+	inFlight := q.Enqueue(send)
+	queued := q.Enqueue(send)
+	require.Eventually(t, func() bool {
+		nonce, err := sim.PendingNonceAt(context.Background(), auth.From)
+		return err == nil && nonce == 1
+	}, time.Second*5, time.Millisecond*10)
+	cancel()
+	result := <-inFlight
+	require.NotNil(t, result.Tx)
+	require.True(t, errors.Is(result.Err, context.Canceled))
+	result = <-queued
+	require.True(t, errors.Is(result.Err, context.Canceled))
+	q.Close()
+}
+
+func TestTxQueueShutdown(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	// blocks are never mined so the transaction stays in flight
+	sim := newSimulatedBackend(t, auth.From)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	q := NewTxQueue(ctx, sim, auth, WithPollInterval(time.Millisecond*10))
+	build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := auth.buildTx(ctx, sim, opts.Nonce, 0, to, big.NewInt(1), nil)
+		if err != nil {
+			return nil, err
+		}
+		return tx, sim.SendTransaction(ctx, tx)
+	}
+	inFlight := q.Enqueue(build)
+	queued := q.Enqueue(build)
+
+	shutdown, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, q.Shutdown(shutdown))
+	result := <-inFlight
+	require.True(t, errors.Is(result.Err, context.Canceled), result.Err)
+	require.NotNil(t, result.Tx)
+	result = <-queued
+	require.True(t, errors.Is(result.Err, context.Canceled), result.Err)
+	require.Nil(t, result.Tx)
+}
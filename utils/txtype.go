@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// TxType selects the type of the transactions sent by an authorizer, overriding the type
+// otherwise picked from its gas settings and the chain
+type TxType uint8
+
+const (
+	// TxTypeAuto sends legacy transactions when GasPrice is set and EIP-1559 transactions
+	// otherwise, falling back to legacy transactions on chains without a base fee
+	TxTypeAuto TxType = iota
+	// TxTypeLegacy sends type 0 transactions priced with GasPrice
+	TxTypeLegacy
+	// TxTypeAccessList sends EIP-2930 type 1 transactions priced with GasPrice
+	TxTypeAccessList
+	// TxTypeDynamicFee sends EIP-1559 type 2 transactions priced with GasFeeCap and GasTipCap
+	TxTypeDynamicFee
+)
+
+// FeeMode selects how the transactions of an authorizer are priced. The type of a transaction
+// decides which fee fields it carries, so it is the same as TxType
+type FeeMode = TxType
+
+// String returns the name of the transaction type
+func (t TxType) String() string {
+	switch t {
+	case TxTypeAuto:
+		return "auto"
+	case TxTypeLegacy:
+		return "legacy"
+	case TxTypeAccessList:
+		return "access list"
+	case TxTypeDynamicFee:
+		return "dynamic fee"
+	}
+	return "unknown"
+}
+
+// envelope returns the EIP-2718 type byte of transactions of type t, and false for TxTypeAuto
+func (t TxType) envelope() (uint8, bool) {
+	switch t {
+	case TxTypeLegacy:
+		return types.LegacyTxType, true
+	case TxTypeAccessList:
+		return types.AccessListTxType, true
+	case TxTypeDynamicFee:
+		return types.DynamicFeeTxType, true
+	}
+	return 0, false
+}
+
+// checkTxType returns an error wrapping ErrTxTypeIncompatible if the gas settings of the
+// authorizer can't be used with its TxType, and expects the caller to hold the lock
+func (a *Authorizer) checkTxType() error {
+	switch a.TxType {
+	case TxTypeAuto:
+		return nil
+	case TxTypeLegacy:
+		if a.isDynamicFee() {
+			return errors.Wrap(ErrTxTypeIncompatible, "legacy transactions are priced with GasPrice, not GasFeeCap and GasTipCap")
+		}
+		if a.accessList != nil {
+			return ErrAccessListLegacy
+		}
+	case TxTypeAccessList:
+		if a.isDynamicFee() {
+			return errors.Wrap(ErrTxTypeIncompatible, "access list transactions are priced with GasPrice, not GasFeeCap and GasTipCap")
+		}
+	case TxTypeDynamicFee:
+		if a.GasPrice != nil {
+			return errors.Wrap(ErrTxTypeIncompatible, "dynamic fee transactions are priced with GasFeeCap and GasTipCap, not GasPrice")
+		}
+	default:
+		return errors.Wrapf(ErrTxTypeIncompatible, "unknown transaction type %d", a.TxType)
+	}
+	return nil
+}
+
+// checkBoundTxType returns an error wrapping ErrTxTypeIncompatible if tx, built by the contract
+// bindings, isn't of the type selected by TxType. This expects the caller to hold the lock
+func (a *Authorizer) checkBoundTxType(tx *types.Transaction) error {
+	if err := a.checkTxType(); err != nil {
+		return err
+	}
+	want, ok := a.TxType.envelope()
+	if !ok || tx.Type() == want {
+		return nil
+	}
+	switch a.TxType {
+	case TxTypeLegacy:
+		return errors.Wrap(ErrTxTypeIncompatible, "the bindings only send legacy transactions when GasPrice is set")
+	case TxTypeAccessList:
+		return errors.Wrap(ErrTxTypeIncompatible, "the bindings don't support access list transactions, use BuildTx")
+	}
+	// without GasPrice the bindings only fall back to legacy transactions on chains without a base fee
+	return errors.Wrap(ErrTxTypeIncompatible, "chain does not support EIP-1559 transactions")
+}
+
+// fillAccessList generates the access list of access list transactions built without one set
+// with SetAccessList, using eth_createAccessList if bc implements CallContext such as backends
+// wrapping an *rpc.Client. This expects the caller to hold the lock
+func (a *Authorizer) fillAccessList(ctx context.Context, bc Blockchain, to common.Address, value *big.Int, data []byte) error {
+	if a.TxType != TxTypeAccessList || a.accessList != nil {
+		return nil
+	}
+	client, ok := bc.(rpcCaller)
+	if !ok {
+		return errors.New("access list transactions require an access list set with SetAccessList as the backend can't create one")
+	}
+	al, _, err := createAccessList(ctx, client, a.From, to, data, value)
+	if err != nil {
+		return err
+	}
+	a.accessList = al
+	return nil
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcBackend is a simulated backend which also serves raw rpc calls from client
+type rpcBackend struct {
+	simulatedBackend
+	client *rpc.Client
+}
+
+func (b rpcBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return b.client.CallContext(ctx, result, method, args...)
+}
+
+func TestTxType(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	al := types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+
+	// the gas price is suggested by the chain when unset
+	auth.TxType = TxTypeLegacy
+	tx, err := auth.BuildTx(ctx, sim, to, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.LegacyTxType), tx.Type())
+	suggested, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	require.Equal(t, suggested.String(), tx.GasPrice().String())
+
+	auth.TxType = TxTypeAccessList
+	require.NoError(t, auth.SetAccessList(al))
+	tx, err = auth.BuildTx(ctx, sim, to, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.AccessListTxType), tx.Type())
+	require.Equal(t, al, tx.AccessList())
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	require.NoError(t, err)
+	require.Equal(t, auth.From, sender)
+	require.NoError(t, Send(ctx, sim, tx))
+	sim.Commit()
+
+	// without an access list set one is generated, which needs the rpc client
+	_, err = auth.BuildTx(ctx, sim, to, nil, nil)
+	require.Error(t, err)
+	service := &accessListService{accessList: al}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	t.Cleanup(server.Stop)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+	tx, err = auth.BuildTx(ctx, rpcBackend{sim, client}, to, nil, []byte{1})
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.AccessListTxType), tx.Type())
+	require.Equal(t, al, tx.AccessList())
+	require.Equal(t, "0x01", service.args["data"])
+
+	auth.TxType = TxTypeDynamicFee
+	tx, err = auth.BuildTx(ctx, sim, to, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+}
+
+func TestTxTypeIncompatible(t *testing.T) {
+	ctx := context.Background()
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	for _, tt := range []struct {
+		name   string
+		txType TxType
+		setup  func(*Authorizer)
+		err    error
+	}{
+		{"dynamic fee with gas price", TxTypeDynamicFee, func(a *Authorizer) { a.UseLegacyGas(big.NewInt(1)) }, ErrTxTypeIncompatible},
+		{"legacy with fee caps", TxTypeLegacy, func(a *Authorizer) { a.SetDynamicFees(big.NewInt(2), big.NewInt(1)) }, ErrTxTypeIncompatible},
+		{"access list with fee caps", TxTypeAccessList, func(a *Authorizer) { a.SetDynamicFees(big.NewInt(2), big.NewInt(1)) }, ErrTxTypeIncompatible},
+		{"legacy with access list", TxTypeLegacy, func(a *Authorizer) { a.accessList = types.AccessList{{Address: to}} }, ErrAccessListLegacy},
+		{"unknown", TxType(9), func(*Authorizer) {}, ErrTxTypeIncompatible},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pk, err := crypto.GenerateKey()
+			require.NoError(t, err)
+			auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+			require.NoError(t, err)
+			sim := newSimulatedBackend(t, auth.From)
+			tt.setup(auth)
+			auth.TxType = tt.txType
+			_, err = auth.BuildTx(ctx, sim, to, nil, nil)
+			require.Equal(t, tt.err, errors.Cause(err))
+		})
+	}
+}
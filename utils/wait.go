@@ -0,0 +1,433 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPollInterval is the delay between polls used by the wait helpers unless overridden
+	DefaultPollInterval = time.Second * 2
+	// maxPollInterval caps the backoff between polls, unless the poll interval is already larger
+	maxPollInterval = time.Second * 16
+	// receiptBackoff is the default backoff between receipt polls
+	receiptBackoff = 2
+)
+
+// DefaultConfirmationPollInterval is the delay between polls previously used by WaitConfirmations
+//
+// Deprecated: WaitConfirmations polls every DefaultPollInterval unless set with WithPollInterval
+const DefaultConfirmationPollInterval = time.Second * 4
+
+// WaitOptions configures how the wait helpers poll for receipts
+type WaitOptions struct {
+	// PollInterval is the delay before the first poll and between subsequent polls
+	PollInterval time.Duration
+	// Timeout bounds the total time spent waiting in addition to the context, zero meaning no timeout
+	Timeout time.Duration
+	// Backoff multiplies the poll interval after each poll, values of 1 or less keeping it constant.
+	// Zero, the default, doubles the interval between receipt polls and keeps that between
+	// confirmation polls constant
+	Backoff float64
+	// Hooks are invoked as the transaction is sent and mined
+	Hooks *Hooks
+	// OnConfirmation is called by WaitConfirmations as the depth of the transaction increases
+	OnConfirmation func(current, target uint64)
+	// HeadTracker is used by WaitConfirmations to follow the head of the chain instead of polling it
+	HeadTracker *HeadTracker
+	// Metrics counts the transactions sent and mined
+	Metrics *Metrics
+}
+
+// WaitOption configures WaitOptions
+type WaitOption func(*WaitOptions)
+
+// WithPollInterval sets the delay between polls, which defaults to DefaultPollInterval
+func WithPollInterval(interval time.Duration) WaitOption {
+	return func(o *WaitOptions) { o.PollInterval = interval }
+}
+
+// WithTimeout bounds the total time spent waiting, returning context.DeadlineExceeded once it passes
+func WithTimeout(timeout time.Duration) WaitOption {
+	return func(o *WaitOptions) { o.Timeout = timeout }
+}
+
+// WithBackoff multiplies the poll interval by factor after each poll, up to 16 seconds or the poll
+// interval if larger. This reduces load on the node when waiting for a long time. Receipts are
+// polled with a backoff of 2 by default, and WithBackoff(1) polls them at a constant interval
+func WithBackoff(factor float64) WaitOption {
+	return func(o *WaitOptions) { o.Backoff = factor }
+}
+
+// WithHooks invokes the OnSend and OnMined hooks as the transaction is sent and mined, which allows
+// passing the hooks of the authorizer that signed it
+func WithHooks(hooks *Hooks) WaitOption {
+	return func(o *WaitOptions) { o.Hooks = hooks }
+}
+
+// WithOnConfirmation calls fn as WaitConfirmations sees the transaction gain confirmations, such
+// as to render a progress bar. It is called once with a current depth of zero when the transaction
+// is first seen mined, then exactly once for each further depth up to and including target, even
+// when several blocks arrive between polls. Depths lost to a reorg aren't reported again
+func WithOnConfirmation(fn func(current, target uint64)) WaitOption {
+	return func(o *WaitOptions) { o.OnConfirmation = fn }
+}
+
+// WithHeadTracker makes WaitConfirmations read the head of the chain from ht and wake up as soon as
+// it sees a new head, rather than polling the block number of the node every poll interval
+func WithHeadTracker(ht *HeadTracker) WaitOption {
+	return func(o *WaitOptions) { o.HeadTracker = ht }
+}
+
+// WithMetrics counts the transactions sent and mined in metrics, such as those of an authorizer
+func WithMetrics(metrics *Metrics) WaitOption {
+	return func(o *WaitOptions) { o.Metrics = metrics }
+}
+
+// newWaitOptions applies opts on top of the defaults
+func newWaitOptions(opts []WaitOption) WaitOptions {
+	o := WaitOptions{PollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultPollInterval
+	}
+	return o
+}
+
+// mined invokes the OnMined hook and counts receipt in the metrics, with tx being the transaction
+// of receipt if known
+func (o WaitOptions) mined(ctx context.Context, bc Blockchain, tx *types.Transaction, receipt *types.Receipt) {
+	o.Hooks.mined(receipt)
+	o.Metrics.mined(ctx, bc, tx, receipt)
+}
+
+// forReceipts returns o with the default backoff of receipt polling applied
+func (o WaitOptions) forReceipts() WaitOptions {
+	if o.Backoff == 0 {
+		o.Backoff = receiptBackoff
+	}
+	return o
+}
+
+// withTimeout returns ctx bounded by the timeout if one is set
+func (o WaitOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout > 0 {
+		return context.WithTimeout(ctx, o.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// next returns the interval following interval after applying the backoff
+func (o WaitOptions) next(interval time.Duration) time.Duration {
+	if o.Backoff <= 1 {
+		return interval
+	}
+	limit := maxPollInterval
+	if o.PollInterval > limit {
+		limit = o.PollInterval
+	}
+	if interval = time.Duration(float64(interval) * o.Backoff); interval > limit {
+		interval = limit
+	}
+	return interval
+}
+
+// sleep waits for interval returning early with the context error if ctx is done, stopping the
+// timer so nothing is left running once it returns
+func sleep(ctx context.Context, interval time.Duration) error {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// confirmationProgress reports each confirmation depth to OnConfirmation exactly once
+type confirmationProgress struct {
+	fn     func(current, target uint64)
+	target uint64
+	// next is the next depth to report
+	next uint64
+}
+
+// report invokes the callback for each depth up to depth, capped at the target, not yet reported
+func (p *confirmationProgress) report(depth uint64) {
+	if p.fn == nil {
+		return
+	}
+	if depth > p.target {
+		depth = p.target
+	}
+	for ; p.next <= depth; p.next++ {
+		p.fn(p.next, p.target)
+	}
+}
+
+// SendAndWait broadcasts tx and waits for it to be mined by polling for the receipt, respecting
+// ctx cancellation between polls. Polling starts every DefaultPollInterval and backs off
+// exponentially up to 16 seconds without a timeout, which can be changed with opts. If the
+// transaction reverted the receipt is returned alongside an error wrapping ErrTxReverted, with the
+// revert reason decoded by replaying the transaction as a call at the block it was mined in. If
+// the transaction was dropped because another transaction with its nonce was mined, such as one
+// replacing it, an error wrapping ErrTxNotFound is returned. This requires a backend implementing
+// NonceAt such as *ethclient.Client, and otherwise waits until ctx is done
+func SendAndWait(ctx context.Context, bc Blockchain, tx *types.Transaction, opts ...WaitOption) (*types.Receipt, error) {
+	o := newWaitOptions(opts)
+	if err := Send(ctx, bc, tx); err != nil {
+		return nil, err
+	}
+	o.Hooks.sent(tx)
+	o.Metrics.sent()
+	receipt, err := waitReceipt(ctx, bc, tx.Hash(), tx, o)
+	if err != nil {
+		return nil, err
+	}
+	o.mined(ctx, bc, tx, receipt)
+	if receipt.Status == types.ReceiptStatusFailed {
+		return receipt, revertError(ctx, bc, tx, receipt)
+	}
+	return receipt, nil
+}
+
+// waitReceipt polls for the receipt of the transaction hash until it is available, returning the
+// context error as soon as ctx is done. Without a receipt once another transaction used its nonce,
+// as seen on the previous poll to give the node a chance to index a receipt of the transaction
+// itself, an error wrapping ErrTxNotFound is returned. tx is the transaction if known, and
+// otherwise is fetched from the node while pending
+func waitReceipt(ctx context.Context, bc Blockchain, hash common.Hash, tx *types.Transaction, o WaitOptions) (*types.Receipt, error) {
+	if receipt, ok := takeDryRunReceipt(hash); ok {
+		return receipt, nil
+	}
+	o = o.forReceipts()
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	interval := o.PollInterval
+	var nonceUsed bool
+	for {
+		receipt, err := bc.TransactionReceipt(ctx, hash)
+		if err != nil && err != ethereum.NotFound {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, errors.Wrap(err, "transaction receipt")
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+		if nonceUsed {
+			return nil, errors.Wrapf(ErrTxNotFound, "nonce of transaction %s used by another transaction", hash.Hex())
+		}
+		if nonceUsed, tx, err = isNonceUsed(ctx, bc, hash, tx); err != nil {
+			return nil, err
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		interval = o.next(interval)
+	}
+}
+
+// isNonceUsed returns true if a transaction with the nonce of the transaction hash was mined, along
+// with the transaction fetched from the node if tx is nil. It returns false if bc doesn't implement
+// NonceAt, or wraps a backend which doesn't, or the transaction isn't known
+func isNonceUsed(ctx context.Context, bc Blockchain, hash common.Hash, tx *types.Transaction) (bool, *types.Transaction, error) {
+	reader, ok := bc.(nonceReader)
+	if !ok {
+		return false, tx, nil
+	}
+	if tx == nil {
+		pending, _, err := bc.TransactionByHash(ctx, hash)
+		if err == ethereum.NotFound {
+			return false, nil, nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, nil, ctx.Err()
+			}
+			return false, nil, errors.Wrap(err, "transaction by hash")
+		}
+		tx = pending
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return false, tx, nil
+	}
+	mined, err := reader.NonceAt(ctx, from, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, tx, ctx.Err()
+		}
+		if errors.Is(err, ErrBackendUnsupported) {
+			return false, tx, nil
+		}
+		return false, tx, errors.Wrap(err, "nonce at")
+	}
+	return mined > tx.Nonce(), tx, nil
+}
+
+// WaitForEvent waits for the transaction to be mined like SendAndWait, returning the first log it
+// emitted whose first topic is eventSig, such as the Swap event of a pool. An error wrapping
+// ErrEventNotFound is returned if the transaction was mined without emitting the event, and one
+// wrapping ErrTxReverted if it reverted, in which case it emitted no events at all. As with
+// SendAndWait, an error wrapping ErrTxNotFound is returned if the transaction was dropped
+func WaitForEvent(ctx context.Context, bc Blockchain, txHash, eventSig common.Hash, opts ...WaitOption) (*types.Log, error) {
+	logs, err := WaitForEvents(ctx, bc, txHash, eventSig, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return logs[0], nil
+}
+
+// WaitForEvents is like WaitForEvent but returns every matching log in the order they were emitted
+func WaitForEvents(ctx context.Context, bc Blockchain, txHash, eventSig common.Hash, opts ...WaitOption) ([]*types.Log, error) {
+	o := newWaitOptions(opts)
+	receipt, err := waitReceipt(ctx, bc, txHash, nil, o)
+	if err != nil {
+		return nil, err
+	}
+	o.mined(ctx, bc, nil, receipt)
+	if receipt.Status == types.ReceiptStatusFailed {
+		return nil, errors.Wrapf(ErrTxReverted, "transaction %s", txHash.Hex())
+	}
+	var logs []*types.Log
+	for _, log := range receipt.Logs {
+		if len(log.Topics) > 0 && log.Topics[0] == eventSig {
+			logs = append(logs, log)
+		}
+	}
+	if len(logs) == 0 {
+		return nil, errors.Wrapf(ErrEventNotFound, "event %s in transaction %s", eventSig.Hex(), txHash.Hex())
+	}
+	return logs, nil
+}
+
+// WaitConfirmationsWithInterval is like WaitConfirmations but polls every interval
+//
+// Deprecated: use WaitConfirmations with WithPollInterval
+func WaitConfirmationsWithInterval(ctx context.Context, bc Blockchain, txHash common.Hash, confirmations uint64, interval time.Duration) (*types.Receipt, error) {
+	return WaitConfirmations(ctx, bc, txHash, confirmations, WithPollInterval(interval))
+}
+
+// WaitConfirmations waits until the transaction has at least confirmations blocks built on top of
+// the block it was mined in, so zero only waits for the transaction to be mined. Polling defaults
+// to every DefaultPollInterval without a timeout, which can be changed with opts. Whenever the block
+// the transaction was mined in is no longer canonical the receipt is fetched again, following the
+// transaction if it was included in another block and returning ErrReorged if it is no longer
+// found. The status of the receipt is not checked, so reverted transactions are returned like any other.
+// Progress can be reported with WithOnConfirmation, and the head of the chain shared with other waiters
+// with WithHeadTracker
+func WaitConfirmations(ctx context.Context, bc Blockchain, txHash common.Hash, confirmations uint64, opts ...WaitOption) (*types.Receipt, error) {
+	o := newWaitOptions(opts)
+	if receipt, ok := takeDryRunReceipt(txHash); ok {
+		return receipt, nil
+	}
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	interval := o.PollInterval
+	progress := confirmationProgress{fn: o.OnConfirmation, target: confirmations}
+	var receipt *types.Receipt
+	// current is the latest block number, used to wait for the next head with a head tracker
+	var current uint64
+	for {
+		if receipt != nil {
+			header, err := bc.HeaderByNumber(ctx, receipt.BlockNumber)
+			if err != nil && err != ethereum.NotFound {
+				return nil, errors.Wrap(err, "header by number")
+			}
+			if header == nil || header.Hash() != receipt.BlockHash {
+				// the block was reorganized out so check whether the transaction was included elsewhere
+				receipt = nil
+				latest, err := bc.TransactionReceipt(ctx, txHash)
+				if err != nil && err != ethereum.NotFound {
+					return nil, errors.Wrap(err, "transaction receipt")
+				}
+				if latest == nil {
+					return nil, ErrReorged
+				}
+				receipt = latest
+				continue
+			}
+		} else {
+			latest, err := bc.TransactionReceipt(ctx, txHash)
+			if err != nil && err != ethereum.NotFound {
+				return nil, errors.Wrap(err, "transaction receipt")
+			}
+			receipt = latest
+		}
+		if receipt != nil {
+			var err error
+			if current, err = o.blockNumber(ctx, bc); err != nil {
+				return nil, err
+			}
+			if mined := receipt.BlockNumber.Uint64(); current >= mined {
+				progress.report(current - mined)
+				if current-mined >= confirmations {
+					o.mined(ctx, bc, nil, receipt)
+					return receipt, nil
+				}
+			}
+		}
+		if o.HeadTracker != nil {
+			if receipt == nil {
+				current = o.HeadTracker.Latest().Number.Uint64()
+			}
+			if err := o.HeadTracker.Wait(ctx, current+1); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		interval = o.next(interval)
+	}
+}
+
+// blockNumber returns the number of the latest block, as seen by the head tracker if one is set
+func (o WaitOptions) blockNumber(ctx context.Context, bc Blockchain) (uint64, error) {
+	if o.HeadTracker != nil {
+		return o.HeadTracker.Latest().Number.Uint64(), nil
+	}
+	current, err := bc.BlockNumber(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "block number")
+	}
+	return current, nil
+}
+
+// revertError replays a reverted transaction as a call at the block it was
+// mined in to recover the revert reason, returning an error wrapping ErrTxReverted
+func revertError(ctx context.Context, bc Blockchain, tx *types.Transaction, receipt *types.Receipt) error {
+	if reason, ok := replayRevertReason(ctx, bc, tx, receipt.BlockNumber); ok {
+		return errors.Wrapf(ErrTxReverted, "execution reverted: %s", reason)
+	}
+	return ErrTxReverted
+}
+
+// replayRevertReason replays tx as a call at blockNumber returning the decoded revert reason
+func replayRevertReason(ctx context.Context, bc Blockchain, tx *types.Transaction, blockNumber *big.Int) (string, bool) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return "", false
+	}
+	// the gas price is omitted so the replay isn't rejected due to the sender balance
+	_, err = bc.CallContract(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}, blockNumber)
+	return revertReason(err)
+}
@@ -0,0 +1,354 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestSendAndWait(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	gasPrice, err := sim.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		sim.Commit()
+	}()
+	receipt, err := SendAndWait(context.Background(), sim, tx)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	require.Equal(t, tx.Hash(), receipt.TxHash)
+}
+
+// multiEmitterCode deploys a contract emitting LOG1 with topics 0x2a, 0x2b and 0x2a whenever it is called
+const multiEmitterCode = "0x6016600c60003960166000f3602a60006000a1602b60006000a1602a60006000a100"
+
+func TestWaitForEvent(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	emitter := deployCode(t, sim, auth, multiEmitterCode)
+	tx, err := auth.BuildTx(ctx, sim, emitter, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, Send(ctx, sim, tx))
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		sim.Commit()
+	}()
+
+	topic := common.BigToHash(big.NewInt(0x2a))
+	log, err := WaitForEvent(ctx, sim, tx.Hash(), topic, WithPollInterval(time.Millisecond*10))
+	require.NoError(t, err)
+	require.Equal(t, emitter, log.Address)
+	require.Equal(t, uint(0), log.Index)
+	logs, err := WaitForEvents(ctx, sim, tx.Hash(), topic, WithPollInterval(time.Millisecond*10))
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, uint(2), logs[1].Index)
+
+	_, err = WaitForEvent(ctx, sim, tx.Hash(), common.BigToHash(big.NewInt(0x2c)), WithPollInterval(time.Millisecond*10))
+	require.True(t, errors.Is(err, ErrEventNotFound))
+}
+
+// receiptSignaller closes found the first time a receipt is returned
+type receiptSignaller struct {
+	simulatedBackend
+	once  sync.Once
+	found chan struct{}
+}
+
+func (r *receiptSignaller) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := r.simulatedBackend.TransactionReceipt(ctx, hash)
+	if receipt != nil {
+		r.once.Do(func() { close(r.found) })
+	}
+	return receipt, err
+}
+
+func TestWaitConfirmations(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, tx))
+	sim.Commit()
+
+	// no confirmations only waits for the transaction to be mined
+	receipt, err := WaitConfirmations(ctx, sim, tx.Hash(), 0, WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, tx.Hash(), receipt.TxHash)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := WaitConfirmations(ctx, sim, tx.Hash(), 3, WithPollInterval(time.Millisecond*10))
+		done <- err
+	}()
+	for i := 0; i < 2; i++ {
+		sim.Commit()
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("returned after 2 confirmations: %v", err)
+	case <-time.After(time.Millisecond * 100):
+	}
+	sim.Commit()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for confirmations")
+	}
+}
+
+func TestWaitConfirmationsProgress(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, tx))
+	sim.Commit()
+	for i := 0; i < 5; i++ {
+		sim.Commit()
+	}
+
+	// every depth is reported once even though all blocks arrived before the first poll
+	var depths []uint64
+	_, err = WaitConfirmations(ctx, sim, tx.Hash(), 3, WithPollInterval(time.Millisecond), WithOnConfirmation(func(current, target uint64) {
+		require.Equal(t, uint64(3), target)
+		depths = append(depths, current)
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1, 2, 3}, depths)
+
+	// with a head tracker each new head wakes the waiter, so the poll interval doesn't matter
+	ht, err := NewHeadTracker(ctx, sim)
+	require.NoError(t, err)
+	t.Cleanup(ht.Close)
+	progress := make(chan uint64, 16)
+	done := make(chan error, 1)
+	go func() {
+		_, err := WaitConfirmations(ctx, sim, tx.Hash(), 8, WithPollInterval(time.Hour), WithHeadTracker(ht), WithOnConfirmation(func(current, _ uint64) {
+			progress <- current
+		}))
+		done <- err
+	}()
+	for want := uint64(0); want <= 5; want++ {
+		require.Equal(t, want, <-progress)
+	}
+	for want := uint64(6); want <= 8; want++ {
+		sim.Commit()
+		select {
+		case got := <-progress:
+			require.Equal(t, want, got)
+		case <-time.After(time.Second * 5):
+			t.Fatalf("timed out waiting for confirmation %d", want)
+		}
+	}
+	require.NoError(t, <-done)
+	require.Empty(t, progress)
+}
+
+func TestWaitConfirmationsReorg(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := &receiptSignaller{simulatedBackend: newSimulatedBackend(t, auth.From), found: make(chan struct{})}
+	genesis := sim.Blockchain().CurrentBlock().Hash()
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	tx, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, tx))
+	sim.Commit()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := WaitConfirmations(ctx, sim, tx.Hash(), 10, WithPollInterval(time.Millisecond*10))
+		done <- err
+	}()
+	<-sim.found
+	// replace the block containing the transaction with a longer chain of empty blocks
+	require.NoError(t, sim.Fork(ctx, genesis))
+	sim.Commit()
+	sim.Commit()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, ErrReorged)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for reorg")
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	sim := newSimulatedBackend(t)
+	start := time.Now()
+	_, err := WaitConfirmations(context.Background(), sim, common.Hash{1}, 0, WithPollInterval(time.Millisecond*10), WithTimeout(time.Millisecond*50))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+// pendingForever never finds the receipt of a transaction
+type pendingForever struct {
+	Blockchain
+}
+
+func (pendingForever) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return nil, ethereum.NotFound
+}
+
+// goroutines returns the stacks of the running goroutines other than the caller keyed by their id
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := make(map[string]string)
+	// the first stack is that of the caller
+	for _, stack := range strings.Split(string(buf), "\n\n")[1:] {
+		// stacks start with a header such as goroutine 18 [select]:
+		if fields := strings.Fields(stack); len(fields) > 1 {
+			stacks[fields[1]] = stack
+		}
+	}
+	return stacks
+}
+
+// startedGoroutines waits up to a second for n of the goroutines started since before was taken to
+// be running, returning their stacks. Goroutines already running are ignored like
+// goleak.IgnoreCurrent. It must be called by the goroutine which took before
+func startedGoroutines(before map[string]string, n int) []string {
+	var started []string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond * 10) {
+		started = started[:0]
+		for id, stack := range goroutines() {
+			if _, ok := before[id]; !ok {
+				started = append(started, stack)
+			}
+		}
+		if len(started) == n {
+			break
+		}
+	}
+	return started
+}
+
+// TestWaitCancel isn't parallel so that goroutines of other tests can't start alongside
+func TestWaitCancel(t *testing.T) {
+	before := goroutines()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := WaitForEvents(ctx, pendingForever{}, common.Hash{1}, common.Hash{2}, WithPollInterval(time.Hour))
+		done <- err
+	}()
+	require.Len(t, startedGoroutines(before, 1), 1)
+	cancel()
+	select {
+	case err := <-done:
+		require.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("wait didn't return once cancelled")
+	}
+	// nothing keeps running once the wait returned
+	require.Empty(t, startedGoroutines(before, 0))
+}
+
+func TestWaitDropped(t *testing.T) {
+	ctx := context.Background()
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	dropped, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(1), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	replacement, err := auth.Signer(auth.From, types.NewTransaction(0, auth.From, big.NewInt(2), 21000, gasPrice, nil))
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, replacement))
+	sim.Commit()
+
+	o := newWaitOptions([]WaitOption{WithPollInterval(time.Millisecond * 10)})
+	_, err = waitReceipt(ctx, sim, dropped.Hash(), dropped, o)
+	require.ErrorIs(t, err, ErrTxNotFound)
+	receipt, err := waitReceipt(ctx, sim, replacement.Hash(), nil, o)
+	require.NoError(t, err)
+	require.Equal(t, replacement.Hash(), receipt.TxHash)
+
+	// a transaction unknown to the node can't be told apart from one yet to be broadcast
+	o.Timeout = time.Millisecond * 50
+	_, err = waitReceipt(ctx, sim, dropped.Hash(), nil, o)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// dropped transactions are detected through the client wrappers as well
+	o.Timeout = time.Second * 5
+	for name, bc := range map[string]Blockchain{
+		"failover":     newFailoverClient([]Blockchain{sim}),
+		"rate limited": NewRateLimitedClient(sim, rate.NewLimiter(rate.Inf, 1), nil),
+		"retry send":   NewRetrySendClient(sim, 3),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := waitReceipt(ctx, bc, dropped.Hash(), dropped, o)
+			require.ErrorIs(t, err, ErrTxNotFound)
+		})
+	}
+}
+
+func TestWaitOptions(t *testing.T) {
+	o := newWaitOptions(nil)
+	require.Equal(t, DefaultPollInterval, o.PollInterval)
+	require.Equal(t, time.Duration(0), o.Timeout)
+	require.Equal(t, DefaultPollInterval, o.next(DefaultPollInterval))
+	// receipts are polled with an exponential backoff unless disabled
+	require.Equal(t, 2*DefaultPollInterval, o.forReceipts().next(DefaultPollInterval))
+	o = newWaitOptions([]WaitOption{WithBackoff(1)})
+	require.Equal(t, DefaultPollInterval, o.forReceipts().next(DefaultPollInterval))
+
+	o = newWaitOptions([]WaitOption{WithPollInterval(time.Second), WithBackoff(2)})
+	require.Equal(t, time.Second*2, o.next(time.Second))
+	require.Equal(t, maxPollInterval, o.next(time.Second*10))
+	// intervals larger than the backoff limit are kept
+	o = newWaitOptions([]WaitOption{WithPollInterval(time.Minute), WithBackoff(2)})
+	require.Equal(t, time.Minute, o.next(time.Minute))
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// WETHAddress is the address of the canonical WETH9 contract on mainnet
+var WETHAddress = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+
+// wethABI is the subset of the WETH9 ABI not already covered by ERC20
+const wethABI = `[
+	{"inputs":[],"name":"deposit","outputs":[],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"wad","type":"uint256"}],"name":"withdraw","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+// WETH provides helpers for wrapping and unwrapping ETH, in addition
+// to the ERC20 helpers for interacting with the WETH token itself
+type WETH struct {
+	*ERC20
+	contract *bind.BoundContract
+}
+
+// NewWETH returns a WETH helper for the WETH9 contract at address,
+// which is WETHAddress on mainnet but differs between chains
+func NewWETH(address common.Address, bc Blockchain) (*WETH, error) {
+	token, err := NewERC20(address, bc)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(wethABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	return &WETH{
+		ERC20:    token,
+		contract: bind.NewBoundContract(address, parsed, bc, bc, bc),
+	}, nil
+}
+
+// Wrap deposits amount of ETH into the WETH contract, minting the same amount of WETH. The
+// value of the authorizer is only set for the duration of the deposit so that it doesn't leak
+// into the next transaction. This claims the authorizer lock and must not be called while the
+// lock is already held.
+func (w *WETH) Wrap(ctx context.Context, auth *Authorizer, amount *big.Int) (*types.Transaction, error) {
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		defer auth.WithValue(amount)()
+		return w.contract.Transact(opts, "deposit")
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "deposit")
+	}
+	return tx, nil
+}
+
+// Unwrap burns amount of WETH, withdrawing the same amount of ETH. This claims the
+// authorizer lock and must not be called while the lock is already held.
+func (w *WETH) Unwrap(ctx context.Context, auth *Authorizer, amount *big.Int) (*types.Transaction, error) {
+	tx, err := auth.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return w.contract.Transact(opts, "withdraw", amount)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "withdraw")
+	}
+	return tx, nil
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWETHWrapResetsValue(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := NewAuthorizerFromPKWithChainID(pk, big.NewInt(1337))
+	require.NoError(t, err)
+	sim := newSimulatedBackend(t, auth.From)
+	// no contract is deployed at the mainnet address on the simulated backend
+	weth, err := NewWETH(WETHAddress, sim)
+	require.NoError(t, err)
+	_, err = weth.Wrap(context.Background(), auth, big.NewInt(1))
+	require.ErrorIs(t, err, bind.ErrNoCode)
+	require.Nil(t, auth.Value)
+}